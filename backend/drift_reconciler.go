@@ -0,0 +1,432 @@
+package main
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	ocmsdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/Azure/ARO-HCP/internal/database"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+)
+
+const (
+	defaultDriftReconcileInterval = 1 * time.Hour
+
+	// driftReconcilerLockName ensures only one replica reconciles at a time.
+	driftReconcilerLockName = "backend-drift-reconciler"
+)
+
+// driftType classifies a mismatch between Cosmos DB and Cluster Service.
+type driftType string
+
+const (
+	// driftOrphaned is a Cluster Service resource with no resource document.
+	driftOrphaned driftType = "orphaned"
+	// driftDangling is a resource document whose InternalID refers to
+	// a resource Cluster Service does not have.
+	driftDangling driftType = "dangling"
+)
+
+// driftResourceLabel returns the metric label for a Cluster Service kind.
+func driftResourceLabel(kind string) string {
+	switch kind {
+	case cmv1.ClusterKind:
+		return "cluster"
+	case cmv1.NodePoolKind:
+		return "node_pool"
+	}
+	return strings.ToLower(kind)
+}
+
+// driftedResource is a resource found in only one of Cosmos DB and Cluster Service.
+type driftedResource struct {
+	drift      driftType
+	internalID ocm.InternalID
+
+	// Only set for dangling resource documents.
+	resourceID *azcorearm.ResourceID
+}
+
+// key identifies the drifted resource across reconciliation passes.
+func (d driftedResource) key() string {
+	return string(d.drift) + ":" + driftKey(d.internalID)
+}
+
+// driftKey identifies a Cluster Service resource regardless of which
+// Cluster Service API its path was obtained from. Clusters obtained from
+// the "aro_hcp" API have a different path prefix than those obtained from
+// the "clusters_mgmt" API, and resource documents may have either.
+func driftKey(internalID ocm.InternalID) string {
+	p := internalID.String()
+	if i := strings.Index(p, "/clusters/"); i >= 0 {
+		p = p[i:]
+	}
+	return p
+}
+
+// driftRepairPolicy controls which kinds of drift DriftReconciler repairs.
+// By default it only reports drift.
+type driftRepairPolicy struct {
+	// deleteDangling deletes resource documents that refer to
+	// resources Cluster Service does not have.
+	deleteDangling bool
+	// deleteOrphaned deletes Cluster Service resources that have
+	// no resource document. This cannot be undone.
+	deleteOrphaned bool
+}
+
+// DriftReconciler periodically compares the resource documents in Cosmos DB
+// with the clusters and node pools in Cluster Service. Partial failures while
+// creating or deleting a resource can leave a resource in Cluster Service with
+// no resource document (orphaned), or a resource document that refers to a
+// resource Cluster Service no longer has (dangling).
+//
+// A resource is considered drifted only if it is found drifted on two consecutive
+// passes, so that resources being created or deleted while a pass runs are not
+// mistaken for drift. Resource documents with an active operation are skipped,
+// since OperationsScanner will delete them once Cluster Service finishes deleting
+// the resource.
+type DriftReconciler struct {
+	dbClient       database.DBClient
+	lockClient     database.LockClient
+	clusterService ocm.ClusterServiceClientSpec
+	policy         driftRepairPolicy
+
+	// Drift found on the previous pass, keyed by driftedResource.key().
+	suspects map[string]driftedResource
+
+	driftGauge             *prometheus.GaugeVec
+	repairsCount           *prometheus.CounterVec
+	lastReconcileTimestamp prometheus.Gauge
+}
+
+func NewDriftReconciler(dbClient database.DBClient, ocmConnection *ocmsdk.Connection) *DriftReconciler {
+	r := &DriftReconciler{
+		dbClient:       dbClient,
+		lockClient:     dbClient.GetLockClient(),
+		clusterService: &ocm.ClusterServiceClient{Conn: ocmConnection},
+		suspects:       make(map[string]driftedResource),
+
+		driftGauge: promauto.With(prometheus.DefaultRegisterer).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "backend_drifted_resources",
+				Help: "Number of resources found in only one of Cosmos DB and Cluster Service on the last reconciliation.",
+			},
+			[]string{"resource", "drift"},
+		),
+		repairsCount: promauto.With(prometheus.DefaultRegisterer).NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "backend_drift_repairs_total",
+				Help: "Total count of attempts to repair drifted resources.",
+			},
+			[]string{"resource", "drift", "result"},
+		),
+		lastReconcileTimestamp: promauto.With(prometheus.DefaultRegisterer).NewGauge(
+			prometheus.GaugeOpts{
+				Name: "backend_last_drift_reconcile_timestamp_seconds",
+				Help: "Timestamp of the last completed drift reconciliation.",
+			},
+		),
+	}
+
+	// Initialize the gauge metrics.
+	for _, resource := range []string{cmv1.ClusterKind, cmv1.NodePoolKind} {
+		for _, drift := range []driftType{driftOrphaned, driftDangling} {
+			r.driftGauge.WithLabelValues(driftResourceLabel(resource), string(drift))
+		}
+	}
+
+	return r
+}
+
+// Run executes the main loop of the DriftReconciler until the context is
+// canceled. Every replica may run a DriftReconciler but only the replica
+// holding the reconciler lock performs a given pass.
+func (r *DriftReconciler) Run(ctx context.Context, logger *slog.Logger) {
+	interval := getInterval("BACKEND_DRIFT_RECONCILE_INTERVAL", defaultDriftReconcileInterval, logger)
+	if interval <= 0 {
+		logger.Info("Drift reconciliation is disabled")
+		return
+	}
+	logger.Info("Reconciling Cosmos DB with Cluster Service every " + interval.String())
+
+	r.policy.deleteDangling = getBool("BACKEND_DRIFT_DELETE_DANGLING", false, logger)
+	r.policy.deleteOrphaned = getBool("BACKEND_DRIFT_DELETE_ORPHANED", false, logger)
+	if r.policy.deleteDangling {
+		logger.Info("Deleting dangling resource documents")
+	}
+	if r.policy.deleteOrphaned {
+		logger.Info("Deleting orphaned Cluster Service resources")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Reconcile immediately on startup.
+	r.reconcile(ctx, logger)
+
+	for {
+		select {
+		case <-ticker.C:
+			r.reconcile(ctx, logger)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reconcile performs one reconciliation pass while holding the reconciler
+// lock. If another replica holds the lock, reconcile does nothing.
+func (r *DriftReconciler) reconcile(ctx context.Context, logger *slog.Logger) {
+	lock, err := r.lockClient.TryAcquireLock(ctx, driftReconcilerLockName)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to acquire drift reconciler lock: %v", err))
+		return
+	} else if lock == nil {
+		return
+	}
+
+	lockedCtx, stop := r.lockClient.HoldLock(ctx, lock)
+	defer func() {
+		if lock := stop(); lock != nil {
+			nonFatalErr := r.lockClient.ReleaseLock(ctx, lock)
+			if nonFatalErr != nil {
+				// Failure here is non-fatal but still log the error.
+				// The lock's TTL ensures it will be released eventually.
+				logger.Warn(fmt.Sprintf("Failed to release drift reconciler lock: %v", nonFatalErr))
+			}
+		}
+	}()
+
+	found, err := r.findDrift(lockedCtx)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to reconcile Cosmos DB with Cluster Service: %v", err))
+		return
+	}
+
+	// Report and repair only drift also found on the previous pass.
+	suspects := make(map[string]driftedResource, len(found))
+	counts := make(map[[2]string]int)
+	for _, d := range found {
+		key := d.key()
+		suspects[key] = d
+		if _, ok := r.suspects[key]; !ok {
+			continue
+		}
+
+		counts[[2]string{driftResourceLabel(d.internalID.Kind()), string(d.drift)}]++
+		r.repair(lockedCtx, logger, d)
+	}
+	r.suspects = suspects
+
+	for _, resource := range []string{cmv1.ClusterKind, cmv1.NodePoolKind} {
+		for _, drift := range []driftType{driftOrphaned, driftDangling} {
+			labels := [2]string{driftResourceLabel(resource), string(drift)}
+			r.driftGauge.WithLabelValues(labels[0], labels[1]).Set(float64(counts[labels]))
+		}
+	}
+	r.lastReconcileTimestamp.SetToCurrentTime()
+}
+
+// findDrift pages through all resource documents and all Cluster Service
+// clusters and node pools, and returns the resources found in only one.
+// Node pools of orphaned clusters are not listed separately, since deleting
+// an orphaned cluster also deletes its node pools.
+func (r *DriftReconciler) findDrift(ctx context.Context) ([]driftedResource, error) {
+	var found []driftedResource
+
+	docs, err := r.listResourceDocs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterIterator := r.clusterService.ListClusters("")
+
+	for csCluster := range clusterIterator.Items(ctx) {
+		clusterID, err := ocm.NewInternalID(csCluster.HREF())
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := docs[driftKey(clusterID)]; !ok {
+			found = append(found, driftedResource{drift: driftOrphaned, internalID: clusterID})
+			continue
+		}
+		delete(docs, driftKey(clusterID))
+
+		nodePoolIterator := r.clusterService.ListNodePools(clusterID, "")
+
+		for csNodePool := range nodePoolIterator.Items(ctx) {
+			nodePoolID, err := ocm.NewInternalID(csNodePool.HREF())
+			if err != nil {
+				return nil, err
+			}
+
+			if _, ok := docs[driftKey(nodePoolID)]; !ok {
+				found = append(found, driftedResource{drift: driftOrphaned, internalID: nodePoolID})
+				continue
+			}
+			delete(docs, driftKey(nodePoolID))
+		}
+
+		err = nodePoolIterator.GetError()
+		if err != nil {
+			return nil, fmt.Errorf("error while paging through Cluster Service node pools: %w", err)
+		}
+	}
+
+	err = clusterIterator.GetError()
+	if err != nil {
+		return nil, fmt.Errorf("error while paging through Cluster Service clusters: %w", err)
+	}
+
+	// Whatever documents remain refer to resources not found in Cluster Service.
+	for _, doc := range docs {
+		if doc.ActiveOperationID != "" {
+			continue
+		}
+		found = append(found, driftedResource{drift: driftDangling, internalID: doc.InternalID, resourceID: doc.ResourceID})
+	}
+
+	return found, nil
+}
+
+// listResourceDocs returns the cluster and node pool documents in all
+// subscriptions, keyed by the driftKey of their InternalID.
+func (r *DriftReconciler) listResourceDocs(ctx context.Context) (map[string]*database.ResourceDocument, error) {
+	var subscriptionIDs []string
+
+	subscriptionIterator := r.dbClient.ListAllSubscriptionDocs()
+
+	for subscriptionID := range subscriptionIterator.Items(ctx) {
+		subscriptionIDs = append(subscriptionIDs, subscriptionID)
+	}
+
+	err := subscriptionIterator.GetError()
+	if err != nil {
+		return nil, fmt.Errorf("error while paging through subscriptions: %w", err)
+	}
+
+	docs := make(map[string]*database.ResourceDocument)
+
+	for _, subscriptionID := range subscriptionIDs {
+		prefix, err := azcorearm.ParseResourceID(path.Join("/subscriptions", subscriptionID))
+		if err != nil {
+			return nil, err
+		}
+
+		iterator := r.dbClient.ListResourceDocs(prefix, -1, nil)
+
+		for _, doc := range iterator.Items(ctx) {
+			switch doc.InternalID.Kind() {
+			case cmv1.ClusterKind, cmv1.NodePoolKind:
+				docs[driftKey(doc.InternalID)] = doc
+			}
+		}
+
+		err = iterator.GetError()
+		if err != nil {
+			return nil, fmt.Errorf("error while paging through resources in subscription %s: %w", subscriptionID, err)
+		}
+	}
+
+	return docs, nil
+}
+
+// repair logs a drifted resource and repairs it if the repair policy allows.
+func (r *DriftReconciler) repair(ctx context.Context, logger *slog.Logger, d driftedResource) {
+	var err error
+
+	logger = logger.With("internal_id", d.internalID.String())
+	if d.resourceID != nil {
+		logger = logger.With("resource_id", d.resourceID.String())
+	}
+
+	switch d.drift {
+	case driftOrphaned:
+		logger.Warn(fmt.Sprintf("Cluster Service %s has no resource document", d.internalID.Kind()))
+		if !r.policy.deleteOrphaned {
+			return
+		}
+		err = r.deleteOrphaned(ctx, d)
+
+	case driftDangling:
+		logger.Warn(fmt.Sprintf("Resource document refers to a %s not found in Cluster Service", d.internalID.Kind()))
+		if !r.policy.deleteDangling {
+			return
+		}
+		err = r.deleteDangling(ctx, d)
+	}
+
+	result := "succeeded"
+	if err != nil {
+		result = "failed"
+		logger.Error(fmt.Sprintf("Failed to repair %s %s: %v", d.drift, d.internalID.Kind(), err))
+	} else {
+		logger.Info(fmt.Sprintf("Repaired %s %s", d.drift, d.internalID.Kind()))
+	}
+	r.repairsCount.WithLabelValues(driftResourceLabel(d.internalID.Kind()), string(d.drift), result).Inc()
+}
+
+// deleteOrphaned deletes an orphaned resource from Cluster Service.
+func (r *DriftReconciler) deleteOrphaned(ctx context.Context, d driftedResource) error {
+	var err error
+
+	switch d.internalID.Kind() {
+	case cmv1.ClusterKind:
+		err = r.clusterService.DeleteCluster(ctx, d.internalID)
+	case cmv1.NodePoolKind:
+		err = r.clusterService.DeleteNodePool(ctx, d.internalID)
+	default:
+		return fmt.Errorf("unsupported Cluster Service path: %s", d.internalID.String())
+	}
+
+	var ocmError *ocmerrors.Error
+	if errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound {
+		return nil
+	}
+
+	return err
+}
+
+// deleteDangling deletes a dangling resource document while holding its
+// resource lock, unless the document changed since it was found dangling.
+func (r *DriftReconciler) deleteDangling(ctx context.Context, d driftedResource) error {
+	timeout := r.lockClient.GetDefaultTimeToLive()
+	lock, err := database.AcquireResourceLock(ctx, r.lockClient, d.resourceID, &timeout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// The lock's TTL ensures it will be released eventually.
+		_ = r.lockClient.ReleaseLock(ctx, lock)
+	}()
+
+	doc, err := r.dbClient.GetResourceDoc(ctx, d.resourceID)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if doc.ActiveOperationID != "" || driftKey(doc.InternalID) != driftKey(d.internalID) {
+		return errors.New("resource document changed")
+	}
+
+	return r.dbClient.DeleteResourceDoc(ctx, d.resourceID)
+}
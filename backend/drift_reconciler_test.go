@@ -0,0 +1,272 @@
+package main
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+	"github.com/Azure/ARO-HCP/internal/ocm/ocmtest"
+)
+
+const driftTestGroupPrefix = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/"
+
+// driftTestEnv holds resources in Cosmos DB and Cluster Service where
+// each kind of drift occurs once.
+type driftTestEnv struct {
+	clock      *ocmtest.FakeClock
+	csClient   *ocm.ClusterServiceClient
+	dbClient   database.DBClient
+	reconciler *DriftReconciler
+
+	orphanedClusterID  ocm.InternalID
+	orphanedNodePoolID ocm.InternalID
+	danglingClusterID  *azcorearm.ResourceID
+	danglingNodePoolID *azcorearm.ResourceID
+	activeClusterID    *azcorearm.ResourceID
+	syncedNodePoolID   *azcorearm.ResourceID
+}
+
+func newDriftTestEnv(t *testing.T, policy driftRepairPolicy) *driftTestEnv {
+	t.Helper()
+
+	ctx := context.Background()
+	env := &driftTestEnv{clock: ocmtest.NewFakeClock(time.Now())}
+
+	server := ocmtest.NewServer(env.clock, ocmtest.Timing{})
+	t.Cleanup(server.Close)
+
+	conn, err := server.NewConnection(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	env.csClient = &ocm.ClusterServiceClient{Conn: conn}
+	env.dbClient = database.NewInMemoryDBClient()
+
+	err = env.dbClient.CreateSubscriptionDoc(ctx, "00000000-0000-0000-0000-000000000000", &arm.Subscription{State: arm.SubscriptionStateRegistered})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	postCluster := func(name string) ocm.InternalID {
+		csCluster, err := arohcpv1alpha1.NewCluster().Name(name).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		csCluster, err = env.csClient.PostCluster(ctx, csCluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+		internalID, err := ocm.NewInternalID(csCluster.HREF())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return internalID
+	}
+
+	postNodePool := func(clusterID ocm.InternalID, name string) ocm.InternalID {
+		csNodePool, err := cmv1.NewNodePool().ID(name).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		csNodePool, err = env.csClient.PostNodePool(ctx, clusterID, csNodePool)
+		if err != nil {
+			t.Fatal(err)
+		}
+		internalID, err := ocm.NewInternalID(csNodePool.HREF())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return internalID
+	}
+
+	createDoc := func(resourceID string, internalID ocm.InternalID, activeOperationID string) *azcorearm.ResourceID {
+		parsed, err := azcorearm.ParseResourceID(resourceID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc := database.NewResourceDocument(parsed)
+		doc.InternalID = internalID
+		doc.ActiveOperationID = activeOperationID
+		err = env.dbClient.CreateResourceDoc(ctx, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	missingInternalID := func(href string) ocm.InternalID {
+		internalID, err := ocm.NewInternalID(href)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return internalID
+	}
+
+	syncedClusterID := postCluster("synced")
+	createDoc(driftTestGroupPrefix+"synced", syncedClusterID, "")
+	env.syncedNodePoolID = createDoc(driftTestGroupPrefix+"synced/nodePools/synced", postNodePool(syncedClusterID, "synced"), "")
+	env.orphanedNodePoolID = postNodePool(syncedClusterID, "orphaned")
+	env.danglingNodePoolID = createDoc(driftTestGroupPrefix+"synced/nodePools/dangling",
+		missingInternalID(ocm.GenerateNodePoolHREF(ocm.GenerateClusterHREF(syncedClusterID.ID()), "dangling")), "")
+
+	env.orphanedClusterID = postCluster("orphaned")
+	env.danglingClusterID = createDoc(driftTestGroupPrefix+"dangling", missingInternalID(ocm.GenerateClusterHREF("dangling")), "")
+
+	// Deleting resources have active operations and are skipped.
+	env.activeClusterID = createDoc(driftTestGroupPrefix+"deleting", missingInternalID(ocm.GenerateClusterHREF("deleting")), "operation")
+
+	// Give the reconciler some unregistered collectors.
+	env.reconciler = &DriftReconciler{
+		dbClient:               env.dbClient,
+		lockClient:             env.dbClient.GetLockClient(),
+		clusterService:         env.csClient,
+		policy:                 policy,
+		suspects:               make(map[string]driftedResource),
+		driftGauge:             prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_drifted"}, []string{"resource", "drift"}),
+		repairsCount:           prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_repairs_total"}, []string{"resource", "drift", "result"}),
+		lastReconcileTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_timestamp_seconds"}),
+	}
+
+	return env
+}
+
+func (env *driftTestEnv) checkDriftGauge(t *testing.T, expect float64) {
+	t.Helper()
+
+	for _, resource := range []string{"cluster", "node_pool"} {
+		for _, drift := range []driftType{driftOrphaned, driftDangling} {
+			value := testutil.ToFloat64(env.reconciler.driftGauge.WithLabelValues(resource, string(drift)))
+			if value != expect {
+				t.Errorf("Expected %v %s %s resources but got %v", expect, drift, resource, value)
+			}
+		}
+	}
+}
+
+func (env *driftTestEnv) resourceDocExists(t *testing.T, resourceID *azcorearm.ResourceID) bool {
+	t.Helper()
+
+	_, err := env.dbClient.GetResourceDoc(context.Background(), resourceID)
+	if errors.Is(err, database.ErrNotFound) {
+		return false
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return true
+}
+
+func isNotFound(err error) bool {
+	var ocmError *ocmerrors.Error
+	return errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound
+}
+
+func TestDriftReconciler(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       driftRepairPolicy
+		expectRepair bool
+	}{
+		{
+			name: "Report only",
+		},
+		{
+			name:         "Repair",
+			policy:       driftRepairPolicy{deleteDangling: true, deleteOrphaned: true},
+			expectRepair: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			logger := slog.Default()
+			env := newDriftTestEnv(t, tt.policy)
+
+			// Drift found only once is not reported or repaired.
+			env.reconciler.reconcile(ctx, logger)
+			env.checkDriftGauge(t, 0)
+			if !env.resourceDocExists(t, env.danglingClusterID) || !env.resourceDocExists(t, env.danglingNodePoolID) {
+				t.Fatal("Dangling resource documents deleted on first pass")
+			}
+
+			env.reconciler.reconcile(ctx, logger)
+			env.checkDriftGauge(t, 1)
+
+			if env.resourceDocExists(t, env.danglingClusterID) == tt.expectRepair {
+				t.Errorf("Expected dangling cluster document deleted: %t", tt.expectRepair)
+			}
+			if env.resourceDocExists(t, env.danglingNodePoolID) == tt.expectRepair {
+				t.Errorf("Expected dangling node pool document deleted: %t", tt.expectRepair)
+			}
+			if !env.resourceDocExists(t, env.activeClusterID) {
+				t.Error("Resource document with active operation deleted")
+			}
+			if !env.resourceDocExists(t, env.syncedNodePoolID) {
+				t.Error("Synced resource document deleted")
+			}
+
+			// Let Cluster Service finish uninstalling deleted resources.
+			env.clock.Step(time.Hour)
+
+			_, err := env.csClient.GetCluster(ctx, env.orphanedClusterID)
+			if isNotFound(err) != tt.expectRepair {
+				t.Errorf("Expected orphaned cluster deleted: %t (%v)", tt.expectRepair, err)
+			}
+			_, err = env.csClient.GetNodePool(ctx, env.orphanedNodePoolID)
+			if isNotFound(err) != tt.expectRepair {
+				t.Errorf("Expected orphaned node pool deleted: %t (%v)", tt.expectRepair, err)
+			}
+
+			// Repaired drift is no longer found.
+			env.reconciler.reconcile(ctx, logger)
+			if tt.expectRepair {
+				env.checkDriftGauge(t, 0)
+			} else {
+				env.checkDriftGauge(t, 1)
+			}
+		})
+	}
+}
+
+func TestDriftReconcilerLock(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.Default()
+	env := newDriftTestEnv(t, driftRepairPolicy{deleteDangling: true})
+
+	lock, err := env.dbClient.GetLockClient().TryAcquireLock(ctx, driftReconcilerLockName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock == nil {
+		t.Fatal("Failed to acquire drift reconciler lock")
+	}
+
+	// Another replica holds the lock, so nothing happens.
+	env.reconciler.reconcile(ctx, logger)
+	env.reconciler.reconcile(ctx, logger)
+
+	if len(env.reconciler.suspects) != 0 {
+		t.Errorf("Expected no suspects but got %d", len(env.reconciler.suspects))
+	}
+	if !env.resourceDocExists(t, env.danglingClusterID) {
+		t.Error("Dangling cluster document deleted without holding the lock")
+	}
+}
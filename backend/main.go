@@ -232,43 +232,67 @@ func Run(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
+	// Every replica runs a drift reconciler, but a lock
+	// ensures only one replica reconciles at a time.
 	group.Go(func() error {
-		var (
-			startedLeading    atomic.Bool
-			operationsScanner = NewOperationsScanner(dbClient, ocmConnection)
-		)
-
-		// FIXME Integrate leaderelection.HealthzAdaptor into a /healthz endpoint.
-		le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
-			Lock:          leaderElectionLock,
-			LeaseDuration: leaderElectionLeaseDuration,
-			RenewDeadline: leaderElectionRenewDeadline,
-			RetryPeriod:   leaderElectionRetryPeriod,
-			Callbacks: leaderelection.LeaderCallbacks{
-				OnStartedLeading: func(ctx context.Context) {
-					operationsScanner.leaderGauge.Set(1)
-					startedLeading.Store(true)
-					go operationsScanner.Run(ctx, logger)
-				},
-				OnStoppedLeading: func() {
-					operationsScanner.leaderGauge.Set(0)
-					if startedLeading.Load() {
-						operationsScanner.Join()
-					}
-				},
-			},
-			ReleaseOnCancel: true,
-			WatchDog:        electionChecker,
-			Name:            leaderElectionLockName,
-		})
-		if err != nil {
-			return err
-		}
-
-		le.Run(ctx)
+		driftReconciler := NewDriftReconciler(dbClient, ocmConnection)
+		driftReconciler.Run(ctx, logger)
 		return nil
 	})
 
+	// In partition ownership mode, every replica scans operations for
+	// the partitions it owns so leader election is not needed.
+	if getBool("BACKEND_PARTITION_OWNERSHIP", false, logger) {
+		group.Go(func() error {
+			operationsScanner := NewOperationsScanner(dbClient, ocmConnection)
+			operationsScanner.partitions = newPartitionOwner(
+				dbClient.GetLockClient(),
+				getPositiveInt("BACKEND_PARTITIONS", defaultPartitionCount, logger),
+				getPositiveInt("BACKEND_MAX_REPLICAS", defaultMaxReplicas, logger))
+
+			operationsScanner.Run(ctx, logger)
+			operationsScanner.Join()
+			return nil
+		})
+	} else {
+		group.Go(func() error {
+			var (
+				startedLeading    atomic.Bool
+				operationsScanner = NewOperationsScanner(dbClient, ocmConnection)
+			)
+
+			// FIXME Integrate leaderelection.HealthzAdaptor into a /healthz endpoint.
+			le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+				Lock:          leaderElectionLock,
+				LeaseDuration: leaderElectionLeaseDuration,
+				RenewDeadline: leaderElectionRenewDeadline,
+				RetryPeriod:   leaderElectionRetryPeriod,
+				Callbacks: leaderelection.LeaderCallbacks{
+					OnStartedLeading: func(ctx context.Context) {
+						operationsScanner.leaderGauge.Set(1)
+						startedLeading.Store(true)
+						go operationsScanner.Run(ctx, logger)
+					},
+					OnStoppedLeading: func() {
+						operationsScanner.leaderGauge.Set(0)
+						if startedLeading.Load() {
+							operationsScanner.Join()
+						}
+					},
+				},
+				ReleaseOnCancel: true,
+				WatchDog:        electionChecker,
+				Name:            leaderElectionLockName,
+			})
+			if err != nil {
+				return err
+			}
+
+			le.Run(ctx)
+			return nil
+		})
+	}
+
 	if err := group.Wait(); err != nil {
 		logger.Error(err.Error())
 		os.Exit(1)
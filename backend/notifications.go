@@ -0,0 +1,259 @@
+package main
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+const (
+	defaultPollIntervalNotifications = 10 * time.Second
+	defaultNotificationTimeout       = 30 * time.Second
+	defaultNotificationBackoff       = 10 * time.Second
+	defaultMaxNotificationBackoff    = 1 * time.Hour
+	defaultMaxNotificationAttempts   = 20
+)
+
+// notificationResult is the outcome of recording or delivering an async
+// notification, for metrics.
+type notificationResult string
+
+const (
+	// notificationResultDelivered means the notification was delivered.
+	notificationResultDelivered notificationResult = "delivered"
+	// notificationResultRetry means delivery failed and will be retried.
+	notificationResultRetry notificationResult = "retry"
+	// notificationResultAbandoned means delivery failed for the last time.
+	notificationResultAbandoned notificationResult = "abandoned"
+	// notificationResultDuplicate means the notification was already
+	// recorded for the same operation status.
+	notificationResultDuplicate notificationResult = "duplicate"
+)
+
+// notificationRetry decides when failed async notifications are retried.
+type notificationRetry struct {
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	maxAttempts int
+
+	// jitter returns a random duration in the range [0, d).
+	// Replaceable for testing.
+	jitter func(d time.Duration) time.Duration
+}
+
+// newNotificationRetry returns a notificationRetry that backs off exponentially
+// from baseDelay up to maxDelay, and gives up after maxAttempts failures.
+func newNotificationRetry(baseDelay, maxDelay time.Duration, maxAttempts int) *notificationRetry {
+	return &notificationRetry{
+		baseDelay:   baseDelay,
+		maxDelay:    maxDelay,
+		maxAttempts: maxAttempts,
+		jitter:      randomJitter,
+	}
+}
+
+// delay returns how long to wait after the given number of failed attempts.
+func (r *notificationRetry) delay(attempts int) time.Duration {
+	return backoffDelay(r.baseDelay, r.maxDelay, attempts, r.jitter)
+}
+
+// notify records the async notification owed to ARM for an operation that
+// reached a new status, if the initial request included an
+// "Azure-AsyncNotificationUri" header. Errors are only logged since the
+// operation's pending notification flag stays set until the notification
+// is recorded, so a later scan tries again.
+func (s *OperationsScanner) notify(ctx context.Context, op operation, status arm.ProvisioningState) {
+	if len(op.doc.NotificationURI) > 0 {
+		err := s.recordNotification(ctx, op, status)
+		if err != nil {
+			op.logger.Error(err.Error())
+		}
+	}
+}
+
+// postPendingNotification records the async notification owed for an
+// operation whose pending notification flag is set, either because it
+// reached a terminal status outside of the backend or because recording
+// the notification failed previously.
+func (s *OperationsScanner) postPendingNotification(ctx context.Context, op operation) {
+	defer s.updateOperationMetrics(postPendingNotificationLabel)()
+
+	err := s.recordNotification(ctx, op, op.doc.Status)
+	if err != nil {
+		s.operationsFailedCount.WithLabelValues(postPendingNotificationLabel).Inc()
+		op.logger.Error(err.Error())
+	}
+}
+
+// recordNotification adds the async notification owed to ARM for an operation
+// reaching the given status to the notification outbox, clears the operation's
+// pending notification flag, and then attempts delivery at once. A notification
+// is recorded only once per operation status, so ARM is not notified twice of
+// the same status even if recording is repeated.
+func (s *OperationsScanner) recordNotification(ctx context.Context, op operation, status arm.ProvisioningState) error {
+	notificationID := database.NotificationID(op.id, status)
+	notificationDoc := database.NewNotificationDocument(op.doc.ExternalID.SubscriptionID, op.id, op.doc.NotificationURI, status)
+
+	// Leave time for the first delivery attempt
+	// before deliverNotifications considers it.
+	notificationDoc.NextAttemptTime = notificationDoc.CreatedTime.Add(s.notificationRetry.baseDelay)
+
+	created, err := s.dbClient.CreateNotificationDoc(ctx, notificationDoc)
+	if err != nil {
+		return fmt.Errorf("failed to record async notification: %w", err)
+	}
+
+	_, err = s.dbClient.UpdateOperationDoc(ctx, op.pk, op.id, func(updateDoc *database.OperationDocument) bool {
+		if !updateDoc.NotificationPending {
+			return false
+		}
+		updateDoc.NotificationPending = false
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clear pending notification: %w", err)
+	}
+
+	if created {
+		s.deliverNotification(ctx, op.pk, notificationID, notificationDoc, op.logger)
+	} else {
+		s.notificationDeliveries.WithLabelValues(string(notificationResultDuplicate)).Inc()
+		op.logger.Info(fmt.Sprintf("Async notification for status '%s' already recorded", status))
+	}
+
+	return nil
+}
+
+// deliverNotifications retries delivery of async notifications in the outbox
+// that are due at the given time.
+func (s *OperationsScanner) deliverNotifications(ctx context.Context, now time.Time, logger *slog.Logger) {
+	defer s.updateOperationMetrics(deliverNotificationsLabel)()
+
+	iterator := s.dbClient.ListPendingNotificationDocs()
+
+	for notificationID, notificationDoc := range iterator.Items(ctx) {
+		if now.Before(notificationDoc.NextAttemptTime) || !s.ownsSubscription(notificationDoc.SubscriptionID) {
+			continue
+		}
+
+		notificationLogger := logger.With(
+			"subscription_id", notificationDoc.SubscriptionID,
+			"operation_id", notificationDoc.OperationID)
+		pk := database.NewPartitionKey(notificationDoc.SubscriptionID)
+		s.deliverNotification(ctx, pk, notificationID, notificationDoc, notificationLogger)
+	}
+
+	err := iterator.GetError()
+	if err != nil {
+		s.operationsFailedCount.WithLabelValues(deliverNotificationsLabel).Inc()
+		logger.Error(fmt.Sprintf("Error while listing pending async notifications: %v", err.Error()))
+	}
+}
+
+// deliverNotification posts an async notification and records the outcome
+// in the notification outbox. A failed delivery is retried with exponential
+// backoff until the maximum number of attempts is reached.
+func (s *OperationsScanner) deliverNotification(ctx context.Context, pk azcosmos.PartitionKey, notificationID string, notificationDoc *database.NotificationDocument, logger *slog.Logger) {
+	var result notificationResult
+	var attempts int
+
+	deliveryErr := s.postAsyncNotification(ctx, pk, notificationDoc)
+
+	_, err := s.dbClient.UpdateNotificationDoc(ctx, pk, notificationID, func(updateDoc *database.NotificationDocument) bool {
+		if updateDoc.State != database.NotificationStatePending {
+			return false
+		}
+
+		if deliveryErr == nil {
+			updateDoc.State = database.NotificationStateDelivered
+			updateDoc.LastError = ""
+			result = notificationResultDelivered
+		} else {
+			updateDoc.Attempts++
+			updateDoc.LastError = deliveryErr.Error()
+			if updateDoc.Attempts >= s.notificationRetry.maxAttempts {
+				updateDoc.State = database.NotificationStateAbandoned
+				result = notificationResultAbandoned
+			} else {
+				updateDoc.NextAttemptTime = time.Now().UTC().Add(s.notificationRetry.delay(updateDoc.Attempts))
+				result = notificationResultRetry
+			}
+		}
+		attempts = updateDoc.Attempts
+
+		return true
+	})
+	if err != nil {
+		// Failure here may cause a delivered notification
+		// to be delivered again, which ARM tolerates.
+		logger.Error(fmt.Sprintf("Failed to update async notification: %v", err))
+		return
+	}
+
+	if result != "" {
+		s.notificationDeliveries.WithLabelValues(string(result)).Inc()
+	}
+
+	switch result {
+	case notificationResultDelivered:
+		s.notificationLatency.Observe(time.Since(notificationDoc.CreatedTime).Seconds())
+		logger.Info(fmt.Sprintf("Posted async notification for status '%s'", notificationDoc.Status))
+	case notificationResultRetry:
+		logger.Warn(fmt.Sprintf("Failed to post async notification (attempt %d): %v", attempts, deliveryErr))
+	case notificationResultAbandoned:
+		logger.Error(fmt.Sprintf("Failed to post async notification; giving up after %d attempts: %v", attempts, deliveryErr))
+	}
+}
+
+// postAsyncNotification submits a POST request with the operation status that
+// was recorded in the notification outbox to the operation's notification URI.
+// The operation may have moved on since, but ARM is told of every status.
+func (s *OperationsScanner) postAsyncNotification(ctx context.Context, pk azcosmos.PartitionKey, notificationDoc *database.NotificationDocument) error {
+	doc, err := s.dbClient.GetOperationDoc(ctx, pk, notificationDoc.OperationID)
+	if err != nil {
+		return err
+	}
+
+	status := doc.ToStatus()
+	if status.Status != notificationDoc.Status {
+		status.Status = notificationDoc.Status
+		status.Error = nil
+		status.EndTime = nil
+	}
+
+	data, err := arm.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, notificationDoc.NotificationURI, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := s.notificationClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode >= 400 {
+		return errors.New(response.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package main
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+)
+
+// newTestNotificationScanner returns an OperationsScanner with what it needs
+// to record and deliver async notifications. Retries back off from one minute
+// without jitter to make retry times predictable.
+func newTestNotificationScanner(dbClient database.DBClient, notificationClient *http.Client) *OperationsScanner {
+	retry := newNotificationRetry(time.Minute, time.Hour, 3)
+	retry.jitter = func(d time.Duration) time.Duration { return d }
+
+	// Give the scanner some unregistered collectors.
+	return &OperationsScanner{
+		dbClient:               dbClient,
+		notificationClient:     notificationClient,
+		notificationRetry:      retry,
+		operationsCount:        prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"type"}),
+		operationsFailedCount:  prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_failed_total"}, []string{"type"}),
+		operationsDuration:     prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_duration_seconds"}, []string{"type"}),
+		lastOperationTimestamp: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_timestamp_seconds"}, []string{"type"}),
+		notificationDeliveries: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_notifications_total"}, []string{"result"}),
+		notificationLatency:    prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_notification_seconds"}),
+	}
+}
+
+// notificationReceiver is a local HTTP endpoint standing in for ARM.
+// It fails the first few requests it receives.
+type notificationReceiver struct {
+	mutex    sync.Mutex
+	failures int
+	statuses []arm.ProvisioningState
+}
+
+func (rcv *notificationReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var operation arm.Operation
+
+	rcv.mutex.Lock()
+	defer rcv.mutex.Unlock()
+
+	if rcv.failures > 0 {
+		rcv.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&operation)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rcv.statuses = append(rcv.statuses, operation.Status)
+}
+
+// delivered returns the operation statuses received so far.
+func (rcv *notificationReceiver) delivered() []arm.ProvisioningState {
+	rcv.mutex.Lock()
+	defer rcv.mutex.Unlock()
+
+	return rcv.statuses
+}
+
+func TestNotificationOutbox(t *testing.T) {
+	tests := []struct {
+		name                string
+		receiverFailures    int
+		expectDeliveryAfter []time.Duration
+		expectAttempts      int
+		expectState         database.NotificationState
+	}{
+		{
+			name:                "Delivered at once",
+			receiverFailures:    0,
+			expectDeliveryAfter: []time.Duration{},
+			expectAttempts:      0,
+			expectState:         database.NotificationStateDelivered,
+		},
+		{
+			name:                "Delivered after retries",
+			receiverFailures:    2,
+			expectDeliveryAfter: []time.Duration{time.Minute, 3 * time.Minute},
+			expectAttempts:      2,
+			expectState:         database.NotificationStateDelivered,
+		},
+		{
+			name:                "Abandoned after too many attempts",
+			receiverFailures:    10,
+			expectDeliveryAfter: []time.Duration{time.Minute, 3 * time.Minute},
+			expectAttempts:      3,
+			expectState:         database.NotificationStateAbandoned,
+		},
+	}
+
+	resourceID, err := azcorearm.ParseResourceID("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/testCluster")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operationID, err := azcorearm.ParseResourceID("/subscriptions/00000000-0000-0000-0000-000000000000/providers/Microsoft.RedHatOpenShift/locations/oz/hcpOperationsStatus/operationID")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Placeholder InternalID for NewOperationDocument
+	internalID, err := ocm.NewInternalID("/api/clusters_mgmt/v1/clusters/placeholder")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dbClient := database.NewInMemoryDBClient()
+
+			receiver := &notificationReceiver{failures: tt.receiverFailures}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			scanner := newTestNotificationScanner(dbClient, server.Client())
+
+			operationDoc := database.NewOperationDocument(database.OperationRequestCreate, resourceID, internalID)
+			operationDoc.OperationID = operationID
+			operationDoc.NotificationURI = server.URL
+			operationDoc.Status = arm.ProvisioningStateProvisioning
+
+			id, err := dbClient.CreateOperationDoc(ctx, operationDoc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resourceDoc := database.NewResourceDocument(resourceID)
+			resourceDoc.ActiveOperationID = id
+			resourceDoc.ProvisioningState = operationDoc.Status
+
+			err = dbClient.CreateResourceDoc(ctx, resourceDoc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			op := newOperation(id, database.NewPartitionKey(resourceID.SubscriptionID), operationDoc, slog.Default())
+
+			err = scanner.updateOperationStatus(ctx, op, arm.ProvisioningStateSucceeded, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Recording the same status again must not
+			// result in a second notification.
+			scanner.notify(ctx, op, arm.ProvisioningStateSucceeded)
+
+			if count := testutil.ToFloat64(scanner.notificationDeliveries.WithLabelValues(string(notificationResultDuplicate))); count != 1 {
+				t.Errorf("Expected 1 duplicate notification but got %g", count)
+			}
+
+			start := time.Now()
+
+			// Nothing is due before the first backoff.
+			scanner.deliverNotifications(ctx, start, slog.Default())
+
+			for _, after := range tt.expectDeliveryAfter {
+				scanner.deliverNotifications(ctx, start.Add(after), slog.Default())
+			}
+
+			// Nothing is due long after delivery succeeds or is abandoned.
+			scanner.deliverNotifications(ctx, start.Add(24*time.Hour), slog.Default())
+
+			expectDelivered := 0
+			if tt.expectState == database.NotificationStateDelivered {
+				expectDelivered = 1
+			}
+
+			statuses := receiver.delivered()
+			if len(statuses) != expectDelivered {
+				t.Fatalf("Expected %d async notifications to be received but got %d", expectDelivered, len(statuses))
+			}
+			if expectDelivered > 0 && statuses[0] != arm.ProvisioningStateSucceeded {
+				t.Errorf("Expected async notification with status '%s' but got '%s'", arm.ProvisioningStateSucceeded, statuses[0])
+			}
+
+			iterator := dbClient.ListPendingNotificationDocs()
+			for range iterator.Items(ctx) {
+				t.Error("Expected no pending async notifications")
+			}
+			if err := iterator.GetError(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Peek at the notification document without changing it.
+			var notificationDoc *database.NotificationDocument
+			_, err = dbClient.UpdateNotificationDoc(ctx, op.pk, database.NotificationID(op.id, arm.ProvisioningStateSucceeded), func(doc *database.NotificationDocument) bool {
+				notificationDoc = doc
+				return false
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if notificationDoc.State != tt.expectState {
+				t.Errorf("Expected async notification state '%s' but got '%s'", tt.expectState, notificationDoc.State)
+			}
+			if notificationDoc.Attempts != tt.expectAttempts {
+				t.Errorf("Expected %d failed delivery attempts but got %d", tt.expectAttempts, notificationDoc.Attempts)
+			}
+
+			storedOperationDoc, err := dbClient.GetOperationDoc(ctx, op.pk, op.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if storedOperationDoc.NotificationPending {
+				t.Error("Pending notification was not cleared")
+			}
+		})
+	}
+}
+
+func TestNotificationRecordedStatus(t *testing.T) {
+	ctx := context.Background()
+	dbClient := database.NewInMemoryDBClient()
+
+	resourceID, err := azcorearm.ParseResourceID("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/testCluster")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operationID, err := azcorearm.ParseResourceID("/subscriptions/00000000-0000-0000-0000-000000000000/providers/Microsoft.RedHatOpenShift/locations/oz/hcpOperationsStatus/operationID")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Placeholder InternalID for NewOperationDocument
+	internalID, err := ocm.NewInternalID("/api/clusters_mgmt/v1/clusters/placeholder")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fail delivery of the first notification so it is retried
+	// after the operation has moved on to another status.
+	receiver := &notificationReceiver{failures: 1}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	scanner := newTestNotificationScanner(dbClient, server.Client())
+
+	operationDoc := database.NewOperationDocument(database.OperationRequestCreate, resourceID, internalID)
+	operationDoc.OperationID = operationID
+	operationDoc.NotificationURI = server.URL
+	operationDoc.Status = arm.ProvisioningStateAccepted
+
+	id, err := dbClient.CreateOperationDoc(ctx, operationDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resourceDoc := database.NewResourceDocument(resourceID)
+	resourceDoc.ActiveOperationID = id
+	resourceDoc.ProvisioningState = operationDoc.Status
+
+	err = dbClient.CreateResourceDoc(ctx, resourceDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op := newOperation(id, database.NewPartitionKey(resourceID.SubscriptionID), operationDoc, slog.Default())
+
+	for _, status := range []arm.ProvisioningState{arm.ProvisioningStateProvisioning, arm.ProvisioningStateSucceeded} {
+		err = scanner.updateOperationStatus(ctx, op, status, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner.deliverNotifications(ctx, time.Now().Add(time.Hour), slog.Default())
+
+	expect := []arm.ProvisioningState{arm.ProvisioningStateSucceeded, arm.ProvisioningStateProvisioning}
+	if statuses := receiver.delivered(); !slices.Equal(statuses, expect) {
+		t.Errorf("Expected async notifications with statuses %v but got %v", expect, statuses)
+	}
+}
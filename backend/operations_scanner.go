@@ -4,7 +4,6 @@ package main
 // Licensed under the Apache License 2.0.
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -13,9 +12,11 @@ import (
 	"os"
 	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	ocmsdk "github.com/openshift-online/ocm-sdk-go"
 	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
@@ -33,12 +34,27 @@ const (
 	defaultSubscriptionConcurrency   = 10
 	defaultPollIntervalSubscriptions = 10 * time.Minute
 	defaultPollIntervalOperations    = 10 * time.Second
-
-	collectSubscriptionsLabel  = "list_subscriptions"
-	processSubscriptionsLabel  = "process_subscriptions"
-	processOperationsLabel     = "process_operations"
-	pollClusterOperationLabel  = "poll_cluster"
-	pollNodePoolOperationLabel = "poll_node_pool"
+	defaultPollIntervalChangeFeed    = 2 * time.Second
+	defaultSweepIntervalOperations   = 10 * time.Minute
+	defaultOperationRetention        = 7 * 24 * time.Hour
+
+	// changeFeedName identifies the persisted change feed continuation token.
+	changeFeedName = "backend-operations"
+
+	collectSubscriptionsLabel           = "list_subscriptions"
+	processSubscriptionsLabel           = "process_subscriptions"
+	processOperationsLabel              = "process_operations"
+	collectOperationLabel               = "collect_operation"
+	readChangeFeedLabel                 = "read_change_feed"
+	processActiveOperationsLabel        = "process_active_operations"
+	pollClusterOperationLabel           = "poll_cluster"
+	pollControlPlaneUpgradePolicyLabel  = "poll_control_plane_upgrade_policy"
+	pollNodePoolOperationLabel          = "poll_node_pool"
+	pollNodePoolUpgradePolicyLabel      = "poll_node_pool_upgrade_policy"
+	pollBreakGlassCredentialLabel       = "poll_break_glass_credential"
+	pollBreakGlassCredentialRevokeLabel = "poll_break_glass_credential_revoke"
+	postPendingNotificationLabel        = "post_pending_notification"
+	deliverNotificationsLabel           = "deliver_notifications"
 )
 
 type operation struct {
@@ -48,9 +64,16 @@ type operation struct {
 	logger *slog.Logger
 }
 
+// operationRef locates an operation document without holding its content,
+// since the content may be stale by the time a worker gets to it.
+type operationRef struct {
+	subscriptionID string
+	operationID    string
+}
+
 type OperationsScanner struct {
 	dbClient            database.DBClient
-	lockClient          *database.LockClient
+	lockClient          database.LockClient
 	clusterService      ocm.ClusterServiceClient
 	notificationClient  *http.Client
 	subscriptions       []string
@@ -58,12 +81,32 @@ type OperationsScanner struct {
 	subscriptionChannel chan string
 	subscriptionWorkers sync.WaitGroup
 
+	// Used only when reading the change feed.
+	changeFeedToken      string
+	activeOperations     map[string]string // operation ID -> subscription ID
+	activeOperationsLock sync.Mutex
+	operationChannel     chan operationRef
+
+	schedule          *operationSchedule
+	notificationRetry *notificationRetry
+
+	// Used only in partition ownership mode.
+	partitions *partitionOwner
+
+	// How long to keep operations after they reach a terminal status.
+	operationRetention time.Duration
+
 	leaderGauge            prometheus.Gauge
 	workerGauge            prometheus.Gauge
+	partitionGauge         prometheus.Gauge
 	operationsCount        *prometheus.CounterVec
 	operationsFailedCount  *prometheus.CounterVec
 	operationsDuration     *prometheus.HistogramVec
 	lastOperationTimestamp *prometheus.GaugeVec
+	schedulingDecisions    *prometheus.CounterVec
+	notificationDeliveries *prometheus.CounterVec
+	notificationLatency    prometheus.Histogram
+	operationsCollected    *prometheus.CounterVec
 }
 
 func NewOperationsScanner(dbClient database.DBClient, ocmConnection *ocmsdk.Connection) *OperationsScanner {
@@ -71,8 +114,11 @@ func NewOperationsScanner(dbClient database.DBClient, ocmConnection *ocmsdk.Conn
 		dbClient:           dbClient,
 		lockClient:         dbClient.GetLockClient(),
 		clusterService:     ocm.ClusterServiceClient{Conn: ocmConnection},
-		notificationClient: http.DefaultClient,
+		notificationClient: &http.Client{Timeout: defaultNotificationTimeout},
 		subscriptions:      make([]string, 0),
+		schedule:           newOperationSchedule(defaultPollIntervalOperations, defaultMaxPollIntervalOperations, defaultMaxPollBackoff, defaultOperationDeadline),
+		notificationRetry:  newNotificationRetry(defaultNotificationBackoff, defaultMaxNotificationBackoff, defaultMaxNotificationAttempts),
+		operationRetention: defaultOperationRetention,
 
 		leaderGauge: promauto.With(prometheus.DefaultRegisterer).NewGauge(
 			prometheus.GaugeOpts{
@@ -86,6 +132,12 @@ func NewOperationsScanner(dbClient database.DBClient, ocmConnection *ocmsdk.Conn
 				Help: "Number of concurrent workers.",
 			},
 		),
+		partitionGauge: promauto.With(prometheus.DefaultRegisterer).NewGauge(
+			prometheus.GaugeOpts{
+				Name: "backend_owned_partitions",
+				Help: "Number of subscription partitions owned in partition ownership mode.",
+			},
+		),
 		operationsCount: promauto.With(prometheus.DefaultRegisterer).NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "backend_operations_total",
@@ -118,6 +170,37 @@ func NewOperationsScanner(dbClient database.DBClient, ocmConnection *ocmsdk.Conn
 			},
 			[]string{"type"},
 		),
+		schedulingDecisions: promauto.With(prometheus.DefaultRegisterer).NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "backend_operation_scheduling_decisions_total",
+				Help: "Total count of operation scheduling decisions.",
+			},
+			[]string{"decision"},
+		),
+		notificationDeliveries: promauto.With(prometheus.DefaultRegisterer).NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "backend_async_notifications_total",
+				Help: "Total count of async notifications by result.",
+			},
+			[]string{"result"},
+		),
+		notificationLatency: promauto.With(prometheus.DefaultRegisterer).NewHistogram(
+			prometheus.HistogramOpts{
+				Name:                            "backend_async_notification_delivery_seconds",
+				Help:                            "Histogram of time from recording to delivering async notifications.",
+				Buckets:                         []float64{.25, 1, 10, 60, 600, 3600},
+				NativeHistogramBucketFactor:     1.1,
+				NativeHistogramMaxBucketNumber:  100,
+				NativeHistogramMinResetDuration: 1 * time.Hour,
+			},
+		),
+		operationsCollected: promauto.With(prometheus.DefaultRegisterer).NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "backend_operations_collected_total",
+				Help: "Total count of terminal operations deleted after the retention period.",
+			},
+			[]string{"status"},
+		),
 	}
 
 	// Initialize the counter and histogram metrics.
@@ -125,8 +208,17 @@ func NewOperationsScanner(dbClient database.DBClient, ocmConnection *ocmsdk.Conn
 		collectSubscriptionsLabel,
 		processSubscriptionsLabel,
 		processOperationsLabel,
+		collectOperationLabel,
+		readChangeFeedLabel,
+		processActiveOperationsLabel,
 		pollClusterOperationLabel,
+		pollControlPlaneUpgradePolicyLabel,
 		pollNodePoolOperationLabel,
+		pollNodePoolUpgradePolicyLabel,
+		pollBreakGlassCredentialLabel,
+		pollBreakGlassCredentialRevokeLabel,
+		postPendingNotificationLabel,
+		deliverNotificationsLabel,
 	} {
 		s.operationsCount.WithLabelValues(v)
 		s.operationsFailedCount.WithLabelValues(v)
@@ -134,6 +226,32 @@ func NewOperationsScanner(dbClient database.DBClient, ocmConnection *ocmsdk.Conn
 		s.lastOperationTimestamp.WithLabelValues(v)
 	}
 
+	for _, v := range []scheduleDecision{
+		scheduleDecisionPoll,
+		scheduleDecisionDefer,
+		scheduleDecisionBackoff,
+		scheduleDecisionExpire,
+	} {
+		s.schedulingDecisions.WithLabelValues(string(v))
+	}
+
+	for _, v := range []notificationResult{
+		notificationResultDelivered,
+		notificationResultRetry,
+		notificationResultAbandoned,
+		notificationResultDuplicate,
+	} {
+		s.notificationDeliveries.WithLabelValues(string(v))
+	}
+
+	for _, v := range []arm.ProvisioningState{
+		arm.ProvisioningStateSucceeded,
+		arm.ProvisioningStateFailed,
+		arm.ProvisioningStateCanceled,
+	} {
+		s.operationsCollected.WithLabelValues(string(v))
+	}
+
 	return s
 }
 
@@ -172,44 +290,187 @@ func getPositiveInt(envName string, defaultVal int, logger *slog.Logger) int {
 	return defaultVal
 }
 
+// getBool parses an environment variable into a boolean.
+// If the environment variable is not defined or its value is invalid,
+// getBool returns defaultVal.
+func getBool(envName string, defaultVal bool, logger *slog.Logger) bool {
+	if boolString, ok := os.LookupEnv(envName); ok {
+		b, err := strconv.ParseBool(boolString)
+		if err == nil {
+			return b
+		}
+
+		logger.Warn(fmt.Sprintf("Cannot use %s: %v", envName, err.Error()))
+	}
+
+	return defaultVal
+}
+
 // Run executes the main loop of the OperationsScanner.
+//
+// By default, Run polls every subscription for operations at a fixed interval.
+// In change feed mode, Run instead learns of new and updated operations from
+// the change feed of the "Resources" container and polls only those operations
+// still in progress. Polling every subscription then serves only as a slow
+// safety sweep.
+//
+// In partition ownership mode, Run only processes subscriptions in partitions
+// owned by this replica, and periodically rebalances partition ownership with
+// other replicas.
+//
+// In all modes, Run also retries failed async notifications recorded in the
+// notification outbox, and deletes operations that reached a terminal status
+// longer ago than the operation retention period when it next polls their
+// subscription.
 func (s *OperationsScanner) Run(ctx context.Context, logger *slog.Logger) {
 	var interval time.Duration
 
+	// These remain nil unless change feed mode is enabled.
+	var readChangeFeedTicker <-chan time.Time
+	var processActiveOperationsTicker <-chan time.Time
+
+	// This remains nil unless partition ownership mode is enabled.
+	var rebalanceTicker <-chan time.Time
+
+	if s.partitions != nil {
+		_, err := s.partitions.Join(ctx, logger)
+		if err != nil {
+			return
+		}
+		defer s.partitions.Leave(context.Background(), logger)
+
+		interval = getInterval("BACKEND_REBALANCE_INTERVAL", defaultRebalanceInterval, logger)
+		logger.Info(fmt.Sprintf("Rebalancing %d partitions every %s", s.partitions.partitionCount, interval))
+		rebalanceTicker = time.NewTicker(interval).C
+
+		s.rebalancePartitions(ctx, logger)
+	}
+
+	changeFeed := getBool("BACKEND_CHANGE_FEED", false, logger)
+
 	interval = getInterval("BACKEND_POLL_INTERVAL_SUBSCRIPTIONS", defaultPollIntervalSubscriptions, logger)
 	logger.Info("Polling subscriptions in Cosmos DB every " + interval.String())
 	collectSubscriptionsTicker := time.NewTicker(interval)
 
-	interval = getInterval("BACKEND_POLL_INTERVAL_OPERATIONS", defaultPollIntervalOperations, logger)
-	logger.Info("Polling operations in Cosmos DB every " + interval.String())
+	pollInterval := getInterval("BACKEND_POLL_INTERVAL_OPERATIONS", defaultPollIntervalOperations, logger)
+
+	if changeFeed {
+		interval = getInterval("BACKEND_SWEEP_INTERVAL_OPERATIONS", defaultSweepIntervalOperations, logger)
+		logger.Info("Sweeping operations in Cosmos DB every " + interval.String())
+	} else {
+		interval = pollInterval
+		logger.Info("Polling operations in Cosmos DB every " + interval.String())
+	}
 	processSubscriptionsTicker := time.NewTicker(interval)
 
+	if changeFeed {
+		interval = getInterval("BACKEND_POLL_INTERVAL_CHANGE_FEED", defaultPollIntervalChangeFeed, logger)
+		logger.Info("Reading the Cosmos DB change feed every " + interval.String())
+		readChangeFeedTicker = time.NewTicker(interval).C
+
+		logger.Info("Polling active operations every " + pollInterval.String())
+		processActiveOperationsTicker = time.NewTicker(pollInterval).C
+
+		s.activeOperations = make(map[string]string)
+
+		continuationToken, err := s.dbClient.GetChangeFeedToken(ctx, s.getChangeFeedName())
+		if err != nil {
+			// Reading the change feed from the beginning is
+			// wasteful but harmless.
+			logger.Error(fmt.Sprintf("Failed to get change feed continuation token: %v", err))
+		}
+		s.changeFeedToken = continuationToken
+	}
+
+	maxPollInterval := getInterval("BACKEND_MAX_POLL_INTERVAL_OPERATIONS", defaultMaxPollIntervalOperations, logger)
+	maxBackoff := getInterval("BACKEND_MAX_POLL_BACKOFF", defaultMaxPollBackoff, logger)
+	deadline := getInterval("BACKEND_OPERATION_DEADLINE", defaultOperationDeadline, logger)
+	logger.Info(fmt.Sprintf("Polling each operation at most every %s, backing off up to %s on errors", maxPollInterval, maxBackoff))
+	if deadline > 0 {
+		logger.Info("Failing operations not completed within " + deadline.String())
+	}
+	s.schedule = newOperationSchedule(pollInterval, maxPollInterval, maxBackoff, deadline)
+
+	s.operationRetention = getInterval("BACKEND_OPERATION_RETENTION", defaultOperationRetention, logger)
+	if s.operationRetention <= 0 {
+		logger.Warn("Cannot use BACKEND_OPERATION_RETENTION: value must be positive")
+		s.operationRetention = defaultOperationRetention
+	} else if s.operationRetention > database.OperationTimeToLive {
+		logger.Warn("Cannot use BACKEND_OPERATION_RETENTION: value must not exceed " + database.OperationTimeToLive.String())
+		s.operationRetention = database.OperationTimeToLive
+	}
+	logger.Info("Deleting operations " + s.operationRetention.String() + " after they complete")
+
+	interval = getInterval("BACKEND_POLL_INTERVAL_NOTIFICATIONS", defaultPollIntervalNotifications, logger)
+	logger.Info("Retrying async notifications every " + interval.String())
+	deliverNotificationsTicker := time.NewTicker(interval)
+
+	notificationBackoff := getInterval("BACKEND_NOTIFICATION_BACKOFF", defaultNotificationBackoff, logger)
+	maxNotificationBackoff := getInterval("BACKEND_MAX_NOTIFICATION_BACKOFF", defaultMaxNotificationBackoff, logger)
+	maxNotificationAttempts := getPositiveInt("BACKEND_MAX_NOTIFICATION_ATTEMPTS", defaultMaxNotificationAttempts, logger)
+	logger.Info(fmt.Sprintf("Backing off async notifications from %s up to %s, giving up after %d attempts", notificationBackoff, maxNotificationBackoff, maxNotificationAttempts))
+	s.notificationRetry = newNotificationRetry(notificationBackoff, maxNotificationBackoff, maxNotificationAttempts)
+
 	numWorkers := getPositiveInt("BACKEND_SUBSCRIPTION_CONCURRENCY", defaultSubscriptionConcurrency, logger)
 	logger.Info(fmt.Sprintf("Processing %d subscriptions at a time", numWorkers))
 	s.workerGauge.Set(float64(numWorkers))
 
-	// Create a buffered channel using worker pool size as a heuristic.
+	// Create buffered channels using worker pool size as a heuristic.
 	s.subscriptionChannel = make(chan string, numWorkers)
 	defer close(s.subscriptionChannel)
+	s.operationChannel = make(chan operationRef, numWorkers)
+	defer close(s.operationChannel)
 
-	// In this worker pool, each worker processes all operations within
-	// a single Azure subscription / Cosmos DB partition.
+	// In this worker pool, each worker processes either all operations
+	// within a single Azure subscription / Cosmos DB partition, or else
+	// a single operation.
 	for i := 0; i < numWorkers; i++ {
 		go func() {
 			defer s.subscriptionWorkers.Done()
-			for subscriptionID := range s.subscriptionChannel {
-				subscriptionLogger := logger.With("subscription_id", subscriptionID)
-				s.withSubscriptionLock(ctx, subscriptionLogger, subscriptionID, func(ctx context.Context) {
+			for {
+				select {
+				case subscriptionID, ok := <-s.subscriptionChannel:
+					if !ok {
+						return
+					}
+					subscriptionLogger := logger.With("subscription_id", subscriptionID)
 					s.processOperations(ctx, subscriptionID, subscriptionLogger)
-				})
+				case ref, ok := <-s.operationChannel:
+					if !ok {
+						return
+					}
+					subscriptionLogger := logger.With("subscription_id", ref.subscriptionID)
+					s.processOperation(ctx, ref, subscriptionLogger)
+				}
 			}
 		}()
 	}
 	s.subscriptionWorkers.Add(numWorkers)
 
+	// Retry async notifications from a separate goroutine
+	// so slow notification endpoints cannot stall the loop.
+	go func() {
+		defer s.subscriptionWorkers.Done()
+		for {
+			select {
+			case <-deliverNotificationsTicker.C:
+				s.deliverNotifications(ctx, time.Now(), logger)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	s.subscriptionWorkers.Add(1)
+
 	// Collect subscriptions immediately on startup.
 	s.collectSubscriptions(ctx, logger)
 
+	// In change feed mode, also sweep immediately on startup to
+	// learn of operations already in progress.
+	if changeFeed {
+		s.processSubscriptions(logger)
+	}
+
 loop:
 	for {
 		select {
@@ -217,6 +478,15 @@ loop:
 			s.collectSubscriptions(ctx, logger)
 		case <-processSubscriptionsTicker.C:
 			s.processSubscriptions(logger)
+		case <-readChangeFeedTicker:
+			s.readChangeFeed(ctx, logger)
+		case <-processActiveOperationsTicker:
+			s.processActiveOperations(logger)
+		case <-rebalanceTicker:
+			if s.rebalancePartitions(ctx, logger) {
+				// Process newly claimed partitions without delay.
+				s.processSubscriptions(logger)
+			}
 		case <-ctx.Done():
 			// break alone just breaks out of select.
 			// Use a label to break out of the loop.
@@ -231,6 +501,30 @@ func (s *OperationsScanner) Join() {
 	s.leaderGauge.Set(0)
 }
 
+// getChangeFeedName returns the name under which to persist the change feed
+// continuation token. Each replica needs its own in partition ownership mode.
+func (s *OperationsScanner) getChangeFeedName() string {
+	if s.partitions != nil {
+		return fmt.Sprintf("%s-%d", changeFeedName, s.partitions.replicaSlot)
+	}
+	return changeFeedName
+}
+
+// rebalancePartitions adjusts partition ownership and returns true if any
+// partitions were claimed.
+func (s *OperationsScanner) rebalancePartitions(ctx context.Context, logger *slog.Logger) bool {
+	claimed := s.partitions.Rebalance(ctx, logger)
+	s.partitionGauge.Set(float64(s.partitions.Count()))
+	return claimed
+}
+
+// ownsSubscription returns true if this replica is responsible for processing
+// operations in the given Azure subscription. Without partition ownership mode
+// this is always true.
+func (s *OperationsScanner) ownsSubscription(subscriptionID string) bool {
+	return s.partitions == nil || s.partitions.Owns(subscriptionID)
+}
+
 func (s *OperationsScanner) updateOperationMetrics(label string) func() {
 	startTime := time.Now()
 	s.operationsCount.WithLabelValues(label).Inc()
@@ -288,6 +582,10 @@ func (s *OperationsScanner) processSubscriptions(logger *slog.Logger) {
 	s.subscriptionsLock.Unlock()
 
 	for _, subscriptionID := range subscriptions {
+		if !s.ownsSubscription(subscriptionID) {
+			continue
+		}
+
 		select {
 		case s.subscriptionChannel <- subscriptionID:
 		default:
@@ -312,22 +610,21 @@ func (s *OperationsScanner) processOperations(ctx context.Context, subscriptionI
 	iterator := s.dbClient.ListOperationDocs(pk)
 
 	for operationID, operationDoc := range iterator.Items(ctx) {
-		if !operationDoc.Status.IsTerminal() {
-			operationLogger := logger.With(
-				"operation", operationDoc.Request,
-				"operation_id", operationID,
-				"resource_id", operationDoc.ExternalID.String(),
-				"internal_id", operationDoc.InternalID.String())
-			op := operation{operationID, pk, operationDoc, operationLogger}
-
-			switch operationDoc.InternalID.Kind() {
-			case cmv1.ClusterKind:
-				s.pollClusterOperation(ctx, op)
-				numProcessed++
-			case cmv1.NodePoolKind:
-				s.pollNodePoolOperation(ctx, op)
-				numProcessed++
+		if !operationNeedsProcessing(operationDoc) {
+			s.schedule.forget(operationID)
+			if operationExpired(operationDoc, s.operationRetention, time.Now()) {
+				s.collectOperation(ctx, newOperation(operationID, pk, operationDoc, logger))
 			}
+			continue
+		}
+
+		if !operationDoc.Status.IsTerminal() {
+			s.trackOperation(operationRef{subscriptionID, operationID})
+		}
+
+		op := newOperation(operationID, pk, operationDoc, logger)
+		if s.pollOperation(ctx, op) {
+			numProcessed++
 		}
 	}
 
@@ -338,8 +635,287 @@ func (s *OperationsScanner) processOperations(ctx context.Context, subscriptionI
 	}
 }
 
+// processOperation processes a single operation in an Azure subscription.
+func (s *OperationsScanner) processOperation(ctx context.Context, ref operationRef, logger *slog.Logger) {
+	pk := database.NewPartitionKey(ref.subscriptionID)
+
+	operationDoc, err := s.dbClient.GetOperationDoc(ctx, pk, ref.operationID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			s.forgetOperation(ref.operationID)
+		} else {
+			logger.Error(fmt.Sprintf("Failed to get operation: %v", err))
+		}
+		return
+	}
+
+	if !operationNeedsProcessing(operationDoc) {
+		s.forgetOperation(ref.operationID)
+		return
+	}
+
+	op := newOperation(ref.operationID, pk, operationDoc, logger)
+	if !s.pollOperation(ctx, op) {
+		s.forgetOperation(ref.operationID)
+	}
+}
+
+// newOperation returns an operation with a logger enriched with attributes
+// from the operation document.
+func newOperation(operationID string, pk azcosmos.PartitionKey, operationDoc *database.OperationDocument, logger *slog.Logger) operation {
+	operationLogger := logger.With(
+		"operation", operationDoc.Request,
+		"operation_id", operationID,
+		"resource_id", operationDoc.ExternalID.String(),
+		"internal_id", operationDoc.InternalID.String())
+	return operation{operationID, pk, operationDoc, operationLogger}
+}
+
+// operationNeedsProcessing returns true if the backend has yet to finish
+// with an operation.
+func operationNeedsProcessing(operationDoc *database.OperationDocument) bool {
+	return !operationDoc.Status.IsTerminal() || operationDoc.NotificationPending
+}
+
+// operationExpired returns true if an operation has been in a terminal
+// status for longer than the retention period.
+func operationExpired(operationDoc *database.OperationDocument, retention time.Duration, now time.Time) bool {
+	return operationDoc.Status.IsTerminal() && now.Sub(operationDoc.LastTransitionTime) > retention
+}
+
+// collectOperation deletes an operation that has been in a terminal
+// status for longer than the retention period. The operation status
+// and result endpoints respond with "404 Not Found" thereafter.
+func (s *OperationsScanner) collectOperation(ctx context.Context, op operation) {
+	defer s.updateOperationMetrics(collectOperationLabel)()
+
+	err := s.dbClient.DeleteOperationDoc(ctx, op.pk, op.id)
+	if err != nil {
+		s.operationsFailedCount.WithLabelValues(collectOperationLabel).Inc()
+		op.logger.Error(fmt.Sprintf("Failed to delete expired operation: %v", err))
+		return
+	}
+
+	s.operationsCollected.WithLabelValues(string(op.doc.Status)).Inc()
+	op.logger.Info("Deleted expired operation")
+}
+
+// pollOperation updates the status of an operation according to its type,
+// if the operation schedule says the operation is due. It returns false if
+// the operation is of a type the backend does not handle.
+func (s *OperationsScanner) pollOperation(ctx context.Context, op operation) bool {
+	var err error
+
+	// An operation may owe ARM an async notification if it reached
+	// a terminal status outside of the backend, such as by cancellation,
+	// or if recording the notification failed previously.
+	if op.doc.NotificationPending {
+		s.postPendingNotification(ctx, op)
+	}
+
+	// A terminal operation is no longer polled.
+	if op.doc.Status.IsTerminal() {
+		s.schedule.forget(op.id)
+		return true
+	}
+
+	switch op.doc.InternalID.Kind() {
+	case cmv1.ClusterKind, cmv1.ControlPlaneUpgradePolicyKind, cmv1.NodePoolKind, cmv1.NodePoolUpgradePolicyKind, cmv1.BreakGlassCredentialKind:
+	default:
+		return false
+	}
+
+	decision := s.schedule.decide(op.id, op.doc.StartTime, time.Now(), operationCanExpire(op.doc))
+	s.schedulingDecisions.WithLabelValues(string(decision)).Inc()
+
+	if decision == scheduleDecisionDefer {
+		return true
+	}
+
+	// Lock the operation's resource so the frontend does not
+	// change the resource or the operation at the same time.
+	locked := s.withResourceLock(ctx, op.logger, op.doc.ExternalID, func(ctx context.Context) {
+		if decision == scheduleDecisionExpire {
+			s.expireOperation(ctx, op)
+			return
+		}
+
+		switch op.doc.InternalID.Kind() {
+		case cmv1.ClusterKind:
+			switch op.doc.Request {
+			case database.OperationRequestRevokeCredentials:
+				err = s.pollBreakGlassCredentialRevoke(ctx, op)
+			default:
+				err = s.pollClusterOperation(ctx, op)
+			}
+		case cmv1.ControlPlaneUpgradePolicyKind:
+			err = s.pollControlPlaneUpgradePolicy(ctx, op)
+		case cmv1.NodePoolKind:
+			err = s.pollNodePoolOperation(ctx, op)
+		case cmv1.NodePoolUpgradePolicyKind:
+			err = s.pollNodePoolUpgradePolicy(ctx, op)
+		case cmv1.BreakGlassCredentialKind:
+			err = s.pollBreakGlassCredential(ctx, op)
+		}
+	})
+	if !locked || decision == scheduleDecisionExpire {
+		// Try again on the next scan.
+		return true
+	}
+
+	delay := s.schedule.record(op.id, op.doc.StartTime, time.Now(), err)
+	if err != nil {
+		s.schedulingDecisions.WithLabelValues(string(scheduleDecisionBackoff)).Inc()
+		op.logger.Info(fmt.Sprintf("Backing off for %s", delay))
+	}
+
+	return true
+}
+
+// operationCanExpire returns true if the operation deadline applies to an
+// operation. The backend does not yet convert node pool status, so polling
+// only ever ends node pool deletions. Failing node pool creates and updates
+// at the deadline would fail node pools that Cluster Service provisioned.
+func operationCanExpire(doc *database.OperationDocument) bool {
+	if doc.InternalID.Kind() == cmv1.NodePoolKind {
+		return doc.Request == database.OperationRequestDelete
+	}
+	return true
+}
+
+// expireOperation fails an operation that exceeded the operation deadline.
+// Whatever Cluster Service is doing carries on, but ARM and the client stop
+// waiting on it.
+func (s *OperationsScanner) expireOperation(ctx context.Context, op operation) {
+	opError := &arm.CloudErrorBody{
+		Code:    arm.CloudErrorCodeOperationTimedOut,
+		Message: fmt.Sprintf("The operation did not complete within %s", s.schedule.deadline),
+	}
+
+	err := s.updateOperationStatus(ctx, op, arm.ProvisioningStateFailed, opError)
+	if err != nil {
+		op.logger.Error(fmt.Sprintf("Failed to update operation status: %v", err))
+		return
+	}
+
+	op.logger.Warn("Operation exceeded its deadline")
+}
+
+// readChangeFeed reads operations created or modified since the last read of
+// the change feed. New operations are dispatched to the worker pool at once and
+// then tracked as active operations until they reach a terminal status.
+func (s *OperationsScanner) readChangeFeed(ctx context.Context, logger *slog.Logger) {
+	defer s.updateOperationMetrics(readChangeFeedLabel)()
+
+	iterator := s.dbClient.ListOperationDocChanges(s.changeFeedToken)
+
+	for operationID, operationDoc := range iterator.Items(ctx) {
+		ref := operationRef{operationDoc.ExternalID.SubscriptionID, operationID}
+
+		switch {
+		case !s.ownsSubscription(ref.subscriptionID):
+			s.forgetOperation(operationID)
+		case !operationNeedsProcessing(operationDoc):
+			s.forgetOperation(operationID)
+		case operationDoc.Status.IsTerminal():
+			// Owes ARM an async notification.
+			s.dispatchOperation(ref, logger)
+		case s.trackOperation(ref):
+			s.dispatchOperation(ref, logger)
+		}
+	}
+
+	err := iterator.GetError()
+	if err != nil {
+		s.operationsFailedCount.WithLabelValues(readChangeFeedLabel).Inc()
+		logger.Error(fmt.Sprintf("Error while reading the change feed: %v", err.Error()))
+		return
+	}
+
+	continuationToken := iterator.GetContinuationToken()
+	if continuationToken != s.changeFeedToken {
+		s.changeFeedToken = continuationToken
+
+		// Failure here only means changes will be read
+		// again if the backend restarts, which is harmless.
+		err = s.dbClient.SetChangeFeedToken(ctx, s.getChangeFeedName(), continuationToken)
+		if err != nil {
+			s.operationsFailedCount.WithLabelValues(readChangeFeedLabel).Inc()
+			logger.Error(fmt.Sprintf("Failed to persist change feed continuation token: %v", err))
+		}
+	}
+}
+
+// processActiveOperations feeds the active operations learned from the change
+// feed to the worker pool for processing. processActiveOperations may block if
+// the worker pool gets overloaded. The log will indicate if this occurs.
+func (s *OperationsScanner) processActiveOperations(logger *slog.Logger) {
+	defer s.updateOperationMetrics(processActiveOperationsLabel)()
+
+	s.activeOperationsLock.Lock()
+	refs := make([]operationRef, 0, len(s.activeOperations))
+	for operationID, subscriptionID := range s.activeOperations {
+		refs = append(refs, operationRef{subscriptionID, operationID})
+	}
+	s.activeOperationsLock.Unlock()
+
+	for _, ref := range refs {
+		// Partition ownership may have changed.
+		if !s.ownsSubscription(ref.subscriptionID) {
+			s.forgetOperation(ref.operationID)
+			continue
+		}
+		s.dispatchOperation(ref, logger)
+	}
+}
+
+// dispatchOperation feeds a single operation to the worker pool for processing.
+func (s *OperationsScanner) dispatchOperation(ref operationRef, logger *slog.Logger) {
+	select {
+	case s.operationChannel <- ref:
+	default:
+		// The channel is full. Push the operation anyway
+		// but log how long we block for. This will indicate
+		// when the worker pool size needs increased.
+		start := time.Now()
+		s.operationChannel <- ref
+		logger.Warn(fmt.Sprintf("Operation processing blocked for %s", time.Since(start)))
+	}
+}
+
+// trackOperation adds an operation to the set of active operations if change
+// feed mode is enabled. It returns true if the operation was not already in the
+// set.
+func (s *OperationsScanner) trackOperation(ref operationRef) bool {
+	s.activeOperationsLock.Lock()
+	defer s.activeOperationsLock.Unlock()
+
+	if s.activeOperations == nil {
+		return false
+	}
+
+	operationID := strings.ToLower(ref.operationID)
+	if _, ok := s.activeOperations[operationID]; ok {
+		return false
+	}
+
+	s.activeOperations[operationID] = ref.subscriptionID
+	return true
+}
+
+// forgetOperation removes an operation from the set of active operations
+// and discards its scheduling state.
+func (s *OperationsScanner) forgetOperation(operationID string) {
+	s.schedule.forget(operationID)
+
+	s.activeOperationsLock.Lock()
+	defer s.activeOperationsLock.Unlock()
+
+	delete(s.activeOperations, strings.ToLower(operationID))
+}
+
 // pollClusterOperation updates the status of a cluster operation.
-func (s *OperationsScanner) pollClusterOperation(ctx context.Context, op operation) {
+func (s *OperationsScanner) pollClusterOperation(ctx context.Context, op operation) error {
 	defer s.updateOperationMetrics(pollClusterOperationLabel)()
 
 	clusterStatus, err := s.clusterService.GetClusterStatus(ctx, op.doc.InternalID)
@@ -355,14 +931,14 @@ func (s *OperationsScanner) pollClusterOperation(ctx context.Context, op operati
 		}
 
 		s.operationsFailedCount.WithLabelValues(pollClusterOperationLabel).Inc()
-		return
+		return err
 	}
 
 	opStatus, opError, err := convertClusterStatus(clusterStatus, op.doc.Status)
 	if err != nil {
 		s.operationsFailedCount.WithLabelValues(pollClusterOperationLabel).Inc()
 		op.logger.Warn(err.Error())
-		return
+		return err
 	}
 
 	err = s.updateOperationStatus(ctx, op, opStatus, opError)
@@ -370,10 +946,12 @@ func (s *OperationsScanner) pollClusterOperation(ctx context.Context, op operati
 		s.operationsFailedCount.WithLabelValues(pollClusterOperationLabel).Inc()
 		op.logger.Error(fmt.Sprintf("Failed to update operation status: %v", err))
 	}
+
+	return err
 }
 
 // pollNodePoolOperation updates the status of a node pool operation.
-func (s *OperationsScanner) pollNodePoolOperation(ctx context.Context, op operation) {
+func (s *OperationsScanner) pollNodePoolOperation(ctx context.Context, op operation) error {
 	defer s.updateOperationMetrics(pollNodePoolOperationLabel)()
 
 	_, err := s.clusterService.GetNodePool(ctx, op.doc.InternalID)
@@ -387,19 +965,190 @@ func (s *OperationsScanner) pollNodePoolOperation(ctx context.Context, op operat
 		} else {
 			op.logger.Error(fmt.Sprintf("Failed to get node pool status: %v", err))
 		}
-		return
 	}
+
+	return err
+}
+
+// pollNodePoolUpgradePolicy updates the status of a node pool upgrade operation.
+func (s *OperationsScanner) pollNodePoolUpgradePolicy(ctx context.Context, op operation) error {
+	defer s.updateOperationMetrics(pollNodePoolUpgradePolicyLabel)()
+
+	upgradePolicy, err := s.clusterService.GetNodePoolUpgradePolicy(ctx, op.doc.InternalID)
+	if err != nil {
+		var ocmError *ocmerrors.Error
+		if errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound {
+			// The upgrade policy is gone, most likely because the node
+			// pool was deleted. There is nothing left to poll, so fail
+			// the operation rather than leave it dangling.
+			opError := &arm.CloudErrorBody{
+				Code:    arm.CloudErrorCodeNotFound,
+				Message: "The node pool upgrade no longer exists",
+			}
+			err = s.updateOperationStatus(ctx, op, arm.ProvisioningStateFailed, opError)
+			if err != nil {
+				op.logger.Error(fmt.Sprintf("Failed to update operation status: %v", err))
+			}
+		} else {
+			op.logger.Error(fmt.Sprintf("Failed to get node pool upgrade policy: %v", err))
+		}
+
+		s.operationsFailedCount.WithLabelValues(pollNodePoolUpgradePolicyLabel).Inc()
+		return err
+	}
+
+	return s.updateUpgradeOperation(ctx, op, pollNodePoolUpgradePolicyLabel, upgradePolicy.State())
+}
+
+// pollControlPlaneUpgradePolicy updates the status of a cluster control plane
+// upgrade operation.
+func (s *OperationsScanner) pollControlPlaneUpgradePolicy(ctx context.Context, op operation) error {
+	defer s.updateOperationMetrics(pollControlPlaneUpgradePolicyLabel)()
+
+	upgradePolicy, err := s.clusterService.GetControlPlaneUpgradePolicy(ctx, op.doc.InternalID)
+	if err != nil {
+		var ocmError *ocmerrors.Error
+		if errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound {
+			// The upgrade policy is gone, most likely because the
+			// cluster was deleted. There is nothing left to poll, so
+			// fail the operation rather than leave it dangling.
+			opError := &arm.CloudErrorBody{
+				Code:    arm.CloudErrorCodeNotFound,
+				Message: "The cluster upgrade no longer exists",
+			}
+			err = s.updateOperationStatus(ctx, op, arm.ProvisioningStateFailed, opError)
+			if err != nil {
+				op.logger.Error(fmt.Sprintf("Failed to update operation status: %v", err))
+			}
+		} else {
+			op.logger.Error(fmt.Sprintf("Failed to get control plane upgrade policy: %v", err))
+		}
+
+		s.operationsFailedCount.WithLabelValues(pollControlPlaneUpgradePolicyLabel).Inc()
+		return err
+	}
+
+	return s.updateUpgradeOperation(ctx, op, pollControlPlaneUpgradePolicyLabel, upgradePolicy.State())
 }
 
-// withSubscriptionLock holds a subscription lock while executing the given function.
-// In the event the subscription lock is lost, the context passed to the function will
-// be canceled.
-func (s *OperationsScanner) withSubscriptionLock(ctx context.Context, logger *slog.Logger, subscriptionID string, fn func(ctx context.Context)) {
-	timeout := s.lockClient.GetDefaultTimeToLive()
-	lock, err := s.lockClient.AcquireLock(ctx, subscriptionID, &timeout)
+// updateUpgradeOperation updates the status and progress of an upgrade
+// operation from the state of a Cluster Service upgrade policy.
+func (s *OperationsScanner) updateUpgradeOperation(ctx context.Context, op operation, label string, state *cmv1.UpgradePolicyState) error {
+	opStatus, opError, err := convertUpgradePolicyState(state, op.doc.Status)
+	if err != nil {
+		s.operationsFailedCount.WithLabelValues(label).Inc()
+		op.logger.Warn(err.Error())
+		return err
+	}
+
+	// Update progress first so the operation never
+	// reaches a terminal status with stale progress.
+	percentComplete := convertUpgradePolicyProgress(state, op.doc.PercentComplete)
+	err = s.updateOperationProgress(ctx, op, percentComplete)
+	if err != nil {
+		s.operationsFailedCount.WithLabelValues(label).Inc()
+		op.logger.Error(fmt.Sprintf("Failed to update operation progress: %v", err))
+		return err
+	}
+
+	err = s.updateOperationStatus(ctx, op, opStatus, opError)
+	if err != nil {
+		s.operationsFailedCount.WithLabelValues(label).Inc()
+		op.logger.Error(fmt.Sprintf("Failed to update operation status: %v", err))
+	}
+
+	return err
+}
+
+// pollBreakGlassCredential updates the status of a credential creation operation.
+func (s *OperationsScanner) pollBreakGlassCredential(ctx context.Context, op operation) error {
+	defer s.updateOperationMetrics(pollBreakGlassCredentialLabel)()
+
+	breakGlassCredential, err := s.clusterService.GetBreakGlassCredential(ctx, op.doc.InternalID)
+	if err != nil {
+		var ocmError *ocmerrors.Error
+		if errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound {
+			// The credential is gone, most likely because the cluster
+			// was deleted. There is nothing left to poll, so fail the
+			// operation rather than leave it dangling.
+			opError := &arm.CloudErrorBody{
+				Code:    arm.CloudErrorCodeNotFound,
+				Message: "The requested credential no longer exists",
+			}
+			err = s.updateOperationStatus(ctx, op, arm.ProvisioningStateFailed, opError)
+			if err != nil {
+				op.logger.Error(fmt.Sprintf("Failed to update operation status: %v", err))
+			}
+		} else {
+			op.logger.Error(fmt.Sprintf("Failed to get break-glass credential: %v", err))
+		}
+
+		s.operationsFailedCount.WithLabelValues(pollBreakGlassCredentialLabel).Inc()
+		return err
+	}
+
+	opStatus, opError, err := convertBreakGlassCredentialStatus(breakGlassCredential.Status(), op.doc.Status)
+	if err != nil {
+		s.operationsFailedCount.WithLabelValues(pollBreakGlassCredentialLabel).Inc()
+		op.logger.Warn(err.Error())
+		return err
+	}
+
+	err = s.updateOperationStatus(ctx, op, opStatus, opError)
+	if err != nil {
+		s.operationsFailedCount.WithLabelValues(pollBreakGlassCredentialLabel).Inc()
+		op.logger.Error(fmt.Sprintf("Failed to update operation status: %v", err))
+	}
+
+	return err
+}
+
+// pollBreakGlassCredentialRevoke updates the status of a credential revocation operation.
+func (s *OperationsScanner) pollBreakGlassCredentialRevoke(ctx context.Context, op operation) error {
+	defer s.updateOperationMetrics(pollBreakGlassCredentialRevokeLabel)()
+
+	var opStatus arm.ProvisioningState = arm.ProvisioningStateSucceeded
+
+	// Revocation is complete once no credentials remain awaiting revocation.
+	iterator := s.clusterService.ListBreakGlassCredentials(op.doc.InternalID, "")
+	for breakGlassCredential := range iterator.Items(ctx) {
+		if breakGlassCredential.Status() == cmv1.BreakGlassCredentialStatusAwaitingRevocation {
+			opStatus = arm.ProvisioningStateDeleting
+			break
+		}
+	}
+
+	err := iterator.GetError()
+	if err != nil {
+		s.operationsFailedCount.WithLabelValues(pollBreakGlassCredentialRevokeLabel).Inc()
+		op.logger.Error(fmt.Sprintf("Failed to list break-glass credentials: %v", err))
+		return err
+	}
+
+	err = s.updateOperationStatus(ctx, op, opStatus, nil)
+	if err != nil {
+		s.operationsFailedCount.WithLabelValues(pollBreakGlassCredentialRevokeLabel).Inc()
+		op.logger.Error(fmt.Sprintf("Failed to update operation status: %v", err))
+	}
+
+	return err
+}
+
+// withResourceLock holds a resource lock while executing the given function.
+// The frontend holds the same lock while handling requests for the resource.
+// In the event the resource lock is lost, the context passed to the function
+// will be canceled. It returns false if the lock could not be acquired, in
+// which case the function is not executed. The lock is only tried once, so
+// a resource busy with a frontend request does not hold up a scanner worker.
+func (s *OperationsScanner) withResourceLock(ctx context.Context, logger *slog.Logger, resourceID *azcorearm.ResourceID, fn func(ctx context.Context)) bool {
+	lock, err := database.TryAcquireResourceLock(ctx, s.lockClient, resourceID)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to acquire lock: %v", err))
-		return
+		return false
+	}
+	if lock == nil {
+		logger.Info("Resource is locked")
+		return false
 	}
 
 	lockedCtx, stop := s.lockClient.HoldLock(ctx, lock)
@@ -414,6 +1163,8 @@ func (s *OperationsScanner) withSubscriptionLock(ctx context.Context, logger *sl
 			logger.Warn(fmt.Sprintf("Failed to release lock: %v", nonFatalErr))
 		}
 	}
+
+	return true
 }
 
 // setDeleteOperationAsCompleted updates Cosmos DB to reflect a completed resource deletion.
@@ -426,30 +1177,43 @@ func (s *OperationsScanner) setDeleteOperationAsCompleted(ctx context.Context, o
 	// Save a final "succeeded" operation status until TTL expires.
 	const opStatus arm.ProvisioningState = arm.ProvisioningStateSucceeded
 	updated, err := s.dbClient.UpdateOperationDoc(ctx, op.pk, op.id, func(updateDoc *database.OperationDocument) bool {
-		return updateDoc.UpdateStatus(opStatus, nil)
+		return updateOperationDocStatus(updateDoc, opStatus, nil)
 	})
 	if err != nil {
 		return err
 	}
 	if updated {
 		op.logger.Info("Deletion completed")
-		s.maybePostAsyncNotification(ctx, op)
+		s.notify(ctx, op, opStatus)
 	}
 
 	return nil
 }
 
+// updateOperationDocStatus is an UpdateOperationDoc callback that updates the
+// operation status and, if ARM expects async notifications, marks the operation
+// as owing one until the notification is recorded in the notification outbox.
+func updateOperationDocStatus(updateDoc *database.OperationDocument, opStatus arm.ProvisioningState, opError *arm.CloudErrorBody) bool {
+	if !updateDoc.UpdateStatus(opStatus, opError) {
+		return false
+	}
+	if updateDoc.NotificationURI != "" {
+		updateDoc.NotificationPending = true
+	}
+	return true
+}
+
 // updateOperationStatus updates Cosmos DB to reflect an updated resource status.
 func (s *OperationsScanner) updateOperationStatus(ctx context.Context, op operation, opStatus arm.ProvisioningState, opError *arm.CloudErrorBody) error {
 	updated, err := s.dbClient.UpdateOperationDoc(ctx, op.pk, op.id, func(updateDoc *database.OperationDocument) bool {
-		return updateDoc.UpdateStatus(opStatus, opError)
+		return updateOperationDocStatus(updateDoc, opStatus, opError)
 	})
 	if err != nil {
 		return err
 	}
 	if updated {
 		op.logger.Info(fmt.Sprintf("Updated status to '%s'", opStatus))
-		s.maybePostAsyncNotification(ctx, op)
+		s.notify(ctx, op, opStatus)
 	}
 
 	_, err = s.dbClient.UpdateResourceDoc(ctx, op.doc.ExternalID, func(updateDoc *database.ResourceDocument) bool {
@@ -475,47 +1239,16 @@ func (s *OperationsScanner) updateOperationStatus(ctx context.Context, op operat
 	return nil
 }
 
-// maybePostAsyncNotification attempts to notify ARM of a completed asynchronous
-// operation if the initial request included an "Azure-AsyncNotificationUri" header.
-func (s *OperationsScanner) maybePostAsyncNotification(ctx context.Context, op operation) {
-	if len(op.doc.NotificationURI) > 0 {
-		err := s.postAsyncNotification(ctx, op)
-		if err == nil {
-			op.logger.Info("Posted async notification")
-		} else {
-			op.logger.Error(fmt.Sprintf("Failed to post async notification: %v", err.Error()))
-		}
-	}
-}
-
-// postAsyncNotification submits an POST request with status payload to the given URL.
-func (s *OperationsScanner) postAsyncNotification(ctx context.Context, op operation) error {
-	// Refetch the operation document to provide the latest status.
-	doc, err := s.dbClient.GetOperationDoc(ctx, op.pk, op.id)
-	if err != nil {
-		return err
-	}
-
-	data, err := arm.Marshal(doc.ToStatus())
-	if err != nil {
-		return err
-	}
-
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.NotificationURI, bytes.NewBuffer(data))
-	if err != nil {
-		return err
-	}
-
-	request.Header.Set("Content-Type", "application/json")
-
-	response, err := s.notificationClient.Do(request)
+// updateOperationProgress updates Cosmos DB to reflect updated operation progress.
+func (s *OperationsScanner) updateOperationProgress(ctx context.Context, op operation, percentComplete float64) error {
+	updated, err := s.dbClient.UpdateOperationDoc(ctx, op.pk, op.id, func(updateDoc *database.OperationDocument) bool {
+		return updateDoc.UpdateProgress(percentComplete)
+	})
 	if err != nil {
 		return err
 	}
-
-	defer response.Body.Close()
-	if response.StatusCode >= 400 {
-		return errors.New(response.Status)
+	if updated {
+		op.logger.Info(fmt.Sprintf("Updated progress to %g%%", percentComplete))
 	}
 
 	return nil
@@ -567,3 +1300,94 @@ func convertClusterStatus(clusterStatus *arohcpv1alpha1.ClusterStatus, current a
 
 	return opStatus, opError, err
 }
+
+// convertBreakGlassCredentialStatus attempts to translate a BreakGlassCredentialStatus
+// from Cluster Service into an ARM provisioning state and, if necessary, a structured
+// OData error.
+func convertBreakGlassCredentialStatus(status cmv1.BreakGlassCredentialStatus, current arm.ProvisioningState) (arm.ProvisioningState, *arm.CloudErrorBody, error) {
+	var opStatus arm.ProvisioningState = current
+	var opError *arm.CloudErrorBody
+	var err error
+
+	switch status {
+	case cmv1.BreakGlassCredentialStatusCreated:
+		opStatus = arm.ProvisioningStateProvisioning
+	case cmv1.BreakGlassCredentialStatusIssued:
+		opStatus = arm.ProvisioningStateSucceeded
+	case cmv1.BreakGlassCredentialStatusFailed:
+		opStatus = arm.ProvisioningStateFailed
+		opError = &arm.CloudErrorBody{
+			Code:    arm.CloudErrorCodeInternalServerError,
+			Message: "Failed to issue credential",
+		}
+	case cmv1.BreakGlassCredentialStatusExpired:
+		opStatus = arm.ProvisioningStateFailed
+		opError = &arm.CloudErrorBody{
+			Code:    arm.CloudErrorCodeInternalServerError,
+			Message: "Credential expired before it could be retrieved",
+		}
+	case cmv1.BreakGlassCredentialStatusAwaitingRevocation, cmv1.BreakGlassCredentialStatusRevoked:
+		// Credentials were revoked before this one was issued.
+		opStatus = arm.ProvisioningStateCanceled
+		opError = &arm.CloudErrorBody{
+			Code:    arm.CloudErrorCodeConflict,
+			Message: "Credential was revoked before it could be retrieved",
+		}
+	default:
+		err = fmt.Errorf("Unhandled BreakGlassCredentialStatus '%s'", status)
+	}
+
+	return opStatus, opError, err
+}
+
+// convertUpgradePolicyState attempts to translate an UpgradePolicyState from
+// Cluster Service into an ARM provisioning state and, if necessary, a structured
+// OData error.
+func convertUpgradePolicyState(state *cmv1.UpgradePolicyState, current arm.ProvisioningState) (arm.ProvisioningState, *arm.CloudErrorBody, error) {
+	var opStatus arm.ProvisioningState = current
+	var opError *arm.CloudErrorBody
+	var err error
+
+	switch value := state.Value(); value {
+	case cmv1.UpgradePolicyStateValuePending, cmv1.UpgradePolicyStateValueScheduled:
+		// The upgrade has not started yet, so hold the
+		// provisioning state where it is.
+	case cmv1.UpgradePolicyStateValueStarted, cmv1.UpgradePolicyStateValueDelayed:
+		opStatus = arm.ProvisioningStateUpdating
+	case cmv1.UpgradePolicyStateValueCompleted:
+		opStatus = arm.ProvisioningStateSucceeded
+	case cmv1.UpgradePolicyStateValueFailed:
+		opStatus = arm.ProvisioningStateFailed
+		message := state.Description()
+		if message == "" {
+			message = "Upgrade failed"
+		}
+		opError = &arm.CloudErrorBody{
+			Code:    arm.CloudErrorCodeInternalServerError,
+			Message: message,
+		}
+	case cmv1.UpgradePolicyStateValueCancelled:
+		opStatus = arm.ProvisioningStateCanceled
+	default:
+		err = fmt.Errorf("Unhandled UpgradePolicyState '%s'", value)
+	}
+
+	return opStatus, opError, err
+}
+
+// convertUpgradePolicyProgress estimates the percent complete of an upgrade
+// from the state of a Cluster Service upgrade policy. Cluster Service does not
+// report finer-grained progress, so this only marks coarse milestones. Failed
+// and cancelled upgrades keep the current value.
+func convertUpgradePolicyProgress(state *cmv1.UpgradePolicyState, current float64) float64 {
+	switch state.Value() {
+	case cmv1.UpgradePolicyStateValuePending, cmv1.UpgradePolicyStateValueScheduled:
+		return 0
+	case cmv1.UpgradePolicyStateValueStarted, cmv1.UpgradePolicyStateValueDelayed:
+		return 50
+	case cmv1.UpgradePolicyStateValueCompleted:
+		return 100
+	default:
+		return current
+	}
+}
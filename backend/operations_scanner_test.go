@@ -5,20 +5,26 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"go.uber.org/mock/gomock"
 
 	"github.com/Azure/ARO-HCP/internal/api/arm"
 	"github.com/Azure/ARO-HCP/internal/database"
 	"github.com/Azure/ARO-HCP/internal/mocks"
 	"github.com/Azure/ARO-HCP/internal/ocm"
+	"github.com/Azure/ARO-HCP/internal/ocm/ocmtest"
 )
 
 func TestSetDeleteOperationAsCompleted(t *testing.T) {
@@ -61,6 +67,7 @@ func TestSetDeleteOperationAsCompleted(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var request *http.Request
+			var notificationDoc *database.NotificationDocument
 
 			ctx := context.Background()
 			ctrl := gomock.NewController(t)
@@ -83,10 +90,7 @@ func TestSetDeleteOperationAsCompleted(t *testing.T) {
 			}))
 			defer server.Close()
 
-			scanner := &OperationsScanner{
-				dbClient:           mockDBClient,
-				notificationClient: server.Client(),
-			}
+			scanner := newTestNotificationScanner(mockDBClient, server.Client())
 
 			operationDoc := database.NewOperationDocument(database.OperationRequestDelete, resourceID, internalID)
 			operationDoc.OperationID = operationID
@@ -113,9 +117,26 @@ func TestSetDeleteOperationAsCompleted(t *testing.T) {
 					return callback(operationDoc), nil
 				})
 			if tt.expectAsyncNotification {
+				mockDBClient.EXPECT().
+					CreateNotificationDoc(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, doc *database.NotificationDocument) (bool, error) {
+						notificationDoc = doc
+						return true, nil
+					})
+				// The second update clears the pending notification flag.
+				mockDBClient.EXPECT().
+					UpdateOperationDoc(gomock.Any(), op.pk, op.id, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, pk azcosmos.PartitionKey, operationID string, callback func(*database.OperationDocument) bool) (bool, error) {
+						return callback(operationDoc), nil
+					})
 				mockDBClient.EXPECT().
 					GetOperationDoc(gomock.Any(), op.pk, op.id).
 					Return(operationDoc, nil)
+				mockDBClient.EXPECT().
+					UpdateNotificationDoc(gomock.Any(), op.pk, database.NotificationID(op.id, arm.ProvisioningStateSucceeded), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, pk azcosmos.PartitionKey, notificationID string, callback func(*database.NotificationDocument) bool) (bool, error) {
+						return callback(notificationDoc), nil
+					})
 			}
 
 			err = scanner.setDeleteOperationAsCompleted(ctx, op)
@@ -126,6 +147,15 @@ func TestSetDeleteOperationAsCompleted(t *testing.T) {
 				t.Error("Unexpected POST to async notification URI")
 			}
 
+			if tt.expectAsyncNotification {
+				if notificationDoc.State != database.NotificationStateDelivered {
+					t.Errorf("Expected async notification state '%s' but got '%s'", database.NotificationStateDelivered, notificationDoc.State)
+				}
+				if operationDoc.NotificationPending {
+					t.Error("Pending notification was not cleared")
+				}
+			}
+
 			if err == nil && tt.expectError {
 				t.Error("Expected error but got none")
 			} else if err != nil && !tt.expectError {
@@ -227,6 +257,7 @@ func TestUpdateOperationStatus(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var request *http.Request
+			var notificationDoc *database.NotificationDocument
 
 			ctx := context.Background()
 			ctrl := gomock.NewController(t)
@@ -249,10 +280,7 @@ func TestUpdateOperationStatus(t *testing.T) {
 			}))
 			defer server.Close()
 
-			scanner := &OperationsScanner{
-				dbClient:           mockDBClient,
-				notificationClient: server.Client(),
-			}
+			scanner := newTestNotificationScanner(mockDBClient, server.Client())
 
 			operationDoc := database.NewOperationDocument(database.OperationRequestCreate, resourceID, internalID)
 			operationDoc.OperationID = operationID
@@ -292,9 +320,26 @@ func TestUpdateOperationStatus(t *testing.T) {
 					}
 				})
 			if tt.expectAsyncNotification {
+				mockDBClient.EXPECT().
+					CreateNotificationDoc(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, doc *database.NotificationDocument) (bool, error) {
+						notificationDoc = doc
+						return true, nil
+					})
+				// The second update clears the pending notification flag.
+				mockDBClient.EXPECT().
+					UpdateOperationDoc(gomock.Any(), op.pk, op.id, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, pk azcosmos.PartitionKey, operationID string, callback func(*database.OperationDocument) bool) (bool, error) {
+						return callback(operationDoc), nil
+					})
 				mockDBClient.EXPECT().
 					GetOperationDoc(gomock.Any(), op.pk, op.id).
 					Return(operationDoc, nil)
+				mockDBClient.EXPECT().
+					UpdateNotificationDoc(gomock.Any(), op.pk, database.NotificationID(op.id, tt.updatedOperationStatus), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, pk azcosmos.PartitionKey, notificationID string, callback func(*database.NotificationDocument) bool) (bool, error) {
+						return callback(notificationDoc), nil
+					})
 			}
 
 			err = scanner.updateOperationStatus(ctx, op, tt.updatedOperationStatus, nil)
@@ -305,6 +350,15 @@ func TestUpdateOperationStatus(t *testing.T) {
 				t.Error("Unexpected POST to async notification URI")
 			}
 
+			if tt.expectAsyncNotification {
+				if notificationDoc.State != database.NotificationStateDelivered {
+					t.Errorf("Expected async notification state '%s' but got '%s'", database.NotificationStateDelivered, notificationDoc.State)
+				}
+				if operationDoc.NotificationPending {
+					t.Error("Pending notification was not cleared")
+				}
+			}
+
 			if err == nil && tt.expectError {
 				t.Error("Expected error but got none")
 			} else if err != nil && !tt.expectError {
@@ -335,6 +389,425 @@ func TestUpdateOperationStatus(t *testing.T) {
 	}
 }
 
+func TestPostPendingNotification(t *testing.T) {
+	tests := []struct {
+		name                    string
+		notificationRecorded    bool
+		expectAsyncNotification bool
+	}{
+		{
+			name:                    "Notification pending",
+			notificationRecorded:    false,
+			expectAsyncNotification: true,
+		},
+		{
+			name:                    "Notification already recorded",
+			notificationRecorded:    true,
+			expectAsyncNotification: false,
+		},
+	}
+
+	// Placeholder InternalID for NewOperationDocument
+	internalID, err := ocm.NewInternalID("/api/clusters_mgmt/v1/clusters/placeholder")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request *http.Request
+
+			ctx := context.Background()
+			dbClient := database.NewInMemoryDBClient()
+
+			resourceID, err := azcorearm.ParseResourceID("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/testCluster")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			operationID, err := azcorearm.ParseResourceID("/subscriptions/00000000-0000-0000-0000-000000000000/providers/Microsoft.RedHatOpenShift/locations/oz/hcpOperationsStatus/operationID")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					request = r
+				}
+			}))
+			defer server.Close()
+
+			scanner := newTestNotificationScanner(dbClient, server.Client())
+
+			operationDoc := database.NewOperationDocument(database.OperationRequestUpdate, resourceID, internalID)
+			operationDoc.OperationID = operationID
+			operationDoc.NotificationURI = server.URL
+			operationDoc.Status = arm.ProvisioningStateCanceled
+			operationDoc.NotificationPending = true
+
+			id, err := dbClient.CreateOperationDoc(ctx, operationDoc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			op := newOperation(id, database.NewPartitionKey(resourceID.SubscriptionID), operationDoc, slog.Default())
+
+			if tt.notificationRecorded {
+				_, err = dbClient.CreateNotificationDoc(ctx, database.NewNotificationDocument(resourceID.SubscriptionID, id, server.URL, operationDoc.Status))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			scanner.postPendingNotification(ctx, op)
+
+			if request == nil && tt.expectAsyncNotification {
+				t.Error("Did not POST to async notification URI")
+			} else if request != nil && !tt.expectAsyncNotification {
+				t.Error("Unexpected POST to async notification URI")
+			}
+
+			storedOperationDoc, err := dbClient.GetOperationDoc(ctx, op.pk, op.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if storedOperationDoc.NotificationPending {
+				t.Error("Pending notification was not cleared")
+			}
+		})
+	}
+}
+
+func TestReadChangeFeed(t *testing.T) {
+	ctx := context.Background()
+	dbClient := database.NewInMemoryDBClient()
+
+	resourceID, err := azcorearm.ParseResourceID("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/testCluster")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internalID, err := ocm.NewInternalID("/api/clusters_mgmt/v1/clusters/placeholder")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createOperation := func(status arm.ProvisioningState, notificationPending bool) string {
+		operationDoc := database.NewOperationDocument(database.OperationRequestUpdate, resourceID, internalID)
+		operationDoc.Status = status
+		operationDoc.NotificationPending = notificationPending
+		operationID, err := dbClient.CreateOperationDoc(ctx, operationDoc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return operationID
+	}
+
+	activeOperationID := createOperation(arm.ProvisioningStateAccepted, false)
+	completedOperationID := createOperation(arm.ProvisioningStateSucceeded, false)
+	canceledOperationID := createOperation(arm.ProvisioningStateCanceled, true)
+
+	// readChangeFeed records metrics, so
+	// give the scanner some unregistered collectors.
+	scanner := &OperationsScanner{
+		dbClient:               dbClient,
+		activeOperations:       make(map[string]string),
+		operationChannel:       make(chan operationRef, 10),
+		schedule:               newOperationSchedule(defaultPollIntervalOperations, defaultMaxPollIntervalOperations, defaultMaxPollBackoff, defaultOperationDeadline),
+		operationsCount:        prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"type"}),
+		operationsFailedCount:  prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_failed_total"}, []string{"type"}),
+		operationsDuration:     prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_duration_seconds"}, []string{"type"}),
+		lastOperationTimestamp: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_timestamp_seconds"}, []string{"type"}),
+	}
+
+	// The completed operation should be forgotten.
+	scanner.trackOperation(operationRef{resourceID.SubscriptionID, completedOperationID})
+
+	dispatched := func() map[string]bool {
+		operationIDs := make(map[string]bool)
+		for len(scanner.operationChannel) > 0 {
+			ref := <-scanner.operationChannel
+			operationIDs[ref.operationID] = true
+		}
+		return operationIDs
+	}
+
+	scanner.readChangeFeed(ctx, slog.Default())
+
+	operationIDs := dispatched()
+	if len(operationIDs) != 2 || !operationIDs[activeOperationID] || !operationIDs[canceledOperationID] {
+		t.Errorf("Expected operations %s and %s to be dispatched but got %v", activeOperationID, canceledOperationID, operationIDs)
+	}
+
+	if len(scanner.activeOperations) != 1 || scanner.activeOperations[activeOperationID] != resourceID.SubscriptionID {
+		t.Errorf("Expected only operation %s to be active but got %v", activeOperationID, scanner.activeOperations)
+	}
+
+	continuationToken, err := dbClient.GetChangeFeedToken(ctx, changeFeedName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if continuationToken == "" || continuationToken != scanner.changeFeedToken {
+		t.Errorf("Expected continuation token '%s' to be persisted but got '%s'", scanner.changeFeedToken, continuationToken)
+	}
+
+	// An operation already being tracked should
+	// wait to be polled with the active operations.
+	scanner.readChangeFeed(ctx, slog.Default())
+
+	operationIDs = dispatched()
+	if operationIDs[activeOperationID] {
+		t.Errorf("Operation %s was dispatched again", activeOperationID)
+	}
+}
+
+func TestPollOperationDeadline(t *testing.T) {
+	const clusterResourceID = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/testCluster"
+
+	tests := []struct {
+		name         string
+		nodePool     bool
+		expectStatus arm.ProvisioningState
+	}{
+		{
+			name:         "Cluster create",
+			expectStatus: arm.ProvisioningStateFailed,
+		},
+		{
+			// The backend cannot tell when a node pool
+			// create completes, so it must not fail one.
+			name:         "Node pool create",
+			nodePool:     true,
+			expectStatus: arm.ProvisioningStateProvisioning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			server := ocmtest.NewServer(nil, ocmtest.Timing{})
+			t.Cleanup(server.Close)
+
+			conn, err := server.NewConnection(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { conn.Close() })
+
+			csClient := ocm.ClusterServiceClient{Conn: conn}
+
+			csCluster, err := arohcpv1alpha1.NewCluster().Name("testCluster").Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			csCluster, err = csClient.PostCluster(ctx, csCluster)
+			if err != nil {
+				t.Fatal(err)
+			}
+			internalID, err := ocm.NewInternalID(csCluster.HREF())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resourceID, err := azcorearm.ParseResourceID(clusterResourceID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.nodePool {
+				csNodePool, err := cmv1.NewNodePool().ID("testNodePool").Build()
+				if err != nil {
+					t.Fatal(err)
+				}
+				csNodePool, err = csClient.PostNodePool(ctx, internalID, csNodePool)
+				if err != nil {
+					t.Fatal(err)
+				}
+				internalID, err = ocm.NewInternalID(csNodePool.HREF())
+				if err != nil {
+					t.Fatal(err)
+				}
+				resourceID, err = azcorearm.ParseResourceID(clusterResourceID + "/nodePools/testNodePool")
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			dbClient := database.NewInMemoryDBClient()
+
+			operationDoc := database.NewOperationDocument(database.OperationRequestCreate, resourceID, internalID)
+			operationDoc.Status = arm.ProvisioningStateProvisioning
+			operationDoc.StartTime = time.Now().Add(-2 * defaultOperationDeadline)
+			operationID, err := dbClient.CreateOperationDoc(ctx, operationDoc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resourceDoc := database.NewResourceDocument(resourceID)
+			resourceDoc.InternalID = internalID
+			resourceDoc.ActiveOperationID = operationID
+			resourceDoc.ProvisioningState = arm.ProvisioningStateProvisioning
+			err = dbClient.CreateResourceDoc(ctx, resourceDoc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Give the scanner some unregistered collectors.
+			scanner := &OperationsScanner{
+				dbClient:               dbClient,
+				lockClient:             dbClient.GetLockClient(),
+				clusterService:         csClient,
+				schedule:               newOperationSchedule(defaultPollIntervalOperations, defaultMaxPollIntervalOperations, defaultMaxPollBackoff, defaultOperationDeadline),
+				operationsCount:        prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"type"}),
+				operationsFailedCount:  prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_failed_total"}, []string{"type"}),
+				operationsDuration:     prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_duration_seconds"}, []string{"type"}),
+				lastOperationTimestamp: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_timestamp_seconds"}, []string{"type"}),
+				schedulingDecisions:    prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_decisions_total"}, []string{"decision"}),
+			}
+
+			pk := database.NewPartitionKey(resourceID.SubscriptionID)
+
+			op := operation{
+				id:     operationID,
+				pk:     pk,
+				doc:    operationDoc,
+				logger: slog.Default(),
+			}
+
+			if !scanner.pollOperation(ctx, op) {
+				t.Fatal("Expected the operation to be handled")
+			}
+
+			storedOperationDoc, err := dbClient.GetOperationDoc(ctx, pk, operationID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if storedOperationDoc.Status != tt.expectStatus {
+				t.Errorf("Expected operation status '%s' but got '%s'", tt.expectStatus, storedOperationDoc.Status)
+			}
+
+			storedResourceDoc, err := dbClient.GetResourceDoc(ctx, resourceID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if storedResourceDoc.ProvisioningState != tt.expectStatus {
+				t.Errorf("Expected provisioning state '%s' but got '%s'", tt.expectStatus, storedResourceDoc.ProvisioningState)
+			}
+		})
+	}
+}
+
+func TestCollectExpiredOperations(t *testing.T) {
+	const retention = time.Hour
+
+	ctx := context.Background()
+	dbClient := database.NewInMemoryDBClient()
+
+	resourceID, err := azcorearm.ParseResourceID("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/testCluster")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internalID, err := ocm.NewInternalID("/api/clusters_mgmt/v1/clusters/placeholder")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createOperation := func(status arm.ProvisioningState, age time.Duration) string {
+		operationDoc := database.NewOperationDocument(database.OperationRequestUpdate, resourceID, internalID)
+		operationDoc.Status = status
+		operationDoc.LastTransitionTime = time.Now().Add(-age)
+		operationID, err := dbClient.CreateOperationDoc(ctx, operationDoc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return operationID
+	}
+
+	recentOperationID := createOperation(arm.ProvisioningStateSucceeded, retention/2)
+	expiredOperationID := createOperation(arm.ProvisioningStateFailed, 2*retention)
+
+	// processOperations records metrics, so
+	// give the scanner some unregistered collectors.
+	scanner := &OperationsScanner{
+		dbClient:               dbClient,
+		activeOperations:       make(map[string]string),
+		operationChannel:       make(chan operationRef, 10),
+		schedule:               newOperationSchedule(defaultPollIntervalOperations, defaultMaxPollIntervalOperations, defaultMaxPollBackoff, defaultOperationDeadline),
+		operationRetention:     retention,
+		operationsCount:        prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"type"}),
+		operationsFailedCount:  prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_failed_total"}, []string{"type"}),
+		operationsDuration:     prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_duration_seconds"}, []string{"type"}),
+		lastOperationTimestamp: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_timestamp_seconds"}, []string{"type"}),
+		operationsCollected:    prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_collected_total"}, []string{"status"}),
+	}
+
+	scanner.processOperations(ctx, resourceID.SubscriptionID, slog.Default())
+
+	pk := database.NewPartitionKey(resourceID.SubscriptionID)
+
+	_, err = dbClient.GetOperationDoc(ctx, pk, recentOperationID)
+	if err != nil {
+		t.Errorf("Expected operation %s to be retained but got %v", recentOperationID, err)
+	}
+
+	_, err = dbClient.GetOperationDoc(ctx, pk, expiredOperationID)
+	if !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected operation %s to be deleted but got %v", expiredOperationID, err)
+	}
+
+	collected := testutil.ToFloat64(scanner.operationsCollected.WithLabelValues(string(arm.ProvisioningStateFailed)))
+	if collected != 1 {
+		t.Errorf("Expected 1 failed operation collected but got %v", collected)
+	}
+}
+
+func TestOperationExpired(t *testing.T) {
+	const retention = time.Hour
+
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		status arm.ProvisioningState
+		age    time.Duration
+		expect bool
+	}{
+		{
+			name:   "Recent terminal operation",
+			status: arm.ProvisioningStateSucceeded,
+			age:    retention / 2,
+			expect: false,
+		},
+		{
+			name:   "Old terminal operation",
+			status: arm.ProvisioningStateCanceled,
+			age:    2 * retention,
+			expect: true,
+		},
+		{
+			name:   "Old non-terminal operation",
+			status: arm.ProvisioningStateDeleting,
+			age:    2 * retention,
+			expect: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operationDoc := &database.OperationDocument{
+				Status:             tt.status,
+				LastTransitionTime: now.Add(-tt.age),
+			}
+			if operationExpired(operationDoc, retention, now) != tt.expect {
+				t.Errorf("Expected operationExpired to return %t", tt.expect)
+			}
+		})
+	}
+}
+
 func TestConvertClusterStatus(t *testing.T) {
 	// FIXME These tests are all tentative until the new "/api/aro_hcp/v1" OCM
 	//       API is available. What's here now is a best guess at converting
@@ -493,3 +966,207 @@ func TestConvertClusterStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertBreakGlassCredentialStatus(t *testing.T) {
+	tests := []struct {
+		name                     string
+		credentialStatus         cmv1.BreakGlassCredentialStatus
+		updatedProvisioningState arm.ProvisioningState
+		expectCloudError         bool
+		expectConversionError    bool
+	}{
+		{
+			name:                     "Convert BreakGlassCredentialStatusAwaitingRevocation",
+			credentialStatus:         cmv1.BreakGlassCredentialStatusAwaitingRevocation,
+			updatedProvisioningState: arm.ProvisioningStateCanceled,
+			expectCloudError:         true,
+			expectConversionError:    false,
+		},
+		{
+			name:                     "Convert BreakGlassCredentialStatusCreated",
+			credentialStatus:         cmv1.BreakGlassCredentialStatusCreated,
+			updatedProvisioningState: arm.ProvisioningStateProvisioning,
+			expectCloudError:         false,
+			expectConversionError:    false,
+		},
+		{
+			name:                     "Convert BreakGlassCredentialStatusExpired",
+			credentialStatus:         cmv1.BreakGlassCredentialStatusExpired,
+			updatedProvisioningState: arm.ProvisioningStateFailed,
+			expectCloudError:         true,
+			expectConversionError:    false,
+		},
+		{
+			name:                     "Convert BreakGlassCredentialStatusFailed",
+			credentialStatus:         cmv1.BreakGlassCredentialStatusFailed,
+			updatedProvisioningState: arm.ProvisioningStateFailed,
+			expectCloudError:         true,
+			expectConversionError:    false,
+		},
+		{
+			name:                     "Convert BreakGlassCredentialStatusIssued",
+			credentialStatus:         cmv1.BreakGlassCredentialStatusIssued,
+			updatedProvisioningState: arm.ProvisioningStateSucceeded,
+			expectCloudError:         false,
+			expectConversionError:    false,
+		},
+		{
+			name:                     "Convert BreakGlassCredentialStatusRevoked",
+			credentialStatus:         cmv1.BreakGlassCredentialStatusRevoked,
+			updatedProvisioningState: arm.ProvisioningStateCanceled,
+			expectCloudError:         true,
+			expectConversionError:    false,
+		},
+		{
+			name:                     "Convert unexpected credential status",
+			credentialStatus:         cmv1.BreakGlassCredentialStatus("unexpected credential status"),
+			updatedProvisioningState: arm.ProvisioningStateAccepted,
+			expectCloudError:         false,
+			expectConversionError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opState, opError, err := convertBreakGlassCredentialStatus(tt.credentialStatus, arm.ProvisioningStateAccepted)
+			if opState != tt.updatedProvisioningState {
+				t.Errorf("Expected provisioning state '%s' but got '%s'", tt.updatedProvisioningState, opState)
+			}
+			if opError == nil && tt.expectCloudError {
+				t.Error("Expected a cloud error but got none")
+			} else if opError != nil && !tt.expectCloudError {
+				t.Errorf("Got unexpected cloud error: %v", opError)
+			}
+			if err == nil && tt.expectConversionError {
+				t.Error("Expected a conversion error but got none")
+			} else if err != nil && !tt.expectConversionError {
+				t.Errorf("Got unexpected conversion error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConvertUpgradePolicyState(t *testing.T) {
+	tests := []struct {
+		name                     string
+		stateValue               cmv1.UpgradePolicyStateValue
+		updatedProvisioningState arm.ProvisioningState
+		expectCloudError         bool
+		expectConversionError    bool
+	}{
+		{
+			name:                     "Convert UpgradePolicyStateValueScheduled",
+			stateValue:               cmv1.UpgradePolicyStateValueScheduled,
+			updatedProvisioningState: arm.ProvisioningStateAccepted,
+			expectCloudError:         false,
+			expectConversionError:    false,
+		},
+		{
+			name:                     "Convert UpgradePolicyStateValueStarted",
+			stateValue:               cmv1.UpgradePolicyStateValueStarted,
+			updatedProvisioningState: arm.ProvisioningStateUpdating,
+			expectCloudError:         false,
+			expectConversionError:    false,
+		},
+		{
+			name:                     "Convert UpgradePolicyStateValueCompleted",
+			stateValue:               cmv1.UpgradePolicyStateValueCompleted,
+			updatedProvisioningState: arm.ProvisioningStateSucceeded,
+			expectCloudError:         false,
+			expectConversionError:    false,
+		},
+		{
+			name:                     "Convert UpgradePolicyStateValueFailed",
+			stateValue:               cmv1.UpgradePolicyStateValueFailed,
+			updatedProvisioningState: arm.ProvisioningStateFailed,
+			expectCloudError:         true,
+			expectConversionError:    false,
+		},
+		{
+			name:                     "Convert UpgradePolicyStateValueCancelled",
+			stateValue:               cmv1.UpgradePolicyStateValueCancelled,
+			updatedProvisioningState: arm.ProvisioningStateCanceled,
+			expectCloudError:         false,
+			expectConversionError:    false,
+		},
+		{
+			name:                     "Convert unexpected upgrade policy state",
+			stateValue:               cmv1.UpgradePolicyStateValue("unexpected upgrade policy state"),
+			updatedProvisioningState: arm.ProvisioningStateAccepted,
+			expectCloudError:         false,
+			expectConversionError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := cmv1.NewUpgradePolicyState().Value(tt.stateValue).Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opState, opError, err := convertUpgradePolicyState(state, arm.ProvisioningStateAccepted)
+			if opState != tt.updatedProvisioningState {
+				t.Errorf("Expected provisioning state '%s' but got '%s'", tt.updatedProvisioningState, opState)
+			}
+			if opError == nil && tt.expectCloudError {
+				t.Error("Expected a cloud error but got none")
+			} else if opError != nil && !tt.expectCloudError {
+				t.Errorf("Got unexpected cloud error: %v", opError)
+			}
+			if err == nil && tt.expectConversionError {
+				t.Error("Expected a conversion error but got none")
+			} else if err != nil && !tt.expectConversionError {
+				t.Errorf("Got unexpected conversion error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConvertUpgradePolicyProgress(t *testing.T) {
+	tests := []struct {
+		name                   string
+		stateValue             cmv1.UpgradePolicyStateValue
+		currentPercentComplete float64
+		expectPercentComplete  float64
+	}{
+		{
+			name:                   "Convert UpgradePolicyStateValuePending",
+			stateValue:             cmv1.UpgradePolicyStateValuePending,
+			currentPercentComplete: 0,
+			expectPercentComplete:  0,
+		},
+		{
+			name:                   "Convert UpgradePolicyStateValueStarted",
+			stateValue:             cmv1.UpgradePolicyStateValueStarted,
+			currentPercentComplete: 0,
+			expectPercentComplete:  50,
+		},
+		{
+			name:                   "Convert UpgradePolicyStateValueCompleted",
+			stateValue:             cmv1.UpgradePolicyStateValueCompleted,
+			currentPercentComplete: 50,
+			expectPercentComplete:  100,
+		},
+		{
+			name:                   "Convert UpgradePolicyStateValueFailed",
+			stateValue:             cmv1.UpgradePolicyStateValueFailed,
+			currentPercentComplete: 50,
+			expectPercentComplete:  50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := cmv1.NewUpgradePolicyState().Value(tt.stateValue).Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			percentComplete := convertUpgradePolicyProgress(state, tt.currentPercentComplete)
+			if percentComplete != tt.expectPercentComplete {
+				t.Errorf("Expected percent complete %g but got %g", tt.expectPercentComplete, percentComplete)
+			}
+		})
+	}
+}
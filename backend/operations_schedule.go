@@ -0,0 +1,160 @@
+package main
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxPollIntervalOperations = 1 * time.Minute
+	defaultMaxPollBackoff            = 5 * time.Minute
+	defaultOperationDeadline         = 24 * time.Hour
+
+	// The poll interval of a healthy operation grows as a
+	// fraction of the operation's age, up to a maximum.
+	operationAgePollDivisor = 20
+)
+
+// scheduleDecision is the outcome of consulting an operationSchedule.
+type scheduleDecision string
+
+const (
+	// scheduleDecisionPoll means the operation is due to be polled.
+	scheduleDecisionPoll scheduleDecision = "poll"
+	// scheduleDecisionDefer means the operation is not yet due to be polled.
+	scheduleDecisionDefer scheduleDecision = "defer"
+	// scheduleDecisionBackoff means polling the operation failed and the
+	// next poll was pushed back accordingly.
+	scheduleDecisionBackoff scheduleDecision = "backoff"
+	// scheduleDecisionExpire means the operation exceeded its deadline.
+	scheduleDecisionExpire scheduleDecision = "expire"
+)
+
+// operationScheduleEntry is the scheduling state of a single operation.
+type operationScheduleEntry struct {
+	nextPoll          time.Time
+	consecutiveErrors int
+}
+
+// operationSchedule decides when each operation is next polled. Operations
+// are polled less often as they age, polls that fail are retried with an
+// exponential backoff, and operations that outlive a deadline are expired.
+// Scheduling state lives only in memory, so a restarted backend polls every
+// operation once before the schedule takes effect again.
+type operationSchedule struct {
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+	maxBackoff      time.Duration
+	deadline        time.Duration
+
+	// jitter returns a random duration in the range [0, d).
+	// Replaceable for testing.
+	jitter func(d time.Duration) time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]*operationScheduleEntry // keyed by operation ID
+}
+
+// newOperationSchedule returns an operationSchedule. The pollInterval should
+// be how often operations are considered for polling. A zero deadline means
+// operations never expire.
+func newOperationSchedule(pollInterval, maxPollInterval, maxBackoff, deadline time.Duration) *operationSchedule {
+	return &operationSchedule{
+		pollInterval:    pollInterval,
+		maxPollInterval: maxPollInterval,
+		maxBackoff:      maxBackoff,
+		deadline:        deadline,
+		jitter:          randomJitter,
+		entries:         make(map[string]*operationScheduleEntry),
+	}
+}
+
+// randomJitter returns a random duration in the range [0, d).
+func randomJitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+// decide returns whether an operation that started at startTime should be
+// polled now, deferred until later, or expired for exceeding the deadline.
+// An operation that cannot expire is never expired, only polled less often.
+func (sch *operationSchedule) decide(operationID string, startTime, now time.Time, canExpire bool) scheduleDecision {
+	if canExpire && sch.deadline > 0 && now.Sub(startTime) > sch.deadline {
+		sch.forget(operationID)
+		return scheduleDecisionExpire
+	}
+
+	sch.mutex.Lock()
+	defer sch.mutex.Unlock()
+
+	entry, ok := sch.entries[strings.ToLower(operationID)]
+	if ok && now.Before(entry.nextPoll) {
+		return scheduleDecisionDefer
+	}
+
+	return scheduleDecisionPoll
+}
+
+// record updates the scheduling state of an operation from the result of
+// polling it and returns the delay until the operation is next due.
+func (sch *operationSchedule) record(operationID string, startTime, now time.Time, err error) time.Duration {
+	var delay time.Duration
+
+	sch.mutex.Lock()
+	defer sch.mutex.Unlock()
+
+	operationID = strings.ToLower(operationID)
+
+	entry, ok := sch.entries[operationID]
+	if !ok {
+		entry = &operationScheduleEntry{}
+		sch.entries[operationID] = entry
+	}
+
+	if err != nil {
+		entry.consecutiveErrors++
+		delay = sch.backoff(entry.consecutiveErrors)
+	} else {
+		entry.consecutiveErrors = 0
+		delay = min(now.Sub(startTime)/operationAgePollDivisor, sch.maxPollInterval)
+	}
+
+	entry.nextPoll = now.Add(delay)
+
+	return delay
+}
+
+// backoff returns an exponential backoff delay with jitter for the given
+// number of consecutive errors.
+func (sch *operationSchedule) backoff(consecutiveErrors int) time.Duration {
+	return backoffDelay(sch.pollInterval, sch.maxBackoff, consecutiveErrors, sch.jitter)
+}
+
+// backoffDelay returns an exponential backoff delay for the given number of
+// consecutive failures, starting from baseDelay and doubling up to maxDelay.
+// Half the delay is fixed and half comes from the jitter function so that
+// things failing together do not retry together.
+func backoffDelay(baseDelay, maxDelay time.Duration, failures int, jitter func(time.Duration) time.Duration) time.Duration {
+	delay := baseDelay
+	for i := 1; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+
+	return delay/2 + jitter(delay/2)
+}
+
+// forget discards the scheduling state of an operation.
+func (sch *operationSchedule) forget(operationID string) {
+	sch.mutex.Lock()
+	defer sch.mutex.Unlock()
+
+	delete(sch.entries, strings.ToLower(operationID))
+}
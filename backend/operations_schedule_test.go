@@ -0,0 +1,97 @@
+package main
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestOperationSchedule() *operationSchedule {
+	sch := newOperationSchedule(10*time.Second, time.Minute, 5*time.Minute, 24*time.Hour)
+	// Take the maximum jitter to make delays predictable.
+	sch.jitter = func(d time.Duration) time.Duration { return d }
+	return sch
+}
+
+func TestOperationScheduleBackoff(t *testing.T) {
+	tests := []struct {
+		consecutiveErrors int
+		expectDelay       time.Duration
+	}{
+		{consecutiveErrors: 1, expectDelay: 10 * time.Second},
+		{consecutiveErrors: 2, expectDelay: 20 * time.Second},
+		{consecutiveErrors: 3, expectDelay: 40 * time.Second},
+		{consecutiveErrors: 6, expectDelay: 5 * time.Minute},
+		{consecutiveErrors: 100, expectDelay: 5 * time.Minute},
+	}
+
+	sch := newTestOperationSchedule()
+
+	for _, tt := range tests {
+		delay := sch.backoff(tt.consecutiveErrors)
+		if delay != tt.expectDelay {
+			t.Errorf("Expected a delay of %s after %d errors but got %s", tt.expectDelay, tt.consecutiveErrors, delay)
+		}
+	}
+}
+
+func TestOperationSchedule(t *testing.T) {
+	const operationID = "operation"
+
+	sch := newTestOperationSchedule()
+	startTime := time.Now()
+	now := startTime.Add(10 * time.Minute)
+
+	if decision := sch.decide(operationID, startTime, now, true); decision != scheduleDecisionPoll {
+		t.Fatalf("Expected a new operation to be polled but got '%s'", decision)
+	}
+
+	// A healthy operation is polled less often as it ages.
+	delay := sch.record(operationID, startTime, now, nil)
+	if delay != 30*time.Second {
+		t.Errorf("Expected a delay of 30s but got %s", delay)
+	}
+	if decision := sch.decide(operationID, startTime, now.Add(delay/2), true); decision != scheduleDecisionDefer {
+		t.Errorf("Expected the operation to be deferred but got '%s'", decision)
+	}
+	if decision := sch.decide(operationID, startTime, now.Add(delay), true); decision != scheduleDecisionPoll {
+		t.Errorf("Expected the operation to be polled but got '%s'", decision)
+	}
+
+	// Errors back off exponentially.
+	now = now.Add(delay)
+	for _, expectDelay := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second} {
+		delay = sch.record(operationID, startTime, now, errors.New("poll failed"))
+		if delay != expectDelay {
+			t.Errorf("Expected a backoff of %s but got %s", expectDelay, delay)
+		}
+		now = now.Add(delay)
+	}
+
+	// Success resets the backoff.
+	delay = sch.record(operationID, startTime, now, nil)
+	if delay != 35*time.Second {
+		t.Errorf("Expected a delay of 35s but got %s", delay)
+	}
+
+	// The delay for a healthy operation is capped.
+	delay = sch.record(operationID, startTime, startTime.Add(time.Hour), nil)
+	if delay != time.Minute {
+		t.Errorf("Expected a delay of 1m0s but got %s", delay)
+	}
+
+	// An operation that cannot expire is polled past the deadline.
+	if decision := sch.decide(operationID, startTime, startTime.Add(25*time.Hour), false); decision != scheduleDecisionPoll {
+		t.Errorf("Expected the operation to be polled but got '%s'", decision)
+	}
+
+	if decision := sch.decide(operationID, startTime, startTime.Add(25*time.Hour), true); decision != scheduleDecisionExpire {
+		t.Errorf("Expected the operation to expire but got '%s'", decision)
+	}
+	if len(sch.entries) != 0 {
+		t.Error("Expected an expired operation to be forgotten")
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+const (
+	defaultPartitionCount    = 64
+	defaultMaxReplicas       = 16
+	defaultRebalanceInterval = 30 * time.Second
+
+	partitionLockPrefix = "backend-partition-"
+	replicaLockPrefix   = "backend-replica-"
+)
+
+// heldLock is a lock kept alive by LockClient.HoldLock.
+type heldLock struct {
+	ctx  context.Context
+	stop database.StopHoldLock
+}
+
+// lost returns true if the lock could not be renewed.
+func (l *heldLock) lost() bool {
+	return l.ctx.Err() != nil
+}
+
+// partitionOwner divides Azure subscriptions into a fixed number of partitions
+// by hashing subscription IDs, and claims a fair share of those partitions for
+// this replica through the Cosmos DB LockClient.
+//
+// To know what a fair share is, each replica also holds one of a fixed number
+// of replica slot locks. Counting the slot locks that are taken gives the number
+// of live replicas. When replicas join, those owning more than their fair share
+// release partitions for the new replicas to claim. When replicas leave, their
+// locks expire and the remaining replicas claim the orphaned partitions.
+type partitionOwner struct {
+	lockClient     database.LockClient
+	partitionCount int
+	maxReplicas    int
+
+	replicaSlot int
+	replicaLock *heldLock
+
+	mutex      sync.RWMutex
+	partitions map[int]*heldLock
+}
+
+// newPartitionOwner returns a partitionOwner that does not yet own anything.
+func newPartitionOwner(lockClient database.LockClient, partitionCount, maxReplicas int) *partitionOwner {
+	return &partitionOwner{
+		lockClient:     lockClient,
+		partitionCount: partitionCount,
+		maxReplicas:    maxReplicas,
+		replicaSlot:    -1,
+		partitions:     make(map[int]*heldLock),
+	}
+}
+
+// subscriptionPartition returns the partition an Azure subscription belongs to.
+func subscriptionPartition(subscriptionID string, partitionCount int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(strings.ToLower(subscriptionID)))
+	return int(h.Sum32() % uint32(partitionCount))
+}
+
+// fairShare returns how many partitions each replica should own.
+func fairShare(partitionCount, replicaCount int) int {
+	replicaCount = max(replicaCount, 1)
+	return (partitionCount + replicaCount - 1) / replicaCount
+}
+
+// hold starts holding an acquired lock.
+func (o *partitionOwner) hold(ctx context.Context, item *azcosmos.ItemResponse) *heldLock {
+	lockCtx, stop := o.lockClient.HoldLock(ctx, item)
+	return &heldLock{ctx: lockCtx, stop: stop}
+}
+
+// release stops holding a lock and releases it.
+func (o *partitionOwner) release(ctx context.Context, lock *heldLock, logger *slog.Logger) {
+	if item := lock.stop(); item != nil {
+		nonFatalErr := o.lockClient.ReleaseLock(ctx, item)
+		if nonFatalErr != nil {
+			// Failure here is non-fatal but still log the error.
+			// The lock's TTL ensures it will be released eventually.
+			logger.Warn(fmt.Sprintf("Failed to release lock: %v", nonFatalErr))
+		}
+	}
+}
+
+// tryJoin tries once to claim a replica slot and returns true if successful.
+func (o *partitionOwner) tryJoin(ctx context.Context, logger *slog.Logger) bool {
+	for slot := range o.maxReplicas {
+		item, err := o.lockClient.TryAcquireLock(ctx, fmt.Sprintf("%s%d", replicaLockPrefix, slot))
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to acquire replica slot %d: %v", slot, err))
+			continue
+		}
+		if item != nil {
+			o.replicaSlot = slot
+			o.replicaLock = o.hold(ctx, item)
+			logger.Info(fmt.Sprintf("Joined as replica %d", slot))
+			return true
+		}
+	}
+
+	logger.Warn(fmt.Sprintf("All %d replica slots are taken", o.maxReplicas))
+	return false
+}
+
+// Join claims a replica slot, waiting for one to become available if
+// necessary. It returns the slot number or an error if the context is
+// canceled first.
+func (o *partitionOwner) Join(ctx context.Context, logger *slog.Logger) (int, error) {
+	for !o.tryJoin(ctx, logger) {
+		err := database.Delay(ctx, o.lockClient.GetDefaultTimeToLive())
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return o.replicaSlot, nil
+}
+
+// Leave releases all partitions and the replica slot.
+func (o *partitionOwner) Leave(ctx context.Context, logger *slog.Logger) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for partition, lock := range o.partitions {
+		o.release(ctx, lock, logger)
+		delete(o.partitions, partition)
+	}
+
+	if o.replicaLock != nil {
+		o.release(ctx, o.replicaLock, logger)
+		o.replicaLock = nil
+		o.replicaSlot = -1
+	}
+}
+
+// countReplicas returns the number of replica slots currently taken. It probes
+// other slots by trying to acquire them, and immediately releases any it gets.
+func (o *partitionOwner) countReplicas(ctx context.Context) (int, error) {
+	var count int
+
+	for slot := range o.maxReplicas {
+		if slot == o.replicaSlot {
+			count++
+			continue
+		}
+
+		item, err := o.lockClient.TryAcquireLock(ctx, fmt.Sprintf("%s%d", replicaLockPrefix, slot))
+		if err != nil {
+			return 0, err
+		}
+		if item == nil {
+			count++
+		} else {
+			// Failure here is harmless. The slot looks taken
+			// until its TTL expires, at worst causing replicas
+			// to claim fewer partitions for a while.
+			_ = o.lockClient.ReleaseLock(ctx, item)
+		}
+	}
+
+	return count, nil
+}
+
+// Rebalance adjusts the partitions owned by this replica toward a fair share,
+// releasing or claiming partitions as needed. It returns true if any partitions
+// were claimed.
+func (o *partitionOwner) Rebalance(ctx context.Context, logger *slog.Logger) bool {
+	var claimed bool
+
+	if o.replicaLock != nil && o.replicaLock.lost() {
+		logger.Error("Lost replica slot; releasing all partitions")
+		o.Leave(ctx, logger)
+	}
+
+	if o.replicaLock == nil && !o.tryJoin(ctx, logger) {
+		return false
+	}
+
+	replicaCount, err := o.countReplicas(ctx)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to count replicas: %v", err))
+		return false
+	}
+
+	target := fairShare(o.partitionCount, replicaCount)
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for partition, lock := range o.partitions {
+		if lock.lost() {
+			logger.Warn(fmt.Sprintf("Lost partition %d", partition))
+			lock.stop()
+			delete(o.partitions, partition)
+		}
+	}
+
+	// Release surplus partitions for other replicas to claim.
+	if surplus := len(o.partitions) - target; surplus > 0 {
+		owned := slices.Sorted(maps.Keys(o.partitions))
+		for _, partition := range owned[len(owned)-surplus:] {
+			o.release(ctx, o.partitions[partition], logger)
+			delete(o.partitions, partition)
+			logger.Info(fmt.Sprintf("Released partition %d", partition))
+		}
+	}
+
+	// Claim unowned partitions, in random order so
+	// replicas are less likely to contend for them.
+	for _, partition := range rand.Perm(o.partitionCount) {
+		if len(o.partitions) >= target {
+			break
+		}
+		if _, ok := o.partitions[partition]; ok {
+			continue
+		}
+
+		item, err := o.lockClient.TryAcquireLock(ctx, fmt.Sprintf("%s%d", partitionLockPrefix, partition))
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to acquire partition %d: %v", partition, err))
+			continue
+		}
+		if item != nil {
+			o.partitions[partition] = o.hold(ctx, item)
+			logger.Info(fmt.Sprintf("Claimed partition %d", partition))
+			claimed = true
+		}
+	}
+
+	return claimed
+}
+
+// Owns returns true if this replica owns the partition an Azure subscription
+// belongs to.
+func (o *partitionOwner) Owns(subscriptionID string) bool {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	lock, ok := o.partitions[subscriptionPartition(subscriptionID, o.partitionCount)]
+	return ok && !lock.lost()
+}
+
+// Count returns the number of partitions this replica owns.
+func (o *partitionOwner) Count() int {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	return len(o.partitions)
+}
@@ -0,0 +1,120 @@
+package main
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+func TestSubscriptionPartition(t *testing.T) {
+	const subscriptionID = "00000000-0000-0000-0000-000000000000"
+	const partitionCount = 16
+
+	partition := subscriptionPartition(subscriptionID, partitionCount)
+	if partition < 0 || partition >= partitionCount {
+		t.Fatalf("Partition %d out of range", partition)
+	}
+
+	if other := subscriptionPartition(subscriptionID, partitionCount); other != partition {
+		t.Errorf("Expected partition %d but got %d", partition, other)
+	}
+
+	// Subscription IDs are case-insensitive.
+	upper := subscriptionPartition("ABCDEF00-0000-0000-0000-000000000000", partitionCount)
+	lower := subscriptionPartition("abcdef00-0000-0000-0000-000000000000", partitionCount)
+	if upper != lower {
+		t.Errorf("Expected subscription ID case to not affect partition but got %d and %d", upper, lower)
+	}
+}
+
+func TestFairShare(t *testing.T) {
+	tests := []struct {
+		partitionCount int
+		replicaCount   int
+		expectShare    int
+	}{
+		{partitionCount: 64, replicaCount: 0, expectShare: 64},
+		{partitionCount: 64, replicaCount: 1, expectShare: 64},
+		{partitionCount: 64, replicaCount: 2, expectShare: 32},
+		{partitionCount: 64, replicaCount: 3, expectShare: 22},
+		{partitionCount: 4, replicaCount: 8, expectShare: 1},
+	}
+
+	for _, tt := range tests {
+		share := fairShare(tt.partitionCount, tt.replicaCount)
+		if share != tt.expectShare {
+			t.Errorf("Expected %d partitions across %d replicas to give %d each but got %d",
+				tt.partitionCount, tt.replicaCount, tt.expectShare, share)
+		}
+	}
+}
+
+func TestPartitionOwnerRebalance(t *testing.T) {
+	const partitionCount = 8
+	const maxReplicas = 4
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := slog.Default()
+	lockClient := database.NewInMemoryDBClient().GetLockClient()
+
+	owner1 := newPartitionOwner(lockClient, partitionCount, maxReplicas)
+	owner2 := newPartitionOwner(lockClient, partitionCount, maxReplicas)
+
+	slot1, err := owner1.Join(ctx, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer owner1.Leave(context.Background(), logger)
+
+	// A lone replica owns every partition.
+	if !owner1.Rebalance(ctx, logger) {
+		t.Error("Expected partitions to be claimed")
+	}
+	if owner1.Count() != partitionCount {
+		t.Fatalf("Expected %d partitions but got %d", partitionCount, owner1.Count())
+	}
+
+	slot2, err := owner2.Join(ctx, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer owner2.Leave(context.Background(), logger)
+
+	if slot1 == slot2 {
+		t.Fatalf("Expected replicas to have different slots but both have %d", slot1)
+	}
+
+	// Nothing is left for the new replica until the first releases its surplus.
+	if owner2.Rebalance(ctx, logger) {
+		t.Error("Expected no partitions to be claimed")
+	}
+	owner1.Rebalance(ctx, logger)
+	owner2.Rebalance(ctx, logger)
+
+	if owner1.Count() != partitionCount/2 || owner2.Count() != partitionCount/2 {
+		t.Fatalf("Expected partitions to be split evenly but got %d and %d", owner1.Count(), owner2.Count())
+	}
+
+	for partition := range partitionCount {
+		_, owned1 := owner1.partitions[partition]
+		_, owned2 := owner2.partitions[partition]
+		if owned1 == owned2 {
+			t.Errorf("Expected partition %d to have exactly one owner", partition)
+		}
+	}
+
+	// The remaining replica takes over when the other leaves.
+	owner2.Leave(ctx, logger)
+	owner1.Rebalance(ctx, logger)
+
+	if owner1.Count() != partitionCount {
+		t.Errorf("Expected %d partitions but got %d", partitionCount, owner1.Count())
+	}
+}
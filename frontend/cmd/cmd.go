@@ -42,6 +42,15 @@ type FrontendOpts struct {
 
 	cosmosName string
 	cosmosURL  string
+	inMemoryDB bool
+
+	quota frontend.ResourceQuota
+
+	adminPort            int
+	adminTLSCertFile     string
+	adminTLSKeyFile      string
+	adminClientCAFile    string
+	adminAllowedSubjects []string
 }
 
 func NewRootCmd() *cobra.Command {
@@ -66,6 +75,7 @@ func NewRootCmd() *cobra.Command {
 
 	rootCmd.Flags().StringVar(&opts.cosmosName, "cosmos-name", os.Getenv("DB_NAME"), "Cosmos database name")
 	rootCmd.Flags().StringVar(&opts.cosmosURL, "cosmos-url", os.Getenv("DB_URL"), "Cosmos database URL")
+	rootCmd.Flags().BoolVar(&opts.inMemoryDB, "in-memory-db", false, "Use a non-persistent in-memory database instead of Cosmos for development purposes")
 	rootCmd.Flags().StringVar(&opts.location, "location", os.Getenv("LOCATION"), "Azure location")
 	rootCmd.Flags().IntVar(&opts.port, "port", 8443, "port to listen on")
 	rootCmd.Flags().IntVar(&opts.metricsPort, "metrics-port", 8081, "port to serve metrics on")
@@ -76,7 +86,20 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.Flags().BoolVar(&opts.clusterServiceNoopProvision, "cluster-service-noop-provision", false, "Skip cluster service provisioning steps for development purposes")
 	rootCmd.Flags().BoolVar(&opts.clusterServiceNoopDeprovision, "cluster-service-noop-deprovision", false, "Skip cluster service deprovisioning steps for development purposes")
 
+	rootCmd.Flags().IntVar(&opts.quota.ClustersPerSubscription, "quota-clusters-per-subscription", frontend.DefaultResourceQuota.ClustersPerSubscription, "Default maximum number of clusters in a subscription")
+	rootCmd.Flags().IntVar(&opts.quota.NodePoolsPerCluster, "quota-node-pools-per-cluster", frontend.DefaultResourceQuota.NodePoolsPerCluster, "Default maximum number of node pools in a cluster")
+	rootCmd.Flags().IntVar(&opts.quota.NodesPerCluster, "quota-nodes-per-cluster", frontend.DefaultResourceQuota.NodesPerCluster, "Default maximum number of nodes in a cluster")
+
+	rootCmd.Flags().IntVar(&opts.adminPort, "admin-port", 8444, "port to serve the admin API on")
+	rootCmd.Flags().StringVar(&opts.adminTLSCertFile, "admin-tls-cert-file", "", "PEM file with the admin API server certificate; the admin API is disabled if unset")
+	rootCmd.Flags().StringVar(&opts.adminTLSKeyFile, "admin-tls-key-file", "", "PEM file with the admin API server private key")
+	rootCmd.Flags().StringVar(&opts.adminClientCAFile, "admin-client-ca-file", "", "PEM file with the certificate authorities that issue admin API client certificates")
+	rootCmd.Flags().StringSliceVar(&opts.adminAllowedSubjects, "admin-allowed-subjects", nil, "Client certificate subject common names allowed to use the admin API; if empty, any certificate from the client CA is allowed")
+
 	rootCmd.MarkFlagsRequiredTogether("cosmos-name", "cosmos-url")
+	rootCmd.MarkFlagsRequiredTogether("admin-tls-cert-file", "admin-tls-key-file", "admin-client-ca-file")
+	rootCmd.MarkFlagsMutuallyExclusive("in-memory-db", "cosmos-name")
+	rootCmd.MarkFlagsMutuallyExclusive("in-memory-db", "cosmos-url")
 
 	return rootCmd
 }
@@ -116,23 +139,29 @@ func (opts *FrontendOpts) Run() error {
 	}
 
 	// Create the database client.
-	cosmosDatabaseClient, err := database.NewCosmosDatabaseClient(
-		opts.cosmosURL,
-		opts.cosmosName,
-		azcore.ClientOptions{
-			// FIXME Cloud should be determined by other means.
-			Cloud:           cloud.AzurePublic,
-			PerCallPolicies: []policy.Policy{policyFunc(correlationIDPolicy)},
-			TracingProvider: azotel.NewTracingProvider(otel.GetTracerProvider(), nil),
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create the CosmosDB client: %w", err)
-	}
-
-	dbClient, err := database.NewDBClient(ctx, cosmosDatabaseClient)
-	if err != nil {
-		return fmt.Errorf("failed to create the database client: %w", err)
+	var dbClient database.DBClient
+	if opts.inMemoryDB {
+		logger.Warn("Using in-memory database, nothing will be persisted")
+		dbClient = database.NewInMemoryDBClient()
+	} else {
+		cosmosDatabaseClient, err := database.NewCosmosDatabaseClient(
+			opts.cosmosURL,
+			opts.cosmosName,
+			azcore.ClientOptions{
+				// FIXME Cloud should be determined by other means.
+				Cloud:           cloud.AzurePublic,
+				PerCallPolicies: []policy.Policy{policyFunc(correlationIDPolicy)},
+				TracingProvider: azotel.NewTracingProvider(otel.GetTracerProvider(), nil),
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create the CosmosDB client: %w", err)
+		}
+
+		dbClient, err = database.NewDBClient(ctx, cosmosDatabaseClient)
+		if err != nil {
+			return fmt.Errorf("failed to create the database client: %w", err)
+		}
 	}
 
 	listener, err := net.Listen("tcp4", fmt.Sprintf(":%d", opts.port))
@@ -177,6 +206,24 @@ func (opts *FrontendOpts) Run() error {
 	logger.Info(fmt.Sprintf("Application running in %s", opts.location))
 
 	f := frontend.NewFrontend(logger, listener, metricsListener, prometheus.DefaultRegisterer, dbClient, opts.location, &csClient)
+	f.SetDefaultQuota(opts.quota)
+
+	if opts.adminTLSCertFile != "" {
+		adminTLSConfig, err := frontend.NewAdminTLSConfig(opts.adminTLSCertFile, opts.adminTLSKeyFile, opts.adminClientCAFile)
+		if err != nil {
+			return err
+		}
+
+		adminListener, err := net.Listen("tcp4", fmt.Sprintf(":%d", opts.adminPort))
+		if err != nil {
+			return err
+		}
+
+		if len(opts.adminAllowedSubjects) == 0 {
+			logger.Warn("Admin API allows any client certificate issued by the client CA")
+		}
+		f.EnableAdminServer(adminListener, adminTLSConfig, opts.adminAllowedSubjects)
+	}
 
 	stop := make(chan struct{})
 	signalChannel := make(chan os.Signal, 1)
@@ -0,0 +1,555 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+const (
+	// Query parameter names for admin endpoints
+	adminResourceIDKey        = "resourceId"
+	adminSubscriptionStateKey = "state"
+
+	// Cluster Service node pool state value for a node pool at rest
+	nodePoolStateReady = "ready"
+)
+
+// adminSubscriptionStates are the values accepted for the subscription state filter.
+var adminSubscriptionStates = []arm.SubscriptionState{
+	arm.SubscriptionStateRegistered,
+	arm.SubscriptionStateUnregistered,
+	arm.SubscriptionStateWarned,
+	arm.SubscriptionStateDeleted,
+	arm.SubscriptionStateSuspended,
+}
+
+// adminSubscriptionEntry is a subscription as listed by the admin API.
+type adminSubscriptionEntry struct {
+	ID string `json:"id"`
+	*arm.Subscription
+}
+
+// NewAdminTLSConfig returns a TLS configuration for the admin server that
+// presents the given server certificate and requires clients to present a
+// certificate issued by one of the certificate authorities in clientCAFile.
+// All files are PEM encoded.
+func NewAdminTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load admin server certificate: %w", err)
+	}
+
+	clientCAs, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admin client CA file: %w", err)
+	}
+
+	clientCAPool := x509.NewCertPool()
+	if !clientCAPool.AppendCertsFromPEM(clientCAs) {
+		return nil, fmt.Errorf("no certificates found in admin client CA file %s", clientCAFile)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAPool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// EnableAdminServer configures the frontend to serve the admin API on the
+// given listener when it runs. Connections must complete a TLS handshake
+// using tlsConfig, which should require and verify client certificates.
+// See NewAdminTLSConfig. The admin API is disabled unless this is called.
+func (f *Frontend) EnableAdminServer(listener net.Listener, tlsConfig *tls.Config, allowedSubjects []string) {
+	f.adminListener = tls.NewListener(listener, tlsConfig)
+	f.adminServer = http.Server{
+		ErrorLog:    f.server.ErrorLog,
+		BaseContext: f.server.BaseContext,
+		Handler:     f.adminRoutes(allowedSubjects),
+	}
+}
+
+// parseAdminResourceIDQuery parses the "resourceId" query parameter, which
+// must be a cluster or node pool resource ID.
+func parseAdminResourceIDQuery(request *http.Request) (*azcorearm.ResourceID, *arm.CloudError) {
+	value := request.URL.Query().Get(adminResourceIDKey)
+	if value == "" {
+		return nil, arm.NewCloudError(
+			http.StatusBadRequest,
+			arm.CloudErrorCodeInvalidParameter,
+			adminResourceIDKey,
+			"The '%s' query parameter is required.",
+			adminResourceIDKey)
+	}
+
+	resourceID, err := azcorearm.ParseResourceID(value)
+	if err != nil || (!strings.EqualFold(resourceID.ResourceType.String(), api.ClusterResourceType.String()) &&
+		!strings.EqualFold(resourceID.ResourceType.String(), api.NodePoolResourceType.String())) {
+		return nil, arm.NewCloudError(
+			http.StatusBadRequest,
+			arm.CloudErrorCodeInvalidParameter,
+			adminResourceIDKey,
+			"The '%s' query parameter '%s' is not a cluster or node pool resource ID.",
+			adminResourceIDKey, value)
+	}
+
+	return resourceID, nil
+}
+
+// adminLockResource locks a resource for the remainder of an admin request,
+// the same as MiddlewareLockResource does for ARM requests. The returned
+// function releases the lock and must be called, even if locking fails.
+func (f *Frontend) adminLockResource(ctx context.Context, writer http.ResponseWriter, resourceID *azcorearm.ResourceID) (context.Context, func(), bool) {
+	lockClient := f.dbClient.GetLockClient()
+	if lockClient == nil {
+		return ctx, func() {}, true
+	}
+
+	lockedCtx, release, cloudError := acquireResourceLock(ctx, writer.Header(), lockClient, resourceID)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
+		return ctx, func() {}, false
+	}
+
+	return lockedCtx, release, true
+}
+
+// AdminOperationFail forces a non-terminal asynchronous operation to fail
+// and clears it from its resource so the resource accepts new requests.
+// This is for operations that are stuck, such as when Cluster Service has
+// lost track of the work. It does not abort anything in Cluster Service.
+func (f *Frontend) AdminOperationFail(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	subscriptionID := request.PathValue(PathSegmentSubscriptionID)
+	operationID := request.PathValue(PathSegmentOperationID)
+
+	pk := database.NewPartitionKey(subscriptionID)
+	doc, err := f.dbClient.GetOperationDoc(ctx, pk, operationID)
+	if errors.Is(err, database.ErrNotFound) {
+		arm.WriteError(
+			writer, http.StatusNotFound,
+			arm.CloudErrorCodeNotFound, "",
+			"The operation '%s' was not found in subscription '%s'.",
+			operationID, subscriptionID)
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	// Locking the operation's resource keeps the backend from updating
+	// the operation status at the same time. Read the operation again
+	// once locked in case its status changed while waiting for the lock.
+	ctx, release, ok := f.adminLockResource(ctx, writer, doc.ExternalID)
+	defer release()
+	if !ok {
+		return
+	}
+
+	doc, err = f.dbClient.GetOperationDoc(ctx, pk, operationID)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	if doc.Status.IsTerminal() {
+		arm.WriteError(
+			writer, http.StatusConflict,
+			arm.CloudErrorCodeConflict, "",
+			"Cannot fail operation because it is already %s",
+			strings.ToLower(string(doc.Status)))
+		return
+	}
+
+	opError := &arm.CloudErrorBody{
+		Code:    arm.CloudErrorCodeInternalServerError,
+		Message: "The operation was failed by a service administrator.",
+	}
+
+	updated, err := f.endOperation(ctx, pk, operationID, doc, arm.ProvisioningStateFailed, opError)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	if updated {
+		logger.Warn(fmt.Sprintf("Force-failed operation '%s' on '%s'", operationID, doc.ExternalID))
+	}
+
+	_, err = arm.WriteJSONResponse(writer, http.StatusOK, newOperationHistoryEntry(operationID, doc))
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+// AdminResourceResync sets the provisioning state of a cluster or node pool
+// from its state in Cluster Service and clears any active operation that
+// has already ended. This repairs resources left in a non-terminal state
+// by a lost operation. Resources with an active operation that has not
+// ended must have the operation failed first.
+func (f *Frontend) AdminResourceResync(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	resourceID, cloudError := parseAdminResourceIDQuery(request)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	ctx, release, ok := f.adminLockResource(ctx, writer, resourceID)
+	defer release()
+	if !ok {
+		return
+	}
+
+	doc, err := f.dbClient.GetResourceDoc(ctx, resourceID)
+	if errors.Is(err, database.ErrNotFound) {
+		arm.WriteResourceNotFoundError(writer, resourceID)
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	if doc.ActiveOperationID != "" {
+		pk := database.NewPartitionKey(resourceID.SubscriptionID)
+		operationDoc, err := f.dbClient.GetOperationDoc(ctx, pk, doc.ActiveOperationID)
+		if err == nil && !operationDoc.Status.IsTerminal() {
+			arm.WriteError(
+				writer, http.StatusConflict,
+				arm.CloudErrorCodeConflict, resourceID.String(),
+				"The resource has an active operation '%s' that must be failed before the resource is resynced.",
+				doc.ActiveOperationID)
+			return
+		} else if err != nil && !errors.Is(err, database.ErrNotFound) {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+	}
+
+	var provisioningState arm.ProvisioningState
+	var csState string
+
+	if strings.EqualFold(resourceID.ResourceType.String(), api.ClusterResourceType.String()) {
+		var csClusterStatus *arohcpv1alpha1.ClusterStatus
+		csClusterStatus, err = f.clusterServiceClient.GetClusterStatus(ctx, doc.InternalID)
+		if err == nil {
+			csState = string(csClusterStatus.State())
+			switch csClusterStatus.State() {
+			case arohcpv1alpha1.ClusterStateReady:
+				provisioningState = arm.ProvisioningStateSucceeded
+			case arohcpv1alpha1.ClusterStateError:
+				provisioningState = arm.ProvisioningStateFailed
+			}
+		}
+	} else {
+		var csNodePool *cmv1.NodePool
+		csNodePool, err = f.clusterServiceClient.GetNodePool(ctx, doc.InternalID)
+		if err == nil {
+			csState = csNodePool.Status().State().NodePoolStateValue()
+			if csState == nodePoolStateReady {
+				provisioningState = arm.ProvisioningStateSucceeded
+			}
+		}
+	}
+	if err != nil {
+		var ocmError *ocmerrors.Error
+		if errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound {
+			arm.WriteError(
+				writer, http.StatusConflict,
+				arm.CloudErrorCodeConflict, resourceID.String(),
+				"Cluster Service has no record of the resource.")
+			return
+		}
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	// Resources in transition are left alone since there
+	// is no operation to carry them to a terminal state.
+	if provisioningState == "" {
+		arm.WriteError(
+			writer, http.StatusConflict,
+			arm.CloudErrorCodeConflict, resourceID.String(),
+			"Cluster Service reports the resource state as '%s'. Retry once the resource is no longer in transition.",
+			csState)
+		return
+	}
+
+	updateResourceDoc := func(updateDoc *database.ResourceDocument) bool {
+		if updateDoc.ActiveOperationID == "" && updateDoc.ProvisioningState == provisioningState {
+			return false
+		}
+		updateDoc.ActiveOperationID = ""
+		updateDoc.ProvisioningState = provisioningState
+		return true
+	}
+
+	updated, err := f.dbClient.UpdateResourceDoc(ctx, resourceID, updateResourceDoc)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	if updated {
+		logger.Warn(fmt.Sprintf("Resynced '%s' from provisioning state '%s' to '%s'", resourceID, doc.ProvisioningState, provisioningState))
+		updateResourceDoc(doc)
+	}
+
+	_, err = arm.WriteJSONResponse(writer, http.StatusOK, doc)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+// AdminSubscriptionList lists the subscriptions known to the resource
+// provider, optionally narrowed to a particular subscription state with
+// a "state" query parameter.
+func (f *Frontend) AdminSubscriptionList(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	var state arm.SubscriptionState
+
+	if value := request.URL.Query().Get(adminSubscriptionStateKey); value != "" {
+		for _, s := range adminSubscriptionStates {
+			if strings.EqualFold(value, string(s)) {
+				state = s
+				break
+			}
+		}
+		if state == "" {
+			arm.WriteError(
+				writer, http.StatusBadRequest,
+				arm.CloudErrorCodeInvalidParameter, adminSubscriptionStateKey,
+				"The '%s' query parameter '%s' is not a valid subscription state.",
+				adminSubscriptionStateKey, value)
+			return
+		}
+	}
+
+	pagedResponse := arm.NewPagedResponse()
+
+	iterator := f.dbClient.ListAllSubscriptionDocs()
+
+	for subscriptionID, subscription := range iterator.Items(ctx) {
+		if state != "" && subscription.State != state {
+			continue
+		}
+		value, err := arm.Marshal(adminSubscriptionEntry{ID: subscriptionID, Subscription: subscription})
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+		pagedResponse.AddValue(value)
+	}
+
+	err := iterator.GetError()
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	_, err = arm.WriteJSONResponse(writer, http.StatusOK, pagedResponse)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+// AdminLockList lists held locks. With a "resourceId" query parameter, it
+// lists only the locks that would keep that resource from being locked.
+func (f *Frontend) AdminLockList(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	lockClient := f.dbClient.GetLockClient()
+	if lockClient == nil {
+		logger.Error("Lock client is not available")
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	var locks []database.LockInfo
+
+	if value := request.URL.Query().Get(adminResourceIDKey); value != "" {
+		resourceID, err := azcorearm.ParseResourceID(value)
+		if err != nil {
+			arm.WriteError(
+				writer, http.StatusBadRequest,
+				arm.CloudErrorCodeInvalidParameter, adminResourceIDKey,
+				"The '%s' query parameter '%s' is not a valid resource ID.",
+				adminResourceIDKey, value)
+			return
+		}
+
+		var lockIDs []string
+		if strings.EqualFold(resourceID.ResourceType.String(), azcorearm.SubscriptionResourceType.String()) {
+			lockIDs = []string{database.SubscriptionLockID(resourceID.SubscriptionID)}
+		} else {
+			lockIDs = append(database.ResourceLockAncestorIDs(resourceID), database.ResourceLockID(resourceID))
+		}
+
+		for _, lockID := range lockIDs {
+			lock, err := lockClient.GetLock(ctx, lockID)
+			if err != nil {
+				logger.Error(err.Error())
+				arm.WriteInternalServerError(writer)
+				return
+			}
+			if lock != nil {
+				locks = append(locks, *lock)
+			}
+		}
+	} else {
+		var err error
+		locks, err = lockClient.ListLocks(ctx)
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+	}
+
+	pagedResponse := arm.NewPagedResponse()
+
+	for _, lock := range locks {
+		value, err := arm.Marshal(lock)
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+		pagedResponse.AddValue(value)
+	}
+
+	_, err := arm.WriteJSONResponse(writer, http.StatusOK, pagedResponse)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+// AdminLockGet describes a held lock.
+func (f *Frontend) AdminLockGet(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	lock, ok := f.adminGetLock(writer, request)
+	if !ok {
+		return
+	}
+
+	setETagHeader(writer.Header(), lock.ETag)
+	_, err := arm.WriteJSONResponse(writer, http.StatusOK, lock)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+// AdminLockBreak forcibly releases a held lock. This is for recovering from
+// a lock holder that is stuck. A lock holder that is merely slow discovers
+// the lock was lost when next it renews the lock and abandons its work.
+//
+// Only the lock as inspected is broken: the request's If-Match header, if
+// present, must match the lock's entity tag, and a lock that changes while
+// the request is handled is left alone.
+func (f *Frontend) AdminLockBreak(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	lock, ok := f.adminGetLock(writer, request)
+	if !ok {
+		return
+	}
+
+	if ifMatch := request.Header.Values("If-Match"); len(ifMatch) > 0 && !matchETag(ifMatch, true, lock.ETag, false) {
+		arm.WriteError(
+			writer, http.StatusPreconditionFailed,
+			arm.CloudErrorCodePreconditionFailed, "",
+			"The lock '%s' does not match the If-Match header.", lock.ID)
+		return
+	}
+
+	err := f.dbClient.GetLockClient().BreakLock(ctx, lock.ID, lock.ETag)
+	if errors.Is(err, database.ErrLockChanged) {
+		arm.WriteError(
+			writer, http.StatusConflict,
+			arm.CloudErrorCodeConflict, "",
+			"The lock '%s' changed while it was being broken.", lock.ID)
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	logger.Warn(fmt.Sprintf("Broke lock '%s' held by '%s'", lock.ID, lock.Owner))
+
+	_, err = arm.WriteJSONResponse(writer, http.StatusOK, lock)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+// adminGetLock looks up the lock named in the request path. If the lock
+// is not held or the lookup fails, it writes an error response.
+func (f *Frontend) adminGetLock(writer http.ResponseWriter, request *http.Request) (*database.LockInfo, bool) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	lockClient := f.dbClient.GetLockClient()
+	if lockClient == nil {
+		logger.Error("Lock client is not available")
+		arm.WriteInternalServerError(writer)
+		return nil, false
+	}
+
+	lockID := request.PathValue(PathSegmentLockID)
+
+	lock, err := lockClient.GetLock(ctx, lockID)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return nil, false
+	}
+	if lock == nil {
+		arm.WriteError(
+			writer, http.StatusNotFound,
+			arm.CloudErrorCodeNotFound, "",
+			"The lock '%s' is not held.", lockID)
+		return nil, false
+	}
+
+	return lock, true
+}
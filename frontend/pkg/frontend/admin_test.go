@@ -0,0 +1,504 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+func newAdminTestRequest(method, target string) *http.Request {
+	request := httptest.NewRequest(method, target, nil)
+	return request.WithContext(ContextWithLogger(request.Context(), testLogger))
+}
+
+// createActiveOperation creates an operation for resourceDoc with the given
+// status and makes it the resource's active operation.
+func createActiveOperation(t *testing.T, dbClient database.DBClient, resourceDoc *database.ResourceDocument, status arm.ProvisioningState) string {
+	t.Helper()
+
+	ctx := ContextWithLogger(context.Background(), testLogger)
+
+	doc := database.NewOperationDocument(database.OperationRequestCreate, resourceDoc.ResourceID, resourceDoc.InternalID)
+	doc.Status = status
+	operationID, err := dbClient.CreateOperationDoc(ctx, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dbClient.UpdateResourceDoc(ctx, resourceDoc.ResourceID, func(updateDoc *database.ResourceDocument) bool {
+		updateDoc.ActiveOperationID = operationID
+		updateDoc.ProvisioningState = status
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return operationID
+}
+
+func TestAdminOperationFail(t *testing.T) {
+	ctx := ContextWithLogger(context.Background(), testLogger)
+	f := &Frontend{dbClient: database.NewInMemoryDBClient()}
+
+	clusterID := mustParseResourceID(t, testGroupPrefix+"testCluster")
+	clusterDoc := database.NewResourceDocument(clusterID)
+	if err := f.dbClient.CreateResourceDoc(ctx, clusterDoc); err != nil {
+		t.Fatal(err)
+	}
+
+	operationID := createActiveOperation(t, f.dbClient, clusterDoc, arm.ProvisioningStateProvisioning)
+
+	fail := func(operationID string) *httptest.ResponseRecorder {
+		request := newAdminTestRequest(http.MethodPost, "/admin/v1/subscriptions/"+clusterID.SubscriptionID+"/operations/"+operationID+"/fail")
+		request.SetPathValue(PathSegmentSubscriptionID, clusterID.SubscriptionID)
+		request.SetPathValue(PathSegmentOperationID, operationID)
+		writer := httptest.NewRecorder()
+		f.AdminOperationFail(writer, request)
+		return writer
+	}
+
+	writer := fail(operationID)
+	if writer.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, writer.Code, writer.Body.String())
+	}
+
+	operationDoc, err := f.dbClient.GetOperationDoc(ctx, database.NewPartitionKey(clusterID.SubscriptionID), operationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if operationDoc.Status != arm.ProvisioningStateFailed || operationDoc.Error == nil {
+		t.Errorf("Expected operation to have failed with an error but got status %s", operationDoc.Status)
+	}
+
+	resourceDoc, err := f.dbClient.GetResourceDoc(ctx, clusterID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resourceDoc.ActiveOperationID != "" {
+		t.Errorf("Expected active operation to be cleared but got '%s'", resourceDoc.ActiveOperationID)
+	}
+	if resourceDoc.ProvisioningState != arm.ProvisioningStateFailed {
+		t.Errorf("Expected provisioning state %s but got %s", arm.ProvisioningStateFailed, resourceDoc.ProvisioningState)
+	}
+
+	if writer = fail(operationID); writer.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d failing a failed operation but got %d", http.StatusConflict, writer.Code)
+	}
+
+	if writer = fail("00000000-0000-0000-0000-000000000000"); writer.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d failing an unknown operation but got %d", http.StatusNotFound, writer.Code)
+	}
+}
+
+func TestAdminResourceResync(t *testing.T) {
+	ctx := ContextWithLogger(context.Background(), testLogger)
+
+	// Cluster Service reports the test cluster is ready.
+	f, clusterDoc := newTestFrontend(t)
+
+	resync := func(resourceID string) *httptest.ResponseRecorder {
+		query := url.Values{adminResourceIDKey: {resourceID}}
+		writer := httptest.NewRecorder()
+		f.AdminResourceResync(writer, newAdminTestRequest(http.MethodPost, "/admin/v1/resync?"+query.Encode()))
+		return writer
+	}
+
+	operationID := createActiveOperation(t, f.dbClient, clusterDoc, arm.ProvisioningStateProvisioning)
+
+	if writer := resync(clusterDoc.ResourceID.String()); writer.Code != http.StatusConflict {
+		t.Fatalf("Expected status code %d with an active operation but got %d", http.StatusConflict, writer.Code)
+	}
+
+	// Simulate an operation that ended without clearing itself from the resource.
+	_, err := f.dbClient.UpdateOperationDoc(ctx, database.NewPartitionKey(clusterDoc.ResourceID.SubscriptionID), operationID, func(updateDoc *database.OperationDocument) bool {
+		return updateDoc.UpdateStatus(arm.ProvisioningStateSucceeded, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer := resync(clusterDoc.ResourceID.String())
+	if writer.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, writer.Code, writer.Body.String())
+	}
+
+	resourceDoc, err := f.dbClient.GetResourceDoc(ctx, clusterDoc.ResourceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resourceDoc.ActiveOperationID != "" {
+		t.Errorf("Expected active operation to be cleared but got '%s'", resourceDoc.ActiveOperationID)
+	}
+	if resourceDoc.ProvisioningState != arm.ProvisioningStateSucceeded {
+		t.Errorf("Expected provisioning state %s but got %s", arm.ProvisioningStateSucceeded, resourceDoc.ProvisioningState)
+	}
+
+	if writer = resync(testGroupPrefix + "otherCluster"); writer.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown cluster but got %d", http.StatusNotFound, writer.Code)
+	}
+}
+
+func TestAdminSubscriptionList(t *testing.T) {
+	ctx := ContextWithLogger(context.Background(), testLogger)
+	f := &Frontend{dbClient: database.NewInMemoryDBClient()}
+
+	subscriptions := map[string]arm.SubscriptionState{
+		"00000000-0000-0000-0000-000000000001": arm.SubscriptionStateRegistered,
+		"00000000-0000-0000-0000-000000000002": arm.SubscriptionStateSuspended,
+		"00000000-0000-0000-0000-000000000003": arm.SubscriptionStateRegistered,
+	}
+	for subscriptionID, state := range subscriptions {
+		subscription := &arm.Subscription{State: state, RegistrationDate: api.Ptr(time.Now().String())}
+		if err := f.dbClient.CreateSubscriptionDoc(ctx, subscriptionID, subscription); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		state        string
+		expectStatus int
+		expectCount  int
+	}{
+		{
+			name:         "All subscriptions",
+			expectStatus: http.StatusOK,
+			expectCount:  3,
+		},
+		{
+			name:         "Registered subscriptions",
+			state:        "registered",
+			expectStatus: http.StatusOK,
+			expectCount:  2,
+		},
+		{
+			name:         "Deleted subscriptions",
+			state:        "Deleted",
+			expectStatus: http.StatusOK,
+			expectCount:  0,
+		},
+		{
+			name:         "Invalid state",
+			state:        "Bogus",
+			expectStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{}
+			if tt.state != "" {
+				query.Set(adminSubscriptionStateKey, tt.state)
+			}
+
+			writer := httptest.NewRecorder()
+			f.AdminSubscriptionList(writer, newAdminTestRequest(http.MethodGet, "/admin/v1/subscriptions?"+query.Encode()))
+
+			if writer.Code != tt.expectStatus {
+				t.Fatalf("Expected status code %d but got %d: %s", tt.expectStatus, writer.Code, writer.Body.String())
+			}
+			if writer.Code != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Value []adminSubscriptionEntry `json:"value"`
+			}
+			if err := json.Unmarshal(writer.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if len(response.Value) != tt.expectCount {
+				t.Fatalf("Expected %d subscriptions but got %d", tt.expectCount, len(response.Value))
+			}
+			for _, entry := range response.Value {
+				if subscriptions[entry.ID] != entry.State {
+					t.Errorf("Expected subscription %s to be %s but got %s", entry.ID, subscriptions[entry.ID], entry.State)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminLocks(t *testing.T) {
+	ctx := context.Background()
+	f := &Frontend{dbClient: database.NewInMemoryDBClient()}
+	lockClient := f.dbClient.GetLockClient()
+
+	clusterID := mustParseResourceID(t, testGroupPrefix+"testCluster")
+	nodePoolID := mustParseResourceID(t, testGroupPrefix+"testCluster/nodePools/testNodePool")
+	otherClusterID := mustParseResourceID(t, testGroupPrefix+"otherCluster")
+
+	for _, lockID := range []string{
+		database.ResourceLockID(clusterID),
+		database.ResourceLockID(otherClusterID),
+	} {
+		if _, err := lockClient.TryAcquireLock(ctx, lockID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	listLocks := func(t *testing.T, query url.Values) []database.LockInfo {
+		writer := httptest.NewRecorder()
+		f.AdminLockList(writer, newAdminTestRequest(http.MethodGet, "/admin/v1/locks?"+query.Encode()))
+		if writer.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, writer.Code, writer.Body.String())
+		}
+
+		var response struct {
+			Value []database.LockInfo `json:"value"`
+		}
+		if err := json.Unmarshal(writer.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		return response.Value
+	}
+
+	lockRequest := func(method, lockID, ifMatch string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		request := newAdminTestRequest(method, "/admin/v1/locks/"+lockID)
+		request.SetPathValue(PathSegmentLockID, lockID)
+		if ifMatch != "" {
+			request.Header.Set("If-Match", ifMatch)
+		}
+		writer := httptest.NewRecorder()
+		handler(writer, request)
+		return writer
+	}
+
+	t.Run("List all locks", func(t *testing.T) {
+		if locks := listLocks(t, nil); len(locks) != 2 {
+			t.Errorf("Expected 2 locks but got %v", locks)
+		}
+	})
+
+	t.Run("List locks blocking a node pool", func(t *testing.T) {
+		locks := listLocks(t, url.Values{adminResourceIDKey: {nodePoolID.String()}})
+		if len(locks) != 1 || locks[0].ID != database.ResourceLockID(clusterID) {
+			t.Errorf("Expected only the cluster lock but got %v", locks)
+		}
+	})
+
+	t.Run("Get and break a lock", func(t *testing.T) {
+		lockID := database.ResourceLockID(clusterID)
+
+		writer := lockRequest(http.MethodGet, lockID, "", f.AdminLockGet)
+		if writer.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, writer.Code, writer.Body.String())
+		}
+
+		var lock database.LockInfo
+		if err := json.Unmarshal(writer.Body.Bytes(), &lock); err != nil {
+			t.Fatal(err)
+		}
+		if lock.ID != lockID || !lock.ExpiresAt.After(time.Now()) || lock.ETag == "" {
+			t.Errorf("Unexpected lock %+v", lock)
+		}
+		if etag := writer.Header().Get("ETag"); etag != string(lock.ETag) {
+			t.Errorf("Expected ETag header %s but got %s", lock.ETag, etag)
+		}
+
+		if writer = lockRequest(http.MethodDelete, lockID, `"stale"`, f.AdminLockBreak); writer.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected status code %d for a stale If-Match header but got %d", http.StatusPreconditionFailed, writer.Code)
+		}
+
+		// If-Match may list several entity tags.
+		if writer = lockRequest(http.MethodDelete, lockID, `"stale", `+string(lock.ETag), f.AdminLockBreak); writer.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, writer.Code, writer.Body.String())
+		}
+
+		if writer = lockRequest(http.MethodGet, lockID, "", f.AdminLockGet); writer.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for a broken lock but got %d", http.StatusNotFound, writer.Code)
+		}
+
+		// The lock can be acquired again once broken.
+		if _, err := lockClient.TryAcquireLock(ctx, lockID); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// adminTestPKI is a self-signed certificate authority that issues the admin
+// server certificate and client certificates, as described in the README.
+type adminTestPKI struct {
+	dir    string
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	serial int64
+}
+
+func newAdminTestPKI(t *testing.T) *adminTestPKI {
+	t.Helper()
+
+	pki := &adminTestPKI{dir: t.TempDir()}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := pki.template("Test Admin CA")
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pki.caCert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pki.caKey = caKey
+	pki.writePEM(t, "ca.crt", "CERTIFICATE", der)
+
+	return pki
+}
+
+func (pki *adminTestPKI) template(commonName string) *x509.Certificate {
+	pki.serial++
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(pki.serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+}
+
+func (pki *adminTestPKI) writePEM(t *testing.T, name, blockType string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(pki.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// issue writes a certificate and key signed by the CA and returns their paths.
+func (pki *adminTestPKI) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := pki.template(commonName)
+	template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+	der, err := x509.CreateCertificate(rand.Reader, template, pki.caCert, &key.PublicKey, pki.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pki.writePEM(t, commonName+".crt", "CERTIFICATE", der), pki.writePEM(t, commonName+".key", "EC PRIVATE KEY", keyDER)
+}
+
+func TestAdminServer(t *testing.T) {
+	pki := newAdminTestPKI(t)
+	caFile := filepath.Join(pki.dir, "ca.crt")
+	serverCertFile, serverKeyFile := pki.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+
+	tlsConfig, err := NewAdminTLSConfig(serverCertFile, serverKeyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dbClient := database.NewInMemoryDBClient()
+	f := &Frontend{
+		dbClient: dbClient,
+		server: http.Server{
+			BaseContext: func(net.Listener) context.Context {
+				ctx := ContextWithLogger(context.Background(), testLogger)
+				return ContextWithDBClient(ctx, dbClient)
+			},
+		},
+	}
+	f.EnableAdminServer(listener, tlsConfig, []string{"test-sre"})
+
+	go func() {
+		_ = f.adminServer.Serve(f.adminListener)
+	}()
+	t.Cleanup(func() {
+		_ = f.adminServer.Close()
+	})
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(pki.caCert)
+
+	newClient := func(t *testing.T, commonName string) *http.Client {
+		clientTLSConfig := &tls.Config{RootCAs: rootCAs}
+		if commonName != "" {
+			certFile, keyFile := pki.issue(t, commonName, x509.ExtKeyUsageClientAuth)
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			clientTLSConfig.Certificates = []tls.Certificate{cert}
+		}
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLSConfig}}
+	}
+
+	operationsURL := "https://" + listener.Addr().String() + "/admin/v1/operations?" +
+		url.Values{adminResourceIDKey: {testGroupPrefix + "testCluster"}}.Encode()
+
+	t.Run("Allowed client", func(t *testing.T) {
+		response, err := newClient(t, "test-sre").Get(operationsURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			t.Errorf("Expected status code %d but got %d", http.StatusOK, response.StatusCode)
+		}
+	})
+
+	t.Run("Unauthorized client", func(t *testing.T) {
+		response, err := newClient(t, "intruder").Get(operationsURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status code %d but got %d", http.StatusForbidden, response.StatusCode)
+		}
+	})
+
+	t.Run("Client without certificate", func(t *testing.T) {
+		response, err := newClient(t, "").Get(operationsURL)
+		if err == nil {
+			response.Body.Close()
+			t.Errorf("Expected TLS handshake to fail but got status code %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+)
+
+// RequestBreakGlassCredential asks Cluster Service to issue a new break-glass
+// credential for a cluster and returns the internal ID of the credential.
+func (f *Frontend) RequestBreakGlassCredential(ctx context.Context, resourceDoc *database.ResourceDocument) (ocm.InternalID, *arm.CloudError) {
+	logger := LoggerFromContext(ctx)
+
+	// Cluster Service will not issue new credentials while
+	// revoking existing credentials, so check for that first.
+	revoking, err := f.revokingBreakGlassCredentials(ctx, resourceDoc)
+	if err != nil {
+		logger.Error(err.Error())
+		return ocm.InternalID{}, arm.NewInternalServerError()
+	}
+	if revoking {
+		return ocm.InternalID{}, arm.NewCloudError(
+			http.StatusConflict,
+			arm.CloudErrorCodeConflict,
+			resourceDoc.ResourceID.String(),
+			"Cannot request credential while credentials are being revoked")
+	}
+
+	csBreakGlassCredential, err := f.clusterServiceClient.PostBreakGlassCredential(ctx, resourceDoc.InternalID)
+	if err != nil {
+		logger.Error(err.Error())
+		var ocmError *ocmerrors.Error
+		if errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound {
+			return ocm.InternalID{}, arm.NewResourceNotFoundError(resourceDoc.ResourceID)
+		}
+		return ocm.InternalID{}, arm.NewInternalServerError()
+	}
+
+	internalID, err := ocm.NewInternalID(csBreakGlassCredential.HREF())
+	if err != nil {
+		logger.Error(err.Error())
+		return ocm.InternalID{}, arm.NewInternalServerError()
+	}
+
+	return internalID, nil
+}
+
+// RevokeBreakGlassCredentials asks Cluster Service to revoke all break-glass
+// credentials for a cluster and returns the internal ID of the cluster.
+func (f *Frontend) RevokeBreakGlassCredentials(ctx context.Context, resourceDoc *database.ResourceDocument) (ocm.InternalID, *arm.CloudError) {
+	logger := LoggerFromContext(ctx)
+
+	err := f.clusterServiceClient.DeleteBreakGlassCredentials(ctx, resourceDoc.InternalID)
+	if err != nil {
+		logger.Error(err.Error())
+		var ocmError *ocmerrors.Error
+		if errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound {
+			return ocm.InternalID{}, arm.NewResourceNotFoundError(resourceDoc.ResourceID)
+		}
+		return ocm.InternalID{}, arm.NewInternalServerError()
+	}
+
+	return resourceDoc.InternalID, nil
+}
+
+// MarshalBreakGlassCredential renders an issued break-glass credential from
+// Cluster Service in JSON format, applying the necessary conversions for the
+// API version of the request.
+func (f *Frontend) MarshalBreakGlassCredential(ctx context.Context, internalID ocm.InternalID, versionedInterface api.Version) ([]byte, *arm.CloudError) {
+	logger := LoggerFromContext(ctx)
+
+	csBreakGlassCredential, err := f.clusterServiceClient.GetBreakGlassCredential(ctx, internalID)
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, arm.NewInternalServerError()
+	}
+
+	hcpAdminCredential := &api.HCPOpenShiftClusterAdminCredential{
+		ExpirationTimestamp: csBreakGlassCredential.ExpirationTimestamp(),
+		Kubeconfig:          csBreakGlassCredential.Kubeconfig(),
+	}
+
+	responseBody, err := arm.Marshal(versionedInterface.NewHCPOpenShiftClusterAdminCredential(hcpAdminCredential))
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, arm.NewInternalServerError()
+	}
+
+	return responseBody, nil
+}
+
+// revokingBreakGlassCredentials returns true if there is an unfinished
+// operation to revoke the break-glass credentials of a cluster.
+func (f *Frontend) revokingBreakGlassCredentials(ctx context.Context, resourceDoc *database.ResourceDocument) (bool, error) {
+	pk := database.NewPartitionKey(resourceDoc.ResourceID.SubscriptionID)
+	iterator := f.dbClient.ListOperationDocs(pk)
+
+	for _, operationDoc := range iterator.Items(ctx) {
+		if operationDoc.Request == database.OperationRequestRevokeCredentials &&
+			!operationDoc.Status.IsTerminal() &&
+			strings.EqualFold(operationDoc.ExternalID.String(), resourceDoc.ResourceID.String()) {
+			return true, nil
+		}
+	}
+
+	return false, iterator.GetError()
+}
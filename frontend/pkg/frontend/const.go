@@ -13,11 +13,23 @@ const (
 	PathSegmentActionName        = "actionname"
 	PathSegmentDeploymentName    = "deploymentname"
 	PathSegmentLocation          = "location"
+	PathSegmentLockID            = "lockid"
 	PathSegmentNodePoolName      = "nodepoolname"
 	PathSegmentOperationID       = "operationid"
 	PathSegmentResourceGroupName = "resourcegroupname"
 	PathSegmentResourceName      = "resourcename"
 	PathSegmentSubscriptionID    = "subscriptionid"
+	PathSegmentVersionName       = "versionname"
+
+	// Resource action names, must be lowercase as they are matched against the lowercased request URL
+	ActionRequestAdminCredential = "requestadmincredential"
+	ActionRevokeCredentials      = "revokecredentials"
+
+	// Operation action names, must be lowercase as they are matched against the lowercased request URL
+	ActionCancelOperation = "cancel"
+
+	// Location action names, must be lowercase as they are matched against the lowercased request URL
+	ActionCheckNameAvailability = "checknameavailability"
 
 	healthGaugeName     = "frontend_health"
 	requestCounterName  = "frontend_http_requests_total"
@@ -48,6 +48,8 @@ func (c contextKey) String() string {
 		return "systemData"
 	case contextKeyPattern:
 		return "pattern"
+	case contextKeySubscription:
+		return "subscription"
 	}
 	return "<unknown>"
 }
@@ -63,6 +65,7 @@ const (
 	contextKeyCorrelationData
 	contextKeySystemData
 	contextKeyPattern
+	contextKeySubscription
 )
 
 func ContextWithOriginalPath(ctx context.Context, originalPath string) context.Context {
@@ -204,3 +207,19 @@ func PatternFromContext(ctx context.Context) *string {
 	pattern, _ := ctx.Value(contextKeyPattern).(*string)
 	return pattern
 }
+
+func ContextWithSubscription(ctx context.Context, subscription *arm.Subscription) context.Context {
+	return context.WithValue(ctx, contextKeySubscription, subscription)
+}
+
+func SubscriptionFromContext(ctx context.Context) (*arm.Subscription, error) {
+	subscription, ok := ctx.Value(contextKeySubscription).(*arm.Subscription)
+	if !ok {
+		err := &ContextError{
+			got: subscription,
+			key: contextKeySubscription,
+		}
+		return subscription, err
+	}
+	return subscription, nil
+}
@@ -0,0 +1,71 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+// actionFeatures maps action names to the features, relative to
+// api.ProviderNamespace, that a subscription must be registered for
+// to perform them. Fields and enum values of API resources are gated
+// by the api.FeatureStructTagKey struct tag instead.
+var actionFeatures = map[string]string{}
+
+// featuresFromContext returns the registered feature states of the
+// subscription in the request context.
+func featuresFromContext(ctx context.Context) map[string]string {
+	subscription, _ := SubscriptionFromContext(ctx)
+	if subscription == nil || subscription.Properties == nil {
+		return nil
+	}
+	return featuresMap(subscription.Properties.RegisteredFeatures)
+}
+
+// validateFeatures returns a "400 Bad Request" error response if a
+// request starts using feature-gated fields or enum values that the
+// subscription is not registered for. The current value is the default
+// resource for create requests.
+func validateFeatures(ctx context.Context, newVal, curVal interface{}) *arm.CloudError {
+	errorDetails := api.ValidateFeatures(newVal, curVal, featuresFromContext(ctx))
+	if len(errorDetails) == 0 {
+		return nil
+	}
+
+	cloudError := arm.NewCloudError(
+		http.StatusBadRequest,
+		arm.CloudErrorCodeMultipleErrorsOccurred, "",
+		"Content validation failed on multiple fields")
+	cloudError.Details = errorDetails
+	if len(errorDetails) == 1 {
+		// Promote a single validation error out of details.
+		cloudError.CloudErrorBody = &errorDetails[0]
+	}
+
+	return cloudError
+}
+
+// checkActionFeature returns a "400 Bad Request" error response if an
+// action is gated by a feature the subscription is not registered for.
+func checkActionFeature(ctx context.Context, resourceID string, actionName string) *arm.CloudError {
+	feature, ok := actionFeatures[actionName]
+	if !ok {
+		return nil
+	}
+
+	feature = api.FeatureName(feature)
+	if api.IsFeatureRegistered(featuresFromContext(ctx), feature) {
+		return nil
+	}
+
+	errorBody := api.NewFeatureNotRegisteredError(feature, resourceID, "Action '%s'", actionName)
+	return &arm.CloudError{
+		StatusCode:     http.StatusBadRequest,
+		CloudErrorBody: &errorBody,
+	}
+}
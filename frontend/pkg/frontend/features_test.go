@@ -0,0 +1,100 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+func TestCheckActionFeature(t *testing.T) {
+	const gatedAction = "gatedaction"
+
+	savedActionFeatures := actionFeatures
+	actionFeatures = map[string]string{gatedAction: "GatedAction"}
+	defer func() { actionFeatures = savedActionFeatures }()
+
+	tests := []struct {
+		name         string
+		action       string
+		subscription *arm.Subscription
+		expectError  bool
+	}{
+		{
+			name:   "Ungated action",
+			action: ActionRequestAdminCredential,
+		},
+		{
+			name:        "Gated action without subscription",
+			action:      gatedAction,
+			expectError: true,
+		},
+		{
+			name:   "Gated action without feature",
+			action: gatedAction,
+			subscription: &arm.Subscription{Properties: &arm.SubscriptionProperties{
+				RegisteredFeatures: &[]arm.Feature{{
+					Name:  api.Ptr(featureIncreasedQuota),
+					State: api.Ptr(api.FeatureStateRegistered),
+				}},
+			}},
+			expectError: true,
+		},
+		{
+			name:   "Gated action with feature",
+			action: gatedAction,
+			subscription: &arm.Subscription{Properties: &arm.SubscriptionProperties{
+				RegisteredFeatures: &[]arm.Feature{{
+					Name:  api.Ptr(api.FeatureName("GatedAction")),
+					State: api.Ptr(api.FeatureStateRegistered),
+				}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.subscription != nil {
+				ctx = ContextWithSubscription(ctx, tt.subscription)
+			}
+
+			cloudError := checkActionFeature(ctx, testGroupPrefix+"testCluster", tt.action)
+
+			if !tt.expectError {
+				if cloudError != nil {
+					t.Errorf("Unexpected error: %v", cloudError)
+				}
+				return
+			}
+
+			if cloudError == nil {
+				t.Fatal("Expected an error")
+			}
+			if cloudError.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, cloudError.StatusCode)
+			}
+			if cloudError.Code != arm.CloudErrorCodeFeatureNotRegistered {
+				t.Errorf("Expected error code '%s' but got '%s'", arm.CloudErrorCodeFeatureNotRegistered, cloudError.Code)
+			}
+		})
+	}
+}
+
+func TestValidateFeaturesDefaultResources(t *testing.T) {
+	ctx := context.Background()
+
+	// Default resources must not use any feature-gated functionality,
+	// or creating a resource would always require a feature.
+	if cloudError := validateFeatures(ctx, api.NewDefaultHCPOpenShiftCluster(), &api.HCPOpenShiftCluster{}); cloudError != nil {
+		t.Errorf("Unexpected cluster error: %v", cloudError)
+	}
+	if cloudError := validateFeatures(ctx, api.NewDefaultHCPOpenShiftClusterNodePool(), &api.HCPOpenShiftClusterNodePool{}); cloudError != nil {
+		t.Errorf("Unexpected node pool error: %v", cloudError)
+	}
+}
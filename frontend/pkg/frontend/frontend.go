@@ -14,12 +14,14 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync/atomic"
 
 	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"golang.org/x/sync/errgroup"
@@ -37,12 +39,16 @@ type Frontend struct {
 	metricsListener      net.Listener
 	server               http.Server
 	metricsServer        http.Server
+	adminListener        net.Listener
+	adminServer          http.Server
 	dbClient             database.DBClient
 	ready                atomic.Value
 	done                 chan struct{}
 	location             string
+	quota                ResourceQuota
 	collector            *metrics.SubscriptionCollector
 	healthGauge          prometheus.Gauge
+	providerOperations   []arm.ProviderOperation
 }
 
 func NewFrontend(
@@ -76,6 +82,7 @@ func NewFrontend(
 		dbClient:  dbClient,
 		done:      make(chan struct{}),
 		location:  strings.ToLower(location),
+		quota:     DefaultResourceQuota,
 		collector: metrics.NewSubscriptionCollector(reg, dbClient, location),
 		healthGauge: promauto.With(reg).NewGauge(
 			prometheus.GaugeOpts{
@@ -101,12 +108,16 @@ func (f *Frontend) Run(ctx context.Context, stop <-chan struct{}) {
 			f.ready.Store(false)
 			_ = f.server.Shutdown(ctx)
 			_ = f.metricsServer.Shutdown(ctx)
+			_ = f.adminServer.Shutdown(ctx)
 			close(f.done)
 		}()
 	}
 
 	logger.Info(fmt.Sprintf("listening on %s", f.listener.Addr().String()))
 	logger.Info(fmt.Sprintf("metrics listening on %s", f.metricsListener.Addr().String()))
+	if f.adminListener != nil {
+		logger.Info(fmt.Sprintf("admin listening on %s", f.adminListener.Addr().String()))
+	}
 	f.ready.Store(true)
 
 	errs, ctx := errgroup.WithContext(ctx)
@@ -116,6 +127,11 @@ func (f *Frontend) Run(ctx context.Context, stop <-chan struct{}) {
 	errs.Go(func() error {
 		return f.metricsServer.Serve(f.metricsListener)
 	})
+	if f.adminListener != nil {
+		errs.Go(func() error {
+			return f.adminServer.Serve(f.adminListener)
+		})
+	}
 	errs.Go(func() error {
 		f.collector.Run(logger, stop)
 		return nil
@@ -341,12 +357,13 @@ func (f *Frontend) ArmResourceRead(writer http.ResponseWriter, request *http.Req
 		return
 	}
 
-	responseBody, cloudError := f.MarshalResource(ctx, resourceID, versionedInterface)
+	responseBody, etag, cloudError := f.MarshalResource(ctx, resourceID, versionedInterface)
 	if cloudError != nil {
 		arm.WriteCloudError(writer, cloudError)
 		return
 	}
 
+	setETagHeader(writer.Header(), etag)
 	_, err = arm.WriteJSONResponse(writer, http.StatusOK, responseBody)
 	if err != nil {
 		logger.Error(err.Error())
@@ -399,11 +416,33 @@ func (f *Frontend) ArmResourceCreateOrUpdate(writer http.ResponseWriter, request
 		return
 	}
 
+	retriedOperationDoc, cloudError := f.getRetriedOperation(ctx, request, doc)
+	if cloudError != nil {
+		logger.Error(cloudError.Error())
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+	if retriedOperationDoc != nil {
+		f.replayOperationResponse(writer, request, retriedOperationDoc, versionedInterface)
+		return
+	}
+
+	// The resource lock held for the duration of this request keeps
+	// the resource from changing between this check and the update below.
+	cloudError = checkPreconditions(request.Header, resourceID, doc)
+	if cloudError != nil {
+		logger.Error(cloudError.Error())
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
 	var updating = (doc != nil)
 	var operationRequest database.OperationRequest
 
+	var currentCSCluster *arohcpv1alpha1.Cluster
 	var versionedCurrentCluster api.VersionedHCPOpenShiftCluster
 	var versionedRequestCluster api.VersionedHCPOpenShiftCluster
+	var currentVersion api.VersionProfile
 	var successStatusCode int
 
 	if updating {
@@ -413,14 +452,15 @@ func (f *Frontend) ArmResourceCreateOrUpdate(writer http.ResponseWriter, request
 		// No special treatment here for "not found" errors. A "not found"
 		// error indicates the database has gotten out of sync and so it's
 		// appropriate to fail.
-		csCluster, err := f.clusterServiceClient.GetCluster(ctx, doc.InternalID)
+		currentCSCluster, err = f.clusterServiceClient.GetCluster(ctx, doc.InternalID)
 		if err != nil {
 			logger.Error(fmt.Sprintf("failed to fetch CS cluster for %s: %v", resourceID, err))
 			arm.WriteInternalServerError(writer)
 			return
 		}
 
-		hcpCluster := ConvertCStoHCPOpenShiftCluster(resourceID, csCluster)
+		hcpCluster := ConvertCStoHCPOpenShiftCluster(resourceID, currentCSCluster)
+		currentVersion = hcpCluster.Properties.Version
 
 		// Do not set the TrackedResource.Tags field here. We need
 		// the Tags map to remain nil so we can see if the request
@@ -457,14 +497,6 @@ func (f *Frontend) ArmResourceCreateOrUpdate(writer http.ResponseWriter, request
 		doc = database.NewResourceDocument(resourceID)
 	}
 
-	// CheckForProvisioningStateConflict does not log conflict errors
-	// but does log unexpected errors like database failures.
-	cloudError := f.CheckForProvisioningStateConflict(ctx, operationRequest, doc)
-	if cloudError != nil {
-		arm.WriteCloudError(writer, cloudError)
-		return
-	}
-
 	body, err := BodyFromContext(ctx)
 	if err != nil {
 		logger.Error(err.Error())
@@ -487,6 +519,75 @@ func (f *Frontend) ArmResourceCreateOrUpdate(writer http.ResponseWriter, request
 	hcpCluster := api.NewDefaultHCPOpenShiftCluster()
 	versionedRequestCluster.Normalize(hcpCluster)
 
+	currentCluster := api.NewDefaultHCPOpenShiftCluster()
+	versionedCurrentCluster.Normalize(currentCluster)
+
+	cloudError = validateFeatures(ctx, hcpCluster, currentCluster)
+	if cloudError != nil {
+		logger.Error(cloudError.Error())
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	// Tags live only in the resource document, so a PATCH request that
+	// changes nothing else completes synchronously without updating the
+	// cluster in Cluster Service or starting an asynchronous operation. It
+	// is therefore not in conflict with an operation already in progress.
+	if updating && request.Method == http.MethodPatch {
+		currentCluster.TrackedResource.Tags = hcpCluster.TrackedResource.Tags
+
+		if reflect.DeepEqual(currentCluster, hcpCluster) {
+			logger.Info(fmt.Sprintf("updating tags for resource %s", resourceID))
+			doc, cloudError = f.UpdateResourceTags(ctx, resourceID, hcpCluster.TrackedResource.Tags, systemData)
+			if cloudError != nil {
+				arm.WriteCloudError(writer, cloudError)
+				return
+			}
+
+			responseBody, err := marshalCSCluster(currentCSCluster, doc, versionedInterface)
+			if err != nil {
+				logger.Error(err.Error())
+				arm.WriteInternalServerError(writer)
+				return
+			}
+
+			setETagHeader(writer.Header(), doc.ETag)
+			_, err = arm.WriteJSONResponse(writer, http.StatusOK, responseBody)
+			if err != nil {
+				logger.Error(err.Error())
+			}
+			return
+		}
+	}
+
+	// CheckForProvisioningStateConflict does not log conflict errors
+	// but does log unexpected errors like database failures.
+	cloudError = f.CheckForProvisioningStateConflict(ctx, operationRequest, doc)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	// A new version ID on an existing cluster is a control plane upgrade
+	// request, which Cluster Service carries out through an upgrade policy
+	// rather than a cluster update.
+	var csUpgradePolicy *cmv1.ControlPlaneUpgradePolicy
+	if updating && convertVersionToCSVersionID(hcpCluster.Properties.Version) != convertVersionToCSVersionID(currentVersion) {
+		cloudError = validateVersionUpgrade(currentVersion, hcpCluster.Properties.Version)
+		if cloudError != nil {
+			logger.Error(cloudError.Error())
+			arm.WriteCloudError(writer, cloudError)
+			return
+		}
+
+		csUpgradePolicy, err = f.BuildCSControlPlaneUpgradePolicy(ctx, hcpCluster)
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+	}
+
 	hcpCluster.Name = request.PathValue(PathSegmentResourceName)
 	csCluster, err := f.BuildCSCluster(resourceID, request.Header, hcpCluster, updating)
 	if err != nil {
@@ -495,7 +596,33 @@ func (f *Frontend) ArmResourceCreateOrUpdate(writer http.ResponseWriter, request
 		return
 	}
 
+	// The operation tracks the cluster itself unless it
+	// tracks an upgrade policy for the cluster control plane.
+	var operationInternalID ocm.InternalID
+
 	if updating {
+		operationInternalID = doc.InternalID
+
+		// Post the upgrade policy before updating the cluster so
+		// that an upgrade Cluster Service rejects leaves the rest
+		// of the cluster unchanged.
+		if csUpgradePolicy != nil {
+			logger.Info(fmt.Sprintf("upgrading resource %s to version %s", resourceID, csUpgradePolicy.Version()))
+			csUpgradePolicy, err = f.clusterServiceClient.PostControlPlaneUpgradePolicy(ctx, doc.InternalID, csUpgradePolicy)
+			if err != nil {
+				logger.Error(err.Error())
+				arm.WriteInternalServerError(writer)
+				return
+			}
+
+			operationInternalID, err = ocm.NewInternalID(csUpgradePolicy.HREF())
+			if err != nil {
+				logger.Error(err.Error())
+				arm.WriteInternalServerError(writer)
+				return
+			}
+		}
+
 		logger.Info(fmt.Sprintf("updating resource %s", resourceID))
 		csCluster, err = f.clusterServiceClient.UpdateCluster(ctx, doc.InternalID, csCluster)
 		if err != nil {
@@ -504,6 +631,18 @@ func (f *Frontend) ArmResourceCreateOrUpdate(writer http.ResponseWriter, request
 			return
 		}
 	} else {
+		// The new cluster counts toward the quota once its
+		// resource document is created, so hold the quota
+		// lock until this request is done.
+		var releaseQuota func()
+		ctx, releaseQuota, cloudError = f.checkClusterQuota(ctx, writer.Header(), resourceID)
+		if cloudError != nil {
+			logger.Error(cloudError.Error())
+			arm.WriteCloudError(writer, cloudError)
+			return
+		}
+		defer releaseQuota()
+
 		logger.Info(fmt.Sprintf("creating resource %s", resourceID))
 		csCluster, err = f.clusterServiceClient.PostCluster(ctx, csCluster)
 		if err != nil {
@@ -518,9 +657,11 @@ func (f *Frontend) ArmResourceCreateOrUpdate(writer http.ResponseWriter, request
 			arm.WriteInternalServerError(writer)
 			return
 		}
+
+		operationInternalID = doc.InternalID
 	}
 
-	operationDoc := database.NewOperationDocument(operationRequest, doc.ResourceID, doc.InternalID)
+	operationDoc := database.NewOperationDocument(operationRequest, doc.ResourceID, operationInternalID)
 
 	operationID, err := f.dbClient.CreateOperationDoc(ctx, operationDoc)
 	if err != nil {
@@ -542,6 +683,7 @@ func (f *Frontend) ArmResourceCreateOrUpdate(writer http.ResponseWriter, request
 	updateResourceMetadata := func(doc *database.ResourceDocument) bool {
 		doc.ActiveOperationID = operationID
 		doc.ProvisioningState = operationDoc.Status
+		doc.ETag = database.NewResourceETag()
 
 		// Record managed identity type and any system-assigned identifiers.
 		// Omit the user-assigned identities map since that is reconstructed
@@ -604,6 +746,7 @@ func (f *Frontend) ArmResourceCreateOrUpdate(writer http.ResponseWriter, request
 		return
 	}
 
+	setETagHeader(writer.Header(), doc.ETag)
 	_, err = arm.WriteJSONResponse(writer, successStatusCode, responseBody)
 	if err != nil {
 		logger.Error(err.Error())
@@ -632,6 +775,12 @@ func (f *Frontend) ArmResourceDelete(writer http.ResponseWriter, request *http.R
 		// For resource not found errors on deletion, ARM requires
 		// us to simply return 204 No Content and no response body.
 		if errors.Is(err, database.ErrNotFound) {
+			cloudError := checkPreconditions(request.Header, resourceID, nil)
+			if cloudError != nil {
+				logger.Error(cloudError.Error())
+				arm.WriteCloudError(writer, cloudError)
+				return
+			}
 			writer.WriteHeader(http.StatusNoContent)
 		} else {
 			logger.Error(err.Error())
@@ -640,9 +789,27 @@ func (f *Frontend) ArmResourceDelete(writer http.ResponseWriter, request *http.R
 		return
 	}
 
+	retriedOperationDoc, cloudError := f.getRetriedOperation(ctx, request, resourceDoc)
+	if cloudError != nil {
+		logger.Error(cloudError.Error())
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+	if retriedOperationDoc != nil {
+		f.replayOperationResponse(writer, request, retriedOperationDoc, nil)
+		return
+	}
+
+	cloudError = checkPreconditions(request.Header, resourceID, resourceDoc)
+	if cloudError != nil {
+		logger.Error(cloudError.Error())
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
 	// CheckForProvisioningStateConflict does not log conflict errors
 	// but does log unexpected errors like database failures.
-	cloudError := f.CheckForProvisioningStateConflict(ctx, operationRequest, resourceDoc)
+	cloudError = f.CheckForProvisioningStateConflict(ctx, operationRequest, resourceDoc)
 	if cloudError != nil {
 		arm.WriteCloudError(writer, cloudError)
 		return
@@ -671,8 +838,85 @@ func (f *Frontend) ArmResourceDelete(writer http.ResponseWriter, request *http.R
 	writer.WriteHeader(http.StatusAccepted)
 }
 
+// ArmResourceAction implements the POST action API contract for ARM
+// * 202 if an asynchronous action is initiated
+// * 404 if the resource or the action does not exist
 func (f *Frontend) ArmResourceAction(writer http.ResponseWriter, request *http.Request) {
-	writer.WriteHeader(http.StatusOK)
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	resourceID, err := ResourceIDFromContext(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	clusterResourceID := actionTargetID(resourceID)
+
+	actionName := request.PathValue(PathSegmentActionName)
+	action, ok := resourceActions[actionName]
+	if !ok {
+		arm.WriteError(
+			writer, http.StatusNotFound,
+			arm.CloudErrorCodeNotFound,
+			clusterResourceID.String(),
+			"The action '%s' is not supported", actionName)
+		return
+	}
+
+	cloudError := checkActionFeature(ctx, clusterResourceID.String(), actionName)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	resourceDoc, err := f.dbClient.GetResourceDoc(ctx, clusterResourceID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			arm.WriteResourceNotFoundError(writer, clusterResourceID)
+		} else {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+		}
+		return
+	}
+
+	// CheckForProvisioningStateConflict does not log conflict errors
+	// but does log unexpected errors like database failures.
+	cloudError = f.CheckForProvisioningStateConflict(ctx, action.operationRequest, resourceDoc)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	internalID, cloudError := action.start(f, ctx, resourceDoc)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	// Actions do not alter the resource itself, so the operation is not
+	// recorded as the resource's active operation and the provisioning
+	// state of the resource is left alone.
+	operationDoc := database.NewOperationDocument(action.operationRequest, resourceDoc.ResourceID, internalID)
+
+	operationID, err := f.dbClient.CreateOperationDoc(ctx, operationDoc)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	pk := database.NewPartitionKey(clusterResourceID.SubscriptionID)
+	err = f.ExposeOperation(writer, request, pk, operationID)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
 }
 
 func (f *Frontend) ArmSubscriptionGet(writer http.ResponseWriter, request *http.Request) {
@@ -805,9 +1049,12 @@ func (f *Frontend) ArmDeploymentPreflight(writer http.ResponseWriter, request *h
 
 	validate := api.NewValidator()
 	preflightErrors := []arm.CloudErrorBody{}
+	validatedResources := []validatedResource{}
 
 	for index, raw := range deploymentPreflight.Resources {
 		var cloudError *arm.CloudError
+		var cluster *api.HCPOpenShiftCluster
+		var nodePool *api.HCPOpenShiftClusterNodePool
 
 		preflightResource := &arm.DeploymentPreflightResource{}
 		err = json.Unmarshal(raw, preflightResource)
@@ -844,6 +1091,9 @@ func (f *Frontend) ArmDeploymentPreflight(writer http.ResponseWriter, request *h
 			// Perform static validation as if for a cluster creation request.
 			cloudError = versionedCluster.ValidateStatic(versionedCluster, false, http.MethodPut)
 
+			cluster = api.NewDefaultHCPOpenShiftCluster()
+			versionedCluster.Normalize(cluster)
+
 		case strings.ToLower(api.NodePoolResourceType.String()):
 			// This is just "preliminary" validation to ensure all the base resource
 			// fields are present and the API version is valid.
@@ -870,6 +1120,9 @@ func (f *Frontend) ArmDeploymentPreflight(writer http.ResponseWriter, request *h
 			// Perform static validation as if for a node pool creation request.
 			cloudError = versionedNodePool.ValidateStatic(versionedNodePool, false, http.MethodPut)
 
+			nodePool = api.NewDefaultHCPOpenShiftClusterNodePool()
+			versionedNodePool.Normalize(nodePool)
+
 		default:
 			// Disregard foreign resource types.
 			continue
@@ -922,9 +1175,23 @@ func (f *Frontend) ArmDeploymentPreflight(writer http.ResponseWriter, request *h
 			continue
 		}
 
-		// FIXME Further preflight steps go here.
+		resourceID, err := azcorearm.ParseResourceID(preflightResource.ResourceID(subscriptionID, resourceGroup))
+		if err != nil {
+			// Preflight is best-effort: failure to parse a resource ID is not a validation failure.
+			logger.Warn(fmt.Sprintf("Failed to parse resource ID for %s resource named '%s': %s", preflightResource.Type, preflightResource.Name, err))
+			continue
+		}
+
+		validatedResources = append(validatedResources, validatedResource{
+			resourceID: resourceID,
+			cluster:    cluster,
+			nodePool:   nodePool,
+		})
 	}
 
+	// Further checks consider the statically valid resources together.
+	preflightErrors = append(preflightErrors, f.deploymentPreflightChecks(ctx, subscriptionID, validatedResources)...)
+
 	arm.WriteDeploymentPreflightResponse(writer, preflightErrors)
 }
 
@@ -944,7 +1211,7 @@ func (f *Frontend) OperationStatus(writer http.ResponseWriter, request *http.Req
 	if err != nil {
 		logger.Error(err.Error())
 		if errors.Is(err, database.ErrNotFound) {
-			writer.WriteHeader(http.StatusNotFound)
+			arm.WriteCloudError(writer, newOperationNotFoundError(resourceID))
 		} else {
 			writer.WriteHeader(http.StatusInternalServerError)
 		}
@@ -954,7 +1221,79 @@ func (f *Frontend) OperationStatus(writer http.ResponseWriter, request *http.Req
 	// Validate the identity retrieving the operation result is the
 	// same identity that triggered the operation. Return 404 if not.
 	if !f.OperationIsVisible(request, resourceID.Name, doc) {
-		writer.WriteHeader(http.StatusNotFound)
+		arm.WriteCloudError(writer, newOperationNotFoundError(resourceID))
+		return
+	}
+
+	_, err = arm.WriteJSONResponse(writer, http.StatusOK, doc.ToStatus())
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+// OperationCancel implements the cancellation of an asynchronous operation.
+// The response body is the operation status after cancellation.
+func (f *Frontend) OperationCancel(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	resourceID, err := ResourceIDFromContext(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	operationResourceID := actionTargetID(resourceID)
+
+	cloudError := checkActionFeature(ctx, operationResourceID.String(), ActionCancelOperation)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	pk := database.NewPartitionKey(operationResourceID.SubscriptionID)
+	doc, err := f.dbClient.GetOperationDoc(ctx, pk, operationResourceID.Name)
+	if err != nil {
+		logger.Error(err.Error())
+		if errors.Is(err, database.ErrNotFound) {
+			arm.WriteCloudError(writer, newOperationNotFoundError(operationResourceID))
+		} else {
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Validate the identity canceling the operation is the
+	// same identity that triggered the operation. Return 404 if not.
+	if !f.OperationIsVisible(request, operationResourceID.Name, doc) {
+		arm.WriteCloudError(writer, newOperationNotFoundError(operationResourceID))
+		return
+	}
+
+	// Locking the operation's resource keeps the backend from updating
+	// the operation status at the same time. Read the operation again
+	// once locked in case its status changed while waiting for the lock.
+	if lockClient := f.dbClient.GetLockClient(); lockClient != nil {
+		lockedCtx, release, cloudError := acquireResourceLock(ctx, writer.Header(), lockClient, doc.ExternalID)
+		if cloudError != nil {
+			arm.WriteCloudError(writer, cloudError)
+			return
+		}
+		defer release()
+		ctx = lockedCtx
+
+		doc, err = f.dbClient.GetOperationDoc(ctx, pk, operationResourceID.Name)
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+	}
+
+	cloudError = f.CancelOperation(ctx, pk, operationResourceID.Name, doc)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
 		return
 	}
 
@@ -1040,7 +1379,7 @@ func (f *Frontend) OperationResult(writer http.ResponseWriter, request *http.Req
 	if err != nil {
 		logger.Error(err.Error())
 		if errors.Is(err, database.ErrNotFound) {
-			writer.WriteHeader(http.StatusNotFound)
+			arm.WriteCloudError(writer, newOperationNotFoundError(resourceID))
 		} else {
 			writer.WriteHeader(http.StatusInternalServerError)
 		}
@@ -1050,7 +1389,7 @@ func (f *Frontend) OperationResult(writer http.ResponseWriter, request *http.Req
 	// Validate the identity retrieving the operation result is the
 	// same identity that triggered the operation. Return 404 if not.
 	if !f.OperationIsVisible(request, resourceID.Name, doc) {
-		writer.WriteHeader(http.StatusNotFound)
+		arm.WriteCloudError(writer, newOperationNotFoundError(resourceID))
 		return
 	}
 
@@ -1075,18 +1414,42 @@ func (f *Frontend) OperationResult(writer http.ResponseWriter, request *http.Req
 		//     In the event of failure, it's unclear what to do here.
 		writer.WriteHeader(http.StatusNoContent)
 		return
+	case database.OperationRequestRequestCredential:
+		if doc.Status != arm.ProvisioningStateSucceeded {
+			arm.WriteCloudError(writer, newOperationCloudError(doc))
+			return
+		}
+		responseBody, cloudError := f.MarshalBreakGlassCredential(ctx, doc.InternalID, versionedInterface)
+		if cloudError != nil {
+			arm.WriteCloudError(writer, cloudError)
+			return
+		}
+		_, err = arm.WriteJSONResponse(writer, http.StatusOK, responseBody)
+		if err != nil {
+			logger.Error(err.Error())
+		}
+		return
+	case database.OperationRequestRevokeCredentials:
+		if doc.Status != arm.ProvisioningStateSucceeded {
+			arm.WriteCloudError(writer, newOperationCloudError(doc))
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+		return
 	default:
 		logger.Error(fmt.Sprintf("Unhandled request type: %s", doc.Request))
 		writer.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	responseBody, cloudError := f.MarshalResource(ctx, doc.ExternalID, versionedInterface)
+	responseBody, etag, cloudError := f.MarshalResource(ctx, doc.ExternalID, versionedInterface)
 	if cloudError != nil {
 		writer.WriteHeader(cloudError.StatusCode)
 		return
 	}
 
+	setETagHeader(writer.Header(), etag)
+
 	_, err = arm.WriteJSONResponse(writer, successStatusCode, responseBody)
 	if err != nil {
 		logger.Error(err.Error())
@@ -2,6 +2,7 @@ package frontend
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,8 @@ import (
 	"time"
 
 	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	dto "github.com/prometheus/client_model/go"
@@ -25,6 +28,8 @@ import (
 	"github.com/Azure/ARO-HCP/internal/api/arm"
 	"github.com/Azure/ARO-HCP/internal/database"
 	"github.com/Azure/ARO-HCP/internal/mocks"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+	"github.com/Azure/ARO-HCP/internal/ocm/ocmtest"
 )
 
 var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
@@ -262,7 +267,7 @@ func TestSubscriptionsPUT(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			// MiddlewareLockSubscription
+			// MiddlewareLockResource
 			// (except when MiddlewareValidateStatic fails)
 			mockDBClient.EXPECT().
 				GetLockClient().
@@ -392,7 +397,7 @@ func TestDeploymentPreflight(t *testing.T) {
 		{
 			name: "Well-formed node pool resource returns no error",
 			resource: map[string]any{
-				"name":       "my-node-pool",
+				"name":       "my-hcp-cluster/my-node-pool",
 				"type":       api.NodePoolResourceType.String(),
 				"location":   "eastus",
 				"apiVersion": "2024-06-10-preview",
@@ -447,6 +452,7 @@ func TestDeploymentPreflight(t *testing.T) {
 
 			ctrl := gomock.NewController(t)
 			mockDBClient := mocks.NewMockDBClient(ctrl)
+			mockCSClient := mocks.NewMockClusterServiceClientSpec(ctrl)
 			reg := prometheus.NewRegistry()
 
 			f := NewFrontend(
@@ -456,9 +462,46 @@ func TestDeploymentPreflight(t *testing.T) {
 				reg,
 				mockDBClient,
 				"",
-				nil,
+				mockCSClient,
 			)
 
+			// Deployment preflight checks against an existing cluster
+			// and a Cluster Service that offers everything requested.
+			clusterResourceID, err := azcorearm.ParseResourceID(fmt.Sprintf("/subscriptions/%s/resourceGroups/myRG/providers/%s/my-hcp-cluster", subscriptionID, api.ClusterResourceType))
+			require.NoError(t, err)
+			clusterDoc := database.NewResourceDocument(clusterResourceID)
+			clusterDoc.ProvisioningState = arm.ProvisioningStateSucceeded
+
+			mockIter := mocks.NewMockDBClientIterator[database.ResourceDocument](ctrl)
+			mockIter.EXPECT().
+				Items(gomock.Any()).
+				Return(database.DBClientIteratorItem[database.ResourceDocument](maps.All(map[string]*database.ResourceDocument{
+					"my-hcp-cluster": clusterDoc,
+				}))).
+				AnyTimes()
+			mockIter.EXPECT().
+				GetError().
+				Return(nil).
+				AnyTimes()
+			mockDBClient.EXPECT().
+				ListResourceDocs(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(mockIter).
+				AnyTimes()
+
+			csVersion, err := cmv1.NewVersion().Enabled(true).HostedControlPlaneEnabled(true).Build()
+			require.NoError(t, err)
+			mockCSClient.EXPECT().
+				GetVersion(gomock.Any(), gomock.Any()).
+				Return(csVersion, nil).
+				AnyTimes()
+
+			csMachineType, err := cmv1.NewMachineType().ID("Standard_D8s_v3").Build()
+			require.NoError(t, err)
+			mockCSClient.EXPECT().
+				GetMachineType(gomock.Any(), gomock.Any()).
+				Return(csMachineType, nil).
+				AnyTimes()
+
 			// MiddlewareValidateSubscriptionState and MetricsMiddleware
 			mockDBClient.EXPECT().
 				GetSubscriptionDoc(gomock.Any(), subscriptionID).
@@ -519,6 +562,137 @@ func TestDeploymentPreflight(t *testing.T) {
 	}
 }
 
+func TestArmResourceDeletePreconditions(t *testing.T) {
+	const etag = `"00000000-0000-0000-0000-000000000001"`
+
+	tests := []struct {
+		name               string
+		resourceID         string
+		exists             bool
+		ifMatch            string
+		ifNoneMatch        string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Cluster with stale If-Match",
+			resourceID:         dummyClusterID,
+			exists:             true,
+			ifMatch:            `"stale"`,
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "Cluster with weak If-Match",
+			resourceID:         dummyClusterID,
+			exists:             true,
+			ifMatch:            "W/" + etag,
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "Cluster with If-None-Match wildcard",
+			resourceID:         dummyClusterID,
+			exists:             true,
+			ifNoneMatch:        "*",
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "Missing cluster with If-Match wildcard",
+			resourceID:         dummyClusterID,
+			ifMatch:            "*",
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "Missing cluster without conditions",
+			resourceID:         dummyClusterID,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Node pool with stale If-Match",
+			resourceID:         dummyNodePoolID,
+			exists:             true,
+			ifMatch:            `"stale"`,
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "Node pool with If-None-Match wildcard",
+			resourceID:         dummyNodePoolID,
+			exists:             true,
+			ifNoneMatch:        "*",
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "Missing node pool with If-Match wildcard",
+			resourceID:         dummyNodePoolID,
+			ifMatch:            "*",
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resourceID, err := azcorearm.ParseResourceID(test.resourceID)
+			require.NoError(t, err)
+
+			var resourceDoc *database.ResourceDocument
+			if test.exists {
+				resourceDoc = database.NewResourceDocument(resourceID)
+				resourceDoc.ETag = etag
+				resourceDoc.ProvisioningState = arm.ProvisioningStateSucceeded
+			}
+
+			subDoc := &arm.Subscription{
+				State:            arm.SubscriptionStateRegistered,
+				RegistrationDate: api.Ptr(time.Now().String()),
+				Properties:       nil,
+			}
+
+			ctrl := gomock.NewController(t)
+			mockDBClient := mocks.NewMockDBClient(ctrl)
+			reg := prometheus.NewRegistry()
+
+			f := NewFrontend(
+				testLogger,
+				nil,
+				nil,
+				reg,
+				mockDBClient,
+				"",
+				nil,
+			)
+
+			subs := map[string]*arm.Subscription{subscriptionID: subDoc}
+			ts := newHTTPServer(f, ctrl, mockDBClient, subs)
+
+			// MiddlewareLockResource
+			mockDBClient.EXPECT().
+				GetLockClient()
+			// MiddlewareValidateSubscriptionState
+			mockDBClient.EXPECT().
+				GetSubscriptionDoc(gomock.Any(), subscriptionID).
+				Return(subDoc, nil)
+			// ArmResourceDelete
+			mockDBClient.EXPECT().
+				GetResourceDoc(gomock.Any(), equalResourceID(resourceID)).
+				Return(getMockDBDoc(resourceDoc))
+
+			// No resource is deleted, so nothing else is expected.
+
+			req, err := http.NewRequest(http.MethodDelete, ts.URL+test.resourceID+"?api-version=2024-06-10-preview", nil)
+			require.NoError(t, err)
+			if test.ifMatch != "" {
+				req.Header.Set("If-Match", test.ifMatch)
+			}
+			if test.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", test.ifNoneMatch)
+			}
+
+			rs, err := ts.Client().Do(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedStatusCode, rs.StatusCode)
+		})
+	}
+}
+
 func lintMetrics(t *testing.T, r prometheus.Gatherer) {
 	t.Helper()
 
@@ -614,3 +788,62 @@ func newHTTPServer(f *Frontend, ctrl *gomock.Controller, mockDBClient *mocks.Moc
 
 	return ts
 }
+
+// newTestFrontend returns a Frontend backed by an in-memory database and
+// an in-process Cluster Service. Cluster Service has a cluster named
+// testCluster with a 3-replica node pool named testNodePool, and the
+// cluster's document is returned alongside the Frontend.
+func newTestFrontend(t *testing.T) (*Frontend, *database.ResourceDocument) {
+	t.Helper()
+
+	ctx := ContextWithLogger(context.Background(), testLogger)
+
+	server := ocmtest.NewServer(nil, ocmtest.Timing{})
+	t.Cleanup(server.Close)
+
+	conn, err := server.NewConnection(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	csClient := &ocm.ClusterServiceClient{Conn: conn}
+
+	csCluster, err := arohcpv1alpha1.NewCluster().Name("testCluster").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	csCluster, err = csClient.PostCluster(ctx, csCluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusterDoc := database.NewResourceDocument(mustParseResourceID(t, testGroupPrefix+"testCluster"))
+	clusterDoc.InternalID, err = ocm.NewInternalID(csCluster.HREF())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csNodePool, err := cmv1.NewNodePool().ID("testNodePool").Replicas(3).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = csClient.PostNodePool(ctx, clusterDoc.InternalID, csNodePool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dbClient := database.NewInMemoryDBClient()
+	err = dbClient.CreateResourceDoc(ctx, clusterDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &Frontend{
+		clusterServiceClient: csClient,
+		dbClient:             dbClient,
+		quota:                DefaultResourceQuota,
+	}
+
+	return f, clusterDoc
+}
@@ -7,9 +7,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
@@ -45,6 +48,14 @@ func (f *Frontend) CheckForProvisioningStateConflict(ctx context.Context, operat
 				"Cannot update resource while resource is %s",
 				strings.ToLower(string(doc.ProvisioningState)))
 		}
+	case database.OperationRequestRequestCredential, database.OperationRequestRevokeCredentials:
+		if doc.ProvisioningState == arm.ProvisioningStateDeleting {
+			return arm.NewCloudError(
+				http.StatusConflict,
+				arm.CloudErrorCodeConflict,
+				doc.ResourceID.String(),
+				"Cannot manage credentials while resource is deleting")
+		}
 	}
 
 	parent := doc.ResourceID.Parent
@@ -214,7 +225,56 @@ func (f *Frontend) DeleteResource(ctx context.Context, resourceDoc *database.Res
 	return operationID, nil
 }
 
-func (f *Frontend) MarshalResource(ctx context.Context, resourceID *azcorearm.ResourceID, versionedInterface api.Version) ([]byte, *arm.CloudError) {
+// UpdateResourceTags records new tags for a resource, which live only in
+// its resource document, and returns the updated document. A nil tags map
+// leaves any existing tags alone, and the document, including its entity
+// tag, is not written at all if the tags do not change. Because Cluster
+// Service is not involved, tags can be updated synchronously without an
+// asynchronous operation.
+func (f *Frontend) UpdateResourceTags(ctx context.Context, resourceID *azcorearm.ResourceID, tags map[string]string, systemData *arm.SystemData) (*database.ResourceDocument, *arm.CloudError) {
+	logger := LoggerFromContext(ctx)
+
+	updated, err := f.dbClient.UpdateResourceDoc(ctx, resourceID, func(updateDoc *database.ResourceDocument) bool {
+		if tags == nil || maps.Equal(updateDoc.Tags, tags) {
+			return false
+		}
+
+		updateDoc.Tags = tags
+
+		// Record the latest system data values from ARM, if present.
+		if systemData != nil {
+			updateDoc.SystemData = systemData
+		}
+
+		updateDoc.ETag = database.NewResourceETag()
+
+		return true
+	})
+	if err != nil {
+		logger.Error(err.Error())
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, arm.NewResourceNotFoundError(resourceID)
+		}
+		return nil, arm.NewInternalServerError()
+	}
+	if updated {
+		logger.Info(fmt.Sprintf("tags updated for %s", resourceID))
+	}
+
+	// Get the updated resource document for the response.
+	doc, err := f.dbClient.GetResourceDoc(ctx, resourceID)
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, arm.NewInternalServerError()
+	}
+
+	return doc, nil
+}
+
+// MarshalResource returns the response body for a cluster or node pool along
+// with its entity tag, which is empty for resources created before entity tags
+// were recorded.
+func (f *Frontend) MarshalResource(ctx context.Context, resourceID *azcorearm.ResourceID, versionedInterface api.Version) ([]byte, azcore.ETag, *arm.CloudError) {
 	var responseBody []byte
 
 	logger := LoggerFromContext(ctx)
@@ -223,9 +283,9 @@ func (f *Frontend) MarshalResource(ctx context.Context, resourceID *azcorearm.Re
 	if err != nil {
 		logger.Error(err.Error())
 		if errors.Is(err, database.ErrNotFound) {
-			return nil, arm.NewResourceNotFoundError(resourceID)
+			return nil, "", arm.NewResourceNotFoundError(resourceID)
 		} else {
-			return nil, arm.NewInternalServerError()
+			return nil, "", arm.NewInternalServerError()
 		}
 	}
 
@@ -236,14 +296,14 @@ func (f *Frontend) MarshalResource(ctx context.Context, resourceID *azcorearm.Re
 			logger.Error(err.Error())
 			var ocmError *ocmerrors.Error
 			if errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound {
-				return nil, arm.NewResourceNotFoundError(resourceID)
+				return nil, "", arm.NewResourceNotFoundError(resourceID)
 			}
-			return nil, arm.NewInternalServerError()
+			return nil, "", arm.NewInternalServerError()
 		}
 		responseBody, err = marshalCSCluster(csCluster, doc, versionedInterface)
 		if err != nil {
 			logger.Error(err.Error())
-			return nil, arm.NewInternalServerError()
+			return nil, "", arm.NewInternalServerError()
 		}
 
 	case cmv1.NodePoolKind:
@@ -252,20 +312,137 @@ func (f *Frontend) MarshalResource(ctx context.Context, resourceID *azcorearm.Re
 			logger.Error(err.Error())
 			var ocmError *ocmerrors.Error
 			if errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound {
-				return nil, arm.NewResourceNotFoundError(resourceID)
+				return nil, "", arm.NewResourceNotFoundError(resourceID)
 			}
-			return nil, arm.NewInternalServerError()
+			return nil, "", arm.NewInternalServerError()
 		}
 		responseBody, err = marshalCSNodePool(csNodePool, doc, versionedInterface)
 		if err != nil {
 			logger.Error(err.Error())
-			return nil, arm.NewInternalServerError()
+			return nil, "", arm.NewInternalServerError()
 		}
 
 	default:
 		logger.Error(fmt.Sprintf("unsupported Cluster Service path: %s", doc.InternalID))
-		return nil, arm.NewInternalServerError()
+		return nil, "", arm.NewInternalServerError()
+	}
+
+	return responseBody, doc.ETag, nil
+}
+
+// validateVersionUpgrade returns a "400 Bad Request" error response if the
+// requested version is not an available upgrade from the current version of
+// a cluster or node pool. Cluster Service version IDs carry a prefix that the
+// available upgrades lack, so the requested version ID may be given either way.
+func validateVersionUpgrade(current, requested api.VersionProfile) *arm.CloudError {
+	if requested.ChannelGroup != current.ChannelGroup {
+		return arm.NewCloudError(
+			http.StatusBadRequest,
+			arm.CloudErrorCodeInvalidRequestContent,
+			"properties.version.channelGroup",
+			"Cannot change channel group from '%s' to '%s' during an upgrade",
+			current.ChannelGroup, requested.ChannelGroup)
+	}
+
+	version := strings.TrimPrefix(requested.ID, csVersionPrefix)
+	if !slices.Contains(current.AvailableUpgrades, version) {
+		return arm.NewCloudError(
+			http.StatusBadRequest,
+			arm.CloudErrorCodeInvalidRequestContent,
+			"properties.version.id",
+			"Cannot upgrade from version '%s' to '%s'. Available upgrades: [%s]",
+			current.ID, requested.ID, strings.Join(current.AvailableUpgrades, ", "))
+	}
+
+	return nil
+}
+
+// validateNodePoolSubnet returns a "400 Bad Request" error response if a
+// node pool specifies a subnet outside of its cluster's virtual network.
+func validateNodePoolSubnet(clusterSubnetID string, nodePool *api.HCPOpenShiftClusterNodePool) *arm.CloudError {
+	errorDetails := api.ValidateNodePoolSubnet(clusterSubnetID, nodePool.Properties.Platform.SubnetID)
+	if len(errorDetails) == 0 {
+		return nil
 	}
 
-	return responseBody, nil
+	// There is only ever one problem with a subnet.
+	return &arm.CloudError{
+		StatusCode:     http.StatusBadRequest,
+		CloudErrorBody: &errorDetails[0],
+	}
+}
+
+// checkPreconditions returns a "412 Precondition Failed" error response if
+// the If-Match or If-None-Match request header does not hold for a resource.
+// The document is nil if the resource does not exist.
+func checkPreconditions(header http.Header, resourceID *azcorearm.ResourceID, doc *database.ResourceDocument) *arm.CloudError {
+	var etag azcore.ETag
+	if doc != nil {
+		etag = doc.ETag
+	}
+
+	if ifMatch := header.Values("If-Match"); len(ifMatch) > 0 && !matchETag(ifMatch, doc != nil, etag, false) {
+		return arm.NewCloudError(
+			http.StatusPreconditionFailed,
+			arm.CloudErrorCodePreconditionFailed,
+			resourceID.String(),
+			"Resource '%s' does not match the If-Match header",
+			resourceID.Name)
+	}
+
+	if ifNoneMatch := header.Values("If-None-Match"); len(ifNoneMatch) > 0 && matchETag(ifNoneMatch, doc != nil, etag, true) {
+		return arm.NewCloudError(
+			http.StatusPreconditionFailed,
+			arm.CloudErrorCodePreconditionFailed,
+			resourceID.String(),
+			"Resource '%s' matches the If-None-Match header",
+			resourceID.Name)
+	}
+
+	return nil
+}
+
+// matchETag returns true if any of the comma-separated entity tags in the
+// header values matches a resource. The wildcard "*" matches any existing
+// resource. RFC 7232 requires a strong comparison for If-Match, where weak
+// entity tags never match, and a weak comparison for If-None-Match.
+func matchETag(values []string, exists bool, etag azcore.ETag, weak bool) bool {
+	if !exists {
+		return false
+	}
+
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag := azcore.ETag(strings.TrimSpace(tag))
+			if tag == azcore.ETagAny {
+				return true
+			}
+			if etag == "" {
+				continue
+			}
+			if (weak && tag.WeakEquals(etag)) || (!weak && tag.Equals(etag)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// setETagHeader sets the ETag response header for a resource that has one.
+func setETagHeader(header http.Header, etag azcore.ETag) {
+	if etag != "" {
+		header.Set("ETag", string(etag))
+	}
+}
+
+// actionTargetID returns the ID of the resource targeted by a request. For
+// action requests, the action name parses as a nested resource type with no
+// name, so the target is the parent of the parsed resource ID. Any other
+// resource ID is returned unchanged.
+func actionTargetID(resourceID *azcorearm.ResourceID) *azcorearm.ResourceID {
+	if resourceID.Name == "" && resourceID.Parent != nil {
+		return resourceID.Parent
+	}
+	return resourceID
 }
@@ -12,6 +12,7 @@ import (
 	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"go.uber.org/mock/gomock"
 
+	"github.com/Azure/ARO-HCP/internal/api"
 	"github.com/Azure/ARO-HCP/internal/api/arm"
 	"github.com/Azure/ARO-HCP/internal/database"
 	"github.com/Azure/ARO-HCP/internal/mocks"
@@ -70,6 +71,34 @@ func TestCheckForProvisioningStateConflict(t *testing.T) {
 				arm.ProvisioningStateUpdating:     true,
 			},
 		},
+		{
+			name:             "Request cluster credential",
+			resourceID:       clusterResourceID,
+			operationRequest: database.OperationRequestRequestCredential,
+			directConflicts: map[arm.ProvisioningState]bool{
+				arm.ProvisioningStateSucceeded:    false,
+				arm.ProvisioningStateFailed:       false,
+				arm.ProvisioningStateCanceled:     false,
+				arm.ProvisioningStateAccepted:     false,
+				arm.ProvisioningStateDeleting:     true,
+				arm.ProvisioningStateProvisioning: false,
+				arm.ProvisioningStateUpdating:     false,
+			},
+		},
+		{
+			name:             "Revoke cluster credentials",
+			resourceID:       clusterResourceID,
+			operationRequest: database.OperationRequestRevokeCredentials,
+			directConflicts: map[arm.ProvisioningState]bool{
+				arm.ProvisioningStateSucceeded:    false,
+				arm.ProvisioningStateFailed:       false,
+				arm.ProvisioningStateCanceled:     false,
+				arm.ProvisioningStateAccepted:     false,
+				arm.ProvisioningStateDeleting:     true,
+				arm.ProvisioningStateProvisioning: false,
+				arm.ProvisioningStateUpdating:     false,
+			},
+		},
 		{
 			name:             "Create node pool",
 			resourceID:       nodePoolResourceID,
@@ -231,3 +260,167 @@ func TestCheckForProvisioningStateConflict(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateVersionUpgrade(t *testing.T) {
+	current := api.VersionProfile{
+		ID:                "openshift-v4.16.0",
+		ChannelGroup:      "stable",
+		AvailableUpgrades: []string{"4.16.1", "4.16.2"},
+	}
+
+	tests := []struct {
+		name         string
+		versionID    string
+		channelGroup string
+		expectTarget string
+	}{
+		{
+			name:         "Available upgrade",
+			versionID:    "openshift-v4.16.2",
+			channelGroup: "stable",
+		},
+		{
+			name:         "Available upgrade without prefix",
+			versionID:    "4.16.1",
+			channelGroup: "stable",
+		},
+		{
+			name:         "Unavailable upgrade",
+			versionID:    "openshift-v4.17.0",
+			channelGroup: "stable",
+			expectTarget: "properties.version.id",
+		},
+		{
+			name:         "Downgrade",
+			versionID:    "openshift-v4.15.0",
+			channelGroup: "stable",
+			expectTarget: "properties.version.id",
+		},
+		{
+			name:         "Channel group change",
+			versionID:    "openshift-v4.16.1",
+			channelGroup: "candidate",
+			expectTarget: "properties.version.channelGroup",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested := current
+			requested.ID = tt.versionID
+			requested.ChannelGroup = tt.channelGroup
+
+			cloudError := validateVersionUpgrade(current, requested)
+
+			if cloudError == nil {
+				if tt.expectTarget != "" {
+					t.Errorf("Expected %d %s but got no error", http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+				}
+			} else {
+				if tt.expectTarget == "" || cloudError.StatusCode != http.StatusBadRequest {
+					t.Errorf("Got unexpected error: %d %s", cloudError.StatusCode, cloudError.Error())
+				} else if cloudError.Target != tt.expectTarget {
+					t.Errorf("Expected error target '%s' but got '%s'", tt.expectTarget, cloudError.Target)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	const etag = `"00000000-0000-0000-0000-000000000001"`
+
+	resourceID, err := azcorearm.ParseResourceID("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/testCluster")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		exists       bool
+		ifMatch      string
+		ifNoneMatch  string
+		expectFailed bool
+	}{
+		{
+			name:   "No conditions",
+			exists: true,
+		},
+		{
+			name:    "If-Match with current etag",
+			exists:  true,
+			ifMatch: etag,
+		},
+		{
+			name:         "If-Match with weak current etag",
+			exists:       true,
+			ifMatch:      "W/" + etag,
+			expectFailed: true,
+		},
+		{
+			name:    "If-Match with current etag in list",
+			exists:  true,
+			ifMatch: `"stale", ` + etag,
+		},
+		{
+			name:         "If-Match with stale etag",
+			exists:       true,
+			ifMatch:      `"stale"`,
+			expectFailed: true,
+		},
+		{
+			name:    "If-Match wildcard with existing resource",
+			exists:  true,
+			ifMatch: "*",
+		},
+		{
+			name:         "If-Match wildcard with missing resource",
+			ifMatch:      "*",
+			expectFailed: true,
+		},
+		{
+			name:        "If-None-Match wildcard with missing resource",
+			ifNoneMatch: "*",
+		},
+		{
+			name:         "If-None-Match with weak current etag",
+			exists:       true,
+			ifNoneMatch:  "W/" + etag,
+			expectFailed: true,
+		},
+		{
+			name:         "If-None-Match wildcard with existing resource",
+			exists:       true,
+			ifNoneMatch:  "*",
+			expectFailed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc *database.ResourceDocument
+			if tt.exists {
+				doc = database.NewResourceDocument(resourceID)
+				doc.ETag = etag
+			}
+
+			header := http.Header{}
+			if tt.ifMatch != "" {
+				header.Set("If-Match", tt.ifMatch)
+			}
+			if tt.ifNoneMatch != "" {
+				header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			cloudError := checkPreconditions(header, resourceID, doc)
+
+			if cloudError == nil {
+				if tt.expectFailed {
+					t.Errorf("Expected %d %s but got no error", http.StatusPreconditionFailed, http.StatusText(http.StatusPreconditionFailed))
+				}
+			} else if !tt.expectFailed || cloudError.StatusCode != http.StatusPreconditionFailed {
+				t.Errorf("Got unexpected error: %d %s", cloudError.StatusCode, cloudError.Error())
+			}
+		})
+	}
+}
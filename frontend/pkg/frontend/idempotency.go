@@ -0,0 +1,132 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+// requestBodyHash returns a hex-encoded SHA-256 hash of the request body,
+// which is empty for requests without a body.
+func requestBodyHash(ctx context.Context) string {
+	body, _ := BodyFromContext(ctx)
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// operationRequestsForMethod returns the operation requests that a
+// request with the given method starts.
+func operationRequestsForMethod(method string) []database.OperationRequest {
+	switch method {
+	case http.MethodPut:
+		return []database.OperationRequest{database.OperationRequestCreate, database.OperationRequestUpdate}
+	case http.MethodPatch:
+		return []database.OperationRequest{database.OperationRequestUpdate}
+	case http.MethodDelete:
+		return []database.OperationRequest{database.OperationRequestDelete}
+	}
+	return nil
+}
+
+// getRetriedOperation checks whether a request is an ARM retry of a request
+// that started an operation on the resource, going by the client request ID.
+// If so, it returns that operation so the original response can be replayed,
+// unless the retry differs from the original request, in which case it returns
+// a "409 Conflict" error response. It returns nil for both if the request is
+// not a retry.
+//
+// ARM retries requests with the same client request ID, so handlers that
+// start an operation call this first and replay the original response
+// rather than treat a retry as a new request. The original operation may
+// have finished by the time a retry arrives, so it is looked up among all
+// of the resource's operations still within retention rather than only
+// the active one.
+func (f *Frontend) getRetriedOperation(ctx context.Context, request *http.Request, doc *database.ResourceDocument) (*database.OperationDocument, *arm.CloudError) {
+	logger := LoggerFromContext(ctx)
+
+	clientRequestID := request.Header.Get(arm.HeaderNameClientRequestID)
+	if clientRequestID == "" || doc == nil {
+		return nil, nil
+	}
+
+	// Most recently started first, so the first match is the latest.
+	filter := database.OperationDocumentFilter{ClientRequestID: clientRequestID}
+	iterator := f.dbClient.ListResourceOperationDocs(doc.ResourceID, filter, 1, nil)
+
+	var operationDoc *database.OperationDocument
+	for _, item := range iterator.Items(ctx) {
+		operationDoc = item
+		break
+	}
+
+	err := iterator.GetError()
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, arm.NewInternalServerError()
+	}
+
+	if operationDoc == nil {
+		return nil, nil
+	}
+
+	for _, operationRequest := range operationRequestsForMethod(request.Method) {
+		if operationDoc.Request == operationRequest && operationDoc.RequestBodyHash == requestBodyHash(ctx) {
+			logger.Info("Replaying response to retried request")
+			return operationDoc, nil
+		}
+	}
+
+	return nil, arm.NewCloudError(
+		http.StatusConflict,
+		arm.CloudErrorCodeConflict,
+		doc.ResourceID.String(),
+		"Client request ID '%s' was already used by a different %s request for this resource",
+		clientRequestID, strings.ToLower(string(operationDoc.Request)))
+}
+
+// replayOperationResponse writes the response to the request that started an
+// operation, with the current resource in the response body for PUT and PATCH
+// requests.
+func (f *Frontend) replayOperationResponse(writer http.ResponseWriter, request *http.Request, operationDoc *database.OperationDocument, versionedInterface api.Version) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	var successStatusCode int
+
+	switch {
+	case request.Method == http.MethodPut && operationDoc.Request == database.OperationRequestCreate:
+		successStatusCode = http.StatusCreated
+	case request.Method == http.MethodPut:
+		successStatusCode = http.StatusOK
+	default:
+		successStatusCode = http.StatusAccepted
+	}
+
+	if request.Method == http.MethodDelete {
+		f.addOperationHeaders(writer, request, operationDoc)
+		writer.WriteHeader(successStatusCode)
+		return
+	}
+
+	responseBody, etag, cloudError := f.MarshalResource(ctx, operationDoc.ExternalID, versionedInterface)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	f.addOperationHeaders(writer, request, operationDoc)
+	setETagHeader(writer.Header(), etag)
+	_, err := arm.WriteJSONResponse(writer, successStatusCode, responseBody)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
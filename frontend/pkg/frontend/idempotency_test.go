@@ -0,0 +1,244 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+const (
+	testClientRequestID = "11111111-1111-1111-1111-111111111111"
+	testRequestBody     = `{"location":"eastus"}`
+)
+
+// newRetryTestFrontend returns a Frontend with a cluster whose active
+// operation was started by a create request with testClientRequestID
+// and testRequestBody.
+func newRetryTestFrontend(t *testing.T) (*Frontend, *database.ResourceDocument) {
+	t.Helper()
+
+	f, clusterDoc := newTestFrontend(t)
+	ctx := ContextWithLogger(context.Background(), testLogger)
+
+	operationDoc := database.NewOperationDocument(database.OperationRequestCreate, clusterDoc.ResourceID, clusterDoc.InternalID)
+	operationDoc.OperationID = mustParseResourceID(t, "/subscriptions/00000000-0000-0000-0000-000000000000/providers/Microsoft.RedHatOpenShift/locations/eastus/hcpOperationsStatus/testOperation")
+	operationDoc.ClientRequestID = testClientRequestID
+	operationDoc.RequestBodyHash = requestBodyHash(ContextWithBody(ctx, []byte(testRequestBody)))
+
+	operationID, err := f.dbClient.CreateOperationDoc(ctx, operationDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = f.dbClient.UpdateResourceDoc(ctx, clusterDoc.ResourceID, func(doc *database.ResourceDocument) bool {
+		doc.ActiveOperationID = operationID
+		doc.ProvisioningState = arm.ProvisioningStateAccepted
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusterDoc, err = f.dbClient.GetResourceDoc(ctx, clusterDoc.ResourceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f, clusterDoc
+}
+
+func newRetryTestRequest(t *testing.T, method, clientRequestID, body string) *http.Request {
+	t.Helper()
+
+	ctx := ContextWithLogger(context.Background(), testLogger)
+	if method != http.MethodDelete {
+		ctx = ContextWithBody(ctx, []byte(body))
+	}
+
+	request := httptest.NewRequestWithContext(ctx, method, testGroupPrefix+"testCluster?api-version=2024-06-10-preview", nil)
+	request.Header.Set("Referer", "https://management.azure.com"+testGroupPrefix+"testCluster")
+	if clientRequestID != "" {
+		request.Header.Set(arm.HeaderNameClientRequestID, clientRequestID)
+	}
+
+	return request
+}
+
+func TestGetRetriedOperation(t *testing.T) {
+	tests := []struct {
+		name            string
+		method          string
+		clientRequestID string
+		body            string
+		expectRetry     bool
+		expectConflict  bool
+	}{
+		{
+			name:   "No client request ID",
+			method: http.MethodPut,
+			body:   testRequestBody,
+		},
+		{
+			name:            "Different client request ID",
+			method:          http.MethodPut,
+			clientRequestID: "22222222-2222-2222-2222-222222222222",
+			body:            testRequestBody,
+		},
+		{
+			name:            "Retried request",
+			method:          http.MethodPut,
+			clientRequestID: testClientRequestID,
+			body:            testRequestBody,
+			expectRetry:     true,
+		},
+		{
+			name:            "Retried request with different body",
+			method:          http.MethodPut,
+			clientRequestID: testClientRequestID,
+			body:            `{"location":"westus"}`,
+			expectConflict:  true,
+		},
+		{
+			name:            "Retried request with different method",
+			method:          http.MethodDelete,
+			clientRequestID: testClientRequestID,
+			expectConflict:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, clusterDoc := newRetryTestFrontend(t)
+			request := newRetryTestRequest(t, tt.method, tt.clientRequestID, tt.body)
+
+			operationDoc, cloudError := f.getRetriedOperation(request.Context(), request, clusterDoc)
+
+			if tt.expectConflict {
+				if cloudError == nil || cloudError.StatusCode != http.StatusConflict {
+					t.Errorf("Expected a conflict error but got %v", cloudError)
+				}
+				return
+			}
+			if cloudError != nil {
+				t.Fatalf("Unexpected error: %v", cloudError)
+			}
+			if tt.expectRetry != (operationDoc != nil) {
+				t.Errorf("Expected retry to be %t but got operation %v", tt.expectRetry, operationDoc)
+			}
+		})
+	}
+}
+
+func TestGetRetriedOperationAfterOperationSucceeded(t *testing.T) {
+	ctx := ContextWithLogger(context.Background(), testLogger)
+	f, clusterDoc := newRetryTestFrontend(t)
+
+	// The backend ends the operation before ARM retries the request.
+	operationID := clusterDoc.ActiveOperationID
+	_, err := f.dbClient.UpdateOperationDoc(ctx, database.NewPartitionKey(clusterDoc.ResourceID.SubscriptionID), operationID, func(updateDoc *database.OperationDocument) bool {
+		return updateDoc.UpdateStatus(arm.ProvisioningStateSucceeded, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.dbClient.UpdateResourceDoc(ctx, clusterDoc.ResourceID, func(doc *database.ResourceDocument) bool {
+		doc.ActiveOperationID = ""
+		doc.ProvisioningState = arm.ProvisioningStateSucceeded
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clusterDoc, err = f.dbClient.GetResourceDoc(ctx, clusterDoc.ResourceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := newRetryTestRequest(t, http.MethodPut, testClientRequestID, testRequestBody)
+
+	operationDoc, cloudError := f.getRetriedOperation(request.Context(), request, clusterDoc)
+	if cloudError != nil {
+		t.Fatalf("Unexpected error: %v", cloudError)
+	}
+	if operationDoc == nil {
+		t.Fatal("Expected the succeeded operation to be replayed")
+	}
+	if operationDoc.Status != arm.ProvisioningStateSucceeded {
+		t.Errorf("Expected operation status %s but got %s", arm.ProvisioningStateSucceeded, operationDoc.Status)
+	}
+}
+
+func TestGetRetriedOperationWithoutOperation(t *testing.T) {
+	f, clusterDoc := newTestFrontend(t)
+	request := newRetryTestRequest(t, http.MethodPut, testClientRequestID, testRequestBody)
+
+	operationDoc, cloudError := f.getRetriedOperation(request.Context(), request, clusterDoc)
+	if operationDoc != nil || cloudError != nil {
+		t.Errorf("Expected no retry but got operation %v and error %v", operationDoc, cloudError)
+	}
+}
+
+func TestReplayOperationResponse(t *testing.T) {
+	tests := []struct {
+		name               string
+		method             string
+		expectStatusCode   int
+		expectLocation     bool
+		expectResponseBody bool
+	}{
+		{
+			name:               "Create",
+			method:             http.MethodPut,
+			expectStatusCode:   http.StatusCreated,
+			expectResponseBody: true,
+		},
+		{
+			name:             "Delete",
+			method:           http.MethodDelete,
+			expectStatusCode: http.StatusAccepted,
+			expectLocation:   true,
+		},
+	}
+
+	versionedInterface, ok := api.Lookup("2024-06-10-preview")
+	if !ok {
+		t.Fatal("API version not found")
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, clusterDoc := newRetryTestFrontend(t)
+			request := newRetryTestRequest(t, tt.method, testClientRequestID, testRequestBody)
+
+			pk := database.NewPartitionKey(clusterDoc.ResourceID.SubscriptionID)
+			operationDoc, err := f.dbClient.GetOperationDoc(request.Context(), pk, clusterDoc.ActiveOperationID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			writer := httptest.NewRecorder()
+			f.replayOperationResponse(writer, request, operationDoc, versionedInterface)
+
+			if writer.Code != tt.expectStatusCode {
+				t.Errorf("Expected status code %d but got %d: %s", tt.expectStatusCode, writer.Code, writer.Body.String())
+			}
+			if writer.Header().Get(arm.HeaderNameAsyncOperation) == "" {
+				t.Errorf("Expected a %s header", arm.HeaderNameAsyncOperation)
+			}
+			if tt.expectLocation != (writer.Header().Get("Location") != "") {
+				t.Errorf("Expected Location header to be present: %t", tt.expectLocation)
+			}
+			if tt.expectResponseBody != (writer.Body.Len() > 0) {
+				t.Errorf("Expected response body to be present: %t", tt.expectResponseBody)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package frontend
 import (
 	"container/list"
 	"net/http"
+	"slices"
 )
 
 // MiddlewareFunc specifies the call signature for middleware functions.
@@ -68,6 +69,7 @@ func (m *Middleware) HandlerFunc(handler func(http.ResponseWriter, *http.Request
 type MiddlewareMux struct {
 	http.ServeMux
 	middleware Middleware
+	patterns   []string
 }
 
 // NewMiddlewareMux allocates and returns a new MiddlewareMux.
@@ -77,6 +79,26 @@ func NewMiddlewareMux(functions ...MiddlewareFunc) *MiddlewareMux {
 	return mux
 }
 
+// Handle registers the handler for the given pattern
+// and records the pattern for later inspection.
+func (mux *MiddlewareMux) Handle(pattern string, handler http.Handler) {
+	mux.ServeMux.Handle(pattern, handler)
+	mux.patterns = append(mux.patterns, pattern)
+}
+
+// HandleFunc registers the handler function for the given
+// pattern and records the pattern for later inspection.
+func (mux *MiddlewareMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	mux.ServeMux.HandleFunc(pattern, handler)
+	mux.patterns = append(mux.patterns, pattern)
+}
+
+// Patterns returns the patterns registered with the
+// MiddlewareMux, in the order they were registered.
+func (mux *MiddlewareMux) Patterns() []string {
+	return slices.Clone(mux.patterns)
+}
+
 // ServeHTTP dispatches the request to each middleware function, and then to
 // the handler whose pattern most closely matches the request URL.
 func (mux *MiddlewareMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,88 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+// adminClientSubject returns the subject common name of the client
+// certificate that the TLS handshake verified, or an empty string if
+// the request was not made with a verified client certificate.
+func adminClientSubject(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName
+}
+
+// MiddlewareAdminAudit records an audit log entry for every admin API request,
+// whether or not it is authorized, identifying the client by its certificate.
+// It should precede the authorization middleware so denied requests are also
+// recorded.
+func MiddlewareAdminAudit(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx := r.Context()
+	logger := LoggerFromContext(ctx)
+
+	// MiddlewareLowercase has not run yet, so this is the original path.
+	path := r.URL.Path
+
+	lw := &LoggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+	startTime := time.Now()
+
+	defer func() {
+		logger.Info("admin audit",
+			"admin_client_subject", adminClientSubject(r),
+			"request_method", r.Method,
+			"request_path", path,
+			"request_query", r.URL.RawQuery,
+			"request_remote_addr", r.RemoteAddr,
+			"response_status_code", lw.statusCode,
+			"duration", time.Since(startTime).Seconds())
+	}()
+
+	next(lw, r)
+}
+
+// newMiddlewareAdminAuthorization returns a middleware function that only
+// admits admin API requests made with a verified client certificate whose
+// subject common name is one of allowedSubjects. If allowedSubjects is empty,
+// any client certificate issued by a trusted certificate authority is admitted.
+//
+// The TLS configuration should already require and verify client certificates.
+// This is a second line of defense and the point where subjects are checked.
+func newMiddlewareAdminAuthorization(allowedSubjects []string) MiddlewareFunc {
+	allowed := make(map[string]bool, len(allowedSubjects))
+	for _, subject := range allowedSubjects {
+		allowed[strings.ToLower(subject)] = true
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		logger := LoggerFromContext(r.Context())
+
+		subject := adminClientSubject(r)
+		if subject == "" {
+			logger.Warn("Rejected admin request without a verified client certificate")
+			arm.WriteError(w, http.StatusForbidden,
+				arm.CloudErrorCodeForbidden, "",
+				"A verified client certificate is required.")
+			return
+		}
+
+		if len(allowed) > 0 && !allowed[strings.ToLower(subject)] {
+			logger.Warn("Rejected admin request from unauthorized client certificate subject '" + subject + "'")
+			arm.WriteError(w, http.StatusForbidden,
+				arm.CloudErrorCodeForbidden, "",
+				"The client certificate subject '%s' is not authorized.", subject)
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,125 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// withClientSubject makes a request appear to have been made with a
+// verified client certificate with the given subject common name.
+func withClientSubject(request *http.Request, subject string) *http.Request {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: subject}}
+	request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	return request
+}
+
+func TestMiddlewareAdminAuthorization(t *testing.T) {
+	tests := []struct {
+		name            string
+		allowedSubjects []string
+		subject         string
+		expectStatus    int
+	}{
+		{
+			name:            "No client certificate",
+			allowedSubjects: []string{"test-sre"},
+			expectStatus:    http.StatusForbidden,
+		},
+		{
+			name:            "Allowed subject",
+			allowedSubjects: []string{"other-sre", "test-sre"},
+			subject:         "test-sre",
+			expectStatus:    http.StatusOK,
+		},
+		{
+			name:            "Allowed subject is case-insensitive",
+			allowedSubjects: []string{"Test-SRE"},
+			subject:         "test-sre",
+			expectStatus:    http.StatusOK,
+		},
+		{
+			name:            "Unauthorized subject",
+			allowedSubjects: []string{"test-sre"},
+			subject:         "intruder",
+			expectStatus:    http.StatusForbidden,
+		},
+		{
+			name:         "Any subject when none are configured",
+			subject:      "anyone",
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "No client certificate when no subjects are configured",
+			expectStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/admin/v1/operations", nil)
+			request = request.WithContext(ContextWithLogger(request.Context(), testLogger))
+			if tt.subject != "" {
+				request = withClientSubject(request, tt.subject)
+			}
+
+			writer := httptest.NewRecorder()
+
+			next := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}
+
+			newMiddlewareAdminAuthorization(tt.allowedSubjects)(writer, request, next)
+
+			if writer.Code != tt.expectStatus {
+				t.Errorf("Expected status code %d but got %d", tt.expectStatus, writer.Code)
+			}
+		})
+	}
+}
+
+func TestMiddlewareAdminAudit(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	request := httptest.NewRequest(http.MethodGet, "/Admin/V1/Operations?status=failed", nil)
+	request = request.WithContext(ContextWithLogger(request.Context(), logger))
+	request = withClientSubject(request, "test-sre")
+
+	writer := httptest.NewRecorder()
+
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}
+
+	MiddlewareAdminAudit(writer, request, next)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected one JSON log entry but got %q: %v", buf.String(), err)
+	}
+
+	expect := map[string]any{
+		"msg":                  "admin audit",
+		"admin_client_subject": "test-sre",
+		"request_method":       http.MethodGet,
+		"request_path":         "/Admin/V1/Operations",
+		"request_query":        "status=failed",
+		"response_status_code": float64(http.StatusNotFound),
+	}
+	for key, value := range expect {
+		if entry[key] != value {
+			t.Errorf("Expected log attribute %s to be %v but got %v", key, value, entry[key])
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+// MiddlewareLockResource locks the resource in the request context for
+// mutating requests. Subscription requests lock the subscription, which
+// excludes locks on resources in the subscription. Resource action
+// requests lock the resource the action applies to. Locks on unrelated
+// resources in the same subscription do not wait on each other.
+func MiddlewareLockResource(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx := r.Context()
+	logger := LoggerFromContext(ctx)
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		// These methods are read-only and don't require locking.
+		next(w, r)
+		return
+	}
+
+	dbClient, err := DBClientFromContext(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(w)
+		return
+	}
+
+	lockClient := dbClient.GetLockClient()
+	if lockClient == nil {
+		next(w, r)
+		return
+	}
+
+	resourceID, err := ResourceIDFromContext(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(w)
+		return
+	}
+
+	lockedCtx, release, cloudError := acquireResourceLock(ctx, w.Header(), lockClient, actionTargetID(resourceID))
+	if cloudError != nil {
+		arm.WriteCloudError(w, cloudError)
+		return
+	}
+
+	next(w, r.WithContext(lockedCtx))
+
+	release()
+}
+
+// acquireResourceLock acquires the lock for a subscription or resource,
+// waiting up to the lock's default TTL for any conflicting lock to be
+// released. It returns a context to use while holding the lock, which
+// is cancelled if the lock is lost, and a function to release the lock.
+func acquireResourceLock(ctx context.Context, header http.Header, lockClient database.LockClient, resourceID *azcorearm.ResourceID) (context.Context, func(), *arm.CloudError) {
+	return acquireLock(ctx, header, lockClient, "lock", resourceID, func(ctx context.Context, timeout *time.Duration) (*azcosmos.ItemResponse, error) {
+		if strings.EqualFold(resourceID.ResourceType.String(), azcorearm.SubscriptionResourceType.String()) {
+			return database.AcquireSubscriptionLock(ctx, lockClient, resourceID.SubscriptionID, timeout)
+		}
+		return database.AcquireResourceLock(ctx, lockClient, resourceID, timeout)
+	})
+}
+
+// acquireLock acquires a lock for the given resource by calling acquire
+// with the lock's default TTL as the timeout, then holds the lock like
+// acquireResourceLock does. The lock is referred to by name in logs.
+func acquireLock(ctx context.Context, header http.Header, lockClient database.LockClient, name string, resourceID *azcorearm.ResourceID, acquire func(context.Context, *time.Duration) (*azcosmos.ItemResponse, error)) (context.Context, func(), *arm.CloudError) {
+	logger := LoggerFromContext(ctx)
+
+	// Wait for the default TTL to acquire lock.
+	timeout := lockClient.GetDefaultTimeToLive()
+	lock, err := acquire(ctx, &timeout)
+	if err != nil {
+		message := fmt.Sprintf("Failed to acquire %s for '%s': ", name, resourceID)
+		var cloudError *arm.CloudError
+		if errors.Is(err, context.DeadlineExceeded) {
+			message += "timed out"
+			lockClient.SetRetryAfterHeader(header)
+			cloudError = arm.NewCloudError(
+				http.StatusConflict, arm.CloudErrorCodeConflict,
+				resourceID.String(), "%s", message)
+		} else {
+			message += err.Error()
+			cloudError = arm.NewInternalServerError()
+		}
+		logger.Error(message)
+		return nil, nil, cloudError
+	}
+	logger.Info(fmt.Sprintf("Acquired %s for '%s'", name, resourceID))
+
+	// Hold the lock until released. If we lose
+	// the lock the context will be cancelled.
+	lockedCtx, stop := lockClient.HoldLock(ctx, lock)
+
+	release := func() {
+		lock := stop()
+		if lock != nil {
+			err := lockClient.ReleaseLock(ctx, lock)
+			if err == nil {
+				logger.Info(fmt.Sprintf("Released %s for '%s'", name, resourceID))
+			} else {
+				// Failure here is non-fatal but still log the error.
+				// The lock's TTL ensures it will be released eventually.
+				logger.Error(fmt.Sprintf("Failed to release %s for '%s': %v", name, resourceID, err))
+			}
+		}
+	}
+
+	return lockedCtx, release, nil
+}
@@ -0,0 +1,204 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+// lockedRequest sends a request for resourceID through MiddlewareLockResource
+// in a goroutine. The request handler signals entered and then waits for
+// release before returning. The returned channel receives the response
+// status code.
+func lockedRequest(t *testing.T, dbClient database.DBClient, method, resourceID string, entered chan<- string, release <-chan struct{}) <-chan int {
+	t.Helper()
+
+	ctx := ContextWithLogger(context.Background(), testLogger)
+	ctx = ContextWithDBClient(ctx, dbClient)
+	ctx = ContextWithResourceID(ctx, mustParseResourceID(t, resourceID))
+
+	request := httptest.NewRequestWithContext(ctx, method, resourceID, nil)
+	writer := httptest.NewRecorder()
+
+	next := func(w http.ResponseWriter, r *http.Request) {
+		entered <- resourceID
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}
+
+	done := make(chan int, 1)
+	go func() {
+		MiddlewareLockResource(writer, request, next)
+		done <- writer.Code
+	}()
+
+	return done
+}
+
+func TestMiddlewareLockResourceConcurrentCreates(t *testing.T) {
+	tests := []struct {
+		name        string
+		resourceIDs []string
+	}{
+		{
+			name: "Clusters in one subscription",
+			resourceIDs: []string{
+				testGroupPrefix + "testCluster1",
+				testGroupPrefix + "testCluster2",
+				otherGroupPrefix + "testCluster3",
+			},
+		},
+		{
+			name: "Node pools in one cluster",
+			resourceIDs: []string{
+				testGroupPrefix + "testCluster/nodePools/testNodePool1",
+				testGroupPrefix + "testCluster/nodePools/testNodePool2",
+			},
+		},
+		{
+			name: "Cluster action and node pool of another cluster",
+			resourceIDs: []string{
+				testGroupPrefix + "testCluster1/requestadmincredential",
+				testGroupPrefix + "testCluster2/nodePools/testNodePool",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbClient := database.NewInMemoryDBClient()
+			entered := make(chan string, len(tt.resourceIDs))
+			release := make(chan struct{})
+
+			var results []<-chan int
+			for _, resourceID := range tt.resourceIDs {
+				results = append(results, lockedRequest(t, dbClient, http.MethodPut, resourceID, entered, release))
+			}
+
+			// Every request must hold its lock at the same time.
+			timeout := time.After(5 * time.Second)
+			for range tt.resourceIDs {
+				select {
+				case <-entered:
+				case <-timeout:
+					close(release)
+					t.Fatal("Timed out waiting for concurrent requests to acquire locks")
+				}
+			}
+			close(release)
+
+			for _, result := range results {
+				if code := <-result; code != http.StatusOK {
+					t.Errorf("Expected status code %d but got %d", http.StatusOK, code)
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareLockResourceSerializes(t *testing.T) {
+	tests := []struct {
+		name         string
+		firstMethod  string
+		firstID      string
+		secondMethod string
+		secondID     string
+	}{
+		{
+			name:         "Same cluster",
+			firstMethod:  http.MethodPut,
+			firstID:      testGroupPrefix + "testCluster",
+			secondMethod: http.MethodDelete,
+			secondID:     testGroupPrefix + "testCluster",
+		},
+		{
+			name:         "Node pool of locked cluster",
+			firstMethod:  http.MethodPatch,
+			firstID:      testGroupPrefix + "testCluster",
+			secondMethod: http.MethodPut,
+			secondID:     testGroupPrefix + "testCluster/nodePools/testNodePool",
+		},
+		{
+			name:         "Cluster of locked node pool",
+			firstMethod:  http.MethodPut,
+			firstID:      testGroupPrefix + "testCluster/nodePools/testNodePool",
+			secondMethod: http.MethodDelete,
+			secondID:     testGroupPrefix + "testCluster",
+		},
+		{
+			name:         "Cluster in locked subscription",
+			firstMethod:  http.MethodPut,
+			firstID:      "/subscriptions/00000000-0000-0000-0000-000000000000",
+			secondMethod: http.MethodPut,
+			secondID:     testGroupPrefix + "testCluster",
+		},
+		{
+			name:         "Subscription of locked cluster",
+			firstMethod:  http.MethodPut,
+			firstID:      testGroupPrefix + "testCluster",
+			secondMethod: http.MethodPut,
+			secondID:     "/subscriptions/00000000-0000-0000-0000-000000000000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbClient := database.NewInMemoryDBClient()
+			entered := make(chan string, 2)
+			releaseFirst := make(chan struct{})
+			releaseSecond := make(chan struct{})
+			close(releaseSecond)
+
+			first := lockedRequest(t, dbClient, tt.firstMethod, tt.firstID, entered, releaseFirst)
+			select {
+			case <-entered:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Timed out waiting for the first request to acquire its lock")
+			}
+
+			second := lockedRequest(t, dbClient, tt.secondMethod, tt.secondID, entered, releaseSecond)
+			select {
+			case <-entered:
+				t.Error("Second request acquired its lock while the first request held its lock")
+			case <-time.After(1500 * time.Millisecond):
+			}
+
+			close(releaseFirst)
+			if code := <-first; code != http.StatusOK {
+				t.Errorf("Expected status code %d but got %d", http.StatusOK, code)
+			}
+			if code := <-second; code != http.StatusOK {
+				t.Errorf("Expected status code %d but got %d", http.StatusOK, code)
+			}
+		})
+	}
+}
+
+func TestMiddlewareLockResourceReadOnly(t *testing.T) {
+	dbClient := database.NewInMemoryDBClient()
+	entered := make(chan string, 2)
+	releaseFirst := make(chan struct{})
+	releaseSecond := make(chan struct{})
+	close(releaseSecond)
+
+	// A read-only request does not wait on a lock.
+	first := lockedRequest(t, dbClient, http.MethodPut, testGroupPrefix+"testCluster", entered, releaseFirst)
+	<-entered
+	second := lockedRequest(t, dbClient, http.MethodGet, testGroupPrefix+"testCluster", entered, releaseSecond)
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Error("Read-only request waited on a lock")
+	}
+
+	close(releaseFirst)
+	<-first
+	<-second
+}
@@ -72,6 +72,10 @@ func MiddlewareValidateSubscriptionState(w http.ResponseWriter, r *http.Request,
 		tracing.SubscriptionStateKey.String(string(subscription.State)),
 	)
 
+	// Handlers consult the subscription for quota overrides.
+	ctx = ContextWithSubscription(ctx, subscription)
+	r = r.WithContext(ctx)
+
 	switch subscription.State {
 	case arm.SubscriptionStateRegistered:
 		next(w, r)
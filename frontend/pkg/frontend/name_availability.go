@@ -0,0 +1,155 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+// isValidResourceName checks a name against the naming restriction that
+// MiddlewareValidateStatic enforces for the resource type. Names must also
+// be valid DNS RFC 1035 labels, which additionally may not end with '-'.
+func isValidResourceName(rx *regexp.Regexp, name string) bool {
+	return rx.MatchString(name) && !strings.HasSuffix(name, "-")
+}
+
+// ArmCheckNameAvailability implements the ARM name availability API
+// contract for clusters and node pools. The action is available at both
+// subscription and resource group scope.
+func (f *Frontend) ArmCheckNameAvailability(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	body, err := BodyFromContext(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	var nameRequest arm.CheckNameAvailabilityRequest
+	err = json.Unmarshal(body, &nameRequest)
+	if err != nil {
+		arm.WriteCloudError(writer, arm.NewInvalidRequestContentError(err))
+		return
+	}
+
+	if nameRequest.Name == "" {
+		arm.WriteError(writer, http.StatusBadRequest,
+			arm.CloudErrorCodeInvalidRequestContent, "name",
+			"Missing required field 'name'")
+		return
+	}
+	if nameRequest.Type == "" {
+		arm.WriteError(writer, http.StatusBadRequest,
+			arm.CloudErrorCodeInvalidRequestContent, "type",
+			"Missing required field 'type'")
+		return
+	}
+
+	subscriptionID := request.PathValue(PathSegmentSubscriptionID)
+	resourceGroupName := request.PathValue(PathSegmentResourceGroupName)
+
+	nameResponse, cloudError := f.checkNameAvailability(ctx, subscriptionID, resourceGroupName, nameRequest)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	_, err = arm.WriteJSONResponse(writer, http.StatusOK, nameResponse)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+// checkNameAvailability checks that the requested name is valid for its
+// resource type and that no existing resource in the resource group already
+// uses it. At subscription scope, resources in any resource group count. A
+// node pool name includes its cluster name, as in deployment templates.
+func (f *Frontend) checkNameAvailability(ctx context.Context, subscriptionID, resourceGroupName string, nameRequest arm.CheckNameAvailabilityRequest) (*arm.CheckNameAvailabilityResponse, *arm.CloudError) {
+	logger := LoggerFromContext(ctx)
+
+	var clusterName, nodePoolName string
+
+	switch {
+	case strings.EqualFold(nameRequest.Type, api.ClusterResourceType.String()):
+		clusterName = nameRequest.Name
+		if !isValidResourceName(rxHCPOpenShiftClusterResourceName, clusterName) {
+			return invalidNameResponse(nameRequest), nil
+		}
+	case strings.EqualFold(nameRequest.Type, api.NodePoolResourceType.String()):
+		var ok bool
+		clusterName, nodePoolName, ok = strings.Cut(nameRequest.Name, "/")
+		if !ok || !isValidResourceName(rxHCPOpenShiftClusterResourceName, clusterName) ||
+			!isValidResourceName(rxNodePoolResourceName, nodePoolName) {
+			return invalidNameResponse(nameRequest), nil
+		}
+	default:
+		return nil, arm.NewCloudError(
+			http.StatusBadRequest,
+			arm.CloudErrorCodeInvalidResourceType, "type",
+			"The resource type '%s' does not support name availability checks.",
+			nameRequest.Type)
+	}
+
+	existing, err := f.listSubscriptionResourceDocs(ctx, subscriptionID)
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, arm.NewInternalServerError()
+	}
+
+	for _, doc := range existing {
+		resourceID := doc.ResourceID
+
+		if resourceGroupName != "" && !strings.EqualFold(resourceID.ResourceGroupName, resourceGroupName) {
+			continue
+		}
+
+		switch {
+		case nodePoolName == "":
+			if strings.EqualFold(resourceID.ResourceType.String(), api.ClusterResourceType.String()) &&
+				strings.EqualFold(resourceID.Name, clusterName) {
+				return &arm.CheckNameAvailabilityResponse{
+					NameAvailable: false,
+					Reason:        arm.CheckNameAvailabilityReasonAlreadyExists,
+					Message: fmt.Sprintf(
+						"A cluster named '%s' already exists in resource group '%s'.",
+						resourceID.Name, resourceID.ResourceGroupName),
+				}, nil
+			}
+		default:
+			if strings.EqualFold(resourceID.ResourceType.String(), api.NodePoolResourceType.String()) &&
+				strings.EqualFold(resourceID.Parent.Name, clusterName) &&
+				strings.EqualFold(resourceID.Name, nodePoolName) {
+				return &arm.CheckNameAvailabilityResponse{
+					NameAvailable: false,
+					Reason:        arm.CheckNameAvailabilityReasonAlreadyExists,
+					Message: fmt.Sprintf(
+						"A node pool named '%s' already exists in cluster '%s'.",
+						resourceID.Name, resourceID.Parent.Name),
+				}, nil
+			}
+		}
+	}
+
+	return &arm.CheckNameAvailabilityResponse{NameAvailable: true}, nil
+}
+
+func invalidNameResponse(nameRequest arm.CheckNameAvailabilityRequest) *arm.CheckNameAvailabilityResponse {
+	return &arm.CheckNameAvailabilityResponse{
+		NameAvailable: false,
+		Reason:        arm.CheckNameAvailabilityReasonInvalid,
+		Message: fmt.Sprintf(
+			"The name '%s' does not conform to the naming restriction for resource type '%s'.",
+			nameRequest.Name, nameRequest.Type),
+	}
+}
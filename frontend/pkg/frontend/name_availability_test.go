@@ -0,0 +1,131 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+func TestCheckNameAvailability(t *testing.T) {
+	existing := []string{
+		testGroupPrefix + "testCluster",
+		testGroupPrefix + "testCluster/nodePools/testNodePool",
+		otherGroupPrefix + "otherCluster",
+	}
+
+	tests := []struct {
+		name              string
+		resourceGroupName string
+		request           arm.CheckNameAvailabilityRequest
+		expectAvailable   bool
+		expectReason      arm.CheckNameAvailabilityReason
+		expectError       bool
+	}{
+		{
+			name:            "Available cluster name",
+			request:         arm.CheckNameAvailabilityRequest{Name: "newCluster", Type: api.ClusterResourceType.String()},
+			expectAvailable: true,
+		},
+		{
+			name:         "Cluster name too short",
+			request:      arm.CheckNameAvailabilityRequest{Name: "ab", Type: api.ClusterResourceType.String()},
+			expectReason: arm.CheckNameAvailabilityReasonInvalid,
+		},
+		{
+			name:         "Cluster name ends with hyphen",
+			request:      arm.CheckNameAvailabilityRequest{Name: "newCluster-", Type: api.ClusterResourceType.String()},
+			expectReason: arm.CheckNameAvailabilityReasonInvalid,
+		},
+		{
+			name:         "Cluster name taken",
+			request:      arm.CheckNameAvailabilityRequest{Name: "TESTCLUSTER", Type: api.ClusterResourceType.String()},
+			expectReason: arm.CheckNameAvailabilityReasonAlreadyExists,
+		},
+		{
+			name:              "Cluster name taken in another resource group",
+			resourceGroupName: "testGroup",
+			request:           arm.CheckNameAvailabilityRequest{Name: "otherCluster", Type: api.ClusterResourceType.String()},
+			expectAvailable:   true,
+		},
+		{
+			name:         "Cluster name taken in another resource group at subscription scope",
+			request:      arm.CheckNameAvailabilityRequest{Name: "otherCluster", Type: api.ClusterResourceType.String()},
+			expectReason: arm.CheckNameAvailabilityReasonAlreadyExists,
+		},
+		{
+			name:            "Available node pool name",
+			request:         arm.CheckNameAvailabilityRequest{Name: "testCluster/newNodePool", Type: api.NodePoolResourceType.String()},
+			expectAvailable: true,
+		},
+		{
+			name:         "Node pool name taken",
+			request:      arm.CheckNameAvailabilityRequest{Name: "testCluster/testNodePool", Type: api.NodePoolResourceType.String()},
+			expectReason: arm.CheckNameAvailabilityReasonAlreadyExists,
+		},
+		{
+			name:              "Node pool name taken in another resource group",
+			resourceGroupName: "otherGroup",
+			request:           arm.CheckNameAvailabilityRequest{Name: "testCluster/testNodePool", Type: api.NodePoolResourceType.String()},
+			expectAvailable:   true,
+		},
+		{
+			name:         "Node pool name without cluster name",
+			request:      arm.CheckNameAvailabilityRequest{Name: "testNodePool", Type: api.NodePoolResourceType.String()},
+			expectReason: arm.CheckNameAvailabilityReasonInvalid,
+		},
+		{
+			name:         "Node pool name too long",
+			request:      arm.CheckNameAvailabilityRequest{Name: "testCluster/nodePoolNameIsTooLong", Type: api.NodePoolResourceType.String()},
+			expectReason: arm.CheckNameAvailabilityReasonInvalid,
+		},
+		{
+			name:        "Unsupported resource type",
+			request:     arm.CheckNameAvailabilityRequest{Name: "newCluster", Type: api.VersionResourceType.String()},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ContextWithLogger(context.Background(), testLogger)
+			dbClient := database.NewInMemoryDBClient()
+
+			for _, resourceID := range existing {
+				err := dbClient.CreateResourceDoc(ctx, database.NewResourceDocument(mustParseResourceID(t, resourceID)))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			f := &Frontend{dbClient: dbClient}
+
+			response, cloudError := f.checkNameAvailability(ctx, "00000000-0000-0000-0000-000000000000", tt.resourceGroupName, tt.request)
+
+			if tt.expectError {
+				if cloudError == nil {
+					t.Fatalf("Expected an error but got %+v", response)
+				}
+				return
+			}
+			if cloudError != nil {
+				t.Fatal(cloudError)
+			}
+
+			if response.NameAvailable != tt.expectAvailable {
+				t.Errorf("Expected nameAvailable %v but got %v: %s", tt.expectAvailable, response.NameAvailable, response.Message)
+			}
+			if response.Reason != tt.expectReason {
+				t.Errorf("Expected reason '%s' but got '%s'", tt.expectReason, response.Reason)
+			}
+			if !response.NameAvailable && response.Message == "" {
+				t.Error("Expected a message for an unavailable name")
+			}
+		})
+	}
+}
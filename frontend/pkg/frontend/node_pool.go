@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"maps"
 	"net/http"
+	"reflect"
 
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 
@@ -64,9 +65,31 @@ func (f *Frontend) CreateOrUpdateNodePool(writer http.ResponseWriter, request *h
 		return
 	}
 
+	retriedOperationDoc, cloudError := f.getRetriedOperation(ctx, request, doc)
+	if cloudError != nil {
+		logger.Error(cloudError.Error())
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+	if retriedOperationDoc != nil {
+		f.replayOperationResponse(writer, request, retriedOperationDoc, versionedInterface)
+		return
+	}
+
+	// The resource lock held for the duration of this request keeps
+	// the resource from changing between this check and the update below.
+	cloudError = checkPreconditions(request.Header, resourceID, doc)
+	if cloudError != nil {
+		logger.Error(cloudError.Error())
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
 	var updating = (doc != nil)
 	var operationRequest database.OperationRequest
 
+	var currentCSNodePool *cmv1.NodePool
+	var currentVersion api.VersionProfile
 	var versionedCurrentNodePool api.VersionedHCPOpenShiftClusterNodePool
 	var versionedRequestNodePool api.VersionedHCPOpenShiftClusterNodePool
 	var successStatusCode int
@@ -78,14 +101,15 @@ func (f *Frontend) CreateOrUpdateNodePool(writer http.ResponseWriter, request *h
 		// No special treatment here for "not found" errors. A "not found"
 		// error indicates the database has gotten out of sync and so it's
 		// appropriate to fail.
-		csNodePool, err := f.clusterServiceClient.GetNodePool(ctx, doc.InternalID)
+		currentCSNodePool, err = f.clusterServiceClient.GetNodePool(ctx, doc.InternalID)
 		if err != nil {
 			logger.Error(fmt.Sprintf("failed to fetch CS node pool for %s: %v", resourceID, err))
 			arm.WriteInternalServerError(writer)
 			return
 		}
 
-		hcpNodePool := ConvertCStoNodePool(resourceID, csNodePool)
+		hcpNodePool := ConvertCStoNodePool(resourceID, currentCSNodePool)
+		currentVersion = hcpNodePool.Properties.Version
 
 		// Do not set the TrackedResource.Tags field here. We need
 		// the Tags map to remain nil so we can see if the request
@@ -122,14 +146,6 @@ func (f *Frontend) CreateOrUpdateNodePool(writer http.ResponseWriter, request *h
 		doc = database.NewResourceDocument(resourceID)
 	}
 
-	// CheckForProvisioningStateConflict does not log conflict errors
-	// but does log unexpected errors like database failures.
-	cloudError := f.CheckForProvisioningStateConflict(ctx, operationRequest, doc)
-	if cloudError != nil {
-		arm.WriteCloudError(writer, cloudError)
-		return
-	}
-
 	body, err := BodyFromContext(ctx)
 	if err != nil {
 		logger.Error(err.Error())
@@ -152,6 +168,75 @@ func (f *Frontend) CreateOrUpdateNodePool(writer http.ResponseWriter, request *h
 	hcpNodePool := api.NewDefaultHCPOpenShiftClusterNodePool()
 	versionedRequestNodePool.Normalize(hcpNodePool)
 
+	currentNodePool := api.NewDefaultHCPOpenShiftClusterNodePool()
+	versionedCurrentNodePool.Normalize(currentNodePool)
+
+	cloudError = validateFeatures(ctx, hcpNodePool, currentNodePool)
+	if cloudError != nil {
+		logger.Error(cloudError.Error())
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	// Tags live only in the resource document, so a PATCH request that
+	// changes nothing else completes synchronously without updating the
+	// node pool in Cluster Service or starting an asynchronous operation. It
+	// is therefore not in conflict with an operation already in progress.
+	if updating && request.Method == http.MethodPatch {
+		currentNodePool.TrackedResource.Tags = hcpNodePool.TrackedResource.Tags
+
+		if reflect.DeepEqual(currentNodePool, hcpNodePool) {
+			logger.Info(fmt.Sprintf("updating tags for resource %s", resourceID))
+			doc, cloudError = f.UpdateResourceTags(ctx, resourceID, hcpNodePool.TrackedResource.Tags, systemData)
+			if cloudError != nil {
+				arm.WriteCloudError(writer, cloudError)
+				return
+			}
+
+			responseBody, err := marshalCSNodePool(currentCSNodePool, doc, versionedInterface)
+			if err != nil {
+				logger.Error(err.Error())
+				arm.WriteInternalServerError(writer)
+				return
+			}
+
+			setETagHeader(writer.Header(), doc.ETag)
+			_, err = arm.WriteJSONResponse(writer, http.StatusOK, responseBody)
+			if err != nil {
+				logger.Error(err.Error())
+			}
+			return
+		}
+	}
+
+	// CheckForProvisioningStateConflict does not log conflict errors
+	// but does log unexpected errors like database failures.
+	cloudError = f.CheckForProvisioningStateConflict(ctx, operationRequest, doc)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	// A new version ID on an existing node pool is an upgrade request,
+	// which Cluster Service carries out through an upgrade policy rather
+	// than a node pool update.
+	var csUpgradePolicy *cmv1.NodePoolUpgradePolicy
+	if updating && convertVersionToCSVersionID(hcpNodePool.Properties.Version) != convertVersionToCSVersionID(currentVersion) {
+		cloudError = validateVersionUpgrade(currentVersion, hcpNodePool.Properties.Version)
+		if cloudError != nil {
+			logger.Error(cloudError.Error())
+			arm.WriteCloudError(writer, cloudError)
+			return
+		}
+
+		csUpgradePolicy, err = f.BuildCSNodePoolUpgradePolicy(ctx, hcpNodePool)
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+	}
+
 	hcpNodePool.Name = request.PathValue(PathSegmentNodePoolName)
 	csNodePool, err := f.BuildCSNodePool(ctx, hcpNodePool, updating)
 	if err != nil {
@@ -160,7 +245,53 @@ func (f *Frontend) CreateOrUpdateNodePool(writer http.ResponseWriter, request *h
 		return
 	}
 
+	// The operation tracks the node pool itself unless
+	// it tracks an upgrade policy for the node pool.
+	var operationInternalID ocm.InternalID
+
 	if updating {
+		// Only a node pool that may grow is checked against the quota,
+		// so lowering a quota does not block unrelated updates.
+		if nodePoolNodes(hcpNodePool.Properties.Replicas, hcpNodePool.Properties.AutoScaling) > csNodePoolNodes(currentCSNodePool) {
+			clusterDoc, err := f.dbClient.GetResourceDoc(ctx, resourceID.Parent)
+			if err != nil {
+				logger.Error(err.Error())
+				arm.WriteInternalServerError(writer)
+				return
+			}
+
+			var releaseQuota func()
+			ctx, releaseQuota, cloudError = f.checkNodePoolQuota(ctx, writer.Header(), clusterDoc, hcpNodePool, true)
+			if cloudError != nil {
+				logger.Error(cloudError.Error())
+				arm.WriteCloudError(writer, cloudError)
+				return
+			}
+			defer releaseQuota()
+		}
+
+		operationInternalID = doc.InternalID
+
+		// Post the upgrade policy before updating the node pool
+		// so that an upgrade Cluster Service rejects leaves the
+		// rest of the node pool unchanged.
+		if csUpgradePolicy != nil {
+			logger.Info(fmt.Sprintf("upgrading resource %s to version %s", resourceID, csUpgradePolicy.Version()))
+			csUpgradePolicy, err = f.clusterServiceClient.PostNodePoolUpgradePolicy(ctx, doc.InternalID, csUpgradePolicy)
+			if err != nil {
+				logger.Error(err.Error())
+				arm.WriteInternalServerError(writer)
+				return
+			}
+
+			operationInternalID, err = ocm.NewInternalID(csUpgradePolicy.HREF())
+			if err != nil {
+				logger.Error(err.Error())
+				arm.WriteInternalServerError(writer)
+				return
+			}
+		}
+
 		logger.Info(fmt.Sprintf("updating resource %s", resourceID))
 		csNodePool, err = f.clusterServiceClient.UpdateNodePool(ctx, doc.InternalID, csNodePool)
 		if err != nil {
@@ -177,6 +308,36 @@ func (f *Frontend) CreateOrUpdateNodePool(writer http.ResponseWriter, request *h
 			return
 		}
 
+		// Cluster Service counts the new node pool toward the
+		// quota once it is created, so hold the quota lock
+		// until this request is done.
+		var releaseQuota func()
+		ctx, releaseQuota, cloudError = f.checkNodePoolQuota(ctx, writer.Header(), clusterDoc, hcpNodePool, false)
+		if cloudError != nil {
+			logger.Error(cloudError.Error())
+			arm.WriteCloudError(writer, cloudError)
+			return
+		}
+		defer releaseQuota()
+
+		// Static validation cannot see the parent cluster, so check
+		// a node pool subnet against the cluster's subnet here.
+		if hcpNodePool.Properties.Platform.SubnetID != "" {
+			csCluster, err := f.clusterServiceClient.GetCluster(ctx, clusterDoc.InternalID)
+			if err != nil {
+				logger.Error(fmt.Sprintf("failed to fetch CS cluster for %s: %v", resourceID.Parent, err))
+				arm.WriteInternalServerError(writer)
+				return
+			}
+
+			cloudError = validateNodePoolSubnet(csCluster.Azure().SubnetResourceID(), hcpNodePool)
+			if cloudError != nil {
+				logger.Error(cloudError.Error())
+				arm.WriteCloudError(writer, cloudError)
+				return
+			}
+		}
+
 		csNodePool, err = f.clusterServiceClient.PostNodePool(ctx, clusterDoc.InternalID, csNodePool)
 		if err != nil {
 			logger.Error(err.Error())
@@ -190,9 +351,11 @@ func (f *Frontend) CreateOrUpdateNodePool(writer http.ResponseWriter, request *h
 			arm.WriteInternalServerError(writer)
 			return
 		}
+
+		operationInternalID = doc.InternalID
 	}
 
-	operationDoc := database.NewOperationDocument(operationRequest, doc.ResourceID, doc.InternalID)
+	operationDoc := database.NewOperationDocument(operationRequest, doc.ResourceID, operationInternalID)
 
 	operationID, err := f.dbClient.CreateOperationDoc(ctx, operationDoc)
 	if err != nil {
@@ -214,6 +377,7 @@ func (f *Frontend) CreateOrUpdateNodePool(writer http.ResponseWriter, request *h
 	updateResourceMetadata := func(doc *database.ResourceDocument) bool {
 		doc.ActiveOperationID = operationID
 		doc.ProvisioningState = operationDoc.Status
+		doc.ETag = database.NewResourceETag()
 
 		// Record the latest system data values from ARM, if present.
 		if systemData != nil {
@@ -267,6 +431,7 @@ func (f *Frontend) CreateOrUpdateNodePool(writer http.ResponseWriter, request *h
 		return
 	}
 
+	setETagHeader(writer.Header(), doc.ETag)
 	_, err = arm.WriteJSONResponse(writer, successStatusCode, responseBody)
 	if err != nil {
 		logger.Error(err.Error())
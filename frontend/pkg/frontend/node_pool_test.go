@@ -118,9 +118,15 @@ func TestCreateNodePool(t *testing.T) {
 					},
 				)
 
-			// MiddlewareLockSubscription
+			// checkNodePoolQuota
+			mockCSClient.EXPECT().
+				ListNodePools(clusterDoc.InternalID, gomock.Any()).
+				Return(ocm.NodePoolListIterator{})
+
+			// MiddlewareLockResource and checkNodePoolQuota
 			mockDBClient.EXPECT().
-				GetLockClient()
+				GetLockClient().
+				Times(2)
 			// MiddlewareValidateSubscriptionState
 			mockDBClient.EXPECT().
 				GetSubscriptionDoc(gomock.Any(), dummySubscriptionId).
@@ -164,6 +170,130 @@ func TestCreateNodePool(t *testing.T) {
 	}
 }
 
+func TestPatchNodePoolTags(t *testing.T) {
+	const etag = `"00000000-0000-0000-0000-000000000001"`
+
+	tests := []struct {
+		name        string
+		body        string
+		currentTags map[string]string
+		expectTags  map[string]string
+		expectWrite bool
+	}{
+		{
+			name:        "New tags",
+			body:        `{"tags":{"team":"aro"}}`,
+			expectTags:  map[string]string{"team": "aro"},
+			expectWrite: true,
+		},
+		{
+			name:        "Unchanged tags",
+			body:        `{"tags":{"team":"aro"}}`,
+			currentTags: map[string]string{"team": "aro"},
+			expectTags:  map[string]string{"team": "aro"},
+		},
+		{
+			name:        "Empty body",
+			body:        `{}`,
+			currentTags: map[string]string{"team": "aro"},
+			expectTags:  map[string]string{"team": "aro"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodePoolResourceID, _ := azcorearm.ParseResourceID(dummyNodePoolID)
+			nodePoolDoc := database.NewResourceDocument(nodePoolResourceID)
+			nodePoolDoc.InternalID, _ = ocm.NewInternalID(dummyNodePoolHREF)
+			nodePoolDoc.Tags = test.currentTags
+			nodePoolDoc.ETag = etag
+			// Tags can be updated while the node pool is being updated.
+			nodePoolDoc.ProvisioningState = arm.ProvisioningStateUpdating
+
+			csNodePool, err := cmv1.NewNodePool().
+				HREF(dummyNodePoolHREF).
+				Version(cmv1.NewVersion().ID(dummyVersionID).ChannelGroup(dummyChannelGroup)).
+				AzureNodePool(cmv1.NewAzureNodePool().VMSize(dummyVMSize)).
+				Replicas(2).
+				Build()
+			require.NoError(t, err)
+
+			subDoc := &arm.Subscription{
+				State:            arm.SubscriptionStateRegistered,
+				RegistrationDate: api.Ptr(time.Now().String()),
+				Properties:       nil,
+			}
+
+			ctrl := gomock.NewController(t)
+			mockDBClient := mocks.NewMockDBClient(ctrl)
+			mockCSClient := mocks.NewMockClusterServiceClientSpec(ctrl)
+			reg := prometheus.NewRegistry()
+
+			f := NewFrontend(
+				testLogger,
+				nil,
+				nil,
+				reg,
+				mockDBClient,
+				"",
+				mockCSClient,
+			)
+
+			subs := map[string]*arm.Subscription{dummySubscriptionId: subDoc}
+			ts := newHTTPServer(f, ctrl, mockDBClient, subs)
+
+			// CreateOrUpdateNodePool
+			mockCSClient.EXPECT().
+				GetNodePool(gomock.Any(), nodePoolDoc.InternalID).
+				Return(csNodePool, nil)
+
+			// MiddlewareLockResource
+			mockDBClient.EXPECT().
+				GetLockClient()
+			// MiddlewareValidateSubscriptionState
+			mockDBClient.EXPECT().
+				GetSubscriptionDoc(gomock.Any(), dummySubscriptionId).
+				Return(subDoc, nil).
+				Times(1)
+			// CreateOrUpdateNodePool and UpdateResourceTags
+			mockDBClient.EXPECT().
+				GetResourceDoc(gomock.Any(), equalResourceID(nodePoolResourceID)).
+				Return(nodePoolDoc, nil).
+				Times(2)
+			// UpdateResourceTags
+			mockDBClient.EXPECT().
+				UpdateResourceDoc(gomock.Any(), equalResourceID(nodePoolResourceID), gomock.Any()).
+				DoAndReturn(
+					func(ctx context.Context, resourceID *azcorearm.ResourceID, callback func(*database.ResourceDocument) bool) (bool, error) {
+						updated := callback(nodePoolDoc)
+						assert.Equal(t, test.expectWrite, updated)
+						return updated, nil
+					},
+				)
+
+			// No provisioning state conflict check, Cluster Service
+			// update, or operation document is expected.
+
+			req, err := http.NewRequest(http.MethodPatch, ts.URL+dummyNodePoolID+"?api-version=2024-06-10-preview", bytes.NewReader([]byte(test.body)))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(arm.HeaderNameARMResourceSystemData, "{}")
+
+			rs, err := ts.Client().Do(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rs.StatusCode)
+			assert.Equal(t, test.expectTags, nodePoolDoc.Tags)
+			if test.expectWrite {
+				assert.NotEqual(t, etag, string(nodePoolDoc.ETag))
+			} else {
+				assert.Equal(t, etag, string(nodePoolDoc.ETag))
+			}
+			assert.Equal(t, string(nodePoolDoc.ETag), rs.Header.Get("ETag"))
+		})
+	}
+}
+
 // TODO: Fix the update logic for this test.
 
 // func TestUpdateNodePool(t *testing.T) {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/google/uuid"
@@ -21,6 +23,15 @@ const (
 	csHypershifEnabled bool   = true
 	csMultiAzEnabled   bool   = true
 	csCCSEnabled       bool   = true
+
+	// csVersionPrefix prefixes the raw OpenShift version in Cluster
+	// Service version IDs. Available upgrades and upgrade policies use
+	// raw OpenShift versions.
+	csVersionPrefix string = "openshift-v"
+
+	// csDefaultChannelGroup is the channel group whose versions lack
+	// a channel group suffix in Cluster Service version IDs.
+	csDefaultChannelGroup string = "stable"
 )
 
 func convertListeningToVisibility(listening arohcpv1alpha1.ListeningMethod) (visibility api.Visibility) {
@@ -59,6 +70,17 @@ func convertOutboundTypeRPToCS(outboundTypeRP api.OutboundType) (outboundTypeCS
 	return
 }
 
+// convertVersionToCSVersionID returns the Cluster Service version ID for a
+// version profile. Cluster Service version IDs carry a prefix, and a suffix
+// naming the channel group for versions outside the "stable" channel group.
+func convertVersionToCSVersionID(version api.VersionProfile) string {
+	versionID := csVersionPrefix + strings.TrimPrefix(version.ID, csVersionPrefix)
+	if version.ChannelGroup != "" && version.ChannelGroup != csDefaultChannelGroup {
+		versionID += "-" + version.ChannelGroup
+	}
+	return versionID
+}
+
 // ConvertCStoHCPOpenShiftCluster converts a CS Cluster object into HCPOpenShiftCluster object
 func ConvertCStoHCPOpenShiftCluster(resourceID *azcorearm.ResourceID, cluster *arohcpv1alpha1.Cluster) *api.HCPOpenShiftCluster {
 	// A word about ProvisioningState:
@@ -262,6 +284,38 @@ func (f *Frontend) BuildCSCluster(resourceID *azcorearm.ResourceID, requestHeade
 	return clusterBuilder.Build()
 }
 
+// convertCSVersionIDToVersionName converts a Cluster Service version ID to
+// the name of an HCPOpenShiftVersion resource. Names keep the channel group
+// suffix so that versions in different channel groups remain distinct.
+func convertCSVersionIDToVersionName(versionID string) string {
+	return strings.TrimPrefix(versionID, csVersionPrefix)
+}
+
+// convertVersionNameToCSVersionID reverses convertCSVersionIDToVersionName.
+func convertVersionNameToCSVersionID(name string) string {
+	return csVersionPrefix + name
+}
+
+// ConvertCStoHCPOpenShiftVersion converts a CS Version object into an HCPOpenShiftVersion object
+func ConvertCStoHCPOpenShiftVersion(resourceID *azcorearm.ResourceID, version *cmv1.Version) *api.HCPOpenShiftVersion {
+	channelGroup := version.ChannelGroup()
+	if channelGroup == "" {
+		channelGroup = csDefaultChannelGroup
+	}
+
+	return &api.HCPOpenShiftVersion{
+		Resource: arm.Resource{
+			ID:   resourceID.String(),
+			Name: resourceID.Name,
+			Type: resourceID.ResourceType.String(),
+		},
+		Properties: api.HCPOpenShiftVersionProperties{
+			ClusterVersion: version.RawID(),
+			ChannelGroup:   channelGroup,
+		},
+	}
+}
+
 // ConvertCStoNodePool converts a CS Node Pool object into HCPOpenShiftClusterNodePool object
 func ConvertCStoNodePool(resourceID *azcorearm.ResourceID, np *cmv1.NodePool) *api.HCPOpenShiftClusterNodePool {
 	nodePool := &api.HCPOpenShiftClusterNodePool{
@@ -365,6 +419,30 @@ func (f *Frontend) BuildCSNodePool(ctx context.Context, nodePool *api.HCPOpenShi
 	return npBuilder.Build()
 }
 
+// BuildCSControlPlaneUpgradePolicy creates a CS Control Plane Upgrade Policy
+// object that upgrades a cluster control plane to the version of an
+// HCPOpenShiftCluster object as soon as possible.
+func (f *Frontend) BuildCSControlPlaneUpgradePolicy(ctx context.Context, hcpCluster *api.HCPOpenShiftCluster) (*cmv1.ControlPlaneUpgradePolicy, error) {
+	return cmv1.NewControlPlaneUpgradePolicy().
+		UpgradeType(cmv1.UpgradeTypeControlPlane).
+		ScheduleType(cmv1.ScheduleTypeManual).
+		Version(strings.TrimPrefix(hcpCluster.Properties.Version.ID, csVersionPrefix)).
+		NextRun(time.Now()).
+		Build()
+}
+
+// BuildCSNodePoolUpgradePolicy creates a CS Node Pool Upgrade Policy object
+// that upgrades a node pool to the version of an HCPOpenShiftClusterNodePool
+// object as soon as possible.
+func (f *Frontend) BuildCSNodePoolUpgradePolicy(ctx context.Context, nodePool *api.HCPOpenShiftClusterNodePool) (*cmv1.NodePoolUpgradePolicy, error) {
+	return cmv1.NewNodePoolUpgradePolicy().
+		UpgradeType(cmv1.UpgradeTypeNodePool).
+		ScheduleType(cmv1.ScheduleTypeManual).
+		Version(strings.TrimPrefix(nodePool.Properties.Version.ID, csVersionPrefix)).
+		NextRun(time.Now()).
+		Build()
+}
+
 // transportFunc implements the http.RoundTripper interface.
 type transportFunc func(*http.Request) (*http.Response, error)
 
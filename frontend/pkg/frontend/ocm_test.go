@@ -10,6 +10,8 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+	"github.com/Azure/ARO-HCP/internal/ocm/ocmtest"
 )
 
 // Copyright (c) Microsoft Corporation.
@@ -64,3 +66,42 @@ func TestRequestIDPropagator(t *testing.T) {
 		t.Fatalf("expecting %q, got %q", testRequestID, ret)
 	}
 }
+
+func TestRequestIDPropagatorClusterService(t *testing.T) {
+	const testRequestID = "00000000-0000-0000-0000-000000000000"
+
+	server := ocmtest.NewServer(nil, ocmtest.Timing{})
+	defer server.Close()
+
+	conn, err := server.NewConnection(RequestIDPropagator)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer conn.Close()
+
+	csc := &ocm.ClusterServiceClient{Conn: conn}
+
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	correlationData := arm.NewCorrelationData(r)
+	correlationData.RequestID = uuid.MustParse(testRequestID)
+	ctx := ContextWithCorrelationData(context.Background(), correlationData)
+
+	clusterInternalID, err := ocm.NewInternalID("/api/aro_hcp/v1alpha1/clusters/missing")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The cluster does not exist; only the request headers matter.
+	_, _ = csc.GetCluster(ctx, clusterInternalID)
+
+	headers := server.RequestHeaders()
+	if len(headers) != 1 {
+		t.Fatalf("expecting 1 request, got %d", len(headers))
+	}
+	if ret := headers[0].Get(clusterServiceRequestIDHeader); ret != testRequestID {
+		t.Fatalf("expecting %q, got %q", testRequestID, ret)
+	}
+}
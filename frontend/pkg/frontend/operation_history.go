@@ -0,0 +1,214 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+const (
+	// Query parameter names for the operation history endpoint
+	operationHistoryRequestKey = "request"
+	operationHistoryStatusKey  = "status"
+
+	defaultOperationHistoryPageSize = 20
+	maxOperationHistoryPageSize     = 100
+)
+
+// operationHistoryRequests are the values accepted for the request filter.
+var operationHistoryRequests = []database.OperationRequest{
+	database.OperationRequestCreate,
+	database.OperationRequestUpdate,
+	database.OperationRequestDelete,
+	database.OperationRequestRequestCredential,
+	database.OperationRequestRevokeCredentials,
+}
+
+// operationHistoryStatuses are the values accepted for the status filter.
+var operationHistoryStatuses = []arm.ProvisioningState{
+	arm.ProvisioningStateSucceeded,
+	arm.ProvisioningStateFailed,
+	arm.ProvisioningStateCanceled,
+	arm.ProvisioningStateAccepted,
+	arm.ProvisioningStateDeleting,
+	arm.ProvisioningStateProvisioning,
+	arm.ProvisioningStateUpdating,
+}
+
+// operationHistoryEntry is an asynchronous operation as listed by the
+// operation history endpoint. Unlike the ARM operation status, it names
+// the resource and the type of request that started the operation.
+type operationHistoryEntry struct {
+	// Name is the operation ID from the Azure-AsyncOperation header
+	Name string `json:"name"`
+	// ID is the operation status resource ID, which is absent for
+	// operations that were implicit such as deleting a node pool
+	// along with its cluster
+	ID              *azcorearm.ResourceID     `json:"id,omitempty"`
+	ResourceID      *azcorearm.ResourceID     `json:"resourceId"`
+	InternalID      string                    `json:"internalId,omitempty"`
+	Request         database.OperationRequest `json:"request"`
+	Status          arm.ProvisioningState     `json:"status"`
+	StartTime       time.Time                 `json:"startTime"`
+	EndTime         *time.Time                `json:"endTime,omitempty"`
+	PercentComplete float64                   `json:"percentComplete,omitempty"`
+	Error           *arm.CloudErrorBody       `json:"error,omitempty"`
+	ClientRequestID string                    `json:"clientRequestId,omitempty"`
+}
+
+func newOperationHistoryEntry(operationID string, doc *database.OperationDocument) *operationHistoryEntry {
+	entry := &operationHistoryEntry{
+		Name:            operationID,
+		ID:              doc.OperationID,
+		ResourceID:      doc.ExternalID,
+		InternalID:      doc.InternalID.String(),
+		Request:         doc.Request,
+		Status:          doc.Status,
+		StartTime:       doc.StartTime,
+		PercentComplete: doc.PercentComplete,
+		Error:           doc.Error,
+		ClientRequestID: doc.ClientRequestID,
+	}
+
+	if doc.Status.IsTerminal() {
+		entry.EndTime = &doc.LastTransitionTime
+	}
+
+	return entry
+}
+
+// parseOperationHistoryQuery validates the query parameters of an operation
+// history request. Filter values are matched case-insensitively.
+func parseOperationHistoryQuery(request *http.Request) (*azcorearm.ResourceID, database.OperationDocumentFilter, *arm.CloudError) {
+	var filter database.OperationDocumentFilter
+
+	urlQuery := request.URL.Query()
+
+	resourceID, cloudError := parseAdminResourceIDQuery(request)
+	if cloudError != nil {
+		return nil, filter, cloudError
+	}
+
+	if value := urlQuery.Get(operationHistoryRequestKey); value != "" {
+		for _, r := range operationHistoryRequests {
+			if strings.EqualFold(value, string(r)) {
+				filter.Request = r
+				break
+			}
+		}
+		if filter.Request == "" {
+			return nil, filter, arm.NewCloudError(
+				http.StatusBadRequest,
+				arm.CloudErrorCodeInvalidParameter,
+				operationHistoryRequestKey,
+				"The '%s' query parameter '%s' is not a valid request type.",
+				operationHistoryRequestKey, value)
+		}
+	}
+
+	if value := urlQuery.Get(operationHistoryStatusKey); value != "" {
+		for _, s := range operationHistoryStatuses {
+			if strings.EqualFold(value, string(s)) {
+				filter.Status = s
+				break
+			}
+		}
+		if filter.Status == "" {
+			return nil, filter, arm.NewCloudError(
+				http.StatusBadRequest,
+				arm.CloudErrorCodeInvalidParameter,
+				operationHistoryStatusKey,
+				"The '%s' query parameter '%s' is not a valid status.",
+				operationHistoryStatusKey, value)
+		}
+	}
+
+	return resourceID, filter, nil
+}
+
+// AdminOperationList lists the asynchronous operations of a cluster or node
+// pool, most recently started first, including operations that have ended
+// but are still within the backend's operation retention period. Results
+// can be narrowed with "request" and "status" query parameters and paged
+// through with "$top" and "$skipToken".
+//
+// This is a troubleshooting endpoint for SREs, who otherwise can only
+// look up an operation if they know its ID. It is not part of the ARM
+// API and therefore not subject to API version validation.
+func (f *Frontend) AdminOperationList(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	resourceID, filter, cloudError := parseOperationHistoryQuery(request)
+	if cloudError != nil {
+		arm.WriteCloudError(writer, cloudError)
+		return
+	}
+
+	var pageSize int32 = defaultOperationHistoryPageSize
+	var continuationToken *string
+
+	urlQuery := request.URL.Query()
+	if urlQuery.Has("$skipToken") {
+		continuationToken = api.Ptr(urlQuery.Get("$skipToken"))
+	}
+	top, err := strconv.ParseInt(urlQuery.Get("$top"), 10, 32)
+	if err == nil && top > 0 {
+		pageSize = min(int32(top), maxOperationHistoryPageSize)
+	}
+
+	pagedResponse := arm.NewPagedResponse()
+
+	iterator := f.dbClient.ListResourceOperationDocs(resourceID, filter, pageSize, continuationToken)
+
+	for operationID, doc := range iterator.Items(ctx) {
+		value, err := arm.Marshal(newOperationHistoryEntry(operationID, doc))
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+		pagedResponse.AddValue(value)
+	}
+
+	err = iterator.GetError()
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	// Unlike ARM requests, there is no Referer header to base nextLink on.
+	nextLinkBase := url.URL{
+		Scheme:   "http",
+		Host:     request.Host,
+		Path:     request.URL.Path,
+		RawQuery: request.URL.RawQuery,
+	}
+	if request.TLS != nil {
+		nextLinkBase.Scheme = "https"
+	}
+
+	err = pagedResponse.SetNextLink(nextLinkBase.String(), iterator.GetContinuationToken())
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	_, err = arm.WriteJSONResponse(writer, http.StatusOK, pagedResponse)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
@@ -0,0 +1,197 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+)
+
+func newOperationHistoryTestRequest(t *testing.T, query url.Values) *http.Request {
+	t.Helper()
+
+	request := httptest.NewRequest(http.MethodGet, "/admin/v1/operations?"+query.Encode(), nil)
+	return request.WithContext(ContextWithLogger(request.Context(), testLogger))
+}
+
+func TestAdminOperationListValidation(t *testing.T) {
+	f := &Frontend{dbClient: database.NewInMemoryDBClient()}
+
+	tests := []struct {
+		name         string
+		query        url.Values
+		expectTarget string
+	}{
+		{
+			name:         "Missing resource ID",
+			query:        url.Values{},
+			expectTarget: adminResourceIDKey,
+		},
+		{
+			name:         "Not a cluster or node pool",
+			query:        url.Values{adminResourceIDKey: {"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup"}},
+			expectTarget: adminResourceIDKey,
+		},
+		{
+			name: "Invalid request type",
+			query: url.Values{
+				adminResourceIDKey:         {testGroupPrefix + "testCluster"},
+				operationHistoryRequestKey: {"Bogus"},
+			},
+			expectTarget: operationHistoryRequestKey,
+		},
+		{
+			name: "Invalid status",
+			query: url.Values{
+				adminResourceIDKey:        {testGroupPrefix + "testCluster"},
+				operationHistoryStatusKey: {"Bogus"},
+			},
+			expectTarget: operationHistoryStatusKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := httptest.NewRecorder()
+			f.AdminOperationList(writer, newOperationHistoryTestRequest(t, tt.query))
+
+			if writer.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status code %d but got %d", http.StatusBadRequest, writer.Code)
+			}
+
+			var cloudError arm.CloudError
+			if err := json.Unmarshal(writer.Body.Bytes(), &cloudError); err != nil {
+				t.Fatal(err)
+			}
+			if cloudError.CloudErrorBody == nil || cloudError.Target != tt.expectTarget {
+				t.Errorf("Expected error target '%s' but got %s", tt.expectTarget, writer.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminOperationList(t *testing.T) {
+	ctx := context.Background()
+	f := &Frontend{dbClient: database.NewInMemoryDBClient()}
+
+	clusterID := mustParseResourceID(t, testGroupPrefix+"testCluster")
+	startTime := time.Now().UTC()
+
+	createOperation := func(request database.OperationRequest, status arm.ProvisioningState, age time.Duration) string {
+		doc := database.NewOperationDocument(request, clusterID, ocm.InternalID{})
+		doc.Status = status
+		doc.StartTime = startTime.Add(-age)
+		operationID, err := f.dbClient.CreateOperationDoc(ctx, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return operationID
+	}
+
+	failedCreate := createOperation(database.OperationRequestCreate, arm.ProvisioningStateFailed, 3*time.Hour)
+	failedUpdate := createOperation(database.OperationRequestUpdate, arm.ProvisioningStateFailed, 2*time.Hour)
+	activeUpdate := createOperation(database.OperationRequestUpdate, arm.ProvisioningStateUpdating, time.Hour)
+
+	// Follow nextLink until the last page, the way a client would.
+	list := func(t *testing.T, query url.Values) []operationHistoryEntry {
+		var entries []operationHistoryEntry
+
+		request := newOperationHistoryTestRequest(t, query)
+		for {
+			writer := httptest.NewRecorder()
+			f.AdminOperationList(writer, request)
+
+			if writer.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, writer.Code, writer.Body.String())
+			}
+
+			var response struct {
+				Value    []operationHistoryEntry `json:"value"`
+				NextLink string                  `json:"nextLink"`
+			}
+			if err := json.Unmarshal(writer.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, response.Value...)
+
+			if response.NextLink == "" {
+				return entries
+			}
+			request = httptest.NewRequest(http.MethodGet, response.NextLink, nil)
+			request = request.WithContext(ContextWithLogger(request.Context(), testLogger))
+		}
+	}
+
+	tests := []struct {
+		name   string
+		query  url.Values
+		expect []string
+	}{
+		{
+			name:   "All operations",
+			query:  url.Values{adminResourceIDKey: {clusterID.String()}},
+			expect: []string{activeUpdate, failedUpdate, failedCreate},
+		},
+		{
+			name: "All operations in pages",
+			query: url.Values{
+				adminResourceIDKey: {clusterID.String()},
+				"$top":             {"1"},
+			},
+			expect: []string{activeUpdate, failedUpdate, failedCreate},
+		},
+		{
+			name: "Failed operations",
+			query: url.Values{
+				adminResourceIDKey:        {clusterID.String()},
+				operationHistoryStatusKey: {"failed"},
+			},
+			expect: []string{failedUpdate, failedCreate},
+		},
+		{
+			name: "Failed creates",
+			query: url.Values{
+				adminResourceIDKey:         {clusterID.String()},
+				operationHistoryRequestKey: {"Create"},
+				operationHistoryStatusKey:  {"Failed"},
+			},
+			expect: []string{failedCreate},
+		},
+		{
+			name:   "Resource without operations",
+			query:  url.Values{adminResourceIDKey: {testGroupPrefix + "otherCluster"}},
+			expect: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actual []string
+			for _, entry := range list(t, tt.query) {
+				actual = append(actual, entry.Name)
+				if entry.Status.IsTerminal() != (entry.EndTime != nil) {
+					t.Errorf("Operation %s with status %s has end time %v", entry.Name, entry.Status, entry.EndTime)
+				}
+			}
+			if len(actual) != len(tt.expect) {
+				t.Fatalf("Expected operations %v but got %v", tt.expect, actual)
+			}
+			for i := range actual {
+				if actual[i] != tt.expect[i] {
+					t.Errorf("Expected operations %v but got %v", tt.expect, actual)
+					break
+				}
+			}
+		})
+	}
+}
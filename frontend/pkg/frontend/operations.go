@@ -14,6 +14,8 @@ import (
 
 	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
 
 	"github.com/Azure/ARO-HCP/internal/api"
 	"github.com/Azure/ARO-HCP/internal/api/arm"
@@ -86,6 +88,25 @@ func (f *Frontend) AddLocationHeader(writer http.ResponseWriter, request *http.R
 	writer.Header().Set("Location", u.String())
 }
 
+// addOperationHeaders adds callback headers for the given OperationDocument
+// to the ResponseWriter based on the request method, and acknowledges any
+// async notification URI.
+func (f *Frontend) addOperationHeaders(writer http.ResponseWriter, request *http.Request, doc *database.OperationDocument) {
+	// If ARM passed a notification URI, acknowledge it.
+	if doc.NotificationURI != "" {
+		writer.Header().Set(arm.HeaderNameAsyncNotification, "Enabled")
+	}
+
+	// Add callback header(s) based on the request method.
+	switch request.Method {
+	case http.MethodDelete, http.MethodPatch, http.MethodPost:
+		f.AddLocationHeader(writer, request, doc)
+		fallthrough
+	case http.MethodPut:
+		f.AddAsyncOperationHeader(writer, request, doc)
+	}
+}
+
 // ExposeOperation fully initiates a new asynchronous operation by enriching
 // the operation database item and adding the necessary response headers.
 func (f *Frontend) ExposeOperation(writer http.ResponseWriter, request *http.Request, pk azcosmos.PartitionKey, operationID string) error {
@@ -108,20 +129,10 @@ func (f *Frontend) ExposeOperation(writer http.ResponseWriter, request *http.Req
 		updateDoc.ClientID = request.Header.Get(arm.HeaderNameClientObjectID)
 		updateDoc.OperationID = operationID
 		updateDoc.NotificationURI = request.Header.Get(arm.HeaderNameAsyncNotificationURI)
+		updateDoc.ClientRequestID = request.Header.Get(arm.HeaderNameClientRequestID)
+		updateDoc.RequestBodyHash = requestBodyHash(ctx)
 
-		// If ARM passed a notification URI, acknowledge it.
-		if updateDoc.NotificationURI != "" {
-			writer.Header().Set(arm.HeaderNameAsyncNotification, "Enabled")
-		}
-
-		// Add callback header(s) based on the request method.
-		switch request.Method {
-		case http.MethodDelete, http.MethodPatch:
-			f.AddLocationHeader(writer, request, updateDoc)
-			fallthrough
-		case http.MethodPut:
-			f.AddAsyncOperationHeader(writer, request, updateDoc)
-		}
+		f.addOperationHeaders(writer, request, updateDoc)
 
 		return true
 	})
@@ -154,6 +165,149 @@ func (f *Frontend) CancelActiveOperation(ctx context.Context, resourceDoc *datab
 	return nil
 }
 
+// CancelOperation cancels an operation at the request of a client. Only
+// operations backed by something Cluster Service can abort, such as an
+// upgrade policy, can be canceled, and Cluster Service is asked to abort
+// it first. Cluster Service cannot abort creating, updating or deleting a
+// cluster or node pool, so canceling those would only misreport work that
+// carries on regardless. On success the given OperationDocument reflects
+// the stored status, which is not Canceled if the operation ended some
+// other way first.
+func (f *Frontend) CancelOperation(ctx context.Context, pk azcosmos.PartitionKey, operationID string, doc *database.OperationDocument) *arm.CloudError {
+	var err error
+
+	logger := LoggerFromContext(ctx)
+
+	if doc.Status.IsTerminal() {
+		return arm.NewCloudError(
+			http.StatusConflict,
+			arm.CloudErrorCodeConflict,
+			doc.OperationID.String(),
+			"Cannot cancel operation because it is already %s",
+			strings.ToLower(string(doc.Status)))
+	}
+
+	if doc.Request == database.OperationRequestDelete {
+		return arm.NewCloudError(
+			http.StatusConflict,
+			arm.CloudErrorCodeConflict,
+			doc.OperationID.String(),
+			"Cannot cancel a delete operation")
+	}
+
+	switch doc.InternalID.Kind() {
+	case cmv1.ControlPlaneUpgradePolicyKind:
+		err = f.clusterServiceClient.DeleteControlPlaneUpgradePolicy(ctx, doc.InternalID)
+	case cmv1.NodePoolUpgradePolicyKind:
+		err = f.clusterServiceClient.DeleteNodePoolUpgradePolicy(ctx, doc.InternalID)
+	default:
+		return arm.NewCloudError(
+			http.StatusConflict,
+			arm.CloudErrorCodeConflict,
+			doc.OperationID.String(),
+			"Operation cannot be canceled")
+	}
+
+	if err != nil {
+		var ocmError *ocmerrors.Error
+		if !errors.As(err, &ocmError) {
+			logger.Error(err.Error())
+			return arm.NewInternalServerError()
+		}
+		switch status := ocmError.Status(); {
+		case status == http.StatusNotFound:
+			// Nothing left in Cluster Service to abort.
+		case status < http.StatusInternalServerError:
+			// Cluster Service refuses to abort work that
+			// has progressed too far, such as an upgrade
+			// that is already underway.
+			logger.Info(err.Error())
+			return arm.NewCloudError(
+				http.StatusConflict,
+				arm.CloudErrorCodeConflict,
+				doc.OperationID.String(),
+				"Operation can no longer be canceled")
+		default:
+			logger.Error(err.Error())
+			return arm.NewInternalServerError()
+		}
+	}
+
+	opError := &arm.CloudErrorBody{
+		Code:    arm.CloudErrorCodeCanceled,
+		Message: "The operation was canceled",
+	}
+
+	updated, err := f.endOperation(ctx, pk, operationID, doc, arm.ProvisioningStateCanceled, opError)
+	if err != nil {
+		logger.Error(err.Error())
+		return arm.NewInternalServerError()
+	}
+	if updated {
+		logger.Info(fmt.Sprintf("Canceled operation '%s'", operationID))
+	}
+
+	return nil
+}
+
+// endOperation sets a terminal status on an operation and, if the operation
+// is active on its resource, sets the same provisioning state on the resource.
+// It returns true if the operation status was updated, or false if something
+// else ended the operation first. On success the given OperationDocument
+// reflects the stored status either way.
+func (f *Frontend) endOperation(ctx context.Context, pk azcosmos.PartitionKey, operationID string, doc *database.OperationDocument, status arm.ProvisioningState, opError *arm.CloudErrorBody) (bool, error) {
+	updated, err := f.dbClient.UpdateOperationDoc(ctx, pk, operationID, func(updateDoc *database.OperationDocument) bool {
+		if updateDoc.Status.IsTerminal() || !updateDoc.UpdateStatus(status, opError) {
+			return false
+		}
+		// The backend posts async notifications, so
+		// leave the notification to the backend.
+		updateDoc.NotificationPending = (updateDoc.NotificationURI != "")
+		return true
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if !updated {
+		current, err := f.dbClient.GetOperationDoc(ctx, pk, operationID)
+		if err != nil {
+			return false, err
+		}
+		*doc = *current
+		return false, nil
+	}
+
+	doc.UpdateStatus(status, opError)
+
+	_, err = f.dbClient.UpdateResourceDoc(ctx, doc.ExternalID, func(updateDoc *database.ResourceDocument) bool {
+		if !strings.EqualFold(updateDoc.ActiveOperationID, operationID) {
+			return false
+		}
+		updateDoc.ActiveOperationID = ""
+		updateDoc.ProvisioningState = status
+		return true
+	})
+	// Disregard "not found" errors; the resource may have since been deleted.
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// newOperationNotFoundError creates a CloudError for an operation that does not
+// exist or is not visible to the client. The backend deletes operations some
+// time after they complete, so the operation may also have expired.
+func newOperationNotFoundError(operationID *azcorearm.ResourceID) *arm.CloudError {
+	return arm.NewCloudError(
+		http.StatusNotFound,
+		arm.CloudErrorCodeNotFound,
+		operationID.String(),
+		"The operation '%s' was not found. Operations are only retained for a limited time after they complete.",
+		operationID.Name)
+}
+
 // OperationIsVisible returns true if the request is being called from the same
 // tenant and subscription that the operation originated in.
 func (f *Frontend) OperationIsVisible(request *http.Request, operationID string, doc *database.OperationDocument) bool {
@@ -187,3 +341,22 @@ func (f *Frontend) OperationIsVisible(request *http.Request, operationID string,
 
 	return visible
 }
+
+// newOperationCloudError returns a CloudError for an operation that did not
+// succeed, suitable for an operation result response. This mimics how the
+// operation would have failed had it been performed synchronously.
+func newOperationCloudError(doc *database.OperationDocument) *arm.CloudError {
+	if doc.Error == nil {
+		return arm.NewInternalServerError()
+	}
+
+	statusCode := http.StatusInternalServerError
+	if doc.Status == arm.ProvisioningStateCanceled {
+		statusCode = http.StatusConflict
+	}
+
+	return &arm.CloudError{
+		StatusCode:     statusCode,
+		CloudErrorBody: doc.Error,
+	}
+}
@@ -0,0 +1,275 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+	"go.uber.org/mock/gomock"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+	"github.com/Azure/ARO-HCP/internal/mocks"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+)
+
+func TestCancelOperation(t *testing.T) {
+	const nodePoolResourceID = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/testCluster/nodePools/testNodePool"
+	const nodePoolHREF = "/api/clusters_mgmt/v1/clusters/abc/node_pools/def"
+	const upgradePolicyHREF = nodePoolHREF + "/upgrade_policies/ghi"
+
+	csBadRequest, err := ocmerrors.NewError().Status(http.StatusBadRequest).Reason("Upgrade already started").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                 string
+		operationRequest     database.OperationRequest
+		operationStatus      arm.ProvisioningState
+		internalHREF         string
+		notificationURI      string
+		csDeleteError        error
+		expectCSDelete       bool
+		expectStatusCode     int
+		expectResourceStatus arm.ProvisioningState
+	}{
+		{
+			name:                 "Cancel node pool update",
+			operationRequest:     database.OperationRequestUpdate,
+			operationStatus:      arm.ProvisioningStateUpdating,
+			internalHREF:         nodePoolHREF,
+			expectStatusCode:     http.StatusConflict,
+			expectResourceStatus: arm.ProvisioningStateUpdating,
+		},
+		{
+			name:                 "Cancel node pool creation",
+			operationRequest:     database.OperationRequestCreate,
+			operationStatus:      arm.ProvisioningStateProvisioning,
+			internalHREF:         nodePoolHREF,
+			expectStatusCode:     http.StatusConflict,
+			expectResourceStatus: arm.ProvisioningStateProvisioning,
+		},
+		{
+			name:                 "Cancel node pool upgrade",
+			operationRequest:     database.OperationRequestUpdate,
+			operationStatus:      arm.ProvisioningStateAccepted,
+			internalHREF:         upgradePolicyHREF,
+			notificationURI:      "https://example.com/notify",
+			expectCSDelete:       true,
+			expectResourceStatus: arm.ProvisioningStateCanceled,
+		},
+		{
+			name:                 "Cancel node pool upgrade already underway",
+			operationRequest:     database.OperationRequestUpdate,
+			operationStatus:      arm.ProvisioningStateUpdating,
+			internalHREF:         upgradePolicyHREF,
+			csDeleteError:        csBadRequest,
+			expectCSDelete:       true,
+			expectStatusCode:     http.StatusConflict,
+			expectResourceStatus: arm.ProvisioningStateUpdating,
+		},
+		{
+			name:                 "Cancel node pool deletion",
+			operationRequest:     database.OperationRequestDelete,
+			operationStatus:      arm.ProvisioningStateDeleting,
+			internalHREF:         nodePoolHREF,
+			expectStatusCode:     http.StatusConflict,
+			expectResourceStatus: arm.ProvisioningStateDeleting,
+		},
+		{
+			name:                 "Cancel completed operation",
+			operationRequest:     database.OperationRequestUpdate,
+			operationStatus:      arm.ProvisioningStateSucceeded,
+			internalHREF:         nodePoolHREF,
+			expectStatusCode:     http.StatusConflict,
+			expectResourceStatus: arm.ProvisioningStateSucceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ContextWithLogger(context.Background(), testLogger)
+			ctrl := gomock.NewController(t)
+			mockCSClient := mocks.NewMockClusterServiceClientSpec(ctrl)
+			dbClient := database.NewInMemoryDBClient()
+
+			frontend := &Frontend{
+				clusterServiceClient: mockCSClient,
+				dbClient:             dbClient,
+			}
+
+			resourceID, err := azcorearm.ParseResourceID(nodePoolResourceID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			internalID, err := ocm.NewInternalID(tt.internalHREF)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			operationDoc := database.NewOperationDocument(tt.operationRequest, resourceID, internalID)
+			operationDoc.Status = tt.operationStatus
+			operationDoc.NotificationURI = tt.notificationURI
+
+			operationID, err := dbClient.CreateOperationDoc(ctx, operationDoc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resourceDoc := database.NewResourceDocument(resourceID)
+			resourceDoc.ActiveOperationID = operationID
+			resourceDoc.ProvisioningState = tt.operationStatus
+
+			err = dbClient.CreateResourceDoc(ctx, resourceDoc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expectCSDelete {
+				mockCSClient.EXPECT().
+					DeleteNodePoolUpgradePolicy(gomock.Any(), internalID).
+					Return(tt.csDeleteError)
+			}
+
+			pk := database.NewPartitionKey(resourceID.SubscriptionID)
+			cloudError := frontend.CancelOperation(ctx, pk, operationID, operationDoc)
+
+			if tt.expectStatusCode == 0 {
+				if cloudError != nil {
+					t.Fatalf("Got unexpected error: %v", cloudError)
+				}
+			} else {
+				if cloudError == nil {
+					t.Fatalf("Expected %d %s but got no error", tt.expectStatusCode, http.StatusText(tt.expectStatusCode))
+				}
+				if cloudError.StatusCode != tt.expectStatusCode {
+					t.Errorf("Expected %d %s but got %d %s",
+						tt.expectStatusCode, http.StatusText(tt.expectStatusCode),
+						cloudError.StatusCode, http.StatusText(cloudError.StatusCode))
+				}
+			}
+
+			storedOperationDoc, err := dbClient.GetOperationDoc(ctx, pk, operationID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if cloudError == nil {
+				if storedOperationDoc.Status != arm.ProvisioningStateCanceled {
+					t.Errorf("Expected operation status '%s' but got '%s'", arm.ProvisioningStateCanceled, storedOperationDoc.Status)
+				}
+				if operationDoc.Status != arm.ProvisioningStateCanceled {
+					t.Errorf("Expected returned operation status '%s' but got '%s'", arm.ProvisioningStateCanceled, operationDoc.Status)
+				}
+				if storedOperationDoc.NotificationPending != (tt.notificationURI != "") {
+					t.Errorf("Expected pending notification to be %t", tt.notificationURI != "")
+				}
+			} else if storedOperationDoc.Status != tt.operationStatus {
+				t.Errorf("Operation status unexpectedly changed to '%s'", storedOperationDoc.Status)
+			}
+
+			storedResourceDoc, err := dbClient.GetResourceDoc(ctx, resourceID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if storedResourceDoc.ProvisioningState != tt.expectResourceStatus {
+				t.Errorf("Expected provisioning state '%s' but got '%s'", tt.expectResourceStatus, storedResourceDoc.ProvisioningState)
+			}
+		})
+	}
+}
+
+func TestCancelOperationLostRace(t *testing.T) {
+	const nodePoolResourceID = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/testCluster/nodePools/testNodePool"
+	const upgradePolicyHREF = "/api/clusters_mgmt/v1/clusters/abc/node_pools/def/upgrade_policies/ghi"
+
+	ctx := ContextWithLogger(context.Background(), testLogger)
+	ctrl := gomock.NewController(t)
+	mockCSClient := mocks.NewMockClusterServiceClientSpec(ctrl)
+	dbClient := database.NewInMemoryDBClient()
+
+	frontend := &Frontend{
+		clusterServiceClient: mockCSClient,
+		dbClient:             dbClient,
+	}
+
+	resourceID, err := azcorearm.ParseResourceID(nodePoolResourceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internalID, err := ocm.NewInternalID(upgradePolicyHREF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The upgrade policy is gone once the upgrade completes.
+	csNotFound, err := ocmerrors.NewError().Status(http.StatusNotFound).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockCSClient.EXPECT().
+		DeleteNodePoolUpgradePolicy(gomock.Any(), internalID).
+		Return(csNotFound)
+
+	operationDoc := database.NewOperationDocument(database.OperationRequestUpdate, resourceID, internalID)
+	operationDoc.Status = arm.ProvisioningStateUpdating
+
+	operationID, err := dbClient.CreateOperationDoc(ctx, operationDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resourceDoc := database.NewResourceDocument(resourceID)
+	resourceDoc.ActiveOperationID = operationID
+	resourceDoc.ProvisioningState = arm.ProvisioningStateUpdating
+
+	err = dbClient.CreateResourceDoc(ctx, resourceDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The operation succeeds after the caller read it.
+	pk := database.NewPartitionKey(resourceID.SubscriptionID)
+	_, err = dbClient.UpdateOperationDoc(ctx, pk, operationID, func(updateDoc *database.OperationDocument) bool {
+		return updateDoc.UpdateStatus(arm.ProvisioningStateSucceeded, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cloudError := frontend.CancelOperation(ctx, pk, operationID, operationDoc)
+	if cloudError != nil {
+		t.Fatalf("Got unexpected error: %v", cloudError)
+	}
+
+	if operationDoc.Status != arm.ProvisioningStateSucceeded {
+		t.Errorf("Expected returned operation status '%s' but got '%s'", arm.ProvisioningStateSucceeded, operationDoc.Status)
+	}
+
+	storedOperationDoc, err := dbClient.GetOperationDoc(ctx, pk, operationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if storedOperationDoc.Status != arm.ProvisioningStateSucceeded {
+		t.Errorf("Operation status unexpectedly changed to '%s'", storedOperationDoc.Status)
+	}
+
+	storedResourceDoc, err := dbClient.GetResourceDoc(ctx, resourceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if storedResourceDoc.ProvisioningState == arm.ProvisioningStateCanceled {
+		t.Errorf("Resource provisioning state unexpectedly changed to '%s'", storedResourceDoc.ProvisioningState)
+	}
+}
@@ -0,0 +1,310 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+// validatedResource is a resource from a deployment preflight request
+// that passed static validation. Exactly one of cluster or nodePool is set.
+type validatedResource struct {
+	resourceID *azcorearm.ResourceID
+	cluster    *api.HCPOpenShiftCluster
+	nodePool   *api.HCPOpenShiftClusterNodePool
+}
+
+// preflightError returns a preflight error for a resource.
+func preflightError(resourceID *azcorearm.ResourceID, code, format string, a ...any) arm.CloudErrorBody {
+	return arm.CloudErrorBody{
+		Code:    code,
+		Message: fmt.Sprintf(format, a...),
+		Target:  resourceID.String(),
+	}
+}
+
+// deploymentPreflightChecks checks the resources in a deployment preflight
+// request beyond what static validation can: against each other, against
+// existing resources in the subscription and its quota, and against what
+// Cluster Service offers. Like the rest of deployment preflight the checks
+// are best-effort, so anything that cannot be checked is skipped. Failure to
+// reach Cluster Service, in particular, is logged but is not a validation
+// failure.
+func (f *Frontend) deploymentPreflightChecks(ctx context.Context, subscriptionID string, resources []validatedResource) []arm.CloudErrorBody {
+	var preflightErrors []arm.CloudErrorBody
+
+	if len(resources) == 0 {
+		return nil
+	}
+
+	logger := LoggerFromContext(ctx)
+
+	existing, err := f.listSubscriptionResourceDocs(ctx, subscriptionID)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Skipping preflight checks against existing resources: %v", err))
+	} else {
+		preflightErrors = append(preflightErrors, f.checkPreflightResources(ctx, resources, existing)...)
+		preflightErrors = append(preflightErrors, f.checkPreflightQuota(f.quotaFromContext(ctx), resources, existing)...)
+	}
+
+	preflightErrors = append(preflightErrors, f.checkPreflightSubnets(ctx, resources, existing)...)
+	preflightErrors = append(preflightErrors, f.checkPreflightClusterService(ctx, resources)...)
+
+	return preflightErrors
+}
+
+// listSubscriptionResourceDocs returns the cluster and node pool documents in
+// a subscription, keyed by lowercase resource ID.
+func (f *Frontend) listSubscriptionResourceDocs(ctx context.Context, subscriptionID string) (map[string]*database.ResourceDocument, error) {
+	prefix, err := azcorearm.ParseResourceID("/subscriptions/" + subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make(map[string]*database.ResourceDocument)
+
+	iterator := f.dbClient.ListResourceDocs(prefix, -1, nil)
+	for _, doc := range iterator.Items(ctx) {
+		docs[strings.ToLower(doc.ResourceID.String())] = doc
+	}
+
+	err = iterator.GetError()
+	if err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+// checkPreflightResources checks for resources that appear more than once
+// in the deployment, node pools whose parent cluster neither appears in the
+// deployment nor exists, and existing resources that cannot be updated.
+func (f *Frontend) checkPreflightResources(ctx context.Context, resources []validatedResource, existing map[string]*database.ResourceDocument) []arm.CloudErrorBody {
+	var preflightErrors []arm.CloudErrorBody
+
+	deployed := make(map[string]bool)
+
+	for _, resource := range resources {
+		key := strings.ToLower(resource.resourceID.String())
+
+		if deployed[key] {
+			preflightErrors = append(preflightErrors, preflightError(
+				resource.resourceID, arm.CloudErrorCodeConflict,
+				"Resource '%s' appears more than once in the deployment",
+				resource.resourceID.Name))
+			continue
+		}
+
+		deployed[key] = true
+	}
+
+	for _, resource := range resources {
+		key := strings.ToLower(resource.resourceID.String())
+
+		if resource.nodePool != nil {
+			parentKey := strings.ToLower(resource.resourceID.Parent.String())
+			if _, ok := existing[parentKey]; !ok && !deployed[parentKey] {
+				preflightErrors = append(preflightErrors, preflightError(
+					resource.resourceID, arm.CloudErrorCodeResourceNotFound,
+					"Parent cluster '%s' is not in the deployment and does not exist",
+					resource.resourceID.Parent.Name))
+				continue
+			}
+		}
+
+		if doc, ok := existing[key]; ok {
+			// Deploying an existing resource updates it.
+			cloudError := f.CheckForProvisioningStateConflict(ctx, database.OperationRequestUpdate, doc)
+			if cloudError != nil {
+				preflightErrors = append(preflightErrors, *cloudError.CloudErrorBody)
+			}
+		}
+	}
+
+	return preflightErrors
+}
+
+// checkPreflightQuota checks that creating the new resources in the
+// deployment would not exceed the subscription's quota.
+func (f *Frontend) checkPreflightQuota(quota ResourceQuota, resources []validatedResource, existing map[string]*database.ResourceDocument) []arm.CloudErrorBody {
+	var preflightErrors []arm.CloudErrorBody
+
+	var clusterCount int
+	nodePoolCounts := make(map[string]int)
+
+	countResource := func(resourceID *azcorearm.ResourceID) {
+		switch {
+		case strings.EqualFold(resourceID.ResourceType.String(), api.ClusterResourceType.String()):
+			clusterCount++
+		case strings.EqualFold(resourceID.ResourceType.String(), api.NodePoolResourceType.String()):
+			nodePoolCounts[strings.ToLower(resourceID.Parent.String())]++
+		}
+	}
+
+	for _, doc := range existing {
+		countResource(doc.ResourceID)
+	}
+
+	for _, resource := range resources {
+		key := strings.ToLower(resource.resourceID.String())
+		if _, ok := existing[key]; ok {
+			continue
+		}
+
+		countResource(resource.resourceID)
+
+		if resource.cluster != nil && clusterCount > quota.ClustersPerSubscription {
+			preflightErrors = append(preflightErrors, preflightError(
+				resource.resourceID, arm.CloudErrorCodeQuotaExceeded,
+				"Cannot create cluster '%s': the subscription is limited to %d clusters",
+				resource.resourceID.Name, quota.ClustersPerSubscription))
+		}
+
+		if resource.nodePool != nil && nodePoolCounts[strings.ToLower(resource.resourceID.Parent.String())] > quota.NodePoolsPerCluster {
+			preflightErrors = append(preflightErrors, preflightError(
+				resource.resourceID, arm.CloudErrorCodeQuotaExceeded,
+				"Cannot create node pool '%s': cluster '%s' is limited to %d node pools",
+				resource.resourceID.Name, resource.resourceID.Parent.Name, quota.NodePoolsPerCluster))
+		}
+	}
+
+	return preflightErrors
+}
+
+// checkPreflightSubnets checks that node pools with their own subnet keep
+// it in the virtual network of their parent cluster, which is taken from the
+// deployment if the cluster appears in it or else from Cluster Service.
+func (f *Frontend) checkPreflightSubnets(ctx context.Context, resources []validatedResource, existing map[string]*database.ResourceDocument) []arm.CloudErrorBody {
+	var preflightErrors []arm.CloudErrorBody
+
+	logger := LoggerFromContext(ctx)
+
+	clusterSubnetIDs := make(map[string]string)
+	for _, resource := range resources {
+		if resource.cluster != nil {
+			clusterSubnetIDs[strings.ToLower(resource.resourceID.String())] = resource.cluster.Properties.Platform.SubnetID
+		}
+	}
+
+	for _, resource := range resources {
+		if resource.nodePool == nil || resource.nodePool.Properties.Platform.SubnetID == "" {
+			continue
+		}
+
+		parentKey := strings.ToLower(resource.resourceID.Parent.String())
+
+		clusterSubnetID, ok := clusterSubnetIDs[parentKey]
+		if !ok {
+			doc, ok := existing[parentKey]
+			if !ok {
+				continue
+			}
+
+			csCluster, err := f.clusterServiceClient.GetCluster(ctx, doc.InternalID)
+			if err != nil {
+				logger.Warn(fmt.Sprintf("Failed to get cluster '%s' from Cluster Service: %v", doc.ResourceID, err))
+				continue
+			}
+
+			clusterSubnetID = csCluster.Azure().SubnetResourceID()
+			clusterSubnetIDs[parentKey] = clusterSubnetID
+		}
+
+		errorDetails := api.ValidateNodePoolSubnet(clusterSubnetID, resource.nodePool.Properties.Platform.SubnetID)
+		if len(errorDetails) > 0 {
+			preflightErrors = append(preflightErrors, arm.CloudErrorBody{
+				Code:    arm.CloudErrorCodeInvalidRequestContent,
+				Message: fmt.Sprintf("Content validation failed for '%s'", resource.resourceID.Name),
+				Target:  resource.resourceID.String(),
+				Details: errorDetails,
+			})
+		}
+	}
+
+	return preflightErrors
+}
+
+// checkPreflightClusterService asks Cluster Service whether it offers the
+// versions and VM sizes requested by resources in the deployment.
+func (f *Frontend) checkPreflightClusterService(ctx context.Context, resources []validatedResource) []arm.CloudErrorBody {
+	var preflightErrors []arm.CloudErrorBody
+
+	logger := LoggerFromContext(ctx)
+
+	// Remember the outcome of each lookup since a deployment
+	// often requests the same version or VM size repeatedly.
+	versionErrors := make(map[string]string)
+	vmSizeErrors := make(map[string]string)
+
+	checkVersion := func(resourceID *azcorearm.ResourceID, version api.VersionProfile) {
+		if version.ID == "" {
+			return
+		}
+
+		csVersionID := convertVersionToCSVersionID(version)
+
+		message, ok := versionErrors[csVersionID]
+		if !ok {
+			csVersion, err := f.clusterServiceClient.GetVersion(ctx, csVersionID)
+			var ocmError *ocmerrors.Error
+			switch {
+			case errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound:
+				message = fmt.Sprintf("Version '%s' is not available in channel group '%s'", version.ID, version.ChannelGroup)
+			case err != nil:
+				logger.Warn(fmt.Sprintf("Failed to get version '%s' from Cluster Service: %v", csVersionID, err))
+			case !csVersion.Enabled() || !csVersion.HostedControlPlaneEnabled():
+				message = fmt.Sprintf("Version '%s' is not enabled", version.ID)
+			}
+			versionErrors[csVersionID] = message
+		}
+
+		if message != "" {
+			preflightErrors = append(preflightErrors, preflightError(
+				resourceID, arm.CloudErrorCodeInvalidRequestContent, "%s", message))
+		}
+	}
+
+	checkVMSize := func(resourceID *azcorearm.ResourceID, vmSize string) {
+		message, ok := vmSizeErrors[vmSize]
+		if !ok {
+			_, err := f.clusterServiceClient.GetMachineType(ctx, vmSize)
+			var ocmError *ocmerrors.Error
+			switch {
+			case errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound:
+				message = fmt.Sprintf("VM size '%s' is not available", vmSize)
+			case err != nil:
+				logger.Warn(fmt.Sprintf("Failed to get machine type '%s' from Cluster Service: %v", vmSize, err))
+			}
+			vmSizeErrors[vmSize] = message
+		}
+
+		if message != "" {
+			preflightErrors = append(preflightErrors, preflightError(
+				resourceID, arm.CloudErrorCodeInvalidRequestContent, "%s", message))
+		}
+	}
+
+	for _, resource := range resources {
+		switch {
+		case resource.cluster != nil:
+			checkVersion(resource.resourceID, resource.cluster.Properties.Version)
+		case resource.nodePool != nil:
+			checkVersion(resource.resourceID, resource.nodePool.Properties.Version)
+			checkVMSize(resource.resourceID, resource.nodePool.Properties.Platform.VMSize)
+		}
+	}
+
+	return preflightErrors
+}
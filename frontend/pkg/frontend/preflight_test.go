@@ -0,0 +1,270 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"testing"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+	"github.com/Azure/ARO-HCP/internal/ocm/ocmtest"
+)
+
+const (
+	testGroupPrefix  = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/"
+	otherGroupPrefix = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/otherGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/"
+	vnetPrefix       = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/testGroup/providers/Microsoft.Network/virtualNetworks/"
+)
+
+func newPreflightCluster(t *testing.T, resourceID, versionID, channelGroup string) validatedResource {
+	t.Helper()
+
+	cluster := api.NewDefaultHCPOpenShiftCluster()
+	cluster.Properties.Version.ID = versionID
+	cluster.Properties.Version.ChannelGroup = channelGroup
+
+	return validatedResource{resourceID: mustParseResourceID(t, resourceID), cluster: cluster}
+}
+
+func newPreflightNodePool(t *testing.T, resourceID, vmSize string) validatedResource {
+	t.Helper()
+
+	nodePool := api.NewDefaultHCPOpenShiftClusterNodePool()
+	nodePool.Properties.Version.ID = "openshift-v4.17.0"
+	nodePool.Properties.Version.ChannelGroup = "stable"
+	nodePool.Properties.Platform.VMSize = vmSize
+
+	return validatedResource{resourceID: mustParseResourceID(t, resourceID), nodePool: nodePool}
+}
+
+func mustParseResourceID(t *testing.T, resourceID string) *azcorearm.ResourceID {
+	t.Helper()
+
+	parsed, err := azcorearm.ParseResourceID(resourceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestDeploymentPreflightChecks(t *testing.T) {
+	tests := []struct {
+		name         string
+		existing     map[string]arm.ProvisioningState
+		quota        *ResourceQuota
+		resources    func(t *testing.T) []validatedResource
+		expectErrors []string
+	}{
+		{
+			name: "New cluster and node pool",
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.17.0", "stable"),
+					newPreflightNodePool(t, testGroupPrefix+"testCluster/nodePools/testNodePool", "Standard_D8s_v3"),
+				}
+			},
+		},
+		{
+			name: "Node pool of existing cluster",
+			existing: map[string]arm.ProvisioningState{
+				testGroupPrefix + "testCluster": arm.ProvisioningStateSucceeded,
+			},
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightNodePool(t, testGroupPrefix+"testCluster/nodePools/testNodePool", "Standard_D8s_v3"),
+				}
+			},
+		},
+		{
+			name: "Node pool without parent cluster",
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightNodePool(t, testGroupPrefix+"testCluster/nodePools/testNodePool", "Standard_D8s_v3"),
+				}
+			},
+			expectErrors: []string{arm.CloudErrorCodeResourceNotFound},
+		},
+		{
+			name: "Resource appears twice",
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.17.0", "stable"),
+					newPreflightCluster(t, testGroupPrefix+"TESTCLUSTER", "openshift-v4.17.0", "stable"),
+				}
+			},
+			expectErrors: []string{arm.CloudErrorCodeConflict},
+		},
+		{
+			name: "Existing resource is updatable",
+			existing: map[string]arm.ProvisioningState{
+				testGroupPrefix + "testCluster": arm.ProvisioningStateSucceeded,
+			},
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.17.0", "stable"),
+				}
+			},
+		},
+		{
+			name: "Existing resource is still provisioning",
+			existing: map[string]arm.ProvisioningState{
+				testGroupPrefix + "testCluster": arm.ProvisioningStateProvisioning,
+			},
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.17.0", "stable"),
+				}
+			},
+			expectErrors: []string{arm.CloudErrorCodeConflict},
+		},
+		{
+			name: "Cluster name taken in another resource group",
+			existing: map[string]arm.ProvisioningState{
+				otherGroupPrefix + "testCluster": arm.ProvisioningStateSucceeded,
+			},
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.17.0", "stable"),
+				}
+			},
+		},
+		{
+			name: "Cluster quota exceeded",
+			existing: map[string]arm.ProvisioningState{
+				otherGroupPrefix + "otherCluster": arm.ProvisioningStateSucceeded,
+			},
+			quota: &ResourceQuota{ClustersPerSubscription: 1, NodePoolsPerCluster: 1},
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.17.0", "stable"),
+				}
+			},
+			expectErrors: []string{arm.CloudErrorCodeQuotaExceeded},
+		},
+		{
+			name:  "Node pool quota exceeded",
+			quota: &ResourceQuota{ClustersPerSubscription: 1, NodePoolsPerCluster: 1},
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.17.0", "stable"),
+					newPreflightNodePool(t, testGroupPrefix+"testCluster/nodePools/testNodePool1", "Standard_D8s_v3"),
+					newPreflightNodePool(t, testGroupPrefix+"testCluster/nodePools/testNodePool2", "Standard_D8s_v3"),
+				}
+			},
+			expectErrors: []string{arm.CloudErrorCodeQuotaExceeded},
+		},
+		{
+			name: "Version in another channel group",
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightCluster(t, testGroupPrefix+"testCluster", "4.18.0", "candidate"),
+				}
+			},
+		},
+		{
+			name: "Unknown version",
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.0.0", "stable"),
+				}
+			},
+			expectErrors: []string{arm.CloudErrorCodeInvalidRequestContent},
+		},
+		{
+			name: "Disabled version",
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.15.0", "stable"),
+				}
+			},
+			expectErrors: []string{arm.CloudErrorCodeInvalidRequestContent},
+		},
+		{
+			name: "Unknown VM size",
+			resources: func(t *testing.T) []validatedResource {
+				return []validatedResource{
+					newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.17.0", "stable"),
+					newPreflightNodePool(t, testGroupPrefix+"testCluster/nodePools/testNodePool1", "Standard_Z1"),
+					newPreflightNodePool(t, testGroupPrefix+"testCluster/nodePools/testNodePool2", "Standard_Z1"),
+				}
+			},
+			expectErrors: []string{arm.CloudErrorCodeInvalidRequestContent, arm.CloudErrorCodeInvalidRequestContent},
+		},
+		{
+			name: "Node pool subnet in cluster virtual network",
+			resources: func(t *testing.T) []validatedResource {
+				cluster := newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.17.0", "stable")
+				cluster.cluster.Properties.Platform.SubnetID = vnetPrefix + "testVNet/subnets/clusterSubnet"
+				nodePool := newPreflightNodePool(t, testGroupPrefix+"testCluster/nodePools/testNodePool", "Standard_D8s_v3")
+				nodePool.nodePool.Properties.Platform.SubnetID = vnetPrefix + "testVNet/subnets/nodePoolSubnet"
+				return []validatedResource{cluster, nodePool}
+			},
+		},
+		{
+			name: "Node pool subnet outside cluster virtual network",
+			resources: func(t *testing.T) []validatedResource {
+				cluster := newPreflightCluster(t, testGroupPrefix+"testCluster", "openshift-v4.17.0", "stable")
+				cluster.cluster.Properties.Platform.SubnetID = vnetPrefix + "testVNet/subnets/clusterSubnet"
+				nodePool := newPreflightNodePool(t, testGroupPrefix+"testCluster/nodePools/testNodePool", "Standard_D8s_v3")
+				nodePool.nodePool.Properties.Platform.SubnetID = vnetPrefix + "otherVNet/subnets/nodePoolSubnet"
+				return []validatedResource{cluster, nodePool}
+			},
+			expectErrors: []string{arm.CloudErrorCodeInvalidRequestContent},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ContextWithLogger(context.Background(), testLogger)
+			dbClient := database.NewInMemoryDBClient()
+
+			server := ocmtest.NewServer(nil, ocmtest.Timing{})
+			defer server.Close()
+
+			conn, err := server.NewConnection(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			frontend := &Frontend{
+				clusterServiceClient: &ocm.ClusterServiceClient{Conn: conn},
+				dbClient:             dbClient,
+				quota:                DefaultResourceQuota,
+			}
+			if tt.quota != nil {
+				frontend.quota = *tt.quota
+			}
+
+			for resourceID, provisioningState := range tt.existing {
+				resourceDoc := database.NewResourceDocument(mustParseResourceID(t, resourceID))
+				resourceDoc.ProvisioningState = provisioningState
+
+				err = dbClient.CreateResourceDoc(ctx, resourceDoc)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			preflightErrors := frontend.deploymentPreflightChecks(ctx, "00000000-0000-0000-0000-000000000000", tt.resources(t))
+
+			if len(preflightErrors) != len(tt.expectErrors) {
+				t.Fatalf("Expected %d preflight errors but got %d: %v", len(tt.expectErrors), len(preflightErrors), preflightErrors)
+			}
+			for index, preflightError := range preflightErrors {
+				if preflightError.Code != tt.expectErrors[index] {
+					t.Errorf("Expected error code '%s' but got '%s': %s", tt.expectErrors[index], preflightError.Code, preflightError.Message)
+				}
+				if preflightError.Target == "" {
+					t.Errorf("Expected error '%s' to have a target", preflightError.Message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+)
+
+// providerSegment describes a literal path segment of a mux pattern.
+// Mux patterns are lowercase, so the segment's canonical spelling is
+// needed to form role-based access control operation names.
+type providerSegment struct {
+	name        string
+	display     string
+	description string
+}
+
+// providerResourceTypes maps lowercase resource type segments of mux
+// patterns to their canonical names and plural display names.
+var providerResourceTypes = map[string]providerSegment{
+	"locations":  {name: "locations", display: "Locations"},
+	"operations": {name: "operations", display: "Operations"},
+	"usages":     {name: "usages", display: "Usages"},
+	strings.ToLower(api.ClusterResourceTypeName):         {name: api.ClusterResourceTypeName, display: api.ResourceTypeDisplay},
+	strings.ToLower(api.NodePoolResourceTypeName):        {name: api.NodePoolResourceTypeName, display: "Node Pools"},
+	strings.ToLower(api.OperationResultResourceTypeName): {name: api.OperationResultResourceTypeName, display: "Operation Results"},
+	strings.ToLower(api.OperationStatusResourceTypeName): {name: api.OperationStatusResourceTypeName, display: "Operation Statuses"},
+	strings.ToLower(api.VersionResourceTypeName):         {name: api.VersionResourceTypeName, display: "OpenShift Versions"},
+}
+
+// providerActions maps lowercase action segments of mux patterns
+// to their canonical names and display information.
+var providerActions = map[string]providerSegment{
+	ActionCancelOperation: {
+		name:        "cancel",
+		display:     "Cancel Operation",
+		description: "Cancels an asynchronous operation.",
+	},
+	ActionCheckNameAvailability: {
+		name:        "checkNameAvailability",
+		display:     "Check Name Availability",
+		description: "Checks that a cluster or node pool name is valid and not in use.",
+	},
+}
+
+// resourceAction describes an action ArmResourceAction accepts through
+// the action name wildcard: how it is listed as a provider operation,
+// which operation it starts, and how it is started in Cluster Service.
+type resourceAction struct {
+	providerSegment
+	operationRequest database.OperationRequest
+	start            func(*Frontend, context.Context, *database.ResourceDocument) (ocm.InternalID, *arm.CloudError)
+}
+
+// resourceActions maps lowercase action names to the actions
+// ArmResourceAction accepts through the action name wildcard.
+var resourceActions = map[string]resourceAction{
+	ActionRequestAdminCredential: {
+		providerSegment: providerSegment{
+			name:        "requestAdminCredential",
+			display:     "Request Admin Credential",
+			description: "Requests a temporary administrator credential for a cluster.",
+		},
+		operationRequest: database.OperationRequestRequestCredential,
+		start:            (*Frontend).RequestBreakGlassCredential,
+	},
+	ActionRevokeCredentials: {
+		providerSegment: providerSegment{
+			name:        "revokeCredentials",
+			display:     "Revoke Credentials",
+			description: "Revokes all administrator credentials issued for a cluster.",
+		},
+		operationRequest: database.OperationRequestRevokeCredentials,
+		start:            (*Frontend).RevokeBreakGlassCredentials,
+	},
+}
+
+// newProviderOperations derives the resource provider's operations from
+// the patterns registered with the request multiplexer, so the operations
+// list cannot advertise actions the frontend does not serve. Patterns that
+// do not address the resource provider namespace, or that contain segments
+// defined by ARM itself such as deployment preflight, are ignored.
+func newProviderOperations(patterns []string) []arm.ProviderOperation {
+	var operations []arm.ProviderOperation
+
+	seen := make(map[string]bool)
+	add := func(resourceType []string, display, operation, description string) {
+		name := api.ProviderNamespace + "/" + strings.Join(resourceType, "/")
+		if seen[strings.ToLower(name)] {
+			return
+		}
+		seen[strings.ToLower(name)] = true
+
+		operations = append(operations, arm.ProviderOperation{
+			Name:         name,
+			IsDataAction: false,
+			Display: arm.ProviderOperationDisplay{
+				Provider:    api.ProviderNamespaceDisplay,
+				Resource:    display,
+				Operation:   operation,
+				Description: description,
+			},
+			Origin: arm.ProviderOperationOriginUserSystem,
+		})
+	}
+
+	for _, pattern := range patterns {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			continue
+		}
+
+		segments := strings.Split(strings.Trim(path, "/"), "/")
+
+		var rest []string
+		for i := 0; i+1 < len(segments); i++ {
+			if segments[i] == "providers" && segments[i+1] == strings.ToLower(api.ProviderNamespace) {
+				rest = segments[i+2:]
+				break
+			}
+		}
+		if len(rest) == 0 {
+			continue
+		}
+
+		var resourceType []string
+		var resource providerSegment
+		var actions []providerSegment
+
+		for i, segment := range rest {
+			last := i == len(rest)-1
+
+			if strings.HasPrefix(segment, "{") {
+				if segment == WildcardActionName && last && method == http.MethodPost {
+					for _, action := range slices.Sorted(maps.Keys(resourceActions)) {
+						actions = append(actions, resourceActions[action].providerSegment)
+					}
+				}
+				continue
+			}
+
+			if segmentType, ok := providerResourceTypes[segment]; ok {
+				resourceType = append(resourceType, segmentType.name)
+				resource = segmentType
+				continue
+			}
+
+			if action, ok := providerActions[segment]; ok && last && method == http.MethodPost {
+				actions = append(actions, action)
+				continue
+			}
+
+			// Segment is not part of this resource provider's API.
+			resourceType = nil
+			break
+		}
+		if len(resourceType) == 0 {
+			continue
+		}
+
+		switch method {
+		case http.MethodGet:
+			add(append(resourceType, "read"), resource.display,
+				"Read "+resource.display,
+				"Gets or lists "+resource.display+".")
+		case http.MethodPut, http.MethodPatch:
+			add(append(resourceType, "write"), resource.display,
+				"Create or Update "+resource.display,
+				"Creates or updates "+resource.display+".")
+		case http.MethodDelete:
+			add(append(resourceType, "delete"), resource.display,
+				"Delete "+resource.display,
+				"Deletes "+resource.display+".")
+		case http.MethodPost:
+			for _, action := range actions {
+				add(append(resourceType, action.name, "action"), resource.display,
+					action.display, action.description)
+			}
+		}
+	}
+
+	return operations
+}
+
+// ArmProviderOperationList implements the ARM operations discovery API
+// contract, listing the role-based access control operations of every
+// route the frontend serves.
+func (f *Frontend) ArmProviderOperationList(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	pagedResponse := arm.NewPagedResponse()
+
+	for _, operation := range f.providerOperations {
+		value, err := arm.Marshal(operation)
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+		pagedResponse.AddValue(value)
+	}
+
+	_, err := arm.WriteJSONResponse(writer, http.StatusOK, pagedResponse)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
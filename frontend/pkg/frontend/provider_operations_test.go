@@ -0,0 +1,114 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+func TestArmProviderOperationList(t *testing.T) {
+	f := NewFrontend(
+		testLogger,
+		nil,
+		nil,
+		prometheus.NewRegistry(),
+		database.NewInMemoryDBClient(),
+		"",
+		nil,
+	)
+
+	ts := httptest.NewUnstartedServer(f.server.Handler)
+	ts.Config.BaseContext = f.server.BaseContext
+	ts.Start()
+	defer ts.Close()
+
+	resp, err := ts.Client().Get(ts.URL + "/providers/" + api.ProviderNamespace + "/operations?api-version=2024-06-10-preview")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response struct {
+		Value []arm.ProviderOperation `json:"value"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+
+	var names []string
+	for _, operation := range response.Value {
+		names = append(names, operation.Name)
+		assert.False(t, operation.IsDataAction, operation.Name)
+		assert.Equal(t, api.ProviderNamespaceDisplay, operation.Display.Provider, operation.Name)
+		assert.NotEmpty(t, operation.Display.Resource, operation.Name)
+		assert.NotEmpty(t, operation.Display.Operation, operation.Name)
+		assert.NotEmpty(t, operation.Display.Description, operation.Name)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"Microsoft.RedHatOpenShift/operations/read",
+		"Microsoft.RedHatOpenShift/hcpOpenShiftClusters/read",
+		"Microsoft.RedHatOpenShift/hcpOpenShiftClusters/write",
+		"Microsoft.RedHatOpenShift/hcpOpenShiftClusters/delete",
+		"Microsoft.RedHatOpenShift/hcpOpenShiftClusters/requestAdminCredential/action",
+		"Microsoft.RedHatOpenShift/hcpOpenShiftClusters/revokeCredentials/action",
+		"Microsoft.RedHatOpenShift/hcpOpenShiftClusters/nodePools/read",
+		"Microsoft.RedHatOpenShift/hcpOpenShiftClusters/nodePools/write",
+		"Microsoft.RedHatOpenShift/hcpOpenShiftClusters/nodePools/delete",
+		"Microsoft.RedHatOpenShift/locations/hcpOperationResults/read",
+		"Microsoft.RedHatOpenShift/locations/hcpOperationsStatus/read",
+		"Microsoft.RedHatOpenShift/locations/hcpOperationsStatus/cancel/action",
+		"Microsoft.RedHatOpenShift/locations/hcpOpenShiftVersions/read",
+		"Microsoft.RedHatOpenShift/locations/checkNameAvailability/action",
+		"Microsoft.RedHatOpenShift/locations/usages/read",
+	}, names)
+}
+
+func TestNewProviderOperations(t *testing.T) {
+	tests := []struct {
+		name        string
+		pattern     string
+		expectNames []string
+	}{
+		{
+			name:    "Unauthenticated route",
+			pattern: MuxPattern(http.MethodGet, "healthz"),
+		},
+		{
+			name:    "Subscription route defined by ARM",
+			pattern: MuxPattern(http.MethodPut, PatternSubscriptions),
+		},
+		{
+			name:    "Deployment preflight route defined by ARM",
+			pattern: MuxPattern(http.MethodPost, PatternSubscriptions, PatternResourceGroups, PatternProviders, PatternDeployments, "preflight"),
+		},
+		{
+			name:        "Nested resource route",
+			pattern:     MuxPattern(http.MethodPatch, PatternSubscriptions, PatternResourceGroups, PatternProviders, PatternClusters, PatternNodePools),
+			expectNames: []string{"Microsoft.RedHatOpenShift/hcpOpenShiftClusters/nodePools/write"},
+		},
+		{
+			name:    "Unknown action",
+			pattern: MuxPattern(http.MethodPost, PatternSubscriptions, PatternResourceGroups, PatternProviders, PatternClusters, "explode"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, operation := range newProviderOperations([]string{tt.pattern}) {
+				names = append(names, operation.Name)
+			}
+			assert.Equal(t, tt.expectNames, names)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+// ResourceQuota limits the number of resources an Azure subscription may have.
+type ResourceQuota struct {
+	// ClustersPerSubscription is the maximum number of clusters in a subscription.
+	ClustersPerSubscription int
+
+	// NodePoolsPerCluster is the maximum number of node pools in a cluster.
+	NodePoolsPerCluster int
+
+	// NodesPerCluster is the maximum number of nodes across all node pools
+	// in a cluster. Autoscaling node pools count their maximum replicas.
+	NodesPerCluster int
+}
+
+// DefaultResourceQuota applies to every Azure subscription
+// unless the frontend is configured otherwise.
+var DefaultResourceQuota = ResourceQuota{
+	ClustersPerSubscription: 50,
+	NodePoolsPerCluster:     20,
+	NodesPerCluster:         api.MaxClusterNodes,
+}
+
+// increasedResourceQuota is the minimum quota for subscriptions
+// registered for the increased quota feature.
+var increasedResourceQuota = ResourceQuota{
+	ClustersPerSubscription: 250,
+	NodePoolsPerCluster:     50,
+	NodesPerCluster:         api.MaxClusterNodes,
+}
+
+const (
+	// featureIncreasedQuota raises a subscription's quota to at least increasedResourceQuota.
+	featureIncreasedQuota = api.ProviderNamespace + "/IncreasedQuota"
+
+	// Subscription additional properties that override individual limits.
+	quotaPropertyClustersPerSubscription = "hcpQuotaClustersPerSubscription"
+	quotaPropertyNodePoolsPerCluster     = "hcpQuotaNodePoolsPerCluster"
+	quotaPropertyNodesPerCluster         = "hcpQuotaNodesPerCluster"
+)
+
+// SetDefaultQuota replaces DefaultResourceQuota as the quota
+// for subscriptions without overrides.
+func (f *Frontend) SetDefaultQuota(quota ResourceQuota) {
+	f.quota = quota
+}
+
+// forSubscription returns the quota for a subscription. Registering the
+// increased quota feature raises the quota, after which any limits given
+// in the subscription's additional properties take precedence. Malformed
+// additional properties are ignored.
+func (q ResourceQuota) forSubscription(subscription *arm.Subscription) ResourceQuota {
+	if subscription == nil || subscription.Properties == nil {
+		return q
+	}
+
+	features := featuresMap(subscription.Properties.RegisteredFeatures)
+	if api.IsFeatureRegistered(features, featureIncreasedQuota) {
+		q.ClustersPerSubscription = max(q.ClustersPerSubscription, increasedResourceQuota.ClustersPerSubscription)
+		q.NodePoolsPerCluster = max(q.NodePoolsPerCluster, increasedResourceQuota.NodePoolsPerCluster)
+		q.NodesPerCluster = max(q.NodesPerCluster, increasedResourceQuota.NodesPerCluster)
+	}
+
+	if subscription.Properties.AdditionalProperties != nil {
+		for key, limit := range map[string]*int{
+			quotaPropertyClustersPerSubscription: &q.ClustersPerSubscription,
+			quotaPropertyNodePoolsPerCluster:     &q.NodePoolsPerCluster,
+			quotaPropertyNodesPerCluster:         &q.NodesPerCluster,
+		} {
+			value, ok := (*subscription.Properties.AdditionalProperties)[key]
+			if !ok {
+				continue
+			}
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				*limit = n
+			}
+		}
+	}
+
+	return q
+}
+
+// quotaFromContext returns the quota for the subscription in the request
+// context, or the frontend's default quota if the context has none.
+func (f *Frontend) quotaFromContext(ctx context.Context) ResourceQuota {
+	subscription, _ := SubscriptionFromContext(ctx)
+	return f.quota.forSubscription(subscription)
+}
+
+// nodePoolNodes returns the number of nodes a node pool may have.
+func nodePoolNodes(replicas int32, autoScaling *api.NodePoolAutoScaling) int {
+	if autoScaling != nil {
+		return int(autoScaling.Max)
+	}
+	return int(replicas)
+}
+
+// csNodePoolNodes returns the number of nodes a
+// Cluster Service node pool may have.
+func csNodePoolNodes(csNodePool *cmv1.NodePool) int {
+	if autoscaling, ok := csNodePool.GetAutoscaling(); ok {
+		return autoscaling.MaxReplica()
+	}
+	return csNodePool.Replicas()
+}
+
+// lockQuota acquires the quota lock for the resources counted under the
+// given subscription or resource lock ID, so that counting them against a
+// quota and creating or growing one of them happen as one step relative to
+// other requests doing the same. It returns a context to use while holding
+// the lock and a function to release the lock, which must be called but
+// only once the created or grown resource counts toward the quota.
+func (f *Frontend) lockQuota(ctx context.Context, header http.Header, resourceID *azcorearm.ResourceID, lockID string) (context.Context, func(), *arm.CloudError) {
+	lockClient := f.dbClient.GetLockClient()
+	if lockClient == nil {
+		return ctx, func() {}, nil
+	}
+
+	return acquireLock(ctx, header, lockClient, "quota lock", resourceID, func(ctx context.Context, timeout *time.Duration) (*azcosmos.ItemResponse, error) {
+		return lockClient.AcquireLock(ctx, database.QuotaLockID(lockID), timeout)
+	})
+}
+
+// checkClusterQuota checks that creating the given cluster would not exceed
+// its subscription's cluster quota. If not, it returns with the subscription's
+// quota lock held, as lockQuota does, so that concurrent creates in the
+// subscription are counted one after another.
+func (f *Frontend) checkClusterQuota(ctx context.Context, header http.Header, resourceID *azcorearm.ResourceID) (context.Context, func(), *arm.CloudError) {
+	logger := LoggerFromContext(ctx)
+	quota := f.quotaFromContext(ctx)
+
+	lockedCtx, release, cloudError := f.lockQuota(ctx, header, resourceID, database.SubscriptionLockID(resourceID.SubscriptionID))
+	if cloudError != nil {
+		return ctx, func() {}, cloudError
+	}
+
+	existing, err := f.listSubscriptionResourceDocs(lockedCtx, resourceID.SubscriptionID)
+	if err != nil {
+		release()
+		logger.Error(err.Error())
+		return ctx, func() {}, arm.NewInternalServerError()
+	}
+
+	var clusterCount int
+	for _, doc := range existing {
+		if strings.EqualFold(doc.ResourceID.ResourceType.String(), api.ClusterResourceType.String()) {
+			clusterCount++
+		}
+	}
+
+	if clusterCount >= quota.ClustersPerSubscription {
+		release()
+		return ctx, func() {}, arm.NewCloudError(
+			http.StatusConflict,
+			arm.CloudErrorCodeQuotaExceeded, "",
+			"The subscription is limited to %d clusters",
+			quota.ClustersPerSubscription)
+	}
+
+	return lockedCtx, release, nil
+}
+
+// checkNodePoolQuota checks that creating or updating a node pool would not
+// exceed its cluster's node pool or node quota. The cluster's existing node
+// pools come from Cluster Service, which knows their replica counts. If the
+// quota is not exceeded, it returns with the cluster's quota lock held, as
+// lockQuota does.
+func (f *Frontend) checkNodePoolQuota(ctx context.Context, header http.Header, clusterDoc *database.ResourceDocument, nodePool *api.HCPOpenShiftClusterNodePool, updating bool) (context.Context, func(), *arm.CloudError) {
+	quota := f.quotaFromContext(ctx)
+
+	lockedCtx, release, cloudError := f.lockQuota(ctx, header, clusterDoc.ResourceID, database.ResourceLockID(clusterDoc.ResourceID))
+	if cloudError != nil {
+		return ctx, func() {}, cloudError
+	}
+
+	nodePoolCount := 1
+	nodeCount := nodePoolNodes(nodePool.Properties.Replicas, nodePool.Properties.AutoScaling)
+
+	iterator := f.clusterServiceClient.ListNodePools(clusterDoc.InternalID, "")
+	for csNodePool := range iterator.Items(lockedCtx) {
+		// An updated node pool replaces its current self.
+		if updating && strings.EqualFold(csNodePool.ID(), nodePool.Name) {
+			continue
+		}
+		nodePoolCount++
+		nodeCount += csNodePoolNodes(csNodePool)
+	}
+
+	err := iterator.GetError()
+	if err != nil {
+		release()
+		LoggerFromContext(ctx).Error(err.Error())
+		return ctx, func() {}, arm.NewInternalServerError()
+	}
+
+	if !updating && nodePoolCount > quota.NodePoolsPerCluster {
+		release()
+		return ctx, func() {}, arm.NewCloudError(
+			http.StatusConflict,
+			arm.CloudErrorCodeQuotaExceeded, "",
+			"Cluster '%s' is limited to %d node pools",
+			clusterDoc.ResourceID.Name, quota.NodePoolsPerCluster)
+	}
+
+	if nodeCount > quota.NodesPerCluster {
+		release()
+		return ctx, func() {}, arm.NewCloudError(
+			http.StatusConflict,
+			arm.CloudErrorCodeQuotaExceeded, "properties.replicas",
+			"Cluster '%s' is limited to %d nodes, but its node pools would have %d",
+			clusterDoc.ResourceID.Name, quota.NodesPerCluster, nodeCount)
+	}
+
+	return lockedCtx, release, nil
+}
@@ -0,0 +1,267 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+func TestResourceQuotaForSubscription(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription *arm.Subscription
+		expectQuota  ResourceQuota
+	}{
+		{
+			name:        "No subscription",
+			expectQuota: DefaultResourceQuota,
+		},
+		{
+			name:         "No overrides",
+			subscription: &arm.Subscription{Properties: &arm.SubscriptionProperties{}},
+			expectQuota:  DefaultResourceQuota,
+		},
+		{
+			name: "Increased quota feature",
+			subscription: &arm.Subscription{Properties: &arm.SubscriptionProperties{
+				RegisteredFeatures: &[]arm.Feature{{
+					Name:  api.Ptr(featureIncreasedQuota),
+					State: api.Ptr("Registered"),
+				}},
+			}},
+			expectQuota: increasedResourceQuota,
+		},
+		{
+			name: "Increased quota feature not registered",
+			subscription: &arm.Subscription{Properties: &arm.SubscriptionProperties{
+				RegisteredFeatures: &[]arm.Feature{{
+					Name:  api.Ptr(featureIncreasedQuota),
+					State: api.Ptr("Unregistered"),
+				}},
+			}},
+			expectQuota: DefaultResourceQuota,
+		},
+		{
+			name: "Additional properties override feature",
+			subscription: &arm.Subscription{Properties: &arm.SubscriptionProperties{
+				RegisteredFeatures: &[]arm.Feature{{
+					Name:  api.Ptr(featureIncreasedQuota),
+					State: api.Ptr("Registered"),
+				}},
+				AdditionalProperties: &map[string]string{
+					quotaPropertyClustersPerSubscription: "5",
+					quotaPropertyNodesPerCluster:         "10",
+				},
+			}},
+			expectQuota: ResourceQuota{
+				ClustersPerSubscription: 5,
+				NodePoolsPerCluster:     increasedResourceQuota.NodePoolsPerCluster,
+				NodesPerCluster:         10,
+			},
+		},
+		{
+			name: "Malformed additional properties",
+			subscription: &arm.Subscription{Properties: &arm.SubscriptionProperties{
+				AdditionalProperties: &map[string]string{
+					quotaPropertyClustersPerSubscription: "many",
+					quotaPropertyNodePoolsPerCluster:     "-1",
+				},
+			}},
+			expectQuota: DefaultResourceQuota,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota := DefaultResourceQuota.forSubscription(tt.subscription)
+			if quota != tt.expectQuota {
+				t.Errorf("Expected quota %+v but got %+v", tt.expectQuota, quota)
+			}
+		})
+	}
+}
+
+func TestCheckNodePoolQuota(t *testing.T) {
+	tests := []struct {
+		name        string
+		quota       ResourceQuota
+		nodePool    string
+		replicas    int32
+		autoScaling *api.NodePoolAutoScaling
+		updating    bool
+		expectError bool
+	}{
+		{
+			name:     "Within quota",
+			quota:    ResourceQuota{NodePoolsPerCluster: 2, NodesPerCluster: 5},
+			nodePool: "newNodePool",
+			replicas: 2,
+		},
+		{
+			name:        "Node pool quota exceeded",
+			quota:       ResourceQuota{NodePoolsPerCluster: 1, NodesPerCluster: 5},
+			nodePool:    "newNodePool",
+			replicas:    2,
+			expectError: true,
+		},
+		{
+			name:        "Node quota exceeded",
+			quota:       ResourceQuota{NodePoolsPerCluster: 2, NodesPerCluster: 5},
+			nodePool:    "newNodePool",
+			replicas:    3,
+			expectError: true,
+		},
+		{
+			name:        "Autoscaling counts maximum replicas",
+			quota:       ResourceQuota{NodePoolsPerCluster: 2, NodesPerCluster: 5},
+			nodePool:    "newNodePool",
+			autoScaling: &api.NodePoolAutoScaling{Min: 1, Max: 3},
+			expectError: true,
+		},
+		{
+			name:     "Updated node pool replaces itself",
+			quota:    ResourceQuota{NodePoolsPerCluster: 1, NodesPerCluster: 5},
+			nodePool: "testNodePool",
+			replicas: 5,
+			updating: true,
+		},
+		{
+			name:        "Updated node pool exceeds node quota",
+			quota:       ResourceQuota{NodePoolsPerCluster: 1, NodesPerCluster: 5},
+			nodePool:    "testNodePool",
+			replicas:    6,
+			updating:    true,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ContextWithLogger(context.Background(), testLogger)
+			f, clusterDoc := newTestFrontend(t)
+			f.quota = tt.quota
+
+			nodePool := api.NewDefaultHCPOpenShiftClusterNodePool()
+			nodePool.Name = tt.nodePool
+			nodePool.Properties.Replicas = tt.replicas
+			nodePool.Properties.AutoScaling = tt.autoScaling
+
+			_, release, cloudError := f.checkNodePoolQuota(ctx, http.Header{}, clusterDoc, nodePool, tt.updating)
+			release()
+			if tt.expectError {
+				if cloudError == nil {
+					t.Fatal("Expected a quota error")
+				}
+				if cloudError.Code != arm.CloudErrorCodeQuotaExceeded {
+					t.Errorf("Expected error code '%s' but got '%s'", arm.CloudErrorCodeQuotaExceeded, cloudError.Code)
+				}
+			} else if cloudError != nil {
+				t.Fatal(cloudError)
+			}
+		})
+	}
+}
+
+func TestCheckClusterQuota(t *testing.T) {
+	ctx := ContextWithLogger(context.Background(), testLogger)
+	resourceID := mustParseResourceID(t, testGroupPrefix+"newCluster")
+
+	f, _ := newTestFrontend(t)
+	lockClient := f.dbClient.GetLockClient()
+	quotaLockID := database.QuotaLockID(database.SubscriptionLockID(resourceID.SubscriptionID))
+
+	isQuotaLocked := func() bool {
+		info, err := lockClient.GetLock(ctx, quotaLockID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info != nil
+	}
+
+	f.quota = ResourceQuota{ClustersPerSubscription: 2}
+	_, release, cloudError := f.checkClusterQuota(ctx, http.Header{}, resourceID)
+	if cloudError != nil {
+		t.Fatal(cloudError)
+	}
+	if !isQuotaLocked() {
+		t.Error("Expected the quota lock to be held until released")
+	}
+	release()
+	if isQuotaLocked() {
+		t.Error("Expected the quota lock to be released")
+	}
+
+	f.quota.ClustersPerSubscription = 1
+	_, release, cloudError = f.checkClusterQuota(ctx, http.Header{}, resourceID)
+	release()
+	if cloudError == nil {
+		t.Fatal("Expected a quota error")
+	}
+	if isQuotaLocked() {
+		t.Error("Expected the quota lock to be released on a quota error")
+	}
+
+	// The subscription's additional properties override the default quota.
+	ctx = ContextWithSubscription(ctx, &arm.Subscription{Properties: &arm.SubscriptionProperties{
+		AdditionalProperties: &map[string]string{quotaPropertyClustersPerSubscription: "2"},
+	}})
+	_, release, cloudError = f.checkClusterQuota(ctx, http.Header{}, resourceID)
+	release()
+	if cloudError != nil {
+		t.Fatal(cloudError)
+	}
+}
+
+func TestArmUsageList(t *testing.T) {
+	ctx := ContextWithLogger(context.Background(), testLogger)
+	f, _ := newTestFrontend(t)
+
+	path := "/subscriptions/" + dummySubscriptionId + "/providers/Microsoft.RedHatOpenShift/locations/eastus/usages"
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.SetPathValue(PathSegmentSubscriptionID, dummySubscriptionId)
+	request = request.WithContext(ctx)
+	writer := httptest.NewRecorder()
+
+	f.ArmUsageList(writer, request)
+
+	if writer.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, writer.Code, writer.Body.String())
+	}
+
+	var response struct {
+		Value []arm.Usage `json:"value"`
+	}
+	if err := json.Unmarshal(writer.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+
+	expectUsages := map[string][2]int64{
+		api.ClusterResourceTypeName:       {1, int64(DefaultResourceQuota.ClustersPerSubscription)},
+		"testGroup/testCluster/nodePools": {1, int64(DefaultResourceQuota.NodePoolsPerCluster)},
+		"testGroup/testCluster/nodes":     {3, int64(DefaultResourceQuota.NodesPerCluster)},
+	}
+
+	if len(response.Value) != len(expectUsages) {
+		t.Fatalf("Expected %d usages but got %d: %s", len(expectUsages), len(response.Value), writer.Body.String())
+	}
+	for _, usage := range response.Value {
+		expect, ok := expectUsages[usage.Name.Value]
+		if !ok {
+			t.Errorf("Unexpected usage '%s'", usage.Name.Value)
+			continue
+		}
+		if usage.CurrentValue != expect[0] || usage.Limit != expect[1] {
+			t.Errorf("Expected usage '%s' to be %d of %d but got %d of %d",
+				usage.Name.Value, expect[0], expect[1], usage.CurrentValue, usage.Limit)
+		}
+	}
+}
@@ -16,11 +16,13 @@ const (
 	WildcardActionName        = "{" + PathSegmentActionName + "}"
 	WildcardDeploymentName    = "{" + PathSegmentDeploymentName + "}"
 	WildcardLocation          = "{" + PathSegmentLocation + "}"
+	WildcardLockID            = "{" + PathSegmentLockID + "}"
 	WildcardNodePoolName      = "{" + PathSegmentNodePoolName + "}"
 	WildcardOperationID       = "{" + PathSegmentOperationID + "}"
 	WildcardResourceGroupName = "{" + PathSegmentResourceGroupName + "}"
 	WildcardResourceName      = "{" + PathSegmentResourceName + "}"
 	WildcardSubscriptionID    = "{" + PathSegmentSubscriptionID + "}"
+	WildcardVersionName       = "{" + PathSegmentVersionName + "}"
 
 	PatternSubscriptions    = "subscriptions/" + WildcardSubscriptionID
 	PatternLocations        = "locations/" + WildcardLocation
@@ -31,6 +33,7 @@ const (
 	PatternResourceGroups   = "resourcegroups/" + WildcardResourceGroupName
 	PatternOperationResults = api.OperationResultResourceTypeName + "/" + WildcardOperationID
 	PatternOperationsStatus = api.OperationStatusResourceTypeName + "/" + WildcardOperationID
+	PatternVersions         = api.VersionResourceTypeName + "/" + WildcardVersionName
 )
 
 // MuxPattern forms a URL pattern suitable for passing to http.ServeMux.
@@ -64,8 +67,16 @@ func (f *Frontend) routes(r prometheus.Registerer) *MiddlewareMux {
 	mux.HandleFunc("/", f.NotFound)
 	mux.HandleFunc(MuxPattern(http.MethodGet, "healthz"), f.Healthz)
 
-	// List endpoints
+	// Provider endpoints
 	postMuxMiddleware := NewMiddleware(
+		MiddlewareLoggingPostMux,
+		MiddlewareValidateAPIVersion)
+	mux.Handle(
+		MuxPattern(http.MethodGet, PatternProviders, "operations"),
+		postMuxMiddleware.HandlerFunc(f.ArmProviderOperationList))
+
+	// List endpoints
+	postMuxMiddleware = NewMiddleware(
 		MiddlewareLoggingPostMux,
 		MiddlewareValidateAPIVersion,
 		MiddlewareValidateSubscriptionState)
@@ -78,6 +89,12 @@ func (f *Frontend) routes(r prometheus.Registerer) *MiddlewareMux {
 	mux.Handle(
 		MuxPattern(http.MethodGet, PatternSubscriptions, PatternResourceGroups, PatternProviders, PatternClusters, api.NodePoolResourceTypeName),
 		postMuxMiddleware.HandlerFunc(f.ArmResourceList))
+	mux.Handle(
+		MuxPattern(http.MethodGet, PatternSubscriptions, PatternProviders, PatternLocations, api.VersionResourceTypeName),
+		postMuxMiddleware.HandlerFunc(f.ArmVersionList))
+	mux.Handle(
+		MuxPattern(http.MethodGet, PatternSubscriptions, PatternProviders, PatternLocations, "usages"),
+		postMuxMiddleware.HandlerFunc(f.ArmUsageList))
 
 	// Resource ID endpoints
 	// Request context holds an azcorearm.ResourceID
@@ -85,7 +102,7 @@ func (f *Frontend) routes(r prometheus.Registerer) *MiddlewareMux {
 		MiddlewareResourceID,
 		MiddlewareLoggingPostMux,
 		MiddlewareValidateAPIVersion,
-		MiddlewareLockSubscription,
+		MiddlewareLockResource,
 		MiddlewareValidateSubscriptionState)
 	mux.Handle(
 		MuxPattern(http.MethodGet, PatternSubscriptions, PatternResourceGroups, PatternProviders, PatternClusters),
@@ -128,13 +145,37 @@ func (f *Frontend) routes(r prometheus.Registerer) *MiddlewareMux {
 		MuxPattern(http.MethodGet, PatternSubscriptions, PatternProviders, PatternLocations, PatternOperationsStatus),
 		postMuxMiddleware.HandlerFunc(f.OperationStatus))
 
+	// Version endpoint
+	mux.Handle(
+		MuxPattern(http.MethodGet, PatternSubscriptions, PatternProviders, PatternLocations, PatternVersions),
+		postMuxMiddleware.HandlerFunc(f.ArmVersionRead))
+
+	// Operation cancel endpoint
+	// The handler locks the operation's resource, which keeps the
+	// backend from updating the operation status at the same time.
+	mux.Handle(
+		MuxPattern(http.MethodPost, PatternSubscriptions, PatternProviders, PatternLocations, PatternOperationsStatus, ActionCancelOperation),
+		postMuxMiddleware.HandlerFunc(f.OperationCancel))
+
+	// Name availability endpoints
+	postMuxMiddleware = NewMiddleware(
+		MiddlewareLoggingPostMux,
+		MiddlewareValidateAPIVersion,
+		MiddlewareValidateSubscriptionState)
+	mux.Handle(
+		MuxPattern(http.MethodPost, PatternSubscriptions, PatternProviders, PatternLocations, ActionCheckNameAvailability),
+		postMuxMiddleware.HandlerFunc(f.ArmCheckNameAvailability))
+	mux.Handle(
+		MuxPattern(http.MethodPost, PatternSubscriptions, PatternResourceGroups, PatternProviders, PatternLocations, ActionCheckNameAvailability),
+		postMuxMiddleware.HandlerFunc(f.ArmCheckNameAvailability))
+
 	// Exclude ARO-HCP API version validation for the following endpoints defined by ARM.
 
 	// Subscription management endpoints
 	postMuxMiddleware = NewMiddleware(
 		MiddlewareResourceID,
 		MiddlewareLoggingPostMux,
-		MiddlewareLockSubscription)
+		MiddlewareLockResource)
 	mux.Handle(
 		MuxPattern(http.MethodGet, PatternSubscriptions),
 		postMuxMiddleware.HandlerFunc(f.ArmSubscriptionGet))
@@ -150,6 +191,58 @@ func (f *Frontend) routes(r prometheus.Registerer) *MiddlewareMux {
 		MuxPattern(http.MethodPost, PatternSubscriptions, PatternResourceGroups, "providers", api.ProviderNamespace, PatternDeployments, "preflight"),
 		postMuxMiddleware.HandlerFunc(f.ArmDeploymentPreflight))
 
+	// Advertise exactly the operations registered above.
+	f.providerOperations = newProviderOperations(mux.Patterns())
+
+	return mux
+}
+
+// adminRoutes returns the handler for the admin API, which is served on
+// its own listener for SREs. Requests must present a client certificate
+// whose subject is one of allowedSubjects. These endpoints are not part
+// of the ARM API, so there is no API version or system data to validate.
+func (f *Frontend) adminRoutes(allowedSubjects []string) *MiddlewareMux {
+	mux := NewMiddlewareMux(
+		MiddlewarePanic,
+		MiddlewareCorrelationData,
+		MiddlewareTracing,
+		MiddlewareLogging,
+		MiddlewareAdminAudit,
+		// NOTE: register panic middlware twice.
+		// Making sure we can capture paniced requests in our trace data.
+		// But we also can recover if the tracing or logging middleware caused a panic.
+		MiddlewarePanic,
+		newMiddlewareAdminAuthorization(allowedSubjects),
+		MiddlewareBody,
+		MiddlewareLowercase,
+	)
+
+	mux.HandleFunc("/", f.NotFound)
+
+	postMuxMiddleware := NewMiddleware(
+		MiddlewareLoggingPostMux)
+	mux.Handle(
+		MuxPattern(http.MethodGet, "admin", "v1", "operations"),
+		postMuxMiddleware.HandlerFunc(f.AdminOperationList))
+	mux.Handle(
+		MuxPattern(http.MethodPost, "admin", "v1", PatternSubscriptions, "operations", WildcardOperationID, "fail"),
+		postMuxMiddleware.HandlerFunc(f.AdminOperationFail))
+	mux.Handle(
+		MuxPattern(http.MethodPost, "admin", "v1", "resync"),
+		postMuxMiddleware.HandlerFunc(f.AdminResourceResync))
+	mux.Handle(
+		MuxPattern(http.MethodGet, "admin", "v1", "subscriptions"),
+		postMuxMiddleware.HandlerFunc(f.AdminSubscriptionList))
+	mux.Handle(
+		MuxPattern(http.MethodGet, "admin", "v1", "locks"),
+		postMuxMiddleware.HandlerFunc(f.AdminLockList))
+	mux.Handle(
+		MuxPattern(http.MethodGet, "admin", "v1", "locks", WildcardLockID),
+		postMuxMiddleware.HandlerFunc(f.AdminLockGet))
+	mux.Handle(
+		MuxPattern(http.MethodDelete, "admin", "v1", "locks", WildcardLockID),
+		postMuxMiddleware.HandlerFunc(f.AdminLockBreak))
+
 	return mux
 }
 
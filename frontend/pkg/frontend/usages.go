@@ -0,0 +1,110 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+)
+
+// ArmUsageList implements the ARM usages API contract, reporting the
+// subscription's resource usage against its quota. Clusters are counted
+// per subscription while node pools and nodes are counted per cluster.
+func (f *Frontend) ArmUsageList(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	subscriptionID := request.PathValue(PathSegmentSubscriptionID)
+	quota := f.quotaFromContext(ctx)
+
+	existing, err := f.listSubscriptionResourceDocs(ctx, subscriptionID)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	var clusterDocs []*database.ResourceDocument
+	for _, doc := range existing {
+		if strings.EqualFold(doc.ResourceID.ResourceType.String(), api.ClusterResourceType.String()) {
+			clusterDocs = append(clusterDocs, doc)
+		}
+	}
+	slices.SortFunc(clusterDocs, func(a, b *database.ResourceDocument) int {
+		return strings.Compare(strings.ToLower(a.ResourceID.String()), strings.ToLower(b.ResourceID.String()))
+	})
+
+	usages := []arm.Usage{{
+		Name: arm.UsageName{
+			Value:          api.ClusterResourceTypeName,
+			LocalizedValue: api.ResourceTypeDisplay,
+		},
+		CurrentValue: int64(len(clusterDocs)),
+		Limit:        int64(quota.ClustersPerSubscription),
+		Unit:         arm.UsageUnitCount,
+	}}
+
+	for _, doc := range clusterDocs {
+		var nodePoolCount, nodeCount int
+
+		iterator := f.clusterServiceClient.ListNodePools(doc.InternalID, "")
+		for csNodePool := range iterator.Items(ctx) {
+			nodePoolCount++
+			nodeCount += csNodePoolNodes(csNodePool)
+		}
+
+		err = iterator.GetError()
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+
+		// Cluster names are only unique within a resource group.
+		clusterName := doc.ResourceID.Name
+		usagePrefix := doc.ResourceID.ResourceGroupName + "/" + clusterName
+
+		usages = append(usages,
+			arm.Usage{
+				Name: arm.UsageName{
+					Value:          usagePrefix + "/" + api.NodePoolResourceTypeName,
+					LocalizedValue: fmt.Sprintf("Node Pools in cluster '%s' in resource group '%s'", clusterName, doc.ResourceID.ResourceGroupName),
+				},
+				CurrentValue: int64(nodePoolCount),
+				Limit:        int64(quota.NodePoolsPerCluster),
+				Unit:         arm.UsageUnitCount,
+			},
+			arm.Usage{
+				Name: arm.UsageName{
+					Value:          usagePrefix + "/nodes",
+					LocalizedValue: fmt.Sprintf("Nodes in cluster '%s' in resource group '%s'", clusterName, doc.ResourceID.ResourceGroupName),
+				},
+				CurrentValue: int64(nodeCount),
+				Limit:        int64(quota.NodesPerCluster),
+				Unit:         arm.UsageUnitCount,
+			})
+	}
+
+	pagedResponse := arm.NewPagedResponse()
+	for _, usage := range usages {
+		value, err := arm.Marshal(usage)
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+		pagedResponse.AddValue(value)
+	}
+
+	_, err = arm.WriteJSONResponse(writer, http.StatusOK, pagedResponse)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
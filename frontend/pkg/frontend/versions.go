@@ -0,0 +1,134 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"errors"
+	"net/http"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+// isVersionAvailable returns true if clusters and node pools
+// may request a Cluster Service version.
+func isVersionAvailable(version *cmv1.Version) bool {
+	return version.Enabled() && version.HostedControlPlaneEnabled()
+}
+
+// availableVersionsSearch is a Cluster Service search expression
+// for the versions that isVersionAvailable returns true for.
+const availableVersionsSearch = "enabled = 'true' and hosted_control_plane_enabled = 'true'"
+
+// ArmVersionList implements the GET collection API contract for the
+// location-scoped hcpOpenShiftVersions resource type. It lists the
+// OpenShift versions in every channel group that Cluster Service offers.
+func (f *Frontend) ArmVersionList(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	versionedInterface, err := VersionFromContext(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	originalPath, err := OriginalPathFromContext(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	pagedResponse := arm.NewPagedResponse()
+
+	csIterator := f.clusterServiceClient.ListVersions(availableVersionsSearch)
+
+	for csVersion := range csIterator.Items(ctx) {
+		resourceID, err := azcorearm.ParseResourceID(originalPath + "/" + convertCSVersionIDToVersionName(csVersion.ID()))
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+
+		value, err := arm.Marshal(versionedInterface.NewHCPOpenShiftVersion(ConvertCStoHCPOpenShiftVersion(resourceID, csVersion)))
+		if err != nil {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+			return
+		}
+		pagedResponse.AddValue(value)
+	}
+
+	// Check for iteration error.
+	err = csIterator.GetError()
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	_, err = arm.WriteJSONResponse(writer, http.StatusOK, pagedResponse)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+// ArmVersionRead implements the GET single resource API contract for the
+// location-scoped hcpOpenShiftVersions resource type.
+// * 200 If Cluster Service offers the version
+// * 404 If Cluster Service does not offer the version
+func (f *Frontend) ArmVersionRead(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	logger := LoggerFromContext(ctx)
+
+	versionedInterface, err := VersionFromContext(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	resourceID, err := ResourceIDFromContext(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	csVersion, err := f.clusterServiceClient.GetVersion(ctx, convertVersionNameToCSVersionID(resourceID.Name))
+	if err != nil {
+		var ocmError *ocmerrors.Error
+		if errors.As(err, &ocmError) && ocmError.Status() == http.StatusNotFound {
+			arm.WriteResourceNotFoundError(writer, resourceID)
+		} else {
+			logger.Error(err.Error())
+			arm.WriteInternalServerError(writer)
+		}
+		return
+	}
+
+	// Hide versions that clusters and node pools cannot request.
+	if !isVersionAvailable(csVersion) {
+		arm.WriteResourceNotFoundError(writer, resourceID)
+		return
+	}
+
+	responseBody, err := arm.Marshal(versionedInterface.NewHCPOpenShiftVersion(ConvertCStoHCPOpenShiftVersion(resourceID, csVersion)))
+	if err != nil {
+		logger.Error(err.Error())
+		arm.WriteInternalServerError(writer)
+		return
+	}
+
+	_, err = arm.WriteJSONResponse(writer, http.StatusOK, responseBody)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
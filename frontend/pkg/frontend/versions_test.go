@@ -0,0 +1,150 @@
+package frontend
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/api/v20240610preview/generated"
+)
+
+const dummyVersionsPath = "/subscriptions/" + dummySubscriptionId + "/providers/Microsoft.RedHatOpenShift/locations/eastus/hcpOpenShiftVersions"
+
+// newVersionsTestContext returns a request context for the API
+// version the versions handlers are tested against.
+func newVersionsTestContext(t *testing.T) context.Context {
+	t.Helper()
+
+	versionedInterface, ok := api.Lookup("2024-06-10-preview")
+	if !ok {
+		t.Fatal("API version 2024-06-10-preview is not registered")
+	}
+
+	return ContextWithVersion(ContextWithLogger(context.Background(), testLogger), versionedInterface)
+}
+
+func TestArmVersionList(t *testing.T) {
+	f, _ := newTestFrontend(t)
+	ctx := newVersionsTestContext(t)
+
+	request := httptest.NewRequest(http.MethodGet, dummyVersionsPath, nil)
+	request = request.WithContext(ContextWithOriginalPath(ctx, dummyVersionsPath))
+	writer := httptest.NewRecorder()
+
+	f.ArmVersionList(writer, request)
+
+	if writer.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, writer.Code, writer.Body.String())
+	}
+
+	var response arm.PagedResponse
+	if err := json.Unmarshal(writer.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+
+	expectVersions := map[string]string{
+		"4.16.0":           "stable",
+		"4.17.0":           "stable",
+		"4.18.0-candidate": "candidate",
+	}
+
+	if len(response.Value) != len(expectVersions) {
+		t.Fatalf("Expected %d versions but got %d: %s", len(expectVersions), len(response.Value), writer.Body.String())
+	}
+
+	for _, value := range response.Value {
+		var version generated.HcpOpenShiftVersionResource
+		if err := json.Unmarshal(value, &version); err != nil {
+			t.Fatal(err)
+		}
+
+		channelGroup, ok := expectVersions[*version.Name]
+		if !ok {
+			t.Errorf("Unexpected version '%s'", *version.Name)
+			continue
+		}
+		if *version.Properties.ChannelGroup != channelGroup {
+			t.Errorf("Expected version '%s' in channel group '%s' but got '%s'", *version.Name, channelGroup, *version.Properties.ChannelGroup)
+		}
+		if *version.ID != dummyVersionsPath+"/"+*version.Name {
+			t.Errorf("Unexpected resource ID '%s'", *version.ID)
+		}
+	}
+}
+
+func TestArmVersionRead(t *testing.T) {
+	tests := []struct {
+		name               string
+		versionName        string
+		expectStatusCode   int
+		expectVersion      string
+		expectChannelGroup string
+	}{
+		{
+			name:               "Enabled version",
+			versionName:        "4.17.0",
+			expectStatusCode:   http.StatusOK,
+			expectVersion:      "4.17.0",
+			expectChannelGroup: "stable",
+		},
+		{
+			name:               "Version in another channel group",
+			versionName:        "4.18.0-candidate",
+			expectStatusCode:   http.StatusOK,
+			expectVersion:      "4.18.0",
+			expectChannelGroup: "candidate",
+		},
+		{
+			name:             "Disabled version",
+			versionName:      "4.15.0",
+			expectStatusCode: http.StatusNotFound,
+		},
+		{
+			name:             "Unknown version",
+			versionName:      "4.0.0",
+			expectStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := newTestFrontend(t)
+			ctx := newVersionsTestContext(t)
+
+			path := dummyVersionsPath + "/" + tt.versionName
+			request := httptest.NewRequest(http.MethodGet, path, nil)
+			request = request.WithContext(ContextWithResourceID(ctx, mustParseResourceID(t, path)))
+			writer := httptest.NewRecorder()
+
+			f.ArmVersionRead(writer, request)
+
+			if writer.Code != tt.expectStatusCode {
+				t.Fatalf("Expected status code %d but got %d: %s", tt.expectStatusCode, writer.Code, writer.Body.String())
+			}
+			if tt.expectStatusCode != http.StatusOK {
+				return
+			}
+
+			var version generated.HcpOpenShiftVersionResource
+			if err := json.Unmarshal(writer.Body.Bytes(), &version); err != nil {
+				t.Fatal(err)
+			}
+			if *version.Name != tt.versionName {
+				t.Errorf("Expected name '%s' but got '%s'", tt.versionName, *version.Name)
+			}
+			if *version.Properties.ClusterVersion != tt.expectVersion {
+				t.Errorf("Expected cluster version '%s' but got '%s'", tt.expectVersion, *version.Properties.ClusterVersion)
+			}
+			if *version.Properties.ChannelGroup != tt.expectChannelGroup {
+				t.Errorf("Expected channel group '%s' but got '%s'", tt.expectChannelGroup, *version.Properties.ChannelGroup)
+			}
+		})
+	}
+}
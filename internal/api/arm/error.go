@@ -21,6 +21,8 @@ const (
 	CloudErrorCodeMultipleErrorsOccurred   = "MultipleErrorsOccurred"
 	CloudErrorCodeUnsupportedMediaType     = "UnsupportedMediaType"
 	CloudErrorCodeConflict                 = "Conflict"
+	CloudErrorCodeCanceled                 = "Canceled"
+	CloudErrorCodeOperationTimedOut        = "OperationTimedOut"
 	CloudErrorCodeNotFound                 = "NotFound"
 	CloudErrorCodeInvalidSubscriptionState = "InvalidSubscriptionState"
 	CloudErrorCodeSubscriptionNotFound     = "SubscriptionNotFound"
@@ -29,6 +31,10 @@ const (
 	CloudErrorCodeInvalidSubscriptionID    = "InvalidSubscriptionID"
 	CloudErrorCodeInvalidResourceName      = "InvalidResourceName"
 	CloudErrorCodeInvalidResourceGroupName = "InvalidResourceGroupName"
+	CloudErrorCodeQuotaExceeded            = "QuotaExceeded"
+	CloudErrorCodePreconditionFailed       = "PreconditionFailed"
+	CloudErrorCodeFeatureNotRegistered     = "FeatureNotRegistered"
+	CloudErrorCodeForbidden                = "Forbidden"
 )
 
 // CloudError represents a complete resource provider error.
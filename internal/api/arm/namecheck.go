@@ -0,0 +1,29 @@
+package arm
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+// See https://github.com/Azure/azure-resource-manager-rpc/blob/master/v1.0/proxy-api-reference.md#resource-name-availability
+
+// CheckNameAvailabilityReason explains why a name is not available.
+type CheckNameAvailabilityReason string
+
+const (
+	CheckNameAvailabilityReasonInvalid       CheckNameAvailabilityReason = "Invalid"
+	CheckNameAvailabilityReasonAlreadyExists CheckNameAvailabilityReason = "AlreadyExists"
+)
+
+// CheckNameAvailabilityRequest represents the body of a name availability request.
+// For nested resource types the name includes the names of the parent resources,
+// separated by slashes, as in deployment templates.
+type CheckNameAvailabilityRequest struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// CheckNameAvailabilityResponse represents the body of a name availability response.
+type CheckNameAvailabilityResponse struct {
+	NameAvailable bool                        `json:"nameAvailable"`
+	Reason        CheckNameAvailabilityReason `json:"reason,omitempty"`
+	Message       string                      `json:"message,omitempty"`
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"path"
+	"strings"
 )
 
 // See https://learn.microsoft.com/en-us/rest/api/datareplication/deployment-preflight/deployment-preflight?view=rest-datareplication-2021-02-16-preview&tabs=Go
@@ -63,9 +64,21 @@ func (r *DeploymentPreflightResource) Convert(v any) error {
 	return json.Unmarshal(data, v)
 }
 
-// ResourceID returns a resource ID string for the resource.
+// ResourceID returns a resource ID string for the resource. Names of nested
+// resources such as "cluster/nodepool" are interleaved with the type segments.
 func (r *DeploymentPreflightResource) ResourceID(subscriptionID, resourceGroup string) string {
-	return path.Join("/subscriptions", subscriptionID, "resourcegroups", resourceGroup, "providers", r.Type, r.Name)
+	types := strings.Split(r.Type, "/")
+	names := strings.Split(r.Name, "/")
+
+	segments := []string{"/subscriptions", subscriptionID, "resourcegroups", resourceGroup, "providers", types[0]}
+	for index, resourceType := range types[1:] {
+		segments = append(segments, resourceType)
+		if index < len(names) {
+			segments = append(segments, names[index])
+		}
+	}
+
+	return path.Join(segments...)
 }
 
 // DeploymentPreflightStatus is used in a DeploymentPreflightResponse.
@@ -0,0 +1,42 @@
+package arm
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"testing"
+)
+
+func TestDeploymentPreflightResourceID(t *testing.T) {
+	tests := []struct {
+		name             string
+		resource         DeploymentPreflightResource
+		expectResourceID string
+	}{
+		{
+			name: "Top-level resource",
+			resource: DeploymentPreflightResource{
+				Name: "myCluster",
+				Type: "Microsoft.RedHatOpenShift/hcpOpenShiftClusters",
+			},
+			expectResourceID: "/subscriptions/00000000-0000-0000-0000-000000000000/resourcegroups/myRG/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/myCluster",
+		},
+		{
+			name: "Nested resource",
+			resource: DeploymentPreflightResource{
+				Name: "myCluster/myNodePool",
+				Type: "Microsoft.RedHatOpenShift/hcpOpenShiftClusters/nodePools",
+			},
+			expectResourceID: "/subscriptions/00000000-0000-0000-0000-000000000000/resourcegroups/myRG/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/myCluster/nodePools/myNodePool",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resourceID := tt.resource.ResourceID("00000000-0000-0000-0000-000000000000", "myRG")
+			if resourceID != tt.expectResourceID {
+				t.Errorf("Expected resource ID '%s' but got '%s'", tt.expectResourceID, resourceID)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package arm
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+// See https://github.com/Azure/azure-resource-manager-rpc/blob/master/v1.0/proxy-api-reference.md#exposing-available-operations
+
+const (
+	// ProviderOperationOriginUserSystem is the default origin of a provider
+	// operation. The operation may be invoked by a user or by the system.
+	ProviderOperationOriginUserSystem = "user,system"
+)
+
+// ProviderOperation describes a REST API operation of a resource provider
+// for role-based access control. It is returned by the provider's operations
+// list endpoint.
+type ProviderOperation struct {
+	Name         string                   `json:"name"`
+	IsDataAction bool                     `json:"isDataAction"`
+	Display      ProviderOperationDisplay `json:"display"`
+	Origin       string                   `json:"origin,omitempty"`
+}
+
+// ProviderOperationDisplay is the localized display information for a
+// ProviderOperation.
+type ProviderOperationDisplay struct {
+	Provider    string `json:"provider"`
+	Resource    string `json:"resource"`
+	Operation   string `json:"operation"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,22 @@
+package arm
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+// See https://github.com/Azure/azure-resource-manager-rpc/blob/master/v1.0/proxy-api-reference.md
+
+const UsageUnitCount = "Count"
+
+// Usage reports the current usage of a resource against its limit.
+type Usage struct {
+	Name         UsageName `json:"name"`
+	CurrentValue int64     `json:"currentValue"`
+	Limit        int64     `json:"limit"`
+	Unit         string    `json:"unit"`
+}
+
+// UsageName is the name of a Usage.
+type UsageName struct {
+	Value          string `json:"value"`
+	LocalizedValue string `json:"localizedValue"`
+}
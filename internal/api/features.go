@@ -0,0 +1,178 @@
+package api
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+// FeatureStructTagKey gates struct fields behind Azure feature
+// registrations (AFEC). The tag value is a space-separated list of
+// gates. A gate of the form "Name" gates the field itself, while a
+// gate of the form "Name=Value" gates only that value of a string
+// enum field. Feature names are relative to ProviderNamespace.
+//
+// For example:
+//
+//	OutboundType OutboundType `json:"outboundType,omitempty" feature:"UserDefinedRouting=userDefinedRouting"`
+const FeatureStructTagKey = "feature"
+
+// FeatureStateRegistered is the state of a subscription feature
+// that has been registered.
+const FeatureStateRegistered = "Registered"
+
+// FeatureName returns the fully-qualified name of a feature
+// in the resource provider's namespace.
+func FeatureName(feature string) string {
+	return ProviderNamespace + "/" + feature
+}
+
+// IsFeatureRegistered returns true if the fully-qualified feature
+// is registered in a map of subscription feature names to states.
+func IsFeatureRegistered(features map[string]string, feature string) bool {
+	for name, state := range features {
+		if strings.EqualFold(name, feature) {
+			return strings.EqualFold(state, FeatureStateRegistered)
+		}
+	}
+	return false
+}
+
+// NewFeatureNotRegisteredError returns a CloudErrorBody for functionality
+// that requires a feature the subscription is not registered for.
+func NewFeatureNotRegisteredError(feature, target, format string, a ...interface{}) arm.CloudErrorBody {
+	return arm.CloudErrorBody{
+		Code: arm.CloudErrorCodeFeatureNotRegistered,
+		Message: fmt.Sprintf(
+			"%s requires the subscription to be registered for feature '%s'",
+			fmt.Sprintf(format, a...), feature),
+		Target: target,
+	}
+}
+
+// featureGate is a parsed element of a feature struct tag value.
+type featureGate struct {
+	feature string
+	value   string
+}
+
+func getFeatureGates(tag reflect.StructTag) []featureGate {
+	var gates []featureGate
+
+	for _, v := range strings.Fields(tag.Get(FeatureStructTagKey)) {
+		feature, value, _ := strings.Cut(v, "=")
+		if feature == "" {
+			panic(fmt.Sprintf("Invalid feature tag value '%s'", v))
+		}
+		gates = append(gates, featureGate{FeatureName(feature), value})
+	}
+
+	return gates
+}
+
+type validateFeatures struct {
+	features map[string]string
+	errs     []arm.CloudErrorBody
+}
+
+// ValidateFeatures compares the new value (newVal) to the current value
+// (curVal) of an internal API struct and returns an error for each
+// feature-gated field or enum value that the new value starts to use
+// without the feature being registered in features. Gated functionality
+// that is already in use is left alone so unregistering a feature does
+// not prevent unrelated updates.
+func ValidateFeatures(newVal, curVal interface{}, features map[string]string) []arm.CloudErrorBody {
+	vf := validateFeatures{
+		features: features,
+	}
+	vf.recurse(reflect.ValueOf(newVal), reflect.ValueOf(curVal), "", "", nil)
+	return vf.errs
+}
+
+// namespace and fieldname have the same meaning as in ValidateVisibility.
+func (vf *validateFeatures) recurse(newVal, curVal reflect.Value, namespace, fieldname string, gates []featureGate) {
+	if newVal.Type() != curVal.Type() {
+		panic(fmt.Sprintf("%s: value types differ (%s vs %s)", join(namespace, fieldname), newVal.Type().Name(), curVal.Type().Name()))
+	}
+
+	if len(gates) > 0 && !reflect.DeepEqual(newVal.Interface(), curVal.Interface()) {
+		vf.checkGates(newVal, namespace, fieldname, gates)
+	}
+
+	switch newVal.Kind() {
+	case reflect.Pointer:
+		if newVal.IsNil() {
+			return
+		}
+		if curVal.IsNil() {
+			curVal = reflect.New(newVal.Type().Elem())
+		}
+		vf.recurse(newVal.Elem(), curVal.Elem(), namespace, fieldname, nil)
+
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < newVal.Len(); i++ {
+			subscript := fmt.Sprintf("[%d]", i)
+			curElem := reflect.New(newVal.Type().Elem()).Elem()
+			if i < curVal.Len() {
+				curElem = curVal.Index(i)
+			}
+			vf.recurse(newVal.Index(i), curElem, namespace, fieldname+subscript, nil)
+		}
+
+	case reflect.Map:
+		iter := newVal.MapRange()
+		for iter.Next() {
+			subscript := fmt.Sprintf("[%q]", iter.Key().Interface())
+			curElem := reflect.New(newVal.Type().Elem()).Elem()
+			if !curVal.IsNil() && curVal.MapIndex(iter.Key()).IsValid() {
+				curElem = curVal.MapIndex(iter.Key())
+			}
+			vf.recurse(iter.Value(), curElem, namespace, fieldname+subscript, nil)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < newVal.NumField(); i++ {
+			structField := newVal.Type().Field(i)
+			if !structField.IsExported() {
+				continue
+			}
+			namespaceNext := namespace
+			fieldnameNext := fieldname
+			// Embedded structs share their parent's namespace.
+			if !structField.Anonymous {
+				namespaceNext = join(namespace, fieldname)
+				fieldnameNext = GetJSONTagName(structField.Tag)
+				if fieldnameNext == "" {
+					fieldnameNext = structField.Name
+				}
+			}
+			vf.recurse(newVal.Field(i), curVal.Field(i), namespaceNext, fieldnameNext, getFeatureGates(structField.Tag))
+		}
+	}
+}
+
+func (vf *validateFeatures) checkGates(newVal reflect.Value, namespace, fieldname string, gates []featureGate) {
+	for _, gate := range gates {
+		if IsFeatureRegistered(vf.features, gate.feature) {
+			continue
+		}
+
+		if gate.value == "" {
+			// Clearing a gated field does not use the feature.
+			if !newVal.IsZero() {
+				vf.errs = append(vf.errs, NewFeatureNotRegisteredError(
+					gate.feature, join(namespace, fieldname),
+					"Field '%s'", fieldname))
+			}
+		} else if newVal.Kind() == reflect.String && strings.EqualFold(newVal.String(), gate.value) {
+			vf.errs = append(vf.errs, NewFeatureNotRegisteredError(
+				gate.feature, join(namespace, fieldname),
+				"Value '%s' for field '%s'", newVal.String(), fieldname))
+		}
+	}
+}
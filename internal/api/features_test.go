@@ -0,0 +1,165 @@
+package api
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"testing"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+type FeatureTestStruct struct {
+	Name     string                       `json:"name,omitempty"`
+	Gated    string                       `json:"gated,omitempty"    feature:"GatedField"`
+	Mode     string                       `json:"mode,omitempty"     feature:"FastMode=fast FastMode=faster"`
+	Nested   FeatureTestNested            `json:"nested,omitempty"`
+	Pointer  *FeatureTestNested           `json:"pointer,omitempty"`
+	Elements []FeatureTestNested          `json:"elements,omitempty"`
+	Entries  map[string]FeatureTestNested `json:"entries,omitempty"`
+}
+
+type FeatureTestNested struct {
+	Enabled bool `json:"enabled,omitempty" feature:"NestedFeature"`
+}
+
+func TestValidateFeatures(t *testing.T) {
+	registered := func(features ...string) map[string]string {
+		m := map[string]string{}
+		for _, feature := range features {
+			m[FeatureName(feature)] = FeatureStateRegistered
+		}
+		return m
+	}
+
+	tests := []struct {
+		name          string
+		newVal        FeatureTestStruct
+		curVal        FeatureTestStruct
+		features      map[string]string
+		expectTargets []string
+	}{
+		{
+			name:   "Ungated field",
+			newVal: FeatureTestStruct{Name: "new"},
+		},
+		{
+			name:          "Gated field without feature",
+			newVal:        FeatureTestStruct{Gated: "value"},
+			expectTargets: []string{"gated"},
+		},
+		{
+			name:     "Gated field with feature",
+			newVal:   FeatureTestStruct{Gated: "value"},
+			features: registered("GatedField"),
+		},
+		{
+			name:   "Gated field with unregistered feature",
+			newVal: FeatureTestStruct{Gated: "value"},
+			features: map[string]string{
+				FeatureName("GatedField"): "Unregistered",
+			},
+			expectTargets: []string{"gated"},
+		},
+		{
+			name:   "Gated field with feature in different case",
+			newVal: FeatureTestStruct{Gated: "value"},
+			features: map[string]string{
+				"microsoft.redhatopenshift/gatedfield": "registered",
+			},
+		},
+		{
+			name:   "Gated field already in use",
+			newVal: FeatureTestStruct{Gated: "value", Name: "new"},
+			curVal: FeatureTestStruct{Gated: "value"},
+		},
+		{
+			name:   "Gated field cleared",
+			curVal: FeatureTestStruct{Gated: "value"},
+		},
+		{
+			name:   "Ungated enum value",
+			newVal: FeatureTestStruct{Mode: "slow"},
+		},
+		{
+			name:          "Gated enum value without feature",
+			newVal:        FeatureTestStruct{Mode: "FAST"},
+			expectTargets: []string{"mode"},
+		},
+		{
+			name:     "Gated enum value with feature",
+			newVal:   FeatureTestStruct{Mode: "faster"},
+			features: registered("FastMode"),
+		},
+		{
+			name:          "Nested gated field",
+			newVal:        FeatureTestStruct{Nested: FeatureTestNested{Enabled: true}},
+			expectTargets: []string{"nested.enabled"},
+		},
+		{
+			name:          "Pointer gated field",
+			newVal:        FeatureTestStruct{Pointer: &FeatureTestNested{Enabled: true}},
+			expectTargets: []string{"pointer.enabled"},
+		},
+		{
+			name: "Slice gated field",
+			newVal: FeatureTestStruct{Elements: []FeatureTestNested{
+				{Enabled: true},
+				{Enabled: true},
+			}},
+			curVal: FeatureTestStruct{Elements: []FeatureTestNested{
+				{Enabled: true},
+			}},
+			expectTargets: []string{"elements[1].enabled"},
+		},
+		{
+			name: "Map gated field",
+			newVal: FeatureTestStruct{Entries: map[string]FeatureTestNested{
+				"key": {Enabled: true},
+			}},
+			expectTargets: []string{"entries[\"key\"].enabled"},
+		},
+		{
+			name: "Multiple gated fields",
+			newVal: FeatureTestStruct{
+				Gated:  "value",
+				Mode:   "fast",
+				Nested: FeatureTestNested{Enabled: true},
+			},
+			features:      registered("FastMode"),
+			expectTargets: []string{"gated", "nested.enabled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateFeatures(tt.newVal, tt.curVal, tt.features)
+
+			if len(errs) != len(tt.expectTargets) {
+				t.Fatalf("Expected %d errors but got %d: %v", len(tt.expectTargets), len(errs), errs)
+			}
+			for index, err := range errs {
+				if err.Code != arm.CloudErrorCodeFeatureNotRegistered {
+					t.Errorf("Expected error code '%s' but got '%s'", arm.CloudErrorCodeFeatureNotRegistered, err.Code)
+				}
+				if err.Target != tt.expectTargets[index] {
+					t.Errorf("Expected error target '%s' but got '%s'", tt.expectTargets[index], err.Target)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFeaturesPanicsOnInvalidTag(t *testing.T) {
+	type invalid struct {
+		Field string `feature:"=value"`
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected ValidateFeatures to panic")
+		}
+	}()
+
+	ValidateFeatures(invalid{Field: "value"}, invalid{}, nil)
+}
@@ -4,6 +4,8 @@ package api
 // Licensed under the Apache License 2.0.
 
 import (
+	"time"
+
 	"github.com/Azure/ARO-HCP/internal/api/arm"
 )
 
@@ -17,7 +19,7 @@ type HCPOpenShiftCluster struct {
 // HCPOpenShiftClusterProperties represents the property bag of a HCPOpenShiftCluster resource.
 type HCPOpenShiftClusterProperties struct {
 	ProvisioningState             arm.ProvisioningState `json:"provisioningState,omitempty" visibility:"read"`
-	Version                       VersionProfile        `json:"version,omitempty"                       visibility:"read create"`
+	Version                       VersionProfile        `json:"version,omitempty"                       visibility:"read create update"`
 	DNS                           DNSProfile            `json:"dns,omitempty"                           visibility:"read create update"`
 	Network                       NetworkProfile        `json:"network,omitempty"                       visibility:"read create"`
 	Console                       ConsoleProfile        `json:"console,omitempty"                       visibility:"read"`
@@ -26,10 +28,11 @@ type HCPOpenShiftClusterProperties struct {
 	Platform                      PlatformProfile       `json:"platform,omitempty"                      visibility:"read create"`
 }
 
-// VersionProfile represents the cluster control plane version.
+// VersionProfile represents the version of a cluster control plane or node
+// pool. Updating the version ID requests an upgrade.
 type VersionProfile struct {
-	ID                string   `json:"id,omitempty"                visibility:"read create" validate:"required_for_put"`
-	ChannelGroup      string   `json:"channelGroup,omitempty"      visibility:"read create" validate:"required_for_put"`
+	ID                string   `json:"id,omitempty"                visibility:"read create update" validate:"required_for_put"`
+	ChannelGroup      string   `json:"channelGroup,omitempty"      visibility:"read create"        validate:"required_for_put"`
 	AvailableUpgrades []string `json:"availableUpgrades,omitempty" visibility:"read"`
 }
 
@@ -86,6 +89,13 @@ type UserAssignedIdentitiesProfile struct {
 	ServiceManagedIdentity string            `json:"serviceManagedIdentity,omitempty" validate:"omitempty,resource_id=Microsoft.ManagedIdentity/userAssignedIdentities"`
 }
 
+// HCPOpenShiftClusterAdminCredential represents a temporary break-glass
+// credential for an HCP OpenShift cluster.
+type HCPOpenShiftClusterAdminCredential struct {
+	ExpirationTimestamp time.Time `json:"expirationTimestamp,omitempty"`
+	Kubeconfig          string    `json:"kubeconfig,omitempty"`
+}
+
 // Creates an HCPOpenShiftCluster with any non-zero default values.
 func NewDefaultHCPOpenShiftCluster() *HCPOpenShiftCluster {
 	return &HCPOpenShiftCluster{
@@ -18,7 +18,7 @@ type HCPOpenShiftClusterNodePool struct {
 // HCPOpenShiftClusterNodePool resource.
 type HCPOpenShiftClusterNodePoolProperties struct {
 	ProvisioningState arm.ProvisioningState   `json:"provisioningState,omitempty" visibility:"read"`
-	Version           VersionProfile          `json:"version,omitempty" visibility:"read create"`
+	Version           VersionProfile          `json:"version,omitempty" visibility:"read create update"`
 	Platform          NodePoolPlatformProfile `json:"platform,omitempty" visibility:"read create"`
 	Replicas          int32                   `json:"replicas,omitempty" visibility:"read create update" validate:"min=0,excluded_with=AutoScaling"`
 	AutoRepair        bool                    `json:"autoRepair,omitempty" visibility:"read create"`
@@ -0,0 +1,21 @@
+package api
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+// HCPOpenShiftVersion represents an OpenShift version that clusters and
+// node pools in a location may request.
+type HCPOpenShiftVersion struct {
+	arm.Resource
+	Properties HCPOpenShiftVersionProperties `json:"properties,omitempty"`
+}
+
+// HCPOpenShiftVersionProperties represents the property bag of a HCPOpenShiftVersion resource.
+type HCPOpenShiftVersionProperties struct {
+	ClusterVersion string `json:"clusterVersion,omitempty"`
+	ChannelGroup   string `json:"channelGroup,omitempty"`
+}
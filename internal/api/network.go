@@ -0,0 +1,154 @@
+package api
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+// MaxClusterNodes is the maximum number of nodes across all node pools of
+// a cluster. The pod CIDR must be large enough to give each node its own
+// host prefix.
+const MaxClusterNodes = 500
+
+// subnetResourceType is the resource type of Azure virtual network subnets.
+var subnetResourceType = azcorearm.NewResourceType("Microsoft.Network", "virtualNetworks/subnets")
+
+// reservedCIDR is an IPv4 range that cluster networks must not overlap.
+type reservedCIDR struct {
+	prefix      netip.Prefix
+	description string
+}
+
+// reservedCIDRs either have special meaning to IPv4 or Azure, or are used
+// internally by OVN-Kubernetes.
+var reservedCIDRs = []reservedCIDR{
+	{netip.MustParsePrefix("0.0.0.0/8"), "the current network range"},
+	{netip.MustParsePrefix("127.0.0.0/8"), "the loopback range"},
+	{netip.MustParsePrefix("169.254.0.0/16"), "the link-local range"},
+	{netip.MustParsePrefix("224.0.0.0/4"), "the multicast range"},
+	{netip.MustParsePrefix("240.0.0.0/4"), "the reserved range"},
+	{netip.MustParsePrefix("168.63.129.16/32"), "the Azure platform address"},
+	{netip.MustParsePrefix("100.64.0.0/16"), "the OVN-Kubernetes join subnet"},
+	{netip.MustParsePrefix("100.88.0.0/16"), "the OVN-Kubernetes transit subnet"},
+}
+
+// ValidateNetworkProfile performs semantic validation of a cluster network
+// configuration, assuming each CIDR field is already known to be a valid v4
+// CIDR range. The ranges must not overlap one another or a reserved range,
+// and the host prefix must divide the pod CIDR into enough host subnets for
+// the maximum number of nodes.
+func ValidateNetworkProfile(network *NetworkProfile) []arm.CloudErrorBody {
+	var errorDetails []arm.CloudErrorBody
+
+	type namedCIDR struct {
+		field  string
+		prefix netip.Prefix
+	}
+
+	var cidrs []namedCIDR
+	for _, cidr := range []struct {
+		field string
+		value string
+	}{
+		{"podCidr", network.PodCIDR},
+		{"serviceCidr", network.ServiceCIDR},
+		{"machineCidr", network.MachineCIDR},
+	} {
+		prefix, err := netip.ParsePrefix(cidr.value)
+		if err == nil {
+			cidrs = append(cidrs, namedCIDR{cidr.field, prefix.Masked()})
+		}
+	}
+
+	for i, cidr := range cidrs {
+		for _, other := range cidrs[i+1:] {
+			if cidr.prefix.Overlaps(other.prefix) {
+				errorDetails = append(errorDetails, arm.CloudErrorBody{
+					Code:    arm.CloudErrorCodeInvalidRequestContent,
+					Message: fmt.Sprintf("Field '%s' (%s) overlaps with field '%s' (%s)", cidr.field, cidr.prefix, other.field, other.prefix),
+					Target:  "properties.network." + cidr.field,
+				})
+			}
+		}
+
+		for _, reserved := range reservedCIDRs {
+			if cidr.prefix.Overlaps(reserved.prefix) {
+				errorDetails = append(errorDetails, arm.CloudErrorBody{
+					Code:    arm.CloudErrorCodeInvalidRequestContent,
+					Message: fmt.Sprintf("Field '%s' (%s) overlaps with %s (%s)", cidr.field, cidr.prefix, reserved.description, reserved.prefix),
+					Target:  "properties.network." + cidr.field,
+				})
+			}
+		}
+	}
+
+	podCIDR, err := netip.ParsePrefix(network.PodCIDR)
+	if err == nil {
+		podBits := podCIDR.Bits()
+		hostPrefix := int(network.HostPrefix)
+
+		switch {
+		case hostPrefix < podBits || hostPrefix > 32:
+			errorDetails = append(errorDetails, arm.CloudErrorBody{
+				Code:    arm.CloudErrorCodeInvalidRequestContent,
+				Message: fmt.Sprintf("Invalid value '%d' for field 'hostPrefix' (must be between %d and 32 to fit the pod CIDR)", hostPrefix, podBits),
+				Target:  "properties.network.hostPrefix",
+			})
+		case 1<<(hostPrefix-podBits) < MaxClusterNodes:
+			errorDetails = append(errorDetails, arm.CloudErrorBody{
+				Code: arm.CloudErrorCodeInvalidRequestContent,
+				Message: fmt.Sprintf(
+					"Invalid value '%d' for field 'hostPrefix' (pod CIDR %s only has room for %d nodes, need %d)",
+					hostPrefix, podCIDR.Masked(), 1<<(hostPrefix-podBits), MaxClusterNodes),
+				Target: "properties.network.hostPrefix",
+			})
+		}
+	}
+
+	return errorDetails
+}
+
+// ValidateNodePoolSubnet checks that a node pool's subnet belongs to the
+// same virtual network as its cluster's subnet. A node pool without its
+// own subnet uses the cluster's subnet, which is always valid. If the
+// cluster's subnet ID cannot be parsed there is nothing to compare with.
+// It returns at most one error.
+func ValidateNodePoolSubnet(clusterSubnetID, nodePoolSubnetID string) []arm.CloudErrorBody {
+	const target = "properties.platform.subnetId"
+
+	if nodePoolSubnetID == "" {
+		return nil
+	}
+
+	nodePoolSubnet, err := azcorearm.ParseResourceID(nodePoolSubnetID)
+	if err != nil || !strings.EqualFold(nodePoolSubnet.ResourceType.String(), subnetResourceType.String()) {
+		return []arm.CloudErrorBody{{
+			Code:    arm.CloudErrorCodeInvalidRequestContent,
+			Message: fmt.Sprintf("Invalid value '%s' for field 'subnetId' (must be a valid '%s' resource ID)", nodePoolSubnetID, subnetResourceType),
+			Target:  target,
+		}}
+	}
+
+	clusterSubnet, err := azcorearm.ParseResourceID(clusterSubnetID)
+	if err != nil {
+		return nil
+	}
+
+	if !strings.EqualFold(nodePoolSubnet.Parent.String(), clusterSubnet.Parent.String()) {
+		return []arm.CloudErrorBody{{
+			Code:    arm.CloudErrorCodeInvalidRequestContent,
+			Message: fmt.Sprintf("Subnet '%s' is not in the cluster's virtual network '%s'", nodePoolSubnetID, clusterSubnet.Parent),
+			Target:  target,
+		}}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package api
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"testing"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+func TestValidateNetworkProfile(t *testing.T) {
+	tests := []struct {
+		name         string
+		network      NetworkProfile
+		expectErrors []arm.CloudErrorBody
+	}{
+		{
+			name: "Valid network",
+			network: NetworkProfile{
+				PodCIDR:     "10.128.0.0/14",
+				ServiceCIDR: "172.30.0.0/16",
+				MachineCIDR: "10.0.0.0/16",
+				HostPrefix:  23,
+			},
+		},
+		{
+			name: "Pod CIDR overlaps machine CIDR",
+			network: NetworkProfile{
+				PodCIDR:     "10.0.0.0/14",
+				ServiceCIDR: "172.30.0.0/16",
+				MachineCIDR: "10.0.0.0/16",
+				HostPrefix:  23,
+			},
+			expectErrors: []arm.CloudErrorBody{
+				{
+					Message: "Field 'podCidr' (10.0.0.0/14) overlaps with field 'machineCidr' (10.0.0.0/16)",
+					Target:  "properties.network.podCidr",
+				},
+			},
+		},
+		{
+			name: "Service CIDR overlaps reserved range",
+			network: NetworkProfile{
+				PodCIDR:     "10.128.0.0/14",
+				ServiceCIDR: "100.64.0.0/16",
+				MachineCIDR: "10.0.0.0/16",
+				HostPrefix:  23,
+			},
+			expectErrors: []arm.CloudErrorBody{
+				{
+					Message: "Field 'serviceCidr' (100.64.0.0/16) overlaps with the OVN-Kubernetes join subnet (100.64.0.0/16)",
+					Target:  "properties.network.serviceCidr",
+				},
+			},
+		},
+		{
+			name: "Host prefix shorter than pod CIDR",
+			network: NetworkProfile{
+				PodCIDR:     "10.128.0.0/14",
+				ServiceCIDR: "172.30.0.0/16",
+				MachineCIDR: "10.0.0.0/16",
+				HostPrefix:  12,
+			},
+			expectErrors: []arm.CloudErrorBody{
+				{
+					Message: "Invalid value '12' for field 'hostPrefix' (must be between 14 and 32 to fit the pod CIDR)",
+					Target:  "properties.network.hostPrefix",
+				},
+			},
+		},
+		{
+			name: "Pod CIDR too small for maximum nodes",
+			network: NetworkProfile{
+				PodCIDR:     "10.128.0.0/16",
+				ServiceCIDR: "172.30.0.0/16",
+				MachineCIDR: "10.0.0.0/16",
+				HostPrefix:  23,
+			},
+			expectErrors: []arm.CloudErrorBody{
+				{
+					Message: "Invalid value '23' for field 'hostPrefix' (pod CIDR 10.128.0.0/16 only has room for 128 nodes, need 500)",
+					Target:  "properties.network.hostPrefix",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualErrors := ValidateNetworkProfile(&tt.network)
+
+			diff := compareErrors(tt.expectErrors, actualErrors)
+			if diff != "" {
+				t.Fatalf("Expected error mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateNodePoolSubnet(t *testing.T) {
+	const (
+		vnetPrefix      = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/myRG/providers/Microsoft.Network/virtualNetworks/"
+		clusterSubnetID = vnetPrefix + "myVNet/subnets/clusterSubnet"
+	)
+
+	tests := []struct {
+		name             string
+		nodePoolSubnetID string
+		expectErrors     []arm.CloudErrorBody
+	}{
+		{
+			name: "No node pool subnet",
+		},
+		{
+			name:             "Subnet in cluster virtual network",
+			nodePoolSubnetID: vnetPrefix + "MYVNET/subnets/nodePoolSubnet",
+		},
+		{
+			name:             "Subnet in another virtual network",
+			nodePoolSubnetID: vnetPrefix + "otherVNet/subnets/nodePoolSubnet",
+			expectErrors: []arm.CloudErrorBody{
+				{
+					Message: "Subnet '" + vnetPrefix + "otherVNet/subnets/nodePoolSubnet' is not in the cluster's virtual network '" + vnetPrefix + "myVNet'",
+					Target:  "properties.platform.subnetId",
+				},
+			},
+		},
+		{
+			name:             "Not a subnet",
+			nodePoolSubnetID: vnetPrefix + "myVNet",
+			expectErrors: []arm.CloudErrorBody{
+				{
+					Message: "Invalid value '" + vnetPrefix + "myVNet' for field 'subnetId' (must be a valid 'Microsoft.Network/virtualNetworks/subnets' resource ID)",
+					Target:  "properties.platform.subnetId",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualErrors := ValidateNodePoolSubnet(clusterSubnetID, tt.nodePoolSubnetID)
+
+			diff := compareErrors(tt.expectErrors, actualErrors)
+			if diff != "" {
+				t.Fatalf("Expected error mismatch:\n%s", diff)
+			}
+		})
+	}
+}
@@ -18,12 +18,14 @@ const (
 	NodePoolResourceTypeName        = "nodePools"
 	OperationResultResourceTypeName = "hcpOperationResults"
 	OperationStatusResourceTypeName = "hcpOperationsStatus"
+	VersionResourceTypeName         = "hcpOpenShiftVersions"
 	ResourceTypeDisplay             = "Hosted Control Plane (HCP) OpenShift Clusters"
 )
 
 var (
 	ClusterResourceType  = azcorearm.NewResourceType(ProviderNamespace, ClusterResourceTypeName)
 	NodePoolResourceType = azcorearm.NewResourceType(ProviderNamespace, ClusterResourceTypeName+"/"+NodePoolResourceTypeName)
+	VersionResourceType  = azcorearm.NewResourceType(ProviderNamespace, "locations/"+VersionResourceTypeName)
 )
 
 type VersionedHCPOpenShiftCluster interface {
@@ -43,6 +45,10 @@ type Version interface {
 	// Passing a nil pointer creates a resource with default values.
 	NewHCPOpenShiftCluster(*HCPOpenShiftCluster) VersionedHCPOpenShiftCluster
 	NewHCPOpenShiftClusterNodePool(*HCPOpenShiftClusterNodePool) VersionedHCPOpenShiftClusterNodePool
+
+	// Response Types
+	NewHCPOpenShiftClusterAdminCredential(*HCPOpenShiftClusterAdminCredential) any
+	NewHCPOpenShiftVersion(*HCPOpenShiftVersion) any
 }
 
 // apiRegistry is the map of registered API versions
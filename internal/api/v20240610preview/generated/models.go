@@ -76,6 +76,15 @@ type ErrorResponse struct {
 	Error *ErrorDetail
 }
 
+// HcpOpenShiftClusterAdminCredential - HCP cluster admin kubeconfig
+type HcpOpenShiftClusterAdminCredential struct {
+	// READ-ONLY; Expiration timestamp for the kubeconfig's token
+	ExpirationTimestamp *time.Time
+
+	// READ-ONLY; Admin kubeconfig with a temporary token
+	Kubeconfig *string
+}
+
 // HcpOpenShiftClusterNodePoolPatch - The template for adding optional properties.
 type HcpOpenShiftClusterNodePoolPatch struct {
 	// Managed Service Identity
@@ -142,6 +151,9 @@ type HcpOpenShiftClusterPatchProperties struct {
 	// Disable user workload monitoring
 	DisableUserWorkloadMonitoring *bool
 
+	// Version of the control plane components
+	Version *VersionProfile
+
 	// READ-ONLY; The status of the last operation.
 	ProvisioningState *ProvisioningState
 }
@@ -238,6 +250,9 @@ type HcpOpenShiftVersionResourceListResult struct {
 
 // HcpOpenShiftVersionsProperties is the installable cluster version
 type HcpOpenShiftVersionsProperties struct {
+	// READ-ONLY; The channel group the cluster version belongs to
+	ChannelGroup *string
+
 	// READ-ONLY; The cluster version
 	ClusterVersion *string
 
@@ -324,6 +339,9 @@ type NodePoolPatchProperties struct {
 	// Taints for the nodes
 	Taints []*Taint
 
+	// OpenShift version for the nodepool
+	Version *VersionProfile
+
 	// READ-ONLY; Provisioning state
 	ProvisioningState *ResourceProvisioningState
 }
@@ -580,7 +598,7 @@ type VersionProfile struct {
 	// REQUIRED; ChannelGroup is the name of the set to which this version belongs. Each version belongs to only a single set.
 	ChannelGroup *string
 
-	// REQUIRED; ID is the unique identifier of the version.
+	// REQUIRED; ID is the unique identifier of the version. Changing it starts an upgrade to one of the available upgrades.
 	ID *string
 
 	// READ-ONLY; AvailableUpgrades is a list of version names the current version can be upgraded to.
@@ -250,6 +250,39 @@ func (e *ErrorResponse) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON implements the json.Marshaller interface for type HcpOpenShiftClusterAdminCredential.
+func (h HcpOpenShiftClusterAdminCredential) MarshalJSON() ([]byte, error) {
+	objectMap := make(map[string]any)
+	populateDateTimeRFC3339(objectMap, "expirationTimestamp", h.ExpirationTimestamp)
+	populate(objectMap, "kubeconfig", h.Kubeconfig)
+	return json.Marshal(objectMap)
+}
+
+// UnmarshalJSON implements the json.Unmarshaller interface for type HcpOpenShiftClusterAdminCredential.
+func (h *HcpOpenShiftClusterAdminCredential) UnmarshalJSON(data []byte) error {
+	var rawMsg map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rawMsg); err != nil {
+		return fmt.Errorf("unmarshalling type %T: %v", h, err)
+	}
+	for key, val := range rawMsg {
+		var err error
+		switch key {
+		case "expirationTimestamp":
+			err = unpopulateDateTimeRFC3339(val, "ExpirationTimestamp", &h.ExpirationTimestamp)
+			delete(rawMsg, key)
+		case "kubeconfig":
+			err = unpopulate(val, "Kubeconfig", &h.Kubeconfig)
+			delete(rawMsg, key)
+		default:
+			err = fmt.Errorf("unmarshalling type %T, unknown field %q", h, key)
+		}
+		if err != nil {
+			return fmt.Errorf("unmarshalling type %T: %v", h, err)
+		}
+	}
+	return nil
+}
+
 // MarshalJSON implements the json.Marshaller interface for type HcpOpenShiftClusterNodePoolPatch.
 func (h HcpOpenShiftClusterNodePoolPatch) MarshalJSON() ([]byte, error) {
 	objectMap := make(map[string]any)
@@ -419,6 +452,7 @@ func (h HcpOpenShiftClusterPatchProperties) MarshalJSON() ([]byte, error) {
 	objectMap := make(map[string]any)
 	populate(objectMap, "disableUserWorkloadMonitoring", h.DisableUserWorkloadMonitoring)
 	populate(objectMap, "provisioningState", h.ProvisioningState)
+	populate(objectMap, "version", h.Version)
 	return json.Marshal(objectMap)
 }
 
@@ -437,6 +471,9 @@ func (h *HcpOpenShiftClusterPatchProperties) UnmarshalJSON(data []byte) error {
 		case "provisioningState":
 			err = unpopulate(val, "ProvisioningState", &h.ProvisioningState)
 			delete(rawMsg, key)
+		case "version":
+			err = unpopulate(val, "Version", &h.Version)
+			delete(rawMsg, key)
 		default:
 			err = fmt.Errorf("unmarshalling type %T, unknown field %q", h, key)
 		}
@@ -675,6 +712,7 @@ func (h *HcpOpenShiftVersionResourceListResult) UnmarshalJSON(data []byte) error
 // MarshalJSON implements the json.Marshaller interface for type HcpOpenShiftVersionsProperties.
 func (h HcpOpenShiftVersionsProperties) MarshalJSON() ([]byte, error) {
 	objectMap := make(map[string]any)
+	populate(objectMap, "channelGroup", h.ChannelGroup)
 	populate(objectMap, "clusterVersion", h.ClusterVersion)
 	populate(objectMap, "provisioningState", h.ProvisioningState)
 	return json.Marshal(objectMap)
@@ -689,6 +727,9 @@ func (h *HcpOpenShiftVersionsProperties) UnmarshalJSON(data []byte) error {
 	for key, val := range rawMsg {
 		var err error
 		switch key {
+		case "channelGroup":
+			err = unpopulate(val, "ChannelGroup", &h.ChannelGroup)
+			delete(rawMsg, key)
 		case "clusterVersion":
 			err = unpopulate(val, "ClusterVersion", &h.ClusterVersion)
 			delete(rawMsg, key)
@@ -898,6 +939,7 @@ func (n NodePoolPatchProperties) MarshalJSON() ([]byte, error) {
 	populate(objectMap, "provisioningState", n.ProvisioningState)
 	populate(objectMap, "replicas", n.Replicas)
 	populate(objectMap, "taints", n.Taints)
+	populate(objectMap, "version", n.Version)
 	return json.Marshal(objectMap)
 }
 
@@ -925,6 +967,9 @@ func (n *NodePoolPatchProperties) UnmarshalJSON(data []byte) error {
 		case "taints":
 			err = unpopulate(val, "Taints", &n.Taints)
 			delete(rawMsg, key)
+		case "version":
+			err = unpopulate(val, "Version", &n.Version)
+			delete(rawMsg, key)
 		default:
 			err = fmt.Errorf("unmarshalling type %T, unknown field %q", n, key)
 		}
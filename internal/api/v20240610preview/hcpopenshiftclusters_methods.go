@@ -125,6 +125,13 @@ func (v version) NewHCPOpenShiftCluster(from *api.HCPOpenShiftCluster) api.Versi
 	return out
 }
 
+func (v version) NewHCPOpenShiftClusterAdminCredential(from *api.HCPOpenShiftClusterAdminCredential) any {
+	return &generated.HcpOpenShiftClusterAdminCredential{
+		ExpirationTimestamp: api.Ptr(from.ExpirationTimestamp),
+		Kubeconfig:          api.Ptr(from.Kubeconfig),
+	}
+}
+
 func (c *HcpOpenShiftClusterResource) Normalize(out *api.HCPOpenShiftCluster) {
 	if c.ID != nil {
 		out.Resource.ID = *c.ID
@@ -315,6 +322,15 @@ func (c *HcpOpenShiftClusterResource) ValidateStatic(current api.VersionedHCPOpe
 		if errorDetails != nil {
 			cloudError.Details = append(cloudError.Details, errorDetails...)
 		}
+
+		// The network profile cannot change after cluster creation,
+		// so only validate its semantics when creating a cluster.
+		if !updating {
+			errorDetails = api.ValidateNetworkProfile(&normalized.Properties.Network)
+			if errorDetails != nil {
+				cloudError.Details = append(cloudError.Details, errorDetails...)
+			}
+		}
 	}
 
 	switch len(cloudError.Details) {
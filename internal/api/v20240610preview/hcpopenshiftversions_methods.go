@@ -0,0 +1,23 @@
+package v20240610preview
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/v20240610preview/generated"
+)
+
+func (v version) NewHCPOpenShiftVersion(from *api.HCPOpenShiftVersion) any {
+	return &generated.HcpOpenShiftVersionResource{
+		ID:   api.Ptr(from.ID),
+		Name: api.Ptr(from.Name),
+		Type: api.Ptr(from.Type),
+		Properties: &generated.HcpOpenShiftVersionsProperties{
+			ChannelGroup:   api.Ptr(from.Properties.ChannelGroup),
+			ClusterVersion: api.Ptr(from.Properties.ClusterVersion),
+			// Versions are read-only, so they are always provisioned.
+			ProvisioningState: api.Ptr(generated.ResourceProvisioningStateSucceeded),
+		},
+	}
+}
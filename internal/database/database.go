@@ -10,6 +10,7 @@ import (
 	"iter"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
@@ -24,9 +25,25 @@ const (
 	locksContainer     = "Locks"
 	resourcesContainer = "Resources"
 
-	operationTimeToLive = 604800 // 7 days
+	notificationTimeToLive = 604800 // 7 days
 )
 
+// OperationTimeToLive is how long Cosmos DB keeps an operation document
+// after it reaches a terminal status. This is a backstop for operations
+// the backend never gets around to deleting, such as those of unregistered
+// subscriptions, so the backend's operation retention must not exceed it.
+const OperationTimeToLive = 7 * 24 * time.Hour
+
+// operationDocTimeToLive returns the time-to-live in seconds of an operation
+// document's container item, which is zero, meaning the item does not expire,
+// until the operation reaches a terminal status.
+func operationDocTimeToLive(doc *OperationDocument) int {
+	if doc.Status.IsTerminal() {
+		return int(OperationTimeToLive / time.Second)
+	}
+	return 0
+}
+
 var ErrNotFound = errors.New("not found")
 
 func isResponseError(err error, statusCode int) bool {
@@ -54,7 +71,7 @@ type DBClient interface {
 	DBConnectionTest(ctx context.Context) error
 
 	// GetLockClient returns a LockClient, or nil if the DBClient does not support a LockClient.
-	GetLockClient() *LockClient
+	GetLockClient() LockClient
 
 	// GetResourceDoc queries the "Resources" container for a cluster or node pool document with a
 	// matching resourceID.
@@ -109,6 +126,10 @@ type DBClient interface {
 	// document was successfully replaced, or false with or without an error to indicate no change.
 	UpdateOperationDoc(ctx context.Context, pk azcosmos.PartitionKey, operationID string, callback func(*OperationDocument) bool) (bool, error)
 
+	// DeleteOperationDoc deletes an asynchronous operation document from the "Resources" container.
+	// If no matching document is found, DeleteOperationDoc returns nil as though it had succeeded.
+	DeleteOperationDoc(ctx context.Context, pk azcosmos.PartitionKey, operationID string) error
+
 	// ListOperationDocs returns an iterator that searches for asynchronous operation documents
 	// in the "Resources" container under the given partition key.
 	//
@@ -117,6 +138,67 @@ type DBClient interface {
 	// the iterator in a ranged for loop.
 	ListOperationDocs(pk azcosmos.PartitionKey) DBClientIterator[OperationDocument]
 
+	// ListResourceOperationDocs returns an iterator that searches for asynchronous operation
+	// documents in the "Resources" container for the given cluster or node pool, most recently
+	// started first. Documents not matching the filter are omitted.
+	//
+	// Note that ListResourceOperationDocs does not perform the search, but merely prepares an
+	// iterator to do so. Hence the lack of a Context argument. The search is performed by calling
+	// Items() on the iterator in a ranged for loop.
+	//
+	// maxItems and continuationToken behave the same as for ListResourceDocs.
+	ListResourceOperationDocs(resourceID *azcorearm.ResourceID, filter OperationDocumentFilter, maxItems int32, continuationToken *string) DBClientIterator[OperationDocument]
+
+	// ListOperationDocChanges returns an iterator over asynchronous operation documents in all
+	// partitions of the "Resources" container that were created or modified since the given
+	// continuation token was issued. An empty continuation token starts from the beginning.
+	// Only the latest version of a changed document is yielded, and a document may be yielded
+	// again by a subsequent call even if it has not changed since.
+	//
+	// Once iteration completes without error, GetContinuationToken on the iterator returns the
+	// token to supply on the next call. If iteration stops early, the continuation token given
+	// here is returned unchanged.
+	//
+	// Note that ListOperationDocChanges does not perform the search, but merely prepares an
+	// iterator to do so. Hence the lack of a Context argument. The search is performed by calling
+	// Items() on the iterator in a ranged for loop.
+	ListOperationDocChanges(continuationToken string) DBClientIterator[OperationDocument]
+
+	// GetChangeFeedToken retrieves the continuation token last persisted for the named change
+	// feed consumer from the "Resources" container. If no token has been persisted, it returns
+	// an empty string.
+	GetChangeFeedToken(ctx context.Context, name string) (string, error)
+
+	// SetChangeFeedToken persists a continuation token for the named change feed consumer in
+	// the "Resources" container, replacing any token previously persisted.
+	SetChangeFeedToken(ctx context.Context, name, continuationToken string) error
+
+	// CreateNotificationDoc creates a new async notification document in the "Resources" container,
+	// in the same partition as the asynchronous operation it refers to. The document ID is derived
+	// from the operation ID and status with NotificationID. If a document for the same operation
+	// and status already exists, the boolean return value is false and the existing document is
+	// left unchanged.
+	CreateNotificationDoc(ctx context.Context, doc *NotificationDocument) (bool, error)
+
+	// UpdateNotificationDoc updates an async notification document in the "Resources" container by
+	// first fetching the document and passing it to the provided callback for modifications to be
+	// applied. It then attempts to replace the existing document with the modified document and an
+	// "etag" precondition. Upon a precondition failure the function repeats for a limited number of
+	// times before giving up.
+	//
+	// The callback function should return true if modifications were applied, signaling to proceed
+	// with the document replacement. The boolean return value reflects this: returning true if the
+	// document was successfully replaced, or false with or without an error to indicate no change.
+	UpdateNotificationDoc(ctx context.Context, pk azcosmos.PartitionKey, notificationID string, callback func(*NotificationDocument) bool) (bool, error)
+
+	// ListPendingNotificationDocs returns an iterator that searches for async notification
+	// documents awaiting delivery in all partitions of the "Resources" container.
+	//
+	// Note that ListPendingNotificationDocs does not perform the search, but merely prepares an
+	// iterator to do so. Hence the lack of a Context argument. The search is performed by calling
+	// Items() on the iterator in a ranged for loop.
+	ListPendingNotificationDocs() DBClientIterator[NotificationDocument]
+
 	// GetSubscriptionDoc retrieves a subscription document from the "Resources" container.
 	GetSubscriptionDoc(ctx context.Context, subscriptionID string) (*arm.Subscription, error)
 
@@ -151,7 +233,7 @@ var _ DBClient = &cosmosDBClient{}
 type cosmosDBClient struct {
 	database   *azcosmos.DatabaseClient
 	resources  *azcosmos.ContainerClient
-	lockClient *LockClient
+	lockClient LockClient
 }
 
 // NewDBClient instantiates a DBClient from a Cosmos DatabaseClient instance
@@ -182,7 +264,7 @@ func (d *cosmosDBClient) DBConnectionTest(ctx context.Context) error {
 	return nil
 }
 
-func (d *cosmosDBClient) GetLockClient() *LockClient {
+func (d *cosmosDBClient) GetLockClient() LockClient {
 	return d.lockClient
 }
 
@@ -382,7 +464,7 @@ func (d *cosmosDBClient) CreateOperationDoc(ctx context.Context, doc *OperationD
 	subscriptionID := strings.ToLower(doc.ExternalID.SubscriptionID)
 
 	typedDoc := newTypedDocument(subscriptionID, OperationResourceType)
-	typedDoc.TimeToLive = operationTimeToLive
+	typedDoc.TimeToLive = operationDocTimeToLive(doc)
 
 	data, err := typedDocumentMarshal(typedDoc, doc)
 	if err != nil {
@@ -416,6 +498,8 @@ func (d *cosmosDBClient) UpdateOperationDoc(ctx context.Context, pk azcosmos.Par
 			return false, nil
 		}
 
+		typedDoc.TimeToLive = operationDocTimeToLive(innerDoc)
+
 		data, err = typedDocumentMarshal(typedDoc, innerDoc)
 		if err != nil {
 			return false, fmt.Errorf("failed to marshal Operations container item for '%s': %w", operationID, err)
@@ -437,6 +521,17 @@ func (d *cosmosDBClient) UpdateOperationDoc(ctx context.Context, pk azcosmos.Par
 	return false, err
 }
 
+func (d *cosmosDBClient) DeleteOperationDoc(ctx context.Context, pk azcosmos.PartitionKey, operationID string) error {
+	// Make sure lookup keys are lowercase.
+	operationID = strings.ToLower(operationID)
+
+	_, err := d.resources.DeleteItem(ctx, pk, operationID, nil)
+	if err != nil && !isResponseError(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to delete Operations container item for '%s': %w", operationID, err)
+	}
+	return nil
+}
+
 func (d *cosmosDBClient) ListOperationDocs(pk azcosmos.PartitionKey) DBClientIterator[OperationDocument] {
 	const query = "SELECT * FROM c WHERE STRINGEQUALS(c.resourceType, @resourceType, true)"
 	opt := azcosmos.QueryOptions{
@@ -453,6 +548,232 @@ func (d *cosmosDBClient) ListOperationDocs(pk azcosmos.PartitionKey) DBClientIte
 	return newQueryItemsIterator[OperationDocument](pager)
 }
 
+func (d *cosmosDBClient) ListResourceOperationDocs(resourceID *azcorearm.ResourceID, filter OperationDocumentFilter, maxItems int32, continuationToken *string) DBClientIterator[OperationDocument] {
+	pk := NewPartitionKey(resourceID.SubscriptionID)
+
+	// See the comment in ListResourceDocs.
+	maxItems = max(maxItems, -1)
+
+	query := "SELECT * FROM c WHERE STRINGEQUALS(c.resourceType, @resourceType, true) AND STRINGEQUALS(c.properties.externalId, @externalId, true)"
+	opt := azcosmos.QueryOptions{
+		PageSizeHint:      maxItems,
+		ContinuationToken: continuationToken,
+		QueryParameters: []azcosmos.QueryParameter{
+			{
+				Name:  "@resourceType",
+				Value: OperationResourceType.String(),
+			},
+			{
+				Name:  "@externalId",
+				Value: resourceID.String(),
+			},
+		},
+	}
+
+	if filter.Request != "" {
+		query += " AND c.properties.request = @request"
+		opt.QueryParameters = append(opt.QueryParameters, azcosmos.QueryParameter{
+			Name:  "@request",
+			Value: string(filter.Request),
+		})
+	}
+
+	if filter.Status != "" {
+		query += " AND c.properties.status = @status"
+		opt.QueryParameters = append(opt.QueryParameters, azcosmos.QueryParameter{
+			Name:  "@status",
+			Value: string(filter.Status),
+		})
+	}
+
+	if filter.ClientRequestID != "" {
+		query += " AND STRINGEQUALS(c.properties.clientRequestId, @clientRequestId, true)"
+		opt.QueryParameters = append(opt.QueryParameters, azcosmos.QueryParameter{
+			Name:  "@clientRequestId",
+			Value: filter.ClientRequestID,
+		})
+	}
+
+	query += " ORDER BY c.properties.startTime DESC"
+
+	pager := d.resources.NewQueryItemsPager(query, pk, &opt)
+
+	if maxItems > 0 {
+		return newQueryItemsSinglePageIterator[OperationDocument](pager)
+	} else {
+		return newQueryItemsIterator[OperationDocument](pager)
+	}
+}
+
+func (d *cosmosDBClient) ListOperationDocChanges(continuationToken string) DBClientIterator[OperationDocument] {
+	// XXX The Cosmos DB Go SDK does not yet expose the change feed,
+	//     so approximate it with a query on the system-generated "_ts"
+	//     property. Like the change feed in "latest version" mode this
+	//     yields the latest version of each created or modified item.
+	const query = "SELECT * FROM c WHERE STRINGEQUALS(c.resourceType, @resourceType, true) AND c._ts >= @timestamp"
+
+	timestamp, err := parseChangeFeedToken(continuationToken)
+	if err != nil {
+		return newErrorIterator[OperationDocument](err)
+	}
+
+	opt := azcosmos.QueryOptions{
+		QueryParameters: []azcosmos.QueryParameter{
+			{
+				Name:  "@resourceType",
+				Value: OperationResourceType.String(),
+			},
+			{
+				Name:  "@timestamp",
+				Value: timestamp,
+			},
+		},
+	}
+
+	// Empty partition key triggers a cross-partition query.
+	pager := d.resources.NewQueryItemsPager(query, azcosmos.NewPartitionKey(), &opt)
+
+	return newChangeFeedIterator[OperationDocument](pager, timestamp)
+}
+
+func (d *cosmosDBClient) GetChangeFeedToken(ctx context.Context, name string) (string, error) {
+	// Make sure lookup keys are lowercase.
+	name = strings.ToLower(name)
+
+	response, err := d.resources.ReadItem(ctx, azcosmos.NewPartitionKeyString(name), name, nil)
+	if err != nil {
+		if isResponseError(err, http.StatusNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read Resources container item for '%s': %w", name, err)
+	}
+
+	_, innerDoc, err := typedDocumentUnmarshal[ChangeFeedDocument](response.Value)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal Resources container item for '%s': %w", name, err)
+	}
+
+	return innerDoc.ContinuationToken, nil
+}
+
+func (d *cosmosDBClient) SetChangeFeedToken(ctx context.Context, name, continuationToken string) error {
+	typedDoc := newTypedDocument(name, ChangeFeedResourceType)
+	typedDoc.ID = strings.ToLower(name)
+
+	data, err := typedDocumentMarshal(typedDoc, &ChangeFeedDocument{ContinuationToken: continuationToken})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Resources container item for '%s': %w", name, err)
+	}
+
+	_, err = d.resources.UpsertItem(ctx, typedDoc.getPartitionKey(), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to upsert Resources container item for '%s': %w", name, err)
+	}
+
+	return nil
+}
+
+func (d *cosmosDBClient) getNotificationDoc(ctx context.Context, pk azcosmos.PartitionKey, notificationID string) (*typedDocument, *NotificationDocument, error) {
+	// Make sure lookup keys are lowercase.
+	notificationID = strings.ToLower(notificationID)
+
+	response, err := d.resources.ReadItem(ctx, pk, notificationID, nil)
+	if err != nil {
+		if isResponseError(err, http.StatusNotFound) {
+			err = ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to read Resources container item for '%s': %w", notificationID, err)
+	}
+
+	typedDoc, innerDoc, err := typedDocumentUnmarshal[NotificationDocument](response.Value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal Resources container item for '%s': %w", notificationID, err)
+	}
+
+	return typedDoc, innerDoc, nil
+}
+
+func (d *cosmosDBClient) CreateNotificationDoc(ctx context.Context, doc *NotificationDocument) (bool, error) {
+	typedDoc := newTypedDocument(doc.SubscriptionID, NotificationResourceType)
+	typedDoc.ID = NotificationID(doc.OperationID, doc.Status)
+	typedDoc.TimeToLive = notificationTimeToLive
+
+	data, err := typedDocumentMarshal(typedDoc, doc)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal Resources container item for '%s': %w", typedDoc.ID, err)
+	}
+
+	_, err = d.resources.CreateItem(ctx, typedDoc.getPartitionKey(), data, nil)
+	if isResponseError(err, http.StatusConflict) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to create Resources container item for '%s': %w", typedDoc.ID, err)
+	}
+
+	return true, nil
+}
+
+func (d *cosmosDBClient) UpdateNotificationDoc(ctx context.Context, pk azcosmos.PartitionKey, notificationID string, callback func(*NotificationDocument) bool) (bool, error) {
+	var err error
+
+	options := &azcosmos.ItemOptions{}
+
+	for try := 0; try < 5; try++ {
+		var typedDoc *typedDocument
+		var innerDoc *NotificationDocument
+		var data []byte
+
+		typedDoc, innerDoc, err = d.getNotificationDoc(ctx, pk, notificationID)
+		if err != nil {
+			return false, err
+		}
+
+		if !callback(innerDoc) {
+			return false, nil
+		}
+
+		data, err = typedDocumentMarshal(typedDoc, innerDoc)
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal Resources container item for '%s': %w", notificationID, err)
+		}
+
+		options.IfMatchEtag = &typedDoc.CosmosETag
+		_, err = d.resources.ReplaceItem(ctx, pk, typedDoc.ID, data, options)
+		if err == nil {
+			return true, nil
+		}
+
+		var responseError *azcore.ResponseError
+		err = fmt.Errorf("failed to replace Resources container item for '%s': %w", notificationID, err)
+		if !errors.As(err, &responseError) || responseError.StatusCode != http.StatusPreconditionFailed {
+			return false, err
+		}
+	}
+
+	return false, err
+}
+
+func (d *cosmosDBClient) ListPendingNotificationDocs() DBClientIterator[NotificationDocument] {
+	const query = "SELECT * FROM c WHERE STRINGEQUALS(c.resourceType, @resourceType, true) AND c.properties.state = @state"
+	opt := azcosmos.QueryOptions{
+		QueryParameters: []azcosmos.QueryParameter{
+			{
+				Name:  "@resourceType",
+				Value: NotificationResourceType.String(),
+			},
+			{
+				Name:  "@state",
+				Value: string(NotificationStatePending),
+			},
+		},
+	}
+
+	// Empty partition key triggers a cross-partition query.
+	pager := d.resources.NewQueryItemsPager(query, azcosmos.NewPartitionKey(), &opt)
+
+	return newQueryItemsIterator[NotificationDocument](pager)
+}
+
 func (d *cosmosDBClient) getSubscriptionDoc(ctx context.Context, subscriptionID string) (*typedDocument, *arm.Subscription, error) {
 	// Make sure lookup keys are lowercase.
 	subscriptionID = strings.ToLower(subscriptionID)
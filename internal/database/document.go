@@ -4,6 +4,8 @@ package database
 // Licensed under the Apache License 2.0.
 
 import (
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
@@ -52,6 +54,16 @@ type ResourceDocument struct {
 	Identity          *arm.ManagedServiceIdentity `json:"identity,omitempty"`
 	SystemData        *arm.SystemData             `json:"systemData,omitempty"`
 	Tags              map[string]string           `json:"tags,omitempty"`
+
+	// ETag is the resource's entity tag, which changes whenever a
+	// client creates or updates the resource. It is distinct from the
+	// Cosmos DB etag, which also changes with internal bookkeeping.
+	ETag azcore.ETag `json:"etag,omitempty"`
+}
+
+// NewResourceETag returns a unique entity tag for a resource.
+func NewResourceETag() azcore.ETag {
+	return azcore.ETag(strconv.Quote(uuid.New().String()))
 }
 
 func NewResourceDocument(resourceID *azcorearm.ResourceID) *ResourceDocument {
@@ -74,13 +86,28 @@ const (
 	OperationRequestCreate OperationRequest = "Create"
 	OperationRequestUpdate OperationRequest = "Update"
 	OperationRequestDelete OperationRequest = "Delete"
+
+	// Cluster-only requests
+	OperationRequestRequestCredential OperationRequest = "RequestCredential"
+	OperationRequestRevokeCredentials OperationRequest = "RevokeCredentials"
 )
 
+// OperationDocumentFilter narrows a search for asynchronous operation
+// documents. Zero-valued fields match any document.
+type OperationDocumentFilter struct {
+	Request         OperationRequest
+	Status          arm.ProvisioningState
+	ClientRequestID string
+}
+
 // OperationResourceType is an artificial resource type for OperationDocuments
 // in Cosmos DB. It omits the location segment from actual operation endpoints.
 var OperationResourceType = azcorearm.NewResourceType(api.ProviderNamespace, api.OperationStatusResourceTypeName)
 
-// OperationDocument tracks an asynchronous operation.
+// OperationDocument tracks an asynchronous operation. The backend deletes
+// operation documents once they have been in a terminal status for longer
+// than its operation retention period. Failing that, they expire on their
+// own after OperationTimeToLive.
 type OperationDocument struct {
 	// TenantID is the tenant ID of the client that requested the operation
 	TenantID string `json:"tenantId,omitempty"`
@@ -91,6 +118,8 @@ type OperationDocument struct {
 	// ExternalID is the Azure resource ID of the cluster or node pool
 	ExternalID *azcorearm.ResourceID `json:"externalId,omitempty"`
 	// InternalID is the Cluster Service resource identifier in the form of a URL path
+	// (for credential requests this identifies the break-glass credential rather than
+	// the cluster)
 	InternalID ocm.InternalID `json:"internalId,omitempty"`
 	// OperationID is the Azure resource ID of the operation status (may be nil if the
 	// operation was implicit, such as deleting a child resource along with the parent)
@@ -98,6 +127,17 @@ type OperationDocument struct {
 	// NotificationURI is provided by the Azure-AsyncNotificationUri header if the
 	// Async Operation Callbacks ARM feature is enabled
 	NotificationURI string `json:"notificationUri,omitempty"`
+	// NotificationPending is set when the operation reaches a new status for
+	// which an async notification has yet to be recorded in the notification
+	// outbox, including when a client cancels the operation, so the backend
+	// knows to record and post the notification
+	NotificationPending bool `json:"notificationPending,omitempty"`
+	// ClientRequestID is provided by the X-Ms-Client-Request-Id header of the
+	// request that started the operation, which ARM reuses when retrying
+	ClientRequestID string `json:"clientRequestId,omitempty"`
+	// RequestBodyHash is a hex-encoded SHA-256 hash of the body of the request
+	// that started the operation, to tell retries from different requests
+	RequestBodyHash string `json:"requestBodyHash,omitempty"`
 
 	// StartTime marks the start of the operation
 	StartTime time.Time `json:"startTime,omitempty"`
@@ -108,6 +148,9 @@ type OperationDocument struct {
 	Status arm.ProvisioningState `json:"status,omitempty"`
 	// Error is an OData error, present when Status is "Failed" or "Canceled"
 	Error *arm.CloudErrorBody `json:"error,omitempty"`
+	// PercentComplete is an estimate of operation progress, for operations
+	// where Cluster Service reports progress (such as upgrades)
+	PercentComplete float64 `json:"percentComplete,omitempty"`
 }
 
 func NewOperationDocument(request OperationRequest, externalID *azcorearm.ResourceID, internalID ocm.InternalID) *OperationDocument {
@@ -139,11 +182,12 @@ func (doc OperationDocument) GetValidTypes() []string {
 // ToStatus converts an OperationDocument to the ARM operation status format.
 func (doc *OperationDocument) ToStatus() *arm.Operation {
 	operation := &arm.Operation{
-		ID:        doc.OperationID,
-		Name:      doc.OperationID.Name,
-		Status:    doc.Status,
-		StartTime: &doc.StartTime,
-		Error:     doc.Error,
+		ID:              doc.OperationID,
+		Name:            doc.OperationID.Name,
+		Status:          doc.Status,
+		StartTime:       &doc.StartTime,
+		PercentComplete: doc.PercentComplete,
+		Error:           doc.Error,
 	}
 
 	if doc.Status.IsTerminal() {
@@ -166,3 +210,97 @@ func (doc *OperationDocument) UpdateStatus(status arm.ProvisioningState, err *ar
 	}
 	return false
 }
+
+// UpdateProgress conditionally updates the document if the percent complete
+// given differs from the percent complete already present. If so, it sets the
+// PercentComplete field and returns true. This is intended to be used with
+// DBClient.UpdateOperationDoc.
+func (doc *OperationDocument) UpdateProgress(percentComplete float64) bool {
+	if doc.PercentComplete != percentComplete {
+		doc.PercentComplete = percentComplete
+		return true
+	}
+	return false
+}
+
+// ChangeFeedResourceType is an artificial resource type for ChangeFeedDocuments
+// in Cosmos DB.
+var ChangeFeedResourceType = azcorearm.NewResourceType(api.ProviderNamespace, "changeFeeds")
+
+// ChangeFeedDocument records how far a change feed consumer has read so it
+// can resume from the same place after a restart.
+type ChangeFeedDocument struct {
+	// ContinuationToken is the opaque token from the last successful read
+	ContinuationToken string `json:"continuationToken,omitempty"`
+}
+
+// GetValidTypes returns the valid resource types for a ChangeFeedDocument.
+func (doc ChangeFeedDocument) GetValidTypes() []string {
+	return []string{ChangeFeedResourceType.String()}
+}
+
+// NotificationResourceType is an artificial resource type for
+// NotificationDocuments in Cosmos DB.
+var NotificationResourceType = azcorearm.NewResourceType(api.ProviderNamespace, "asyncNotifications")
+
+type NotificationState string
+
+const (
+	// NotificationStatePending means the notification is awaiting delivery.
+	NotificationStatePending NotificationState = "Pending"
+	// NotificationStateDelivered means the notification was delivered.
+	NotificationStateDelivered NotificationState = "Delivered"
+	// NotificationStateAbandoned means delivery failed too many times.
+	NotificationStateAbandoned NotificationState = "Abandoned"
+)
+
+// NotificationDocument is an outbox record of an async notification owed
+// to ARM for an asynchronous operation reaching a new status. It lives in
+// the same partition as the OperationDocument it refers to.
+type NotificationDocument struct {
+	// SubscriptionID is the Azure subscription of the operation
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+	// OperationID is the item ID of the OperationDocument
+	OperationID string `json:"operationId,omitempty"`
+	// NotificationURI is copied from the OperationDocument
+	NotificationURI string `json:"notificationUri,omitempty"`
+	// Status is the operation status that triggered the notification
+	Status arm.ProvisioningState `json:"status,omitempty"`
+
+	// State is the delivery state of the notification
+	State NotificationState `json:"state,omitempty"`
+	// CreatedTime marks when the notification was recorded
+	CreatedTime time.Time `json:"createdTime,omitempty"`
+	// Attempts is the number of failed delivery attempts
+	Attempts int `json:"attempts,omitempty"`
+	// NextAttemptTime marks when delivery should next be attempted
+	NextAttemptTime time.Time `json:"nextAttemptTime,omitempty"`
+	// LastError describes why the last delivery attempt failed
+	LastError string `json:"lastError,omitempty"`
+}
+
+func NewNotificationDocument(subscriptionID, operationID, notificationURI string, status arm.ProvisioningState) *NotificationDocument {
+	now := time.Now().UTC()
+
+	return &NotificationDocument{
+		SubscriptionID:  subscriptionID,
+		OperationID:     operationID,
+		NotificationURI: notificationURI,
+		Status:          status,
+		State:           NotificationStatePending,
+		CreatedTime:     now,
+		NextAttemptTime: now,
+	}
+}
+
+// GetValidTypes returns the valid resource types for a NotificationDocument.
+func (doc NotificationDocument) GetValidTypes() []string {
+	return []string{NotificationResourceType.String()}
+}
+
+// NotificationID returns the item ID of the NotificationDocument for an
+// operation reaching the given status. Deriving the ID from the status
+// transition ensures ARM is notified of each transition at most once.
+func NotificationID(operationID string, status arm.ProvisioningState) string {
+	return strings.ToLower(operationID + "-" + string(status))
+}
@@ -5,14 +5,24 @@ package database
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+
+	"github.com/Azure/ARO-HCP/internal/api"
 )
 
 // Copied from azcore/internal/shared/shared.go
@@ -25,7 +35,101 @@ func Delay(ctx context.Context, delay time.Duration) error {
 	}
 }
 
-type LockClient struct {
+// LockClient provides a distributed locking mechanism for arbitrary IDs.
+type LockClient interface {
+	// SetName overrides how a lock item identifies the owner. This is for
+	// informational purposes only.
+	SetName(name string)
+
+	// GetDefaultTimeToLive returns the default time-to-live value of a
+	// lock as a time.Duration.
+	GetDefaultTimeToLive() time.Duration
+
+	// SetRetryAfterHeader sets a "Retry-After" header to the default TTL value.
+	SetRetryAfterHeader(header http.Header)
+
+	// AcquireLock persistently tries to acquire a lock for the given ID. If a
+	// timeout is provided, the function will cease after the timeout duration
+	// and return a context.DeadlineExceeded error.
+	AcquireLock(ctx context.Context, id string, timeout *time.Duration) (*azcosmos.ItemResponse, error)
+
+	// TryAcquireLock tries once to acquire a lock for the given ID. If the lock
+	// is already taken, it returns a nil azcosmos.ItemResponse and no error.
+	TryAcquireLock(ctx context.Context, id string) (*azcosmos.ItemResponse, error)
+
+	// TryAcquireHierarchicalLock tries once to acquire a lock for the given ID,
+	// like TryAcquireLock, as a descendant of the locks with the given ancestor
+	// IDs. The lock is not acquired while any ancestor lock is held or while
+	// any descendant lock, one acquired with this ID among its ancestor IDs,
+	// is held. In that case it returns a nil azcosmos.ItemResponse and no error.
+	TryAcquireHierarchicalLock(ctx context.Context, id string, ancestorIDs []string) (*azcosmos.ItemResponse, error)
+
+	// HoldLock tries to hold an acquired lock by renewing it periodically from a
+	// goroutine until the returned stop function is called. The function also returns
+	// a new context which is cancelled if the lock is lost or some other error occurs.
+	// The stop function terminates the goroutine and returns the current lock, or nil
+	// if the lock was lost.
+	HoldLock(ctx context.Context, item *azcosmos.ItemResponse) (cancelCtx context.Context, stop StopHoldLock)
+
+	// RenewLock attempts to renew an acquired lock. If successful it returns a new lock.
+	// If the lock was somehow lost, it returns a nil azcosmos.ItemResponse and no error.
+	RenewLock(ctx context.Context, item *azcosmos.ItemResponse) (*azcosmos.ItemResponse, error)
+
+	// ReleaseLock attempts to release an acquired lock. Errors should be logged but not
+	// treated as fatal, since the lock's TTL value guarantees that it will be released
+	// eventually.
+	ReleaseLock(ctx context.Context, item *azcosmos.ItemResponse) error
+
+	// GetLock returns information about the lock for the given ID. If the lock is
+	// not held, it returns a nil LockInfo and no error.
+	GetLock(ctx context.Context, id string) (*LockInfo, error)
+
+	// ListLocks returns information about all held locks, ordered by ID.
+	ListLocks(ctx context.Context) ([]LockInfo, error)
+
+	// BreakLock forcibly releases the lock for the given ID, regardless of which
+	// process holds it. This is for recovering from a lock holder that is stuck.
+	// The holder is not notified and only discovers the lock was lost when next
+	// it tries to renew the lock. The lock is only released if its entity tag
+	// still matches the given one, so a lock that was renewed or acquired again
+	// since it was inspected is left alone and BreakLock returns ErrLockChanged.
+	// If the lock is not held, BreakLock returns nil.
+	BreakLock(ctx context.Context, id string, etag azcore.ETag) error
+}
+
+// ErrLockChanged is returned by LockClient.BreakLock if the
+// lock changed since it was inspected.
+var ErrLockChanged = errors.New("lock changed")
+
+// LockInfo describes a held lock, for troubleshooting.
+type LockInfo struct {
+	ID        string      `json:"id"`
+	Owner     string      `json:"owner,omitempty"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+	ETag      azcore.ETag `json:"etag"`
+}
+
+// newLockInfo returns a LockInfo for a lock document.
+func newLockInfo(data []byte) (*LockInfo, error) {
+	var doc *lockDocument
+
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LockInfo{
+		ID:        doc.ID,
+		Owner:     doc.Owner,
+		ExpiresAt: time.Unix(int64(doc.CosmosTimestamp), 0).Add(time.Duration(doc.TTL) * time.Second).UTC(),
+		ETag:      doc.CosmosETag,
+	}, nil
+}
+
+var _ LockClient = &cosmosLockClient{}
+
+// cosmosLockClient implements LockClient using a Cosmos DB container.
+type cosmosLockClient struct {
 	name              string
 	containerClient   *azcosmos.ContainerClient
 	defaultTimeToLive int32
@@ -35,20 +139,21 @@ type LockClient struct {
 // Its contents should be opaque outside of LockClient.
 type lockDocument struct {
 	baseDocument
-	Owner string `json:"owner,omitempty"`
-	TTL   int32  `json:"ttl,omitempty"`
+	Owner     string   `json:"owner,omitempty"`
+	Ancestors []string `json:"ancestors,omitempty"`
+	TTL       int32    `json:"ttl,omitempty"`
 }
 
 // NewLockClient creates a LockClient around a ContainerClient. It attempts to
 // read container properties to extract a default TTL. If this fails or if the
 // container does not define a default TTL, the function returns an error.
-func NewLockClient(ctx context.Context, containerClient *azcosmos.ContainerClient) (*LockClient, error) {
+func NewLockClient(ctx context.Context, containerClient *azcosmos.ContainerClient) (LockClient, error) {
 	hostname, err := os.Hostname()
 	if err != nil {
 		return nil, err
 	}
 
-	c := &LockClient{
+	c := &cosmosLockClient{
 		name:            hostname,
 		containerClient: containerClient,
 	}
@@ -69,59 +174,67 @@ func NewLockClient(ctx context.Context, containerClient *azcosmos.ContainerClien
 
 // SetName overrides how a lock item identifies the owner. This is for
 // informational purposes only. LockClient uses the hostname by default.
-func (c *LockClient) SetName(name string) {
+func (c *cosmosLockClient) SetName(name string) {
 	c.name = name
 }
 
 // GetDefaultTimeToLive returns the default time-to-live value of the
 // container as a time.Duration.
-func (c *LockClient) GetDefaultTimeToLive() time.Duration {
+func (c *cosmosLockClient) GetDefaultTimeToLive() time.Duration {
 	return time.Duration(c.defaultTimeToLive) * time.Second
 }
 
 // SetRetryAfterHeader sets a "Retry-After" header to the default TTL value.
-func (c *LockClient) SetRetryAfterHeader(header http.Header) {
+func (c *cosmosLockClient) SetRetryAfterHeader(header http.Header) {
 	header.Set("Retry-After", strconv.Itoa(int(c.defaultTimeToLive)))
 }
 
 // AcquireLock persistently tries to acquire a lock for the given ID. If a
 // timeout is provided, the function will cease after the timeout duration
 // and return a context.DeadlineExceeded error.
-func (c *LockClient) AcquireLock(ctx context.Context, id string, timeout *time.Duration) (*azcosmos.ItemResponse, error) {
-	var lock *azcosmos.ItemResponse
+func (c *cosmosLockClient) AcquireLock(ctx context.Context, id string, timeout *time.Duration) (*azcosmos.ItemResponse, error) {
+	return acquireLock(ctx, id, timeout, c.TryAcquireLock)
+}
 
-	if timeout != nil {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, *timeout)
-		defer cancel()
-	}
+// TryAcquireLock tries once to acquire a lock for the given ID. If the lock
+// is already taken, it returns a nil azcosmos.ItemResponse and no error.
+func (c *cosmosLockClient) TryAcquireLock(ctx context.Context, id string) (*azcosmos.ItemResponse, error) {
+	return c.createLock(ctx, id, nil)
+}
 
-	for lock == nil {
-		var err error
+// TryAcquireHierarchicalLock tries once to acquire a lock for the given ID as
+// a descendant of the locks with the given ancestor IDs. If the lock, any of
+// its ancestor locks, or any of its descendant locks is already taken, it
+// returns a nil azcosmos.ItemResponse and no error.
+//
+// The lock is created before checking for conflicting locks, so of two
+// conflicting locks acquired concurrently, at least the one checked last
+// sees the other and is released again.
+func (c *cosmosLockClient) TryAcquireHierarchicalLock(ctx context.Context, id string, ancestorIDs []string) (*azcosmos.ItemResponse, error) {
+	lock, err := c.createLock(ctx, id, ancestorIDs)
+	if lock == nil || err != nil {
+		return lock, err
+	}
 
-		lock, err = c.TryAcquireLock(ctx, id)
-		if err != nil {
-			return nil, err
-		}
-		if lock == nil {
-			// TTL values are in whole seconds,
-			// so wait one second before retrying.
-			err = Delay(ctx, time.Second)
-			if err != nil {
-				return nil, err
-			}
-		}
+	conflict, err := c.hasConflictingLock(ctx, id, ancestorIDs)
+	if conflict || err != nil {
+		// Failure here is non-fatal. The lock's
+		// TTL ensures it will be released eventually.
+		_ = c.ReleaseLock(ctx, lock)
+		return nil, err
 	}
 
 	return lock, nil
 }
 
-// TryAcquireLock tries once to acquire a lock for the given ID. If the lock
-// is already taken, it returns a nil azcosmos.ItemResponse and no error.
-func (c *LockClient) TryAcquireLock(ctx context.Context, id string) (*azcosmos.ItemResponse, error) {
+// createLock creates a lock item for the given ID, recording its ancestor
+// lock IDs. If the lock is already taken, it returns a nil
+// azcosmos.ItemResponse and no error.
+func (c *cosmosLockClient) createLock(ctx context.Context, id string, ancestorIDs []string) (*azcosmos.ItemResponse, error) {
 	doc := &lockDocument{
 		baseDocument: baseDocument{ID: id},
 		Owner:        c.name,
+		Ancestors:    ancestorIDs,
 		TTL:          c.defaultTimeToLive,
 	}
 
@@ -151,57 +264,13 @@ type StopHoldLock func() *azcosmos.ItemResponse
 // a new context which is cancelled if the lock is lost or some other error occurs.
 // The stop function terminates the goroutine and returns the current lock, or nil
 // if the lock was lost.
-func (c *LockClient) HoldLock(ctx context.Context, item *azcosmos.ItemResponse) (cancelCtx context.Context, stop StopHoldLock) {
-	cancelCtx, cancelCause := context.WithCancelCause(ctx)
-	done := make(chan struct{})
-
-	stop = func() *azcosmos.ItemResponse {
-		cancelCause(nil)
-		<-done // wait for goroutine to finish
-		return item
-	}
-
-	go func() {
-		defer close(done)
-		for {
-			var doc *lockDocument
-
-			err := json.Unmarshal(item.Value, &doc)
-			if err != nil {
-				cancelCause(fmt.Errorf("Failed to unmarshal lock: %w", err))
-				return
-			}
-
-			// Aim to renew one second before TTL expires.
-			timeToRenew := time.Unix(int64(doc.CosmosTimestamp), 0)
-			if doc.TTL > 0 {
-				timeToRenew = timeToRenew.Add(time.Duration(doc.TTL-1) * time.Second)
-			}
-
-			select {
-			case <-time.After(time.Until(timeToRenew)):
-				item, err = c.RenewLock(cancelCtx, item)
-				if err != nil {
-					cancelCause(fmt.Errorf("Failed to renew lock: %w", err))
-					return
-				}
-				if item == nil {
-					// We lost the lock, cancel the context.
-					cancelCause(nil)
-					return
-				}
-			case <-cancelCtx.Done():
-				return
-			}
-		}
-	}()
-
-	return
+func (c *cosmosLockClient) HoldLock(ctx context.Context, item *azcosmos.ItemResponse) (cancelCtx context.Context, stop StopHoldLock) {
+	return holdLock(ctx, item, c.RenewLock)
 }
 
 // RenewLock attempts to renew an acquired lock. If successful it returns a new lock.
 // If the lock was somehow lost, it returns a nil azcosmos.ItemResponse and no error.
-func (c *LockClient) RenewLock(ctx context.Context, item *azcosmos.ItemResponse) (*azcosmos.ItemResponse, error) {
+func (c *cosmosLockClient) RenewLock(ctx context.Context, item *azcosmos.ItemResponse) (*azcosmos.ItemResponse, error) {
 	var doc *lockDocument
 
 	err := json.Unmarshal(item.Value, &doc)
@@ -227,7 +296,7 @@ func (c *LockClient) RenewLock(ctx context.Context, item *azcosmos.ItemResponse)
 // ReleaseLock attempts to release an acquired lock. Errors should be logged but not
 // treated as fatal, since the container item's TTL value guarantees that it will be
 // released eventually.
-func (c *LockClient) ReleaseLock(ctx context.Context, item *azcosmos.ItemResponse) error {
+func (c *cosmosLockClient) ReleaseLock(ctx context.Context, item *azcosmos.ItemResponse) error {
 	var doc *lockDocument
 
 	err := json.Unmarshal(item.Value, &doc)
@@ -246,3 +315,255 @@ func (c *LockClient) ReleaseLock(ctx context.Context, item *azcosmos.ItemRespons
 
 	return err
 }
+
+// GetLock returns information about the lock for the given ID. If the lock is
+// not held, it returns a nil LockInfo and no error.
+func (c *cosmosLockClient) GetLock(ctx context.Context, id string) (*LockInfo, error) {
+	pk := azcosmos.NewPartitionKeyString(id)
+	response, err := c.containerClient.ReadItem(ctx, pk, id, nil)
+	if isResponseError(err, http.StatusNotFound) {
+		return nil, nil // lock not held
+	} else if err != nil {
+		return nil, err
+	}
+
+	return newLockInfo(response.Value)
+}
+
+// hasConflictingLock returns true if any of the given ancestor locks is held
+// or if any lock recording the given ID among its ancestors is held.
+func (c *cosmosLockClient) hasConflictingLock(ctx context.Context, id string, ancestorIDs []string) (bool, error) {
+	for _, ancestorID := range ancestorIDs {
+		info, err := c.GetLock(ctx, ancestorID)
+		if info != nil || err != nil {
+			return info != nil, err
+		}
+	}
+
+	// Empty partition key triggers a cross-partition query.
+	const query = "SELECT c.id FROM c WHERE ARRAY_CONTAINS(c.ancestors, @id)"
+	opt := azcosmos.QueryOptions{
+		QueryParameters: []azcosmos.QueryParameter{
+			{
+				Name:  "@id",
+				Value: id,
+			},
+		},
+	}
+	pager := c.containerClient.NewQueryItemsPager(query, azcosmos.NewPartitionKey(), &opt)
+
+	for pager.More() {
+		response, err := pager.NextPage(ctx)
+		if err != nil {
+			return false, err
+		}
+		if len(response.Items) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ListLocks returns information about all held locks, ordered by ID.
+func (c *cosmosLockClient) ListLocks(ctx context.Context) ([]LockInfo, error) {
+	var locks []LockInfo
+
+	// Empty partition key triggers a cross-partition query.
+	const query = "SELECT * FROM c"
+	pager := c.containerClient.NewQueryItemsPager(query, azcosmos.NewPartitionKey(), nil)
+
+	for pager.More() {
+		response, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range response.Items {
+			info, err := newLockInfo(item)
+			if err != nil {
+				return nil, err
+			}
+			locks = append(locks, *info)
+		}
+	}
+
+	// The Go SDK does not support ORDER BY in cross-partition queries.
+	slices.SortFunc(locks, func(a, b LockInfo) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return locks, nil
+}
+
+// BreakLock forcibly releases the lock for the given ID, regardless of which
+// process holds it, if its entity tag matches. If the lock changed, BreakLock
+// returns ErrLockChanged. If the lock is not held, BreakLock returns nil.
+func (c *cosmosLockClient) BreakLock(ctx context.Context, id string, etag azcore.ETag) error {
+	pk := azcosmos.NewPartitionKeyString(id)
+	options := &azcosmos.ItemOptions{
+		IfMatchEtag: &etag,
+	}
+	_, err := c.containerClient.DeleteItem(ctx, pk, id, options)
+	if isResponseError(err, http.StatusNotFound) {
+		return nil // lock not held
+	} else if isResponseError(err, http.StatusPreconditionFailed) {
+		return ErrLockChanged
+	}
+
+	return err
+}
+
+// SubscriptionLockID returns the lock ID for an Azure subscription.
+func SubscriptionLockID(subscriptionID string) string {
+	return strings.ToLower(subscriptionID)
+}
+
+// ResourceLockID returns the lock ID for a resource. Resource IDs contain
+// characters that Cosmos DB does not allow in item IDs and can exceed its
+// item ID length limit, so the lock ID is a hash of the resource ID. Like
+// resource IDs, lock IDs are case-insensitive.
+func ResourceLockID(resourceID *azcorearm.ResourceID) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(resourceID.String())))
+	return hex.EncodeToString(sum[:])
+}
+
+// QuotaLockID returns the lock ID for the quota counted under the given
+// subscription or resource lock ID. Quota locks are plain locks outside
+// the hierarchy of resource locks, so a quota lock can be acquired while
+// holding a resource lock.
+func QuotaLockID(lockID string) string {
+	return "quota-" + lockID
+}
+
+// ResourceLockAncestorIDs returns the lock IDs of a resource's subscription
+// and of any parent resources in the resource provider's namespace, from
+// the subscription down.
+func ResourceLockAncestorIDs(resourceID *azcorearm.ResourceID) []string {
+	var ancestorIDs []string
+
+	for parent := resourceID.Parent; parent != nil; parent = parent.Parent {
+		if strings.EqualFold(parent.ResourceType.Namespace, api.ProviderNamespace) {
+			ancestorIDs = append(ancestorIDs, ResourceLockID(parent))
+		}
+	}
+	if resourceID.SubscriptionID != "" {
+		ancestorIDs = append(ancestorIDs, SubscriptionLockID(resourceID.SubscriptionID))
+	}
+
+	slices.Reverse(ancestorIDs)
+	return ancestorIDs
+}
+
+// AcquireResourceLock persistently tries to acquire the lock for a resource,
+// as LockClient.AcquireLock does, but only while none of the locks returned
+// by ResourceLockAncestorIDs and none of the locks of the resource's child
+// resources are held. Locks are therefore hierarchical: a subscription lock
+// and the locks of resources in the subscription exclude each other, as do
+// a cluster lock and the locks of its node pools, while sibling resources
+// lock independently of one another. Only the resource's own lock is taken.
+func AcquireResourceLock(ctx context.Context, c LockClient, resourceID *azcorearm.ResourceID, timeout *time.Duration) (*azcosmos.ItemResponse, error) {
+	return acquireLock(ctx, ResourceLockID(resourceID), timeout, func(ctx context.Context, id string) (*azcosmos.ItemResponse, error) {
+		return TryAcquireResourceLock(ctx, c, resourceID)
+	})
+}
+
+// TryAcquireResourceLock tries once to acquire the lock for a resource, with
+// the same exclusions as AcquireResourceLock. If the lock or a conflicting
+// lock is already taken, it returns a nil azcosmos.ItemResponse and no error.
+func TryAcquireResourceLock(ctx context.Context, c LockClient, resourceID *azcorearm.ResourceID) (*azcosmos.ItemResponse, error) {
+	return c.TryAcquireHierarchicalLock(ctx, ResourceLockID(resourceID), ResourceLockAncestorIDs(resourceID))
+}
+
+// AcquireSubscriptionLock persistently tries to acquire the lock for a
+// subscription, as LockClient.AcquireLock does, but only while none of the
+// resource locks in the subscription are held.
+func AcquireSubscriptionLock(ctx context.Context, c LockClient, subscriptionID string, timeout *time.Duration) (*azcosmos.ItemResponse, error) {
+	return acquireLock(ctx, SubscriptionLockID(subscriptionID), timeout, func(ctx context.Context, id string) (*azcosmos.ItemResponse, error) {
+		return c.TryAcquireHierarchicalLock(ctx, id, nil)
+	})
+}
+
+// acquireLock calls tryAcquireLock about once per second until it obtains a
+// lock, an error occurs, or the optional timeout elapses.
+func acquireLock(ctx context.Context, id string, timeout *time.Duration, tryAcquireLock func(context.Context, string) (*azcosmos.ItemResponse, error)) (*azcosmos.ItemResponse, error) {
+	var lock *azcosmos.ItemResponse
+
+	if timeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	for lock == nil {
+		var err error
+
+		lock, err = tryAcquireLock(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if lock == nil {
+			// TTL values are in whole seconds, so wait at least one
+			// second before retrying. Two conflicting hierarchical
+			// locks acquired at the same moment can each see the
+			// other and back off, so add jitter to keep them from
+			// retrying in lockstep.
+			err = Delay(ctx, time.Second+rand.N(time.Second/2))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return lock, nil
+}
+
+// holdLock renews an acquired lock with renewLock shortly before it expires
+// until the returned stop function is called. It implements HoldLock for all
+// LockClient implementations.
+func holdLock(ctx context.Context, item *azcosmos.ItemResponse, renewLock func(context.Context, *azcosmos.ItemResponse) (*azcosmos.ItemResponse, error)) (cancelCtx context.Context, stop StopHoldLock) {
+	cancelCtx, cancelCause := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+
+	stop = func() *azcosmos.ItemResponse {
+		cancelCause(nil)
+		<-done // wait for goroutine to finish
+		return item
+	}
+
+	go func() {
+		defer close(done)
+		for {
+			var doc *lockDocument
+
+			err := json.Unmarshal(item.Value, &doc)
+			if err != nil {
+				cancelCause(fmt.Errorf("Failed to unmarshal lock: %w", err))
+				return
+			}
+
+			// Aim to renew one second before TTL expires.
+			timeToRenew := time.Unix(int64(doc.CosmosTimestamp), 0)
+			if doc.TTL > 0 {
+				timeToRenew = timeToRenew.Add(time.Duration(doc.TTL-1) * time.Second)
+			}
+
+			select {
+			case <-time.After(time.Until(timeToRenew)):
+				item, err = renewLock(cancelCtx, item)
+				if err != nil {
+					cancelCause(fmt.Errorf("Failed to renew lock: %w", err))
+					return
+				}
+				if item == nil {
+					// We lost the lock, cancel the context.
+					cancelCause(nil)
+					return
+				}
+			case <-cancelCtx.Done():
+				return
+			}
+		}
+	}()
+
+	return
+}
@@ -0,0 +1,250 @@
+package database
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+)
+
+const (
+	testSubscriptionID = "00000000-0000-0000-0000-000000000000"
+	testClusterID      = "/subscriptions/" + testSubscriptionID + "/resourceGroups/testGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/testCluster"
+)
+
+func mustParseResourceID(t *testing.T, resourceID string) *azcorearm.ResourceID {
+	t.Helper()
+
+	parsed, err := azcorearm.ParseResourceID(resourceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestResourceLockIDs(t *testing.T) {
+	clusterID := mustParseResourceID(t, testClusterID)
+	nodePoolID := mustParseResourceID(t, testClusterID+"/nodePools/testNodePool")
+
+	if ResourceLockID(clusterID) != ResourceLockID(mustParseResourceID(t, "/SUBSCRIPTIONS/"+testSubscriptionID+"/resourcegroups/TESTGROUP/providers/microsoft.redhatopenshift/hcpopenshiftclusters/TESTCLUSTER")) {
+		t.Error("Expected resource lock IDs to be case-insensitive")
+	}
+	if ResourceLockID(clusterID) == ResourceLockID(nodePoolID) {
+		t.Error("Expected cluster and node pool lock IDs to differ")
+	}
+
+	tests := []struct {
+		name              string
+		resourceID        *azcorearm.ResourceID
+		expectAncestorIDs []string
+	}{
+		{
+			name:              "Cluster",
+			resourceID:        clusterID,
+			expectAncestorIDs: []string{testSubscriptionID},
+		},
+		{
+			name:              "Node pool",
+			resourceID:        nodePoolID,
+			expectAncestorIDs: []string{testSubscriptionID, ResourceLockID(clusterID)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ancestorIDs := ResourceLockAncestorIDs(tt.resourceID)
+			if !slices.Equal(ancestorIDs, tt.expectAncestorIDs) {
+				t.Errorf("Expected ancestor lock IDs %v but got %v", tt.expectAncestorIDs, ancestorIDs)
+			}
+		})
+	}
+}
+
+func TestAcquireResourceLock(t *testing.T) {
+	clusterID := mustParseResourceID(t, testClusterID)
+	otherClusterID := mustParseResourceID(t, testClusterID+"2")
+	nodePoolID := mustParseResourceID(t, testClusterID+"/nodePools/testNodePool")
+	otherNodePoolID := mustParseResourceID(t, testClusterID+"/nodePools/testNodePool2")
+
+	timeout := 100 * time.Millisecond
+
+	tests := []struct {
+		name               string
+		subscriptionLocked bool
+		heldResourceIDs    []*azcorearm.ResourceID
+		resourceID         *azcorearm.ResourceID
+		expectWait         bool
+	}{
+		{
+			name:       "Unlocked cluster",
+			resourceID: clusterID,
+		},
+		{
+			name:            "Sibling cluster locked",
+			heldResourceIDs: []*azcorearm.ResourceID{otherClusterID},
+			resourceID:      clusterID,
+		},
+		{
+			name:            "Sibling node pool locked",
+			heldResourceIDs: []*azcorearm.ResourceID{otherNodePoolID},
+			resourceID:      nodePoolID,
+		},
+		{
+			name:            "Cluster already locked",
+			heldResourceIDs: []*azcorearm.ResourceID{clusterID},
+			resourceID:      clusterID,
+			expectWait:      true,
+		},
+		{
+			name:               "Subscription locked",
+			subscriptionLocked: true,
+			resourceID:         clusterID,
+			expectWait:         true,
+		},
+		{
+			name:            "Parent cluster locked",
+			heldResourceIDs: []*azcorearm.ResourceID{clusterID},
+			resourceID:      nodePoolID,
+			expectWait:      true,
+		},
+		{
+			name:            "Child node pool locked",
+			heldResourceIDs: []*azcorearm.ResourceID{nodePoolID},
+			resourceID:      clusterID,
+			expectWait:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			lockClient := NewInMemoryDBClient().GetLockClient()
+
+			var heldLockIDs []string
+			if tt.subscriptionLocked {
+				lock, err := AcquireSubscriptionLock(ctx, lockClient, testSubscriptionID, &timeout)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if lock == nil {
+					t.Fatal("Failed to acquire subscription lock")
+				}
+				heldLockIDs = append(heldLockIDs, SubscriptionLockID(testSubscriptionID))
+			}
+			for _, resourceID := range tt.heldResourceIDs {
+				lock, err := AcquireResourceLock(ctx, lockClient, resourceID, &timeout)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if lock == nil {
+					t.Fatalf("Failed to acquire lock for '%s'", resourceID)
+				}
+				heldLockIDs = append(heldLockIDs, ResourceLockID(resourceID))
+			}
+
+			lock, err := AcquireResourceLock(ctx, lockClient, tt.resourceID, &timeout)
+
+			if tt.expectWait {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("Expected to time out but got lock %v and error %v", lock, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lock == nil {
+				t.Fatal("Expected to acquire lock")
+			}
+
+			// Only the resource's own lock is taken.
+			locks, err := lockClient.ListLocks(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var lockIDs []string
+			for _, info := range locks {
+				lockIDs = append(lockIDs, info.ID)
+			}
+			expectLockIDs := append(heldLockIDs, ResourceLockID(tt.resourceID))
+			slices.Sort(expectLockIDs)
+			if !slices.Equal(lockIDs, expectLockIDs) {
+				t.Errorf("Expected locks %v but got %v", expectLockIDs, lockIDs)
+			}
+		})
+	}
+}
+
+func TestAcquireSubscriptionLock(t *testing.T) {
+	ctx := context.Background()
+	lockClient := NewInMemoryDBClient().GetLockClient()
+
+	timeout := 100 * time.Millisecond
+
+	nodePoolLock, err := AcquireResourceLock(ctx, lockClient, mustParseResourceID(t, testClusterID+"/nodePools/testNodePool"), &timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := AcquireSubscriptionLock(ctx, lockClient, testSubscriptionID, &timeout)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected to time out while a node pool is locked but got lock %v and error %v", lock, err)
+	}
+
+	err = lockClient.ReleaseLock(ctx, nodePoolLock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err = AcquireSubscriptionLock(ctx, lockClient, testSubscriptionID, &timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock == nil {
+		t.Error("Expected to acquire subscription lock")
+	}
+}
+
+func TestTryAcquireResourceLock(t *testing.T) {
+	ctx := context.Background()
+	lockClient := NewInMemoryDBClient().GetLockClient()
+
+	clusterID := mustParseResourceID(t, testClusterID)
+	nodePoolID := mustParseResourceID(t, testClusterID+"/nodePools/testNodePool")
+
+	clusterLock, err := TryAcquireResourceLock(ctx, lockClient, clusterID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clusterLock == nil {
+		t.Fatal("Expected to acquire cluster lock")
+	}
+
+	lock, err := TryAcquireResourceLock(ctx, lockClient, nodePoolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock != nil {
+		t.Error("Expected not to acquire node pool lock while its cluster is locked")
+	}
+
+	err = lockClient.ReleaseLock(ctx, clusterLock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err = TryAcquireResourceLock(ctx, lockClient, nodePoolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock == nil {
+		t.Error("Expected to acquire node pool lock")
+	}
+}
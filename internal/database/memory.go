@@ -0,0 +1,1004 @@
+package database
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+// inMemoryLockTimeToLive matches the default TTL of the "Locks" container.
+const inMemoryLockTimeToLive = 10 // seconds
+
+// newETag returns a unique entity tag formatted like a Cosmos DB etag.
+func newETag() azcore.ETag {
+	return azcore.ETag(strconv.Quote(uuid.New().String()))
+}
+
+// newResponseError returns an azcore.ResponseError resembling what Cosmos
+// DB would return for the given status code, so that callers can use the
+// same error handling for both DBClient implementations.
+func newResponseError(statusCode int) error {
+	return &azcore.ResponseError{
+		ErrorCode:  strings.ReplaceAll(http.StatusText(statusCode), " ", ""),
+		StatusCode: statusCode,
+	}
+}
+
+// inMemoryItem is a serialized container item.
+type inMemoryItem struct {
+	data    []byte
+	etag    azcore.ETag
+	expires time.Time // zero value means no expiration
+}
+
+// isLive returns true if the item has not yet reached its time-to-live.
+func (item *inMemoryItem) isLive(now time.Time) bool {
+	return item.expires.IsZero() || now.Before(item.expires)
+}
+
+// inMemoryQueryResult is a serialized container item and its sort key.
+type inMemoryQueryResult struct {
+	key  string
+	data []byte
+}
+
+var _ DBClient = &inMemoryDBClient{}
+
+// inMemoryDBClient implements DBClient without external dependencies for
+// local development and hermetic tests. Items are stored in serialized form
+// and grouped into logical partitions by subscription ID, same as the Cosmos
+// DB "Resources" container. The client emulates the Cosmos DB behaviors that
+// callers rely on: system-generated etags with "If-Match" preconditions, item
+// time-to-live, and continuation tokens for paged queries.
+type inMemoryDBClient struct {
+	mutex      sync.RWMutex
+	partitions map[string]map[string]*inMemoryItem
+	lockClient *inMemoryLockClient
+}
+
+// NewInMemoryDBClient instantiates a DBClient that keeps all documents in
+// process memory. Nothing is persisted.
+func NewInMemoryDBClient() DBClient {
+	return &inMemoryDBClient{
+		partitions: make(map[string]map[string]*inMemoryItem),
+		lockClient: newInMemoryLockClient(inMemoryLockTimeToLive),
+	}
+}
+
+func (d *inMemoryDBClient) DBConnectionTest(ctx context.Context) error {
+	return nil
+}
+
+func (d *inMemoryDBClient) GetLockClient() LockClient {
+	return d.lockClient
+}
+
+// readItem returns the serialized item with the given ID in the given
+// partition, or ErrNotFound.
+func (d *inMemoryDBClient) readItem(pk azcosmos.PartitionKey, id string) ([]byte, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	// azcosmos.PartitionKey is opaque so compare
+	// it against each known partition by value.
+	for name, partition := range d.partitions {
+		if reflect.DeepEqual(azcosmos.NewPartitionKeyString(name), pk) {
+			item, ok := partition[id]
+			if ok && item.isLive(time.Now()) {
+				return item.data, nil
+			}
+			break
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// writeItem serializes typedDoc, whose Properties field must already be set,
+// and stores it with a newly generated etag and timestamp. If create is true
+// the item must not already exist, otherwise it must already exist. If ifMatch
+// is not nil, the existing item's etag must match.
+func (d *inMemoryDBClient) writeItem(typedDoc *typedDocument, ifMatch *azcore.ETag, create bool) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := time.Now()
+
+	partition, ok := d.partitions[typedDoc.PartitionKey]
+	if !ok {
+		partition = make(map[string]*inMemoryItem)
+		d.partitions[typedDoc.PartitionKey] = partition
+	}
+
+	existing, exists := partition[typedDoc.ID]
+	exists = exists && existing.isLive(now)
+
+	switch {
+	case create && exists:
+		return newResponseError(http.StatusConflict)
+	case !create && !exists:
+		return newResponseError(http.StatusNotFound)
+	case ifMatch != nil && *ifMatch != existing.etag:
+		return newResponseError(http.StatusPreconditionFailed)
+	}
+
+	typedDoc.CosmosETag = newETag()
+	typedDoc.CosmosTimestamp = int(now.Unix())
+
+	data, err := json.Marshal(typedDoc)
+	if err != nil {
+		return err
+	}
+
+	item := &inMemoryItem{data: data, etag: typedDoc.CosmosETag}
+	if typedDoc.TimeToLive > 0 {
+		item.expires = now.Add(time.Duration(typedDoc.TimeToLive) * time.Second)
+	}
+	partition[typedDoc.ID] = item
+
+	return nil
+}
+
+// deleteItem removes an item from the given partition, if present.
+func (d *inMemoryDBClient) deleteItem(partitionName, id string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if partition, ok := d.partitions[partitionName]; ok {
+		delete(partition, id)
+	}
+}
+
+// queryItems returns all live items across all partitions for which the
+// key function returns true, sorted by the returned key.
+func (d *inMemoryDBClient) queryItems(key func(typedDoc *typedDocument) (string, bool)) ([]inMemoryQueryResult, error) {
+	var results []inMemoryQueryResult
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	now := time.Now()
+
+	for _, partition := range d.partitions {
+		for _, item := range partition {
+			var typedDoc typedDocument
+
+			if !item.isLive(now) {
+				continue
+			}
+
+			err := json.Unmarshal(item.data, &typedDoc)
+			if err != nil {
+				return nil, err
+			}
+
+			if k, ok := key(&typedDoc); ok {
+				results = append(results, inMemoryQueryResult{key: k, data: item.data})
+			}
+		}
+	}
+
+	slices.SortFunc(results, func(a, b inMemoryQueryResult) int {
+		return cmp.Compare(a.key, b.key)
+	})
+
+	return results, nil
+}
+
+// resourceIDProperty extracts the resource ID from the properties of a
+// typedDocument, or returns an empty string if there is none.
+func resourceIDProperty(typedDoc *typedDocument) string {
+	var properties struct {
+		ResourceID string `json:"resourceId"`
+	}
+
+	// Properties of other document types will simply lack the field.
+	_ = json.Unmarshal(typedDoc.Properties, &properties)
+
+	return properties.ResourceID
+}
+
+func (d *inMemoryDBClient) getResourceDoc(resourceID *azcorearm.ResourceID) (*typedDocument, *ResourceDocument, error) {
+	pk := strings.ToLower(resourceID.SubscriptionID)
+
+	results, err := d.queryItems(func(typedDoc *typedDocument) (string, bool) {
+		return typedDoc.ID, typedDoc.PartitionKey == pk &&
+			strings.EqualFold(typedDoc.ResourceType, resourceID.ResourceType.String()) &&
+			strings.EqualFold(resourceIDProperty(typedDoc), resourceID.String())
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query Resources container for '%s': %w", resourceID, err)
+	}
+
+	for _, result := range results {
+		typedDoc, innerDoc, err := typedDocumentUnmarshal[ResourceDocument](result.data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal Resources container item for '%s': %w", resourceID, err)
+		}
+
+		return typedDoc, innerDoc, nil
+	}
+
+	return nil, nil, fmt.Errorf("failed to read Resources container item for '%s': %w", resourceID, ErrNotFound)
+}
+
+func (d *inMemoryDBClient) GetResourceDoc(ctx context.Context, resourceID *azcorearm.ResourceID) (*ResourceDocument, error) {
+	_, innerDoc, err := d.getResourceDoc(resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Preserve the casing of the given resourceID.
+	// See cosmosDBClient.GetResourceDoc for details.
+	innerDoc.ResourceID = resourceID
+
+	return innerDoc, nil
+}
+
+func (d *inMemoryDBClient) CreateResourceDoc(ctx context.Context, doc *ResourceDocument) error {
+	typedDoc := newTypedDocument(doc.ResourceID.SubscriptionID, doc.ResourceID.ResourceType)
+
+	_, err := typedDocumentMarshal(typedDoc, doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Resources container item for '%s': %w", doc.ResourceID, err)
+	}
+
+	err = d.writeItem(typedDoc, nil, true)
+	if err != nil {
+		return fmt.Errorf("failed to create Resources container item for '%s': %w", doc.ResourceID, err)
+	}
+
+	return nil
+}
+
+func (d *inMemoryDBClient) UpdateResourceDoc(ctx context.Context, resourceID *azcorearm.ResourceID, callback func(*ResourceDocument) bool) (bool, error) {
+	get := func() (*typedDocument, *ResourceDocument, error) {
+		return d.getResourceDoc(resourceID)
+	}
+	return inMemoryUpdateDoc(d, "Resources", resourceID.String(), get, callback)
+}
+
+func (d *inMemoryDBClient) DeleteResourceDoc(ctx context.Context, resourceID *azcorearm.ResourceID) error {
+	typedDoc, _, err := d.getResourceDoc(resourceID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	d.deleteItem(typedDoc.PartitionKey, typedDoc.ID)
+
+	return nil
+}
+
+func (d *inMemoryDBClient) ListResourceDocs(prefix *azcorearm.ResourceID, maxItems int32, continuationToken *string) DBClientIterator[ResourceDocument] {
+	pk := strings.ToLower(prefix.SubscriptionID)
+	keyPrefix := strings.ToLower(prefix.String()) + "/"
+
+	return newInMemoryIterator[ResourceDocument](func() ([][]byte, string, error) {
+		var startAfter string
+
+		if continuationToken != nil {
+			data, err := base64.StdEncoding.DecodeString(*continuationToken)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid continuation token: %w", err)
+			}
+			startAfter = string(data)
+		}
+
+		results, err := d.queryItems(func(typedDoc *typedDocument) (string, bool) {
+			key := strings.ToLower(resourceIDProperty(typedDoc))
+			return key, typedDoc.PartitionKey == pk && strings.HasPrefix(key, keyPrefix) && key > startAfter
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		var nextToken string
+		if maxItems > 0 && len(results) > int(maxItems) {
+			results = results[:maxItems]
+			nextToken = base64.StdEncoding.EncodeToString([]byte(results[len(results)-1].key))
+		}
+
+		items := make([][]byte, 0, len(results))
+		for _, result := range results {
+			items = append(items, result.data)
+		}
+
+		return items, nextToken, nil
+	})
+}
+
+func (d *inMemoryDBClient) getOperationDoc(pk azcosmos.PartitionKey, operationID string) (*typedDocument, *OperationDocument, error) {
+	// Make sure lookup keys are lowercase.
+	operationID = strings.ToLower(operationID)
+
+	data, err := d.readItem(pk, operationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read Operations container item for '%s': %w", operationID, err)
+	}
+
+	typedDoc, innerDoc, err := typedDocumentUnmarshal[OperationDocument](data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal Operations container item for '%s': %w", operationID, err)
+	}
+
+	return typedDoc, innerDoc, nil
+}
+
+func (d *inMemoryDBClient) GetOperationDoc(ctx context.Context, pk azcosmos.PartitionKey, operationID string) (*OperationDocument, error) {
+	_, innerDoc, err := d.getOperationDoc(pk, operationID)
+	return innerDoc, err
+}
+
+func (d *inMemoryDBClient) CreateOperationDoc(ctx context.Context, doc *OperationDocument) (string, error) {
+	// Make sure partition key is lowercase.
+	subscriptionID := strings.ToLower(doc.ExternalID.SubscriptionID)
+
+	typedDoc := newTypedDocument(subscriptionID, OperationResourceType)
+	typedDoc.TimeToLive = operationDocTimeToLive(doc)
+
+	_, err := typedDocumentMarshal(typedDoc, doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal Operations container item for '%s': %w", typedDoc.ID, err)
+	}
+
+	err = d.writeItem(typedDoc, nil, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Operations container item for '%s': %w", typedDoc.ID, err)
+	}
+
+	return typedDoc.ID, nil
+}
+
+func (d *inMemoryDBClient) UpdateOperationDoc(ctx context.Context, pk azcosmos.PartitionKey, operationID string, callback func(*OperationDocument) bool) (bool, error) {
+	var typedDoc *typedDocument
+
+	get := func() (*typedDocument, *OperationDocument, error) {
+		var innerDoc *OperationDocument
+		var err error
+
+		typedDoc, innerDoc, err = d.getOperationDoc(pk, operationID)
+		return typedDoc, innerDoc, err
+	}
+	return inMemoryUpdateDoc(d, "Operations", operationID, get, func(updateDoc *OperationDocument) bool {
+		if !callback(updateDoc) {
+			return false
+		}
+		typedDoc.TimeToLive = operationDocTimeToLive(updateDoc)
+		return true
+	})
+}
+
+func (d *inMemoryDBClient) DeleteOperationDoc(ctx context.Context, pk azcosmos.PartitionKey, operationID string) error {
+	typedDoc, _, err := d.getOperationDoc(pk, operationID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	d.deleteItem(typedDoc.PartitionKey, typedDoc.ID)
+
+	return nil
+}
+
+func (d *inMemoryDBClient) ListOperationDocs(pk azcosmos.PartitionKey) DBClientIterator[OperationDocument] {
+	return newInMemoryIterator[OperationDocument](func() ([][]byte, string, error) {
+		results, err := d.queryItems(func(typedDoc *typedDocument) (string, bool) {
+			return typedDoc.ID, reflect.DeepEqual(typedDoc.getPartitionKey(), pk) &&
+				strings.EqualFold(typedDoc.ResourceType, OperationResourceType.String())
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		items := make([][]byte, 0, len(results))
+		for _, result := range results {
+			items = append(items, result.data)
+		}
+
+		return items, "", nil
+	})
+}
+
+func (d *inMemoryDBClient) ListResourceOperationDocs(resourceID *azcorearm.ResourceID, filter OperationDocumentFilter, maxItems int32, continuationToken *string) DBClientIterator[OperationDocument] {
+	pk := strings.ToLower(resourceID.SubscriptionID)
+
+	return newInMemoryIterator[OperationDocument](func() ([][]byte, string, error) {
+		var startBefore string
+
+		if continuationToken != nil {
+			data, err := base64.StdEncoding.DecodeString(*continuationToken)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid continuation token: %w", err)
+			}
+			startBefore = string(data)
+		}
+
+		results, err := d.queryItems(func(typedDoc *typedDocument) (string, bool) {
+			if typedDoc.PartitionKey != pk || !strings.EqualFold(typedDoc.ResourceType, OperationResourceType.String()) {
+				return "", false
+			}
+
+			var doc OperationDocument
+			if err := json.Unmarshal(typedDoc.Properties, &doc); err != nil || doc.ExternalID == nil {
+				return "", false
+			}
+
+			// Fixed-width timestamps sort chronologically, and
+			// the document ID breaks ties to keep keys unique.
+			key := doc.StartTime.UTC().Format("2006-01-02T15:04:05.000000000") + "/" + typedDoc.ID
+
+			return key, strings.EqualFold(doc.ExternalID.String(), resourceID.String()) &&
+				(filter.Request == "" || doc.Request == filter.Request) &&
+				(filter.Status == "" || doc.Status == filter.Status) &&
+				(filter.ClientRequestID == "" || strings.EqualFold(doc.ClientRequestID, filter.ClientRequestID)) &&
+				(startBefore == "" || key < startBefore)
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		// Most recently started first.
+		slices.Reverse(results)
+
+		var nextToken string
+		if maxItems > 0 && len(results) > int(maxItems) {
+			results = results[:maxItems]
+			nextToken = base64.StdEncoding.EncodeToString([]byte(results[len(results)-1].key))
+		}
+
+		items := make([][]byte, 0, len(results))
+		for _, result := range results {
+			items = append(items, result.data)
+		}
+
+		return items, nextToken, nil
+	})
+}
+
+func (d *inMemoryDBClient) ListOperationDocChanges(continuationToken string) DBClientIterator[OperationDocument] {
+	return newInMemoryChangeFeedIterator[OperationDocument](continuationToken, func() ([][]byte, string, error) {
+		timestamp, err := parseChangeFeedToken(continuationToken)
+		if err != nil {
+			return nil, "", err
+		}
+
+		latest := timestamp
+
+		results, err := d.queryItems(func(typedDoc *typedDocument) (string, bool) {
+			if typedDoc.CosmosTimestamp < timestamp ||
+				!strings.EqualFold(typedDoc.ResourceType, OperationResourceType.String()) {
+				return "", false
+			}
+			latest = max(latest, typedDoc.CosmosTimestamp)
+			return typedDoc.ID, true
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		items := make([][]byte, 0, len(results))
+		for _, result := range results {
+			items = append(items, result.data)
+		}
+
+		return items, strconv.Itoa(latest), nil
+	})
+}
+
+func (d *inMemoryDBClient) GetChangeFeedToken(ctx context.Context, name string) (string, error) {
+	// Make sure lookup keys are lowercase.
+	name = strings.ToLower(name)
+
+	data, err := d.readItem(azcosmos.NewPartitionKeyString(name), name)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read Resources container item for '%s': %w", name, err)
+	}
+
+	_, innerDoc, err := typedDocumentUnmarshal[ChangeFeedDocument](data)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal Resources container item for '%s': %w", name, err)
+	}
+
+	return innerDoc.ContinuationToken, nil
+}
+
+func (d *inMemoryDBClient) SetChangeFeedToken(ctx context.Context, name, continuationToken string) error {
+	typedDoc := newTypedDocument(name, ChangeFeedResourceType)
+	typedDoc.ID = strings.ToLower(name)
+
+	_, err := typedDocumentMarshal(typedDoc, &ChangeFeedDocument{ContinuationToken: continuationToken})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Resources container item for '%s': %w", name, err)
+	}
+
+	// Emulate an upsert.
+	_, err = d.readItem(typedDoc.getPartitionKey(), typedDoc.ID)
+	err = d.writeItem(typedDoc, nil, errors.Is(err, ErrNotFound))
+	if err != nil {
+		return fmt.Errorf("failed to upsert Resources container item for '%s': %w", name, err)
+	}
+
+	return nil
+}
+
+func (d *inMemoryDBClient) getNotificationDoc(pk azcosmos.PartitionKey, notificationID string) (*typedDocument, *NotificationDocument, error) {
+	// Make sure lookup keys are lowercase.
+	notificationID = strings.ToLower(notificationID)
+
+	data, err := d.readItem(pk, notificationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read Resources container item for '%s': %w", notificationID, err)
+	}
+
+	typedDoc, innerDoc, err := typedDocumentUnmarshal[NotificationDocument](data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal Resources container item for '%s': %w", notificationID, err)
+	}
+
+	return typedDoc, innerDoc, nil
+}
+
+func (d *inMemoryDBClient) CreateNotificationDoc(ctx context.Context, doc *NotificationDocument) (bool, error) {
+	typedDoc := newTypedDocument(doc.SubscriptionID, NotificationResourceType)
+	typedDoc.ID = NotificationID(doc.OperationID, doc.Status)
+	typedDoc.TimeToLive = notificationTimeToLive
+
+	_, err := typedDocumentMarshal(typedDoc, doc)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal Resources container item for '%s': %w", typedDoc.ID, err)
+	}
+
+	err = d.writeItem(typedDoc, nil, true)
+	if isResponseError(err, http.StatusConflict) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to create Resources container item for '%s': %w", typedDoc.ID, err)
+	}
+
+	return true, nil
+}
+
+func (d *inMemoryDBClient) UpdateNotificationDoc(ctx context.Context, pk azcosmos.PartitionKey, notificationID string, callback func(*NotificationDocument) bool) (bool, error) {
+	get := func() (*typedDocument, *NotificationDocument, error) {
+		return d.getNotificationDoc(pk, notificationID)
+	}
+	return inMemoryUpdateDoc(d, "Notifications", notificationID, get, callback)
+}
+
+func (d *inMemoryDBClient) ListPendingNotificationDocs() DBClientIterator[NotificationDocument] {
+	return newInMemoryIterator[NotificationDocument](func() ([][]byte, string, error) {
+		results, err := d.queryItems(func(typedDoc *typedDocument) (string, bool) {
+			var properties struct {
+				State NotificationState `json:"state"`
+			}
+
+			if !strings.EqualFold(typedDoc.ResourceType, NotificationResourceType.String()) {
+				return "", false
+			}
+			_ = json.Unmarshal(typedDoc.Properties, &properties)
+
+			return typedDoc.ID, properties.State == NotificationStatePending
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		items := make([][]byte, 0, len(results))
+		for _, result := range results {
+			items = append(items, result.data)
+		}
+
+		return items, "", nil
+	})
+}
+
+func (d *inMemoryDBClient) getSubscriptionDoc(subscriptionID string) (*typedDocument, *arm.Subscription, error) {
+	// Make sure lookup keys are lowercase.
+	subscriptionID = strings.ToLower(subscriptionID)
+
+	data, err := d.readItem(NewPartitionKey(subscriptionID), subscriptionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read Subscriptions container item for '%s': %w", subscriptionID, err)
+	}
+
+	typedDoc, innerDoc, err := typedDocumentUnmarshal[arm.Subscription](data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal Subscriptions container item for '%s': %w", subscriptionID, err)
+	}
+
+	// Expose the "_ts" field for metics reporting.
+	innerDoc.LastUpdated = typedDoc.CosmosTimestamp
+
+	return typedDoc, innerDoc, nil
+}
+
+func (d *inMemoryDBClient) GetSubscriptionDoc(ctx context.Context, subscriptionID string) (*arm.Subscription, error) {
+	_, innerDoc, err := d.getSubscriptionDoc(subscriptionID)
+	return innerDoc, err
+}
+
+func (d *inMemoryDBClient) CreateSubscriptionDoc(ctx context.Context, subscriptionID string, subscription *arm.Subscription) error {
+	typedDoc := newTypedDocument(subscriptionID, azcorearm.SubscriptionResourceType)
+	typedDoc.ID = strings.ToLower(subscriptionID)
+
+	_, err := typedDocumentMarshal(typedDoc, subscription)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Subscriptions container item for '%s': %w", subscriptionID, err)
+	}
+
+	err = d.writeItem(typedDoc, nil, true)
+	if err != nil {
+		return fmt.Errorf("failed to create Subscriptions container item for '%s': %w", subscriptionID, err)
+	}
+
+	return nil
+}
+
+func (d *inMemoryDBClient) UpdateSubscriptionDoc(ctx context.Context, subscriptionID string, callback func(*arm.Subscription) bool) (bool, error) {
+	get := func() (*typedDocument, *arm.Subscription, error) {
+		return d.getSubscriptionDoc(subscriptionID)
+	}
+	return inMemoryUpdateDoc(d, "Subscriptions", subscriptionID, get, callback)
+}
+
+func (d *inMemoryDBClient) ListAllSubscriptionDocs() DBClientIterator[arm.Subscription] {
+	return newInMemoryIterator[arm.Subscription](func() ([][]byte, string, error) {
+		results, err := d.queryItems(func(typedDoc *typedDocument) (string, bool) {
+			return typedDoc.ID, strings.EqualFold(typedDoc.ResourceType, azcorearm.SubscriptionResourceType.String())
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		items := make([][]byte, 0, len(results))
+		for _, result := range results {
+			items = append(items, result.data)
+		}
+
+		return items, "", nil
+	})
+}
+
+// inMemoryUpdateDoc implements the read-modify-write loop of the DBClient
+// Update methods, retrying a limited number of times when the item changes
+// between reading and replacing it.
+func inMemoryUpdateDoc[T DocumentProperties](d *inMemoryDBClient, containerName, key string, get func() (*typedDocument, *T, error), callback func(*T) bool) (bool, error) {
+	var err error
+
+	for try := 0; try < 5; try++ {
+		var typedDoc *typedDocument
+		var innerDoc *T
+
+		typedDoc, innerDoc, err = get()
+		if err != nil {
+			return false, err
+		}
+
+		if !callback(innerDoc) {
+			return false, nil
+		}
+
+		_, err = typedDocumentMarshal(typedDoc, innerDoc)
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal %s container item for '%s': %w", containerName, key, err)
+		}
+
+		err = d.writeItem(typedDoc, &typedDoc.CosmosETag, false)
+		if err == nil {
+			return true, nil
+		}
+
+		err = fmt.Errorf("failed to replace %s container item for '%s': %w", containerName, key, err)
+		if !isResponseError(err, http.StatusPreconditionFailed) {
+			return false, err
+		}
+	}
+
+	return false, err
+}
+
+type inMemoryIterator[T DocumentProperties] struct {
+	query             func() ([][]byte, string, error)
+	continuationToken string
+	deferToken        bool
+	err               error
+}
+
+// newInMemoryIterator is a failable push iterator over the results of a
+// query function. The query function is not called until iteration begins.
+func newInMemoryIterator[T DocumentProperties](query func() ([][]byte, string, error)) DBClientIterator[T] {
+	return &inMemoryIterator[T]{query: query}
+}
+
+// newInMemoryChangeFeedIterator is like newInMemoryIterator except that, like
+// a change feed, the continuation token given here is returned unchanged until
+// every item has been yielded.
+func newInMemoryChangeFeedIterator[T DocumentProperties](continuationToken string, query func() ([][]byte, string, error)) DBClientIterator[T] {
+	return &inMemoryIterator[T]{query: query, continuationToken: continuationToken, deferToken: true}
+}
+
+// Items returns a push iterator that can be used directly in for/range loops.
+// If an error occurs during iteration, iteration stops and the error is recorded.
+func (iter *inMemoryIterator[T]) Items(ctx context.Context) DBClientIteratorItem[T] {
+	return func(yield func(string, *T) bool) {
+		items, continuationToken, err := iter.query()
+		if err != nil {
+			iter.err = err
+			return
+		}
+		if !iter.deferToken {
+			iter.continuationToken = continuationToken
+		}
+
+		for _, item := range items {
+			if err := ctx.Err(); err != nil {
+				iter.err = err
+				return
+			}
+
+			typedDoc, innerDoc, err := typedDocumentUnmarshal[T](item)
+			if err != nil {
+				iter.err = err
+				return
+			}
+
+			if !yield(typedDoc.ID, innerDoc) {
+				return
+			}
+		}
+
+		if iter.deferToken {
+			iter.continuationToken = continuationToken
+		}
+	}
+}
+
+// GetContinuationToken returns a continuation token that can be used to obtain
+// the next page of results, if additional items are available.
+func (iter *inMemoryIterator[T]) GetContinuationToken() string {
+	return iter.continuationToken
+}
+
+// GetError returns any error that occurred during iteration. Call this after the
+// for/range loop that calls Items() to check if iteration completed successfully.
+func (iter *inMemoryIterator[T]) GetError() error {
+	return iter.err
+}
+
+var _ LockClient = &inMemoryLockClient{}
+
+// inMemoryLockClient implements LockClient within a single process.
+// Locks expire after their time-to-live unless renewed, same as the
+// Cosmos DB implementation.
+type inMemoryLockClient struct {
+	mutex             sync.Mutex
+	name              string
+	defaultTimeToLive int32
+	locks             map[string]*inMemoryItem
+}
+
+func newInMemoryLockClient(defaultTimeToLive int32) *inMemoryLockClient {
+	// Same default as cosmosLockClient, but not critical.
+	hostname, _ := os.Hostname()
+
+	return &inMemoryLockClient{
+		name:              hostname,
+		defaultTimeToLive: defaultTimeToLive,
+		locks:             make(map[string]*inMemoryItem),
+	}
+}
+
+// storeLock stores a new revision of a lock document. The mutex must be held.
+func (c *inMemoryLockClient) storeLock(doc *lockDocument) (*azcosmos.ItemResponse, error) {
+	now := time.Now()
+
+	doc.CosmosETag = newETag()
+	doc.CosmosTimestamp = int(now.Unix())
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	c.locks[doc.ID] = &inMemoryItem{
+		data:    data,
+		etag:    doc.CosmosETag,
+		expires: now.Add(time.Duration(doc.TTL) * time.Second),
+	}
+
+	return &azcosmos.ItemResponse{
+		Value:    data,
+		Response: azcosmos.Response{ETag: doc.CosmosETag},
+	}, nil
+}
+
+func (c *inMemoryLockClient) SetName(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.name = name
+}
+
+func (c *inMemoryLockClient) GetDefaultTimeToLive() time.Duration {
+	return time.Duration(c.defaultTimeToLive) * time.Second
+}
+
+func (c *inMemoryLockClient) SetRetryAfterHeader(header http.Header) {
+	header.Set("Retry-After", strconv.Itoa(int(c.defaultTimeToLive)))
+}
+
+func (c *inMemoryLockClient) AcquireLock(ctx context.Context, id string, timeout *time.Duration) (*azcosmos.ItemResponse, error) {
+	return acquireLock(ctx, id, timeout, c.TryAcquireLock)
+}
+
+func (c *inMemoryLockClient) TryAcquireLock(ctx context.Context, id string) (*azcosmos.ItemResponse, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if item, ok := c.locks[id]; ok && item.isLive(time.Now()) {
+		return nil, nil // lock already acquired by someone else
+	}
+
+	doc := &lockDocument{
+		baseDocument: baseDocument{ID: id},
+		Owner:        c.name,
+		TTL:          c.defaultTimeToLive,
+	}
+
+	return c.storeLock(doc)
+}
+
+func (c *inMemoryLockClient) TryAcquireHierarchicalLock(ctx context.Context, id string, ancestorIDs []string) (*azcosmos.ItemResponse, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for lockID, item := range c.locks {
+		if !item.isLive(now) {
+			continue
+		}
+		if lockID == id || slices.Contains(ancestorIDs, lockID) {
+			return nil, nil // conflicting lock already acquired
+		}
+
+		var doc *lockDocument
+		if err := json.Unmarshal(item.data, &doc); err != nil {
+			return nil, err
+		}
+		if slices.Contains(doc.Ancestors, id) {
+			return nil, nil // conflicting lock already acquired
+		}
+	}
+
+	doc := &lockDocument{
+		baseDocument: baseDocument{ID: id},
+		Owner:        c.name,
+		Ancestors:    ancestorIDs,
+		TTL:          c.defaultTimeToLive,
+	}
+
+	return c.storeLock(doc)
+}
+
+func (c *inMemoryLockClient) HoldLock(ctx context.Context, item *azcosmos.ItemResponse) (cancelCtx context.Context, stop StopHoldLock) {
+	return holdLock(ctx, item, c.RenewLock)
+}
+
+func (c *inMemoryLockClient) RenewLock(ctx context.Context, item *azcosmos.ItemResponse) (*azcosmos.ItemResponse, error) {
+	var doc *lockDocument
+
+	err := json.Unmarshal(item.Value, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	current, ok := c.locks[doc.ID]
+	if !ok || !current.isLive(time.Now()) || current.etag != item.Response.ETag {
+		return nil, nil // lock already acquired by someone else
+	}
+
+	return c.storeLock(doc)
+}
+
+func (c *inMemoryLockClient) ReleaseLock(ctx context.Context, item *azcosmos.ItemResponse) error {
+	var doc *lockDocument
+
+	err := json.Unmarshal(item.Value, &doc)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	current, ok := c.locks[doc.ID]
+	if ok && current.etag == item.Response.ETag {
+		delete(c.locks, doc.ID)
+	}
+
+	return nil
+}
+
+func (c *inMemoryLockClient) GetLock(ctx context.Context, id string) (*LockInfo, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, ok := c.locks[id]
+	if !ok || !item.isLive(time.Now()) {
+		return nil, nil // lock not held
+	}
+
+	return newLockInfo(item.data)
+}
+
+func (c *inMemoryLockClient) ListLocks(ctx context.Context) ([]LockInfo, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var locks []LockInfo
+
+	now := time.Now()
+	for _, id := range slices.Sorted(maps.Keys(c.locks)) {
+		item := c.locks[id]
+		if !item.isLive(now) {
+			continue
+		}
+		info, err := newLockInfo(item.data)
+		if err != nil {
+			return nil, err
+		}
+		locks = append(locks, *info)
+	}
+
+	return locks, nil
+}
+
+func (c *inMemoryLockClient) BreakLock(ctx context.Context, id string, etag azcore.ETag) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	current, ok := c.locks[id]
+	if !ok || !current.isLive(time.Now()) {
+		return nil // lock not held
+	}
+	if current.etag != etag {
+		return ErrLockChanged
+	}
+
+	delete(c.locks, id)
+
+	return nil
+}
@@ -0,0 +1,489 @@
+package database
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+)
+
+func newTestClusterResourceID(t *testing.T, subscriptionID, clusterName string) *azcorearm.ResourceID {
+	resourceID, err := azcorearm.ParseResourceID(fmt.Sprintf(
+		"/subscriptions/%s/resourceGroups/testGroup/providers/%s/%s",
+		subscriptionID, api.ClusterResourceType, clusterName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resourceID
+}
+
+func TestInMemoryUpdateResourceDoc(t *testing.T) {
+	ctx := context.Background()
+	dbClient := NewInMemoryDBClient()
+
+	resourceID := newTestClusterResourceID(t, "00000000-0000-0000-0000-000000000000", "testCluster")
+
+	err := dbClient.CreateResourceDoc(ctx, NewResourceDocument(resourceID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first callback invocation sneaks in a conflicting
+	// update, which should cause the etag precondition to fail
+	// and the outer update to be retried with the new document.
+	calls := 0
+	updated, err := dbClient.UpdateResourceDoc(ctx, resourceID, func(doc *ResourceDocument) bool {
+		calls++
+		if calls == 1 {
+			_, err := dbClient.UpdateResourceDoc(ctx, resourceID, func(doc *ResourceDocument) bool {
+				doc.ActiveOperationID = "conflicting"
+				return true
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		doc.ProvisioningState = arm.ProvisioningStateSucceeded
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated {
+		t.Error("expected document to be updated")
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 callback invocations, got %d", calls)
+	}
+
+	doc, err := dbClient.GetResourceDoc(ctx, resourceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.ActiveOperationID != "conflicting" || doc.ProvisioningState != arm.ProvisioningStateSucceeded {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+}
+
+func TestInMemoryListResourceDocs(t *testing.T) {
+	const subscriptionID = "00000000-0000-0000-0000-000000000000"
+	const pageSize = 2
+
+	ctx := context.Background()
+	dbClient := NewInMemoryDBClient()
+
+	var expected []string
+	for i := range 5 {
+		resourceID := newTestClusterResourceID(t, subscriptionID, fmt.Sprintf("cluster%d", i))
+		expected = append(expected, resourceID.String())
+		if err := dbClient.CreateResourceDoc(ctx, NewResourceDocument(resourceID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Documents in other partitions should not be listed.
+	otherResourceID := newTestClusterResourceID(t, "11111111-1111-1111-1111-111111111111", "cluster0")
+	if err := dbClient.CreateResourceDoc(ctx, NewResourceDocument(otherResourceID)); err != nil {
+		t.Fatal(err)
+	}
+
+	prefix, err := azcorearm.ParseResourceID("/subscriptions/" + subscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []string
+	var continuationToken *string
+	for pages := 1; ; pages++ {
+		iterator := dbClient.ListResourceDocs(prefix, pageSize, continuationToken)
+
+		count := 0
+		for _, doc := range iterator.Items(ctx) {
+			actual = append(actual, doc.ResourceID.String())
+			count++
+		}
+		if err := iterator.GetError(); err != nil {
+			t.Fatal(err)
+		}
+		if count > pageSize {
+			t.Fatalf("page %d has %d items, expected at most %d", pages, count, pageSize)
+		}
+
+		token := iterator.GetContinuationToken()
+		if token == "" {
+			if pages != 3 {
+				t.Errorf("expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		continuationToken = &token
+	}
+
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestInMemoryListOperationDocChanges(t *testing.T) {
+	const changeFeedName = "test"
+
+	ctx := context.Background()
+	dbClient := NewInMemoryDBClient()
+
+	resourceID := newTestClusterResourceID(t, "00000000-0000-0000-0000-000000000000", "testCluster")
+
+	// Resource documents should not be listed.
+	if err := dbClient.CreateResourceDoc(ctx, NewResourceDocument(resourceID)); err != nil {
+		t.Fatal(err)
+	}
+
+	operationID, err := dbClient.CreateOperationDoc(ctx, NewOperationDocument(OperationRequestCreate, resourceID, ocm.InternalID{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	continuationToken, err := dbClient.GetChangeFeedToken(ctx, changeFeedName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if continuationToken != "" {
+		t.Fatalf("expected no continuation token, got '%s'", continuationToken)
+	}
+
+	iterator := dbClient.ListOperationDocChanges(continuationToken)
+
+	var actual []string
+	for id := range iterator.Items(ctx) {
+		actual = append(actual, id)
+	}
+	if err := iterator.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(actual) != fmt.Sprint([]string{operationID}) {
+		t.Errorf("expected %v, got %v", []string{operationID}, actual)
+	}
+
+	// Stopping early should leave the continuation token unchanged.
+	iterator = dbClient.ListOperationDocChanges(continuationToken)
+	for range iterator.Items(ctx) {
+		break
+	}
+	if token := iterator.GetContinuationToken(); token != continuationToken {
+		t.Errorf("expected unchanged continuation token '%s', got '%s'", continuationToken, token)
+	}
+
+	iterator = dbClient.ListOperationDocChanges(continuationToken)
+	for range iterator.Items(ctx) {
+	}
+	if err := iterator.GetError(); err != nil {
+		t.Fatal(err)
+	}
+
+	continuationToken = iterator.GetContinuationToken()
+	if continuationToken == "" {
+		t.Fatal("expected a continuation token")
+	}
+
+	for range 2 {
+		if err := dbClient.SetChangeFeedToken(ctx, changeFeedName, continuationToken); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	persistedToken, err := dbClient.GetChangeFeedToken(ctx, changeFeedName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if persistedToken != continuationToken {
+		t.Errorf("expected persisted continuation token '%s', got '%s'", continuationToken, persistedToken)
+	}
+
+	// A continuation token past the last change should list nothing.
+	timestamp, err := parseChangeFeedToken(continuationToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iterator = dbClient.ListOperationDocChanges(strconv.Itoa(timestamp + 1))
+	for id := range iterator.Items(ctx) {
+		t.Errorf("unexpected change to '%s'", id)
+	}
+	if err := iterator.GetError(); err != nil {
+		t.Fatal(err)
+	}
+
+	iterator = dbClient.ListOperationDocChanges("bogus")
+	for range iterator.Items(ctx) {
+	}
+	if iterator.GetError() == nil {
+		t.Error("expected an error for an invalid continuation token")
+	}
+}
+
+func TestInMemoryDeleteOperationDoc(t *testing.T) {
+	ctx := context.Background()
+	dbClient := NewInMemoryDBClient()
+
+	resourceID := newTestClusterResourceID(t, "00000000-0000-0000-0000-000000000000", "testCluster")
+	pk := NewPartitionKey(resourceID.SubscriptionID)
+
+	operationID, err := dbClient.CreateOperationDoc(ctx, NewOperationDocument(OperationRequestCreate, resourceID, ocm.InternalID{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting an operation twice should succeed both times.
+	for range 2 {
+		if err := dbClient.DeleteOperationDoc(ctx, pk, operationID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = dbClient.GetOperationDoc(ctx, pk, operationID)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestInMemoryTerminalOperationExpires(t *testing.T) {
+	ctx := context.Background()
+	dbClient := NewInMemoryDBClient()
+
+	const subscriptionID = "00000000-0000-0000-0000-000000000000"
+
+	// The backend does not poll unregistered subscriptions, so their
+	// operations are never deleted and have to expire on their own.
+	err := dbClient.CreateSubscriptionDoc(ctx, subscriptionID, &arm.Subscription{
+		State:            arm.SubscriptionStateUnregistered,
+		RegistrationDate: api.Ptr(time.Now().String()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resourceID := newTestClusterResourceID(t, subscriptionID, "testCluster")
+	pk := NewPartitionKey(resourceID.SubscriptionID)
+
+	operationID, err := dbClient.CreateOperationDoc(ctx, NewOperationDocument(OperationRequestCreate, resourceID, ocm.InternalID{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getItem := func() *inMemoryItem {
+		d := dbClient.(*inMemoryDBClient)
+		d.mutex.RLock()
+		defer d.mutex.RUnlock()
+		return d.partitions[subscriptionID][operationID]
+	}
+
+	if item := getItem(); !item.expires.IsZero() {
+		t.Errorf("Expected operation in progress not to expire but it expires at %s", item.expires)
+	}
+
+	_, err = dbClient.UpdateOperationDoc(ctx, pk, operationID, func(updateDoc *OperationDocument) bool {
+		return updateDoc.UpdateStatus(arm.ProvisioningStateSucceeded, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := getItem()
+	if until := time.Until(item.expires); until <= 0 || until > OperationTimeToLive {
+		t.Fatalf("Expected completed operation to expire within %s but it expires at %s", OperationTimeToLive, item.expires)
+	}
+
+	// Let the time-to-live elapse.
+	item.expires = time.Now()
+
+	_, err = dbClient.GetOperationDoc(ctx, pk, operationID)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestInMemoryListResourceOperationDocs(t *testing.T) {
+	ctx := context.Background()
+	dbClient := NewInMemoryDBClient()
+
+	resourceID := newTestClusterResourceID(t, "00000000-0000-0000-0000-000000000000", "testCluster")
+	otherResourceID := newTestClusterResourceID(t, "00000000-0000-0000-0000-000000000000", "otherCluster")
+
+	createOperation := func(resourceID *azcorearm.ResourceID, request OperationRequest, status arm.ProvisioningState, startTime time.Time) string {
+		doc := NewOperationDocument(request, resourceID, ocm.InternalID{})
+		doc.Status = status
+		doc.StartTime = startTime
+		operationID, err := dbClient.CreateOperationDoc(ctx, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return operationID
+	}
+
+	startTime := time.Now().UTC()
+
+	var all []string
+	for i, status := range []arm.ProvisioningState{
+		arm.ProvisioningStateFailed,
+		arm.ProvisioningStateSucceeded,
+		arm.ProvisioningStateFailed,
+		arm.ProvisioningStateUpdating,
+	} {
+		operationID := createOperation(resourceID, OperationRequestUpdate, status, startTime.Add(time.Duration(i)*time.Minute))
+		all = append([]string{operationID}, all...)
+	}
+	createFailed := createOperation(resourceID, OperationRequestCreate, arm.ProvisioningStateFailed, startTime.Add(-time.Hour))
+	all = append(all, createFailed)
+
+	_, err := dbClient.UpdateOperationDoc(ctx, NewPartitionKey(resourceID.SubscriptionID), createFailed, func(doc *OperationDocument) bool {
+		doc.ClientRequestID = "11111111-1111-1111-1111-111111111111"
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Operations of other resources should not be listed.
+	createOperation(otherResourceID, OperationRequestCreate, arm.ProvisioningStateFailed, startTime)
+
+	list := func(t *testing.T, filter OperationDocumentFilter, maxItems int32) []string {
+		var actual []string
+		var continuationToken *string
+		for {
+			iterator := dbClient.ListResourceOperationDocs(resourceID, filter, maxItems, continuationToken)
+			for operationID := range iterator.Items(ctx) {
+				actual = append(actual, operationID)
+			}
+			if err := iterator.GetError(); err != nil {
+				t.Fatal(err)
+			}
+			token := iterator.GetContinuationToken()
+			if token == "" {
+				return actual
+			}
+			continuationToken = &token
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filter   OperationDocumentFilter
+		maxItems int32
+		expected []string
+	}{
+		{
+			name:     "All operations, most recent first",
+			maxItems: -1,
+			expected: all,
+		},
+		{
+			name:     "All operations in pages",
+			maxItems: 2,
+			expected: all,
+		},
+		{
+			name:     "Filter by request",
+			filter:   OperationDocumentFilter{Request: OperationRequestCreate},
+			maxItems: -1,
+			expected: []string{createFailed},
+		},
+		{
+			name:     "Filter by status",
+			filter:   OperationDocumentFilter{Status: arm.ProvisioningStateFailed},
+			maxItems: 1,
+			expected: []string{all[1], all[3], createFailed},
+		},
+		{
+			name:     "Filter by request and status",
+			filter:   OperationDocumentFilter{Request: OperationRequestUpdate, Status: arm.ProvisioningStateSucceeded},
+			maxItems: -1,
+			expected: []string{all[2]},
+		},
+		{
+			name:     "Filter by client request ID",
+			filter:   OperationDocumentFilter{ClientRequestID: "11111111-1111-1111-1111-111111111111"},
+			maxItems: 1,
+			expected: []string{createFailed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := list(t, tt.filter, tt.maxItems)
+			if fmt.Sprint(actual) != fmt.Sprint(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestInMemoryLockClient(t *testing.T) {
+	const lockID = "00000000-0000-0000-0000-000000000000"
+
+	ctx := context.Background()
+	lockClient := NewInMemoryDBClient().GetLockClient()
+
+	lock, err := lockClient.TryAcquireLock(ctx, lockID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock == nil {
+		t.Fatal("expected to acquire lock")
+	}
+
+	other, err := lockClient.TryAcquireLock(ctx, lockID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other != nil {
+		t.Fatal("expected lock to already be held")
+	}
+
+	renewed, err := lockClient.RenewLock(ctx, lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed == nil {
+		t.Fatal("expected to renew lock")
+	}
+
+	// Releasing a stale lock revision must have no effect.
+	if err = lockClient.ReleaseLock(ctx, lock); err != nil {
+		t.Fatal(err)
+	}
+	if other, _ = lockClient.TryAcquireLock(ctx, lockID); other != nil {
+		t.Fatal("expected stale release to be ignored")
+	}
+
+	if err = lockClient.ReleaseLock(ctx, renewed); err != nil {
+		t.Fatal(err)
+	}
+	if other, _ = lockClient.TryAcquireLock(ctx, lockID); other == nil {
+		t.Fatal("expected to acquire released lock")
+	}
+
+	// Breaking a stale lock revision must have no effect.
+	if err = lockClient.BreakLock(ctx, lockID, renewed.Response.ETag); !errors.Is(err, ErrLockChanged) {
+		t.Fatalf("expected ErrLockChanged, got %v", err)
+	}
+	if info, _ := lockClient.GetLock(ctx, lockID); info == nil {
+		t.Fatal("expected stale break to be ignored")
+	}
+
+	if err = lockClient.BreakLock(ctx, lockID, other.Response.ETag); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := lockClient.GetLock(ctx, lockID); info != nil {
+		t.Fatal("expected lock to be broken")
+	}
+}
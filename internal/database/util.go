@@ -5,6 +5,8 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
@@ -72,3 +74,84 @@ func (iter queryItemsIterator[T]) GetContinuationToken() string {
 func (iter queryItemsIterator[T]) GetError() error {
 	return iter.err
 }
+
+// parseChangeFeedToken parses a continuation token from a change feed iterator
+// into the "_ts" value it encodes. An empty token parses as zero.
+func parseChangeFeedToken(continuationToken string) (int, error) {
+	if continuationToken == "" {
+		return 0, nil
+	}
+
+	timestamp, err := strconv.Atoi(continuationToken)
+	if err != nil || timestamp < 0 {
+		return 0, fmt.Errorf("invalid change feed continuation token '%s'", continuationToken)
+	}
+
+	return timestamp, nil
+}
+
+type changeFeedIterator[T DocumentProperties] struct {
+	pager     *runtime.Pager[azcosmos.QueryItemsResponse]
+	timestamp int
+	err       error
+}
+
+// newChangeFeedIterator is a failable push iterator for a paged query response
+// of items modified at or after the given "_ts" value. The continuation token
+// encodes the latest "_ts" value seen once all pages have been consumed.
+func newChangeFeedIterator[T DocumentProperties](pager *runtime.Pager[azcosmos.QueryItemsResponse], timestamp int) DBClientIterator[T] {
+	return &changeFeedIterator[T]{pager: pager, timestamp: timestamp}
+}
+
+// newErrorIterator is a change feed iterator that yields nothing and
+// reports the given error.
+func newErrorIterator[T DocumentProperties](err error) DBClientIterator[T] {
+	return &changeFeedIterator[T]{err: err}
+}
+
+// Items returns a push iterator that can be used directly in for/range loops.
+// If an error occurs during paging, iteration stops and the error is recorded.
+func (iter *changeFeedIterator[T]) Items(ctx context.Context) DBClientIteratorItem[T] {
+	return func(yield func(string, *T) bool) {
+		if iter.err != nil {
+			return
+		}
+
+		latest := iter.timestamp
+
+		for iter.pager.More() {
+			response, err := iter.pager.NextPage(ctx)
+			if err != nil {
+				iter.err = err
+				return
+			}
+			for _, item := range response.Items {
+				typedDoc, innerDoc, err := typedDocumentUnmarshal[T](item)
+				if err != nil {
+					iter.err = err
+					return
+				}
+
+				latest = max(latest, typedDoc.CosmosTimestamp)
+
+				if !yield(typedDoc.ID, innerDoc) {
+					return
+				}
+			}
+		}
+
+		iter.timestamp = latest
+	}
+}
+
+// GetContinuationToken returns a continuation token that can be used to obtain
+// items modified after those already yielded.
+func (iter *changeFeedIterator[T]) GetContinuationToken() string {
+	return strconv.Itoa(iter.timestamp)
+}
+
+// GetError returns any error that occurred during iteration. Call this after the
+// for/range loop that calls Items() to check if iteration completed successfully.
+func (iter *changeFeedIterator[T]) GetError() error {
+	return iter.err
+}
@@ -183,6 +183,45 @@ func (m *MockDBClient) EXPECT() *MockDBClientMockRecorder {
 	return m.recorder
 }
 
+// CreateNotificationDoc mocks base method.
+func (m *MockDBClient) CreateNotificationDoc(ctx context.Context, doc *database.NotificationDocument) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNotificationDoc", ctx, doc)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNotificationDoc indicates an expected call of CreateNotificationDoc.
+func (mr *MockDBClientMockRecorder) CreateNotificationDoc(ctx, doc any) *MockDBClientCreateNotificationDocCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNotificationDoc", reflect.TypeOf((*MockDBClient)(nil).CreateNotificationDoc), ctx, doc)
+	return &MockDBClientCreateNotificationDocCall{Call: call}
+}
+
+// MockDBClientCreateNotificationDocCall wrap *gomock.Call
+type MockDBClientCreateNotificationDocCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDBClientCreateNotificationDocCall) Return(arg0 bool, arg1 error) *MockDBClientCreateNotificationDocCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDBClientCreateNotificationDocCall) Do(f func(context.Context, *database.NotificationDocument) (bool, error)) *MockDBClientCreateNotificationDocCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDBClientCreateNotificationDocCall) DoAndReturn(f func(context.Context, *database.NotificationDocument) (bool, error)) *MockDBClientCreateNotificationDocCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // CreateOperationDoc mocks base method.
 func (m *MockDBClient) CreateOperationDoc(ctx context.Context, doc *database.OperationDocument) (string, error) {
 	m.ctrl.T.Helper()
@@ -336,6 +375,44 @@ func (c *MockDBClientDBConnectionTestCall) DoAndReturn(f func(context.Context) e
 	return c
 }
 
+// DeleteOperationDoc mocks base method.
+func (m *MockDBClient) DeleteOperationDoc(ctx context.Context, pk azcosmos.PartitionKey, operationID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOperationDoc", ctx, pk, operationID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOperationDoc indicates an expected call of DeleteOperationDoc.
+func (mr *MockDBClientMockRecorder) DeleteOperationDoc(ctx, pk, operationID any) *MockDBClientDeleteOperationDocCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOperationDoc", reflect.TypeOf((*MockDBClient)(nil).DeleteOperationDoc), ctx, pk, operationID)
+	return &MockDBClientDeleteOperationDocCall{Call: call}
+}
+
+// MockDBClientDeleteOperationDocCall wrap *gomock.Call
+type MockDBClientDeleteOperationDocCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDBClientDeleteOperationDocCall) Return(arg0 error) *MockDBClientDeleteOperationDocCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDBClientDeleteOperationDocCall) Do(f func(context.Context, azcosmos.PartitionKey, string) error) *MockDBClientDeleteOperationDocCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDBClientDeleteOperationDocCall) DoAndReturn(f func(context.Context, azcosmos.PartitionKey, string) error) *MockDBClientDeleteOperationDocCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // DeleteResourceDoc mocks base method.
 func (m *MockDBClient) DeleteResourceDoc(ctx context.Context, resourceID *arm0.ResourceID) error {
 	m.ctrl.T.Helper()
@@ -374,11 +451,50 @@ func (c *MockDBClientDeleteResourceDocCall) DoAndReturn(f func(context.Context,
 	return c
 }
 
+// GetChangeFeedToken mocks base method.
+func (m *MockDBClient) GetChangeFeedToken(ctx context.Context, name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChangeFeedToken", ctx, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChangeFeedToken indicates an expected call of GetChangeFeedToken.
+func (mr *MockDBClientMockRecorder) GetChangeFeedToken(ctx, name any) *MockDBClientGetChangeFeedTokenCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChangeFeedToken", reflect.TypeOf((*MockDBClient)(nil).GetChangeFeedToken), ctx, name)
+	return &MockDBClientGetChangeFeedTokenCall{Call: call}
+}
+
+// MockDBClientGetChangeFeedTokenCall wrap *gomock.Call
+type MockDBClientGetChangeFeedTokenCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDBClientGetChangeFeedTokenCall) Return(arg0 string, arg1 error) *MockDBClientGetChangeFeedTokenCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDBClientGetChangeFeedTokenCall) Do(f func(context.Context, string) (string, error)) *MockDBClientGetChangeFeedTokenCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDBClientGetChangeFeedTokenCall) DoAndReturn(f func(context.Context, string) (string, error)) *MockDBClientGetChangeFeedTokenCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // GetLockClient mocks base method.
-func (m *MockDBClient) GetLockClient() *database.LockClient {
+func (m *MockDBClient) GetLockClient() database.LockClient {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetLockClient")
-	ret0, _ := ret[0].(*database.LockClient)
+	ret0, _ := ret[0].(database.LockClient)
 	return ret0
 }
 
@@ -395,19 +511,19 @@ type MockDBClientGetLockClientCall struct {
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockDBClientGetLockClientCall) Return(arg0 *database.LockClient) *MockDBClientGetLockClientCall {
+func (c *MockDBClientGetLockClientCall) Return(arg0 database.LockClient) *MockDBClientGetLockClientCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockDBClientGetLockClientCall) Do(f func() *database.LockClient) *MockDBClientGetLockClientCall {
+func (c *MockDBClientGetLockClientCall) Do(f func() database.LockClient) *MockDBClientGetLockClientCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockDBClientGetLockClientCall) DoAndReturn(f func() *database.LockClient) *MockDBClientGetLockClientCall {
+func (c *MockDBClientGetLockClientCall) DoAndReturn(f func() database.LockClient) *MockDBClientGetLockClientCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -567,6 +683,44 @@ func (c *MockDBClientListAllSubscriptionDocsCall) DoAndReturn(f func() database.
 	return c
 }
 
+// ListOperationDocChanges mocks base method.
+func (m *MockDBClient) ListOperationDocChanges(continuationToken string) database.DBClientIterator[database.OperationDocument] {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOperationDocChanges", continuationToken)
+	ret0, _ := ret[0].(database.DBClientIterator[database.OperationDocument])
+	return ret0
+}
+
+// ListOperationDocChanges indicates an expected call of ListOperationDocChanges.
+func (mr *MockDBClientMockRecorder) ListOperationDocChanges(continuationToken any) *MockDBClientListOperationDocChangesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOperationDocChanges", reflect.TypeOf((*MockDBClient)(nil).ListOperationDocChanges), continuationToken)
+	return &MockDBClientListOperationDocChangesCall{Call: call}
+}
+
+// MockDBClientListOperationDocChangesCall wrap *gomock.Call
+type MockDBClientListOperationDocChangesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDBClientListOperationDocChangesCall) Return(arg0 database.DBClientIterator[database.OperationDocument]) *MockDBClientListOperationDocChangesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDBClientListOperationDocChangesCall) Do(f func(string) database.DBClientIterator[database.OperationDocument]) *MockDBClientListOperationDocChangesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDBClientListOperationDocChangesCall) DoAndReturn(f func(string) database.DBClientIterator[database.OperationDocument]) *MockDBClientListOperationDocChangesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // ListOperationDocs mocks base method.
 func (m *MockDBClient) ListOperationDocs(pk azcosmos.PartitionKey) database.DBClientIterator[database.OperationDocument] {
 	m.ctrl.T.Helper()
@@ -605,6 +759,44 @@ func (c *MockDBClientListOperationDocsCall) DoAndReturn(f func(azcosmos.Partitio
 	return c
 }
 
+// ListPendingNotificationDocs mocks base method.
+func (m *MockDBClient) ListPendingNotificationDocs() database.DBClientIterator[database.NotificationDocument] {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingNotificationDocs")
+	ret0, _ := ret[0].(database.DBClientIterator[database.NotificationDocument])
+	return ret0
+}
+
+// ListPendingNotificationDocs indicates an expected call of ListPendingNotificationDocs.
+func (mr *MockDBClientMockRecorder) ListPendingNotificationDocs() *MockDBClientListPendingNotificationDocsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingNotificationDocs", reflect.TypeOf((*MockDBClient)(nil).ListPendingNotificationDocs))
+	return &MockDBClientListPendingNotificationDocsCall{Call: call}
+}
+
+// MockDBClientListPendingNotificationDocsCall wrap *gomock.Call
+type MockDBClientListPendingNotificationDocsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDBClientListPendingNotificationDocsCall) Return(arg0 database.DBClientIterator[database.NotificationDocument]) *MockDBClientListPendingNotificationDocsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDBClientListPendingNotificationDocsCall) Do(f func() database.DBClientIterator[database.NotificationDocument]) *MockDBClientListPendingNotificationDocsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDBClientListPendingNotificationDocsCall) DoAndReturn(f func() database.DBClientIterator[database.NotificationDocument]) *MockDBClientListPendingNotificationDocsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // ListResourceDocs mocks base method.
 func (m *MockDBClient) ListResourceDocs(prefix *arm0.ResourceID, maxItems int32, continuationToken *string) database.DBClientIterator[database.ResourceDocument] {
 	m.ctrl.T.Helper()
@@ -643,6 +835,121 @@ func (c *MockDBClientListResourceDocsCall) DoAndReturn(f func(*arm0.ResourceID,
 	return c
 }
 
+// ListResourceOperationDocs mocks base method.
+func (m *MockDBClient) ListResourceOperationDocs(resourceID *arm0.ResourceID, filter database.OperationDocumentFilter, maxItems int32, continuationToken *string) database.DBClientIterator[database.OperationDocument] {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListResourceOperationDocs", resourceID, filter, maxItems, continuationToken)
+	ret0, _ := ret[0].(database.DBClientIterator[database.OperationDocument])
+	return ret0
+}
+
+// ListResourceOperationDocs indicates an expected call of ListResourceOperationDocs.
+func (mr *MockDBClientMockRecorder) ListResourceOperationDocs(resourceID, filter, maxItems, continuationToken any) *MockDBClientListResourceOperationDocsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListResourceOperationDocs", reflect.TypeOf((*MockDBClient)(nil).ListResourceOperationDocs), resourceID, filter, maxItems, continuationToken)
+	return &MockDBClientListResourceOperationDocsCall{Call: call}
+}
+
+// MockDBClientListResourceOperationDocsCall wrap *gomock.Call
+type MockDBClientListResourceOperationDocsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDBClientListResourceOperationDocsCall) Return(arg0 database.DBClientIterator[database.OperationDocument]) *MockDBClientListResourceOperationDocsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDBClientListResourceOperationDocsCall) Do(f func(*arm0.ResourceID, database.OperationDocumentFilter, int32, *string) database.DBClientIterator[database.OperationDocument]) *MockDBClientListResourceOperationDocsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDBClientListResourceOperationDocsCall) DoAndReturn(f func(*arm0.ResourceID, database.OperationDocumentFilter, int32, *string) database.DBClientIterator[database.OperationDocument]) *MockDBClientListResourceOperationDocsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SetChangeFeedToken mocks base method.
+func (m *MockDBClient) SetChangeFeedToken(ctx context.Context, name string, continuationToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChangeFeedToken", ctx, name, continuationToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChangeFeedToken indicates an expected call of SetChangeFeedToken.
+func (mr *MockDBClientMockRecorder) SetChangeFeedToken(ctx, name, continuationToken any) *MockDBClientSetChangeFeedTokenCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChangeFeedToken", reflect.TypeOf((*MockDBClient)(nil).SetChangeFeedToken), ctx, name, continuationToken)
+	return &MockDBClientSetChangeFeedTokenCall{Call: call}
+}
+
+// MockDBClientSetChangeFeedTokenCall wrap *gomock.Call
+type MockDBClientSetChangeFeedTokenCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDBClientSetChangeFeedTokenCall) Return(arg0 error) *MockDBClientSetChangeFeedTokenCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDBClientSetChangeFeedTokenCall) Do(f func(context.Context, string, string) error) *MockDBClientSetChangeFeedTokenCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDBClientSetChangeFeedTokenCall) DoAndReturn(f func(context.Context, string, string) error) *MockDBClientSetChangeFeedTokenCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// UpdateNotificationDoc mocks base method.
+func (m *MockDBClient) UpdateNotificationDoc(ctx context.Context, pk azcosmos.PartitionKey, notificationID string, callback func(*database.NotificationDocument) bool) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNotificationDoc", ctx, pk, notificationID, callback)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateNotificationDoc indicates an expected call of UpdateNotificationDoc.
+func (mr *MockDBClientMockRecorder) UpdateNotificationDoc(ctx, pk, notificationID, callback any) *MockDBClientUpdateNotificationDocCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNotificationDoc", reflect.TypeOf((*MockDBClient)(nil).UpdateNotificationDoc), ctx, pk, notificationID, callback)
+	return &MockDBClientUpdateNotificationDocCall{Call: call}
+}
+
+// MockDBClientUpdateNotificationDocCall wrap *gomock.Call
+type MockDBClientUpdateNotificationDocCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDBClientUpdateNotificationDocCall) Return(arg0 bool, arg1 error) *MockDBClientUpdateNotificationDocCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDBClientUpdateNotificationDocCall) Do(f func(context.Context, azcosmos.PartitionKey, string, func(*database.NotificationDocument) bool) (bool, error)) *MockDBClientUpdateNotificationDocCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDBClientUpdateNotificationDocCall) DoAndReturn(f func(context.Context, azcosmos.PartitionKey, string, func(*database.NotificationDocument) bool) (bool, error)) *MockDBClientUpdateNotificationDocCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // UpdateOperationDoc mocks base method.
 func (m *MockDBClient) UpdateOperationDoc(ctx context.Context, pk azcosmos.PartitionKey, operationID string, callback func(*database.OperationDocument) bool) (bool, error) {
 	m.ctrl.T.Helper()
@@ -158,6 +158,44 @@ func (c *MockClusterServiceClientSpecDeleteClusterCall) DoAndReturn(f func(conte
 	return c
 }
 
+// DeleteControlPlaneUpgradePolicy mocks base method.
+func (m *MockClusterServiceClientSpec) DeleteControlPlaneUpgradePolicy(ctx context.Context, internalID ocm.InternalID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteControlPlaneUpgradePolicy", ctx, internalID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteControlPlaneUpgradePolicy indicates an expected call of DeleteControlPlaneUpgradePolicy.
+func (mr *MockClusterServiceClientSpecMockRecorder) DeleteControlPlaneUpgradePolicy(ctx, internalID any) *MockClusterServiceClientSpecDeleteControlPlaneUpgradePolicyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteControlPlaneUpgradePolicy", reflect.TypeOf((*MockClusterServiceClientSpec)(nil).DeleteControlPlaneUpgradePolicy), ctx, internalID)
+	return &MockClusterServiceClientSpecDeleteControlPlaneUpgradePolicyCall{Call: call}
+}
+
+// MockClusterServiceClientSpecDeleteControlPlaneUpgradePolicyCall wrap *gomock.Call
+type MockClusterServiceClientSpecDeleteControlPlaneUpgradePolicyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClusterServiceClientSpecDeleteControlPlaneUpgradePolicyCall) Return(arg0 error) *MockClusterServiceClientSpecDeleteControlPlaneUpgradePolicyCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClusterServiceClientSpecDeleteControlPlaneUpgradePolicyCall) Do(f func(context.Context, ocm.InternalID) error) *MockClusterServiceClientSpecDeleteControlPlaneUpgradePolicyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClusterServiceClientSpecDeleteControlPlaneUpgradePolicyCall) DoAndReturn(f func(context.Context, ocm.InternalID) error) *MockClusterServiceClientSpecDeleteControlPlaneUpgradePolicyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // DeleteNodePool mocks base method.
 func (m *MockClusterServiceClientSpec) DeleteNodePool(ctx context.Context, internalID ocm.InternalID) error {
 	m.ctrl.T.Helper()
@@ -196,6 +234,44 @@ func (c *MockClusterServiceClientSpecDeleteNodePoolCall) DoAndReturn(f func(cont
 	return c
 }
 
+// DeleteNodePoolUpgradePolicy mocks base method.
+func (m *MockClusterServiceClientSpec) DeleteNodePoolUpgradePolicy(ctx context.Context, internalID ocm.InternalID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNodePoolUpgradePolicy", ctx, internalID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNodePoolUpgradePolicy indicates an expected call of DeleteNodePoolUpgradePolicy.
+func (mr *MockClusterServiceClientSpecMockRecorder) DeleteNodePoolUpgradePolicy(ctx, internalID any) *MockClusterServiceClientSpecDeleteNodePoolUpgradePolicyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNodePoolUpgradePolicy", reflect.TypeOf((*MockClusterServiceClientSpec)(nil).DeleteNodePoolUpgradePolicy), ctx, internalID)
+	return &MockClusterServiceClientSpecDeleteNodePoolUpgradePolicyCall{Call: call}
+}
+
+// MockClusterServiceClientSpecDeleteNodePoolUpgradePolicyCall wrap *gomock.Call
+type MockClusterServiceClientSpecDeleteNodePoolUpgradePolicyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClusterServiceClientSpecDeleteNodePoolUpgradePolicyCall) Return(arg0 error) *MockClusterServiceClientSpecDeleteNodePoolUpgradePolicyCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClusterServiceClientSpecDeleteNodePoolUpgradePolicyCall) Do(f func(context.Context, ocm.InternalID) error) *MockClusterServiceClientSpecDeleteNodePoolUpgradePolicyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClusterServiceClientSpecDeleteNodePoolUpgradePolicyCall) DoAndReturn(f func(context.Context, ocm.InternalID) error) *MockClusterServiceClientSpecDeleteNodePoolUpgradePolicyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // GetBreakGlassCredential mocks base method.
 func (m *MockClusterServiceClientSpec) GetBreakGlassCredential(ctx context.Context, internalID ocm.InternalID) (*v1.BreakGlassCredential, error) {
 	m.ctrl.T.Helper()
@@ -313,6 +389,84 @@ func (c *MockClusterServiceClientSpecGetClusterStatusCall) DoAndReturn(f func(co
 	return c
 }
 
+// GetControlPlaneUpgradePolicy mocks base method.
+func (m *MockClusterServiceClientSpec) GetControlPlaneUpgradePolicy(ctx context.Context, internalID ocm.InternalID) (*v1.ControlPlaneUpgradePolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetControlPlaneUpgradePolicy", ctx, internalID)
+	ret0, _ := ret[0].(*v1.ControlPlaneUpgradePolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetControlPlaneUpgradePolicy indicates an expected call of GetControlPlaneUpgradePolicy.
+func (mr *MockClusterServiceClientSpecMockRecorder) GetControlPlaneUpgradePolicy(ctx, internalID any) *MockClusterServiceClientSpecGetControlPlaneUpgradePolicyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetControlPlaneUpgradePolicy", reflect.TypeOf((*MockClusterServiceClientSpec)(nil).GetControlPlaneUpgradePolicy), ctx, internalID)
+	return &MockClusterServiceClientSpecGetControlPlaneUpgradePolicyCall{Call: call}
+}
+
+// MockClusterServiceClientSpecGetControlPlaneUpgradePolicyCall wrap *gomock.Call
+type MockClusterServiceClientSpecGetControlPlaneUpgradePolicyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClusterServiceClientSpecGetControlPlaneUpgradePolicyCall) Return(arg0 *v1.ControlPlaneUpgradePolicy, arg1 error) *MockClusterServiceClientSpecGetControlPlaneUpgradePolicyCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClusterServiceClientSpecGetControlPlaneUpgradePolicyCall) Do(f func(context.Context, ocm.InternalID) (*v1.ControlPlaneUpgradePolicy, error)) *MockClusterServiceClientSpecGetControlPlaneUpgradePolicyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClusterServiceClientSpecGetControlPlaneUpgradePolicyCall) DoAndReturn(f func(context.Context, ocm.InternalID) (*v1.ControlPlaneUpgradePolicy, error)) *MockClusterServiceClientSpecGetControlPlaneUpgradePolicyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// GetMachineType mocks base method.
+func (m *MockClusterServiceClientSpec) GetMachineType(ctx context.Context, machineTypeID string) (*v1.MachineType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMachineType", ctx, machineTypeID)
+	ret0, _ := ret[0].(*v1.MachineType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMachineType indicates an expected call of GetMachineType.
+func (mr *MockClusterServiceClientSpecMockRecorder) GetMachineType(ctx, machineTypeID any) *MockClusterServiceClientSpecGetMachineTypeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMachineType", reflect.TypeOf((*MockClusterServiceClientSpec)(nil).GetMachineType), ctx, machineTypeID)
+	return &MockClusterServiceClientSpecGetMachineTypeCall{Call: call}
+}
+
+// MockClusterServiceClientSpecGetMachineTypeCall wrap *gomock.Call
+type MockClusterServiceClientSpecGetMachineTypeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClusterServiceClientSpecGetMachineTypeCall) Return(arg0 *v1.MachineType, arg1 error) *MockClusterServiceClientSpecGetMachineTypeCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClusterServiceClientSpecGetMachineTypeCall) Do(f func(context.Context, string) (*v1.MachineType, error)) *MockClusterServiceClientSpecGetMachineTypeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClusterServiceClientSpecGetMachineTypeCall) DoAndReturn(f func(context.Context, string) (*v1.MachineType, error)) *MockClusterServiceClientSpecGetMachineTypeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // GetNodePool mocks base method.
 func (m *MockClusterServiceClientSpec) GetNodePool(ctx context.Context, internalID ocm.InternalID) (*v1.NodePool, error) {
 	m.ctrl.T.Helper()
@@ -352,6 +506,84 @@ func (c *MockClusterServiceClientSpecGetNodePoolCall) DoAndReturn(f func(context
 	return c
 }
 
+// GetNodePoolUpgradePolicy mocks base method.
+func (m *MockClusterServiceClientSpec) GetNodePoolUpgradePolicy(ctx context.Context, internalID ocm.InternalID) (*v1.NodePoolUpgradePolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNodePoolUpgradePolicy", ctx, internalID)
+	ret0, _ := ret[0].(*v1.NodePoolUpgradePolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNodePoolUpgradePolicy indicates an expected call of GetNodePoolUpgradePolicy.
+func (mr *MockClusterServiceClientSpecMockRecorder) GetNodePoolUpgradePolicy(ctx, internalID any) *MockClusterServiceClientSpecGetNodePoolUpgradePolicyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNodePoolUpgradePolicy", reflect.TypeOf((*MockClusterServiceClientSpec)(nil).GetNodePoolUpgradePolicy), ctx, internalID)
+	return &MockClusterServiceClientSpecGetNodePoolUpgradePolicyCall{Call: call}
+}
+
+// MockClusterServiceClientSpecGetNodePoolUpgradePolicyCall wrap *gomock.Call
+type MockClusterServiceClientSpecGetNodePoolUpgradePolicyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClusterServiceClientSpecGetNodePoolUpgradePolicyCall) Return(arg0 *v1.NodePoolUpgradePolicy, arg1 error) *MockClusterServiceClientSpecGetNodePoolUpgradePolicyCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClusterServiceClientSpecGetNodePoolUpgradePolicyCall) Do(f func(context.Context, ocm.InternalID) (*v1.NodePoolUpgradePolicy, error)) *MockClusterServiceClientSpecGetNodePoolUpgradePolicyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClusterServiceClientSpecGetNodePoolUpgradePolicyCall) DoAndReturn(f func(context.Context, ocm.InternalID) (*v1.NodePoolUpgradePolicy, error)) *MockClusterServiceClientSpecGetNodePoolUpgradePolicyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// GetVersion mocks base method.
+func (m *MockClusterServiceClientSpec) GetVersion(ctx context.Context, versionID string) (*v1.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", ctx, versionID)
+	ret0, _ := ret[0].(*v1.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockClusterServiceClientSpecMockRecorder) GetVersion(ctx, versionID any) *MockClusterServiceClientSpecGetVersionCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockClusterServiceClientSpec)(nil).GetVersion), ctx, versionID)
+	return &MockClusterServiceClientSpecGetVersionCall{Call: call}
+}
+
+// MockClusterServiceClientSpecGetVersionCall wrap *gomock.Call
+type MockClusterServiceClientSpecGetVersionCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClusterServiceClientSpecGetVersionCall) Return(arg0 *v1.Version, arg1 error) *MockClusterServiceClientSpecGetVersionCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClusterServiceClientSpecGetVersionCall) Do(f func(context.Context, string) (*v1.Version, error)) *MockClusterServiceClientSpecGetVersionCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClusterServiceClientSpecGetVersionCall) DoAndReturn(f func(context.Context, string) (*v1.Version, error)) *MockClusterServiceClientSpecGetVersionCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // ListBreakGlassCredentials mocks base method.
 func (m *MockClusterServiceClientSpec) ListBreakGlassCredentials(clusterInternalID ocm.InternalID, searchExpression string) ocm.BreakGlassCredentialListIterator {
 	m.ctrl.T.Helper()
@@ -466,6 +698,44 @@ func (c *MockClusterServiceClientSpecListNodePoolsCall) DoAndReturn(f func(ocm.I
 	return c
 }
 
+// ListVersions mocks base method.
+func (m *MockClusterServiceClientSpec) ListVersions(searchExpression string) ocm.VersionListIterator {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVersions", searchExpression)
+	ret0, _ := ret[0].(ocm.VersionListIterator)
+	return ret0
+}
+
+// ListVersions indicates an expected call of ListVersions.
+func (mr *MockClusterServiceClientSpecMockRecorder) ListVersions(searchExpression any) *MockClusterServiceClientSpecListVersionsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVersions", reflect.TypeOf((*MockClusterServiceClientSpec)(nil).ListVersions), searchExpression)
+	return &MockClusterServiceClientSpecListVersionsCall{Call: call}
+}
+
+// MockClusterServiceClientSpecListVersionsCall wrap *gomock.Call
+type MockClusterServiceClientSpecListVersionsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClusterServiceClientSpecListVersionsCall) Return(arg0 ocm.VersionListIterator) *MockClusterServiceClientSpecListVersionsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClusterServiceClientSpecListVersionsCall) Do(f func(string) ocm.VersionListIterator) *MockClusterServiceClientSpecListVersionsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClusterServiceClientSpecListVersionsCall) DoAndReturn(f func(string) ocm.VersionListIterator) *MockClusterServiceClientSpecListVersionsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // PostBreakGlassCredential mocks base method.
 func (m *MockClusterServiceClientSpec) PostBreakGlassCredential(ctx context.Context, clusterInternalID ocm.InternalID) (*v1.BreakGlassCredential, error) {
 	m.ctrl.T.Helper()
@@ -544,6 +814,45 @@ func (c *MockClusterServiceClientSpecPostClusterCall) DoAndReturn(f func(context
 	return c
 }
 
+// PostControlPlaneUpgradePolicy mocks base method.
+func (m *MockClusterServiceClientSpec) PostControlPlaneUpgradePolicy(ctx context.Context, clusterInternalID ocm.InternalID, upgradePolicy *v1.ControlPlaneUpgradePolicy) (*v1.ControlPlaneUpgradePolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostControlPlaneUpgradePolicy", ctx, clusterInternalID, upgradePolicy)
+	ret0, _ := ret[0].(*v1.ControlPlaneUpgradePolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostControlPlaneUpgradePolicy indicates an expected call of PostControlPlaneUpgradePolicy.
+func (mr *MockClusterServiceClientSpecMockRecorder) PostControlPlaneUpgradePolicy(ctx, clusterInternalID, upgradePolicy any) *MockClusterServiceClientSpecPostControlPlaneUpgradePolicyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostControlPlaneUpgradePolicy", reflect.TypeOf((*MockClusterServiceClientSpec)(nil).PostControlPlaneUpgradePolicy), ctx, clusterInternalID, upgradePolicy)
+	return &MockClusterServiceClientSpecPostControlPlaneUpgradePolicyCall{Call: call}
+}
+
+// MockClusterServiceClientSpecPostControlPlaneUpgradePolicyCall wrap *gomock.Call
+type MockClusterServiceClientSpecPostControlPlaneUpgradePolicyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClusterServiceClientSpecPostControlPlaneUpgradePolicyCall) Return(arg0 *v1.ControlPlaneUpgradePolicy, arg1 error) *MockClusterServiceClientSpecPostControlPlaneUpgradePolicyCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClusterServiceClientSpecPostControlPlaneUpgradePolicyCall) Do(f func(context.Context, ocm.InternalID, *v1.ControlPlaneUpgradePolicy) (*v1.ControlPlaneUpgradePolicy, error)) *MockClusterServiceClientSpecPostControlPlaneUpgradePolicyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClusterServiceClientSpecPostControlPlaneUpgradePolicyCall) DoAndReturn(f func(context.Context, ocm.InternalID, *v1.ControlPlaneUpgradePolicy) (*v1.ControlPlaneUpgradePolicy, error)) *MockClusterServiceClientSpecPostControlPlaneUpgradePolicyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // PostNodePool mocks base method.
 func (m *MockClusterServiceClientSpec) PostNodePool(ctx context.Context, clusterInternalID ocm.InternalID, nodePool *v1.NodePool) (*v1.NodePool, error) {
 	m.ctrl.T.Helper()
@@ -583,6 +892,45 @@ func (c *MockClusterServiceClientSpecPostNodePoolCall) DoAndReturn(f func(contex
 	return c
 }
 
+// PostNodePoolUpgradePolicy mocks base method.
+func (m *MockClusterServiceClientSpec) PostNodePoolUpgradePolicy(ctx context.Context, nodePoolInternalID ocm.InternalID, upgradePolicy *v1.NodePoolUpgradePolicy) (*v1.NodePoolUpgradePolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostNodePoolUpgradePolicy", ctx, nodePoolInternalID, upgradePolicy)
+	ret0, _ := ret[0].(*v1.NodePoolUpgradePolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostNodePoolUpgradePolicy indicates an expected call of PostNodePoolUpgradePolicy.
+func (mr *MockClusterServiceClientSpecMockRecorder) PostNodePoolUpgradePolicy(ctx, nodePoolInternalID, upgradePolicy any) *MockClusterServiceClientSpecPostNodePoolUpgradePolicyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostNodePoolUpgradePolicy", reflect.TypeOf((*MockClusterServiceClientSpec)(nil).PostNodePoolUpgradePolicy), ctx, nodePoolInternalID, upgradePolicy)
+	return &MockClusterServiceClientSpecPostNodePoolUpgradePolicyCall{Call: call}
+}
+
+// MockClusterServiceClientSpecPostNodePoolUpgradePolicyCall wrap *gomock.Call
+type MockClusterServiceClientSpecPostNodePoolUpgradePolicyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClusterServiceClientSpecPostNodePoolUpgradePolicyCall) Return(arg0 *v1.NodePoolUpgradePolicy, arg1 error) *MockClusterServiceClientSpecPostNodePoolUpgradePolicyCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClusterServiceClientSpecPostNodePoolUpgradePolicyCall) Do(f func(context.Context, ocm.InternalID, *v1.NodePoolUpgradePolicy) (*v1.NodePoolUpgradePolicy, error)) *MockClusterServiceClientSpecPostNodePoolUpgradePolicyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClusterServiceClientSpecPostNodePoolUpgradePolicyCall) DoAndReturn(f func(context.Context, ocm.InternalID, *v1.NodePoolUpgradePolicy) (*v1.NodePoolUpgradePolicy, error)) *MockClusterServiceClientSpecPostNodePoolUpgradePolicyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // UpdateCluster mocks base method.
 func (m *MockClusterServiceClientSpec) UpdateCluster(ctx context.Context, internalID ocm.InternalID, cluster *v1alpha1.Cluster) (*v1alpha1.Cluster, error) {
 	m.ctrl.T.Helper()
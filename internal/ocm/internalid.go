@@ -14,10 +14,12 @@ import (
 )
 
 const (
-	v1Pattern                     = "/api/clusters_mgmt/v1"
-	v1ClusterPattern              = v1Pattern + "/clusters/*"
-	v1NodePoolPattern             = v1ClusterPattern + "/node_pools/*"
-	v1BreakGlassCredentialPattern = v1ClusterPattern + "/break_glass_credentials/*"
+	v1Pattern                          = "/api/clusters_mgmt/v1"
+	v1ClusterPattern                   = v1Pattern + "/clusters/*"
+	v1ControlPlaneUpgradePolicyPattern = v1ClusterPattern + "/control_plane/upgrade_policies/*"
+	v1NodePoolPattern                  = v1ClusterPattern + "/node_pools/*"
+	v1NodePoolUpgradePolicyPattern     = v1NodePoolPattern + "/upgrade_policies/*"
+	v1BreakGlassCredentialPattern      = v1ClusterPattern + "/break_glass_credentials/*"
 
 	aroHcpV1Alpha1Pattern        = "/api/aro_hcp/v1alpha1"
 	aroHcpV1Alpha1ClusterPattern = aroHcpV1Alpha1Pattern + "/clusters/*"
@@ -51,11 +53,21 @@ func (id *InternalID) validate() error {
 		return nil
 	}
 
+	if match, _ = path.Match(v1ControlPlaneUpgradePolicyPattern, id.path); match {
+		id.kind = cmv1.ControlPlaneUpgradePolicyKind
+		return nil
+	}
+
 	if match, _ = path.Match(v1NodePoolPattern, id.path); match {
 		id.kind = cmv1.NodePoolKind
 		return nil
 	}
 
+	if match, _ = path.Match(v1NodePoolUpgradePolicyPattern, id.path); match {
+		id.kind = cmv1.NodePoolUpgradePolicyKind
+		return nil
+	}
+
 	if match, _ = path.Match(v1BreakGlassCredentialPattern, id.path); match {
 		id.kind = cmv1.BreakGlassCredentialKind
 		return nil
@@ -161,6 +173,16 @@ func matchClusterPath(clusterPath string) string {
 	return ""
 }
 
+// GetControlPlaneUpgradePolicyClient returns a v1 ControlPlaneUpgradePolicyClient
+// from the InternalID. The transport is most likely to be a Connection object
+// from the SDK.
+func (id *InternalID) GetControlPlaneUpgradePolicyClient(transport http.RoundTripper) (*cmv1.ControlPlaneUpgradePolicyClient, bool) {
+	if id.Kind() != cmv1.ControlPlaneUpgradePolicyKind {
+		return nil, false
+	}
+	return cmv1.NewControlPlaneUpgradePolicyClient(transport, id.path), true
+}
+
 // GetNodePoolClient returns a v1 NodePoolClient from the InternalID.
 // The transport is most likely to be a Connection object from the SDK.
 func (id *InternalID) GetNodePoolClient(transport http.RoundTripper) (*cmv1.NodePoolClient, bool) {
@@ -170,6 +192,16 @@ func (id *InternalID) GetNodePoolClient(transport http.RoundTripper) (*cmv1.Node
 	return cmv1.NewNodePoolClient(transport, id.path), true
 }
 
+// GetNodePoolUpgradePolicyClient returns a v1 NodePoolUpgradePolicyClient
+// from the InternalID. The transport is most likely to be a Connection
+// object from the SDK.
+func (id *InternalID) GetNodePoolUpgradePolicyClient(transport http.RoundTripper) (*cmv1.NodePoolUpgradePolicyClient, bool) {
+	if id.Kind() != cmv1.NodePoolUpgradePolicyKind {
+		return nil, false
+	}
+	return cmv1.NewNodePoolUpgradePolicyClient(transport, id.path), true
+}
+
 // GetBreakGlassCredentialClient returns a v1 BreakGlassCredentialClient
 // from the InternalID. The transport is most likely to be a Connection
 // object from the SDK.
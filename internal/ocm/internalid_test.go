@@ -53,6 +53,20 @@ func TestInternalID(t *testing.T) {
 			kind:      cmv1.NodePoolKind,
 			expectErr: false,
 		},
+		{
+			name:      "parse v1 node pool upgrade policy",
+			path:      "/api/clusters_mgmt/v1/clusters/abc/node_pools/def/upgrade_policies/ghi",
+			id:        "ghi",
+			kind:      cmv1.NodePoolUpgradePolicyKind,
+			expectErr: false,
+		},
+		{
+			name:      "parse v1 control plane upgrade policy",
+			path:      "/api/clusters_mgmt/v1/clusters/abc/control_plane/upgrade_policies/def",
+			id:        "def",
+			kind:      cmv1.ControlPlaneUpgradePolicyKind,
+			expectErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -96,6 +110,12 @@ func TestInternalID(t *testing.T) {
 				}
 			}
 
+			if kind == cmv1.NodePoolUpgradePolicyKind {
+				if _, ok := internalID.GetNodePoolUpgradePolicyClient(transport); !ok {
+					t.Errorf("failed to get node pool upgrade policy client")
+				}
+			}
+
 			bytes, err := json.Marshal(internalID)
 			if err != nil {
 				t.Error(err)
@@ -19,7 +19,7 @@ type ClusterListIterator struct {
 
 // Items returns a push iterator that can be used directly in for/range loops.
 // If an error occurs during paging, iteration stops and the error is recorded.
-func (iter ClusterListIterator) Items(ctx context.Context) iter.Seq[*arohcpv1alpha1.Cluster] {
+func (iter *ClusterListIterator) Items(ctx context.Context) iter.Seq[*arohcpv1alpha1.Cluster] {
 	return func(yield func(*arohcpv1alpha1.Cluster) bool) {
 		// Request can be nil to allow for mocking.
 		if iter.request != nil {
@@ -62,7 +62,7 @@ func (iter ClusterListIterator) Items(ctx context.Context) iter.Seq[*arohcpv1alp
 
 // GetError returns any error that occurred during iteration. Call this after the
 // for/range loop that calls Items() to check if iteration completed successfully.
-func (iter ClusterListIterator) GetError() error {
+func (iter *ClusterListIterator) GetError() error {
 	return iter.err
 }
 
@@ -73,7 +73,7 @@ type NodePoolListIterator struct {
 
 // Items returns a push iterator that can be used directly in for/range loops.
 // If an error occurs during paging, iteration stops and the error is recorded.
-func (iter NodePoolListIterator) Items(ctx context.Context) iter.Seq[*cmv1.NodePool] {
+func (iter *NodePoolListIterator) Items(ctx context.Context) iter.Seq[*cmv1.NodePool] {
 	return func(yield func(*cmv1.NodePool) bool) {
 		// Request can be nil to allow for mocking.
 		if iter.request != nil {
@@ -116,7 +116,7 @@ func (iter NodePoolListIterator) Items(ctx context.Context) iter.Seq[*cmv1.NodeP
 
 // GetError returns any error that occurred during iteration. Call this after the
 // for/range loop that calls Items() to check if iteration completed successfully.
-func (iter NodePoolListIterator) GetError() error {
+func (iter *NodePoolListIterator) GetError() error {
 	return iter.err
 }
 
@@ -127,7 +127,7 @@ type BreakGlassCredentialListIterator struct {
 
 // Items returns a push iterator that can be used directly in for/range loops.
 // If an error occurs during paging, iteration stops and the error is recorded.
-func (iter BreakGlassCredentialListIterator) Items(ctx context.Context) iter.Seq[*cmv1.BreakGlassCredential] {
+func (iter *BreakGlassCredentialListIterator) Items(ctx context.Context) iter.Seq[*cmv1.BreakGlassCredential] {
 	return func(yield func(*cmv1.BreakGlassCredential) bool) {
 		// Request can be nil to allow for mocking.
 		if iter.request != nil {
@@ -170,6 +170,60 @@ func (iter BreakGlassCredentialListIterator) Items(ctx context.Context) iter.Seq
 
 // GetError returns any error that occurred during iteration. Call this after the
 // for/range loop that calls Items() to check if iteration completed successfully.
-func (iter BreakGlassCredentialListIterator) GetError() error {
+func (iter *BreakGlassCredentialListIterator) GetError() error {
+	return iter.err
+}
+
+type VersionListIterator struct {
+	request *cmv1.VersionsListRequest
+	err     error
+}
+
+// Items returns a push iterator that can be used directly in for/range loops.
+// If an error occurs during paging, iteration stops and the error is recorded.
+func (iter *VersionListIterator) Items(ctx context.Context) iter.Seq[*cmv1.Version] {
+	return func(yield func(*cmv1.Version) bool) {
+		// Request can be nil to allow for mocking.
+		if iter.request != nil {
+			var page int = 0
+			var count int = 0
+			var total int = math.MaxInt
+
+			for count < total {
+				page++
+				result, err := iter.request.Page(page).SendContext(ctx)
+				if err != nil {
+					iter.err = err
+					return
+				}
+
+				total = result.Total()
+				items := result.Items()
+
+				// Safety check to prevent an infinite loop in case
+				// the result is somehow empty before count = total.
+				if items == nil || items.Empty() {
+					return
+				}
+
+				count += items.Len()
+
+				// XXX VersionList.Each() lacks a boolean return to
+				//     indicate whether iteration fully completed.
+				//     VersionList.Slice() may be less efficient but
+				//     is easier to work with.
+				for _, item := range items.Slice() {
+					if !yield(item) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// GetError returns any error that occurred during iteration. Call this after the
+// for/range loop that calls Items() to check if iteration completed successfully.
+func (iter *VersionListIterator) GetError() error {
 	return iter.err
 }
@@ -0,0 +1,81 @@
+package ocm
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
+
+	"github.com/Azure/ARO-HCP/internal/ocm/ocmtest"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestListIteratorGetError(t *testing.T) {
+	ctx := context.Background()
+
+	server := ocmtest.NewServer(nil, ocmtest.Timing{})
+	t.Cleanup(server.Close)
+
+	// List one item per page and fail the request for the second page,
+	// so paging fails after the first item has been yielded.
+	conn, err := server.NewConnection(func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Method != http.MethodGet {
+				return next.RoundTrip(r)
+			}
+			query := r.URL.Query()
+			if query.Get("page") == "2" {
+				return &http.Response{
+					StatusCode: http.StatusBadRequest,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"kind": "Error", "reason": "page unavailable"}`)),
+					Request:    r,
+				}, nil
+			}
+			query.Set("size", "1")
+			r.URL.RawQuery = query.Encode()
+			return next.RoundTrip(r)
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	csc := &ClusterServiceClient{Conn: conn}
+
+	for _, name := range []string{"test-cluster-1", "test-cluster-2"} {
+		cluster, err := arohcpv1alpha1.NewCluster().Name(name).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = csc.PostCluster(ctx, cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	iterator := csc.ListClusters("")
+
+	var count int
+	for range iterator.Items(ctx) {
+		count++
+	}
+
+	if count != 1 {
+		t.Errorf("Expected 1 cluster before paging failed but got %d", count)
+	}
+	if iterator.GetError() == nil {
+		t.Error("Expected GetError to report the failed page request")
+	}
+}
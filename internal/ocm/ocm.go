@@ -6,12 +6,18 @@ package ocm
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"regexp"
 
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
 )
 
+// machineTypeIDRegex matches Azure VM size names such as "Standard_D8s_v3".
+var machineTypeIDRegex = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
 type ClusterServiceClientSpec interface {
 	// AddProperties injects the some additional properties into the ClusterBuilder.
 	AddProperties(builder *arohcpv1alpha1.ClusterBuilder) *arohcpv1alpha1.ClusterBuilder
@@ -36,6 +42,15 @@ type ClusterServiceClientSpec interface {
 	// then call GetError() to check for an iteration error.
 	ListClusters(searchExpression string) ClusterListIterator
 
+	// GetControlPlaneUpgradePolicy sends a GET request to fetch a control plane upgrade policy from Cluster Service.
+	GetControlPlaneUpgradePolicy(ctx context.Context, internalID InternalID) (*cmv1.ControlPlaneUpgradePolicy, error)
+
+	// PostControlPlaneUpgradePolicy sends a POST request to create a control plane upgrade policy in Cluster Service.
+	PostControlPlaneUpgradePolicy(ctx context.Context, clusterInternalID InternalID, upgradePolicy *cmv1.ControlPlaneUpgradePolicy) (*cmv1.ControlPlaneUpgradePolicy, error)
+
+	// DeleteControlPlaneUpgradePolicy sends a DELETE request to cancel a control plane upgrade policy in Cluster Service.
+	DeleteControlPlaneUpgradePolicy(ctx context.Context, internalID InternalID) error
+
 	// GetNodePool sends a GET request to fetch a node pool from Cluster Service.
 	GetNodePool(ctx context.Context, internalID InternalID) (*cmv1.NodePool, error)
 
@@ -53,6 +68,15 @@ type ClusterServiceClientSpec interface {
 	// then call GetError() to check for an iteration error.
 	ListNodePools(clusterInternalID InternalID, searchExpression string) NodePoolListIterator
 
+	// GetNodePoolUpgradePolicy sends a GET request to fetch a node pool upgrade policy from Cluster Service.
+	GetNodePoolUpgradePolicy(ctx context.Context, internalID InternalID) (*cmv1.NodePoolUpgradePolicy, error)
+
+	// PostNodePoolUpgradePolicy sends a POST request to create a node pool upgrade policy in Cluster Service.
+	PostNodePoolUpgradePolicy(ctx context.Context, nodePoolInternalID InternalID, upgradePolicy *cmv1.NodePoolUpgradePolicy) (*cmv1.NodePoolUpgradePolicy, error)
+
+	// DeleteNodePoolUpgradePolicy sends a DELETE request to cancel a node pool upgrade policy in Cluster Service.
+	DeleteNodePoolUpgradePolicy(ctx context.Context, internalID InternalID) error
+
 	// GetBreakGlassCredential sends a GET request to fetch a break-glass cluster credential from Cluster Service.
 	GetBreakGlassCredential(ctx context.Context, internalID InternalID) (*cmv1.BreakGlassCredential, error)
 
@@ -66,6 +90,19 @@ type ClusterServiceClientSpec interface {
 	// Items() on the returned iterator in a for/range loop to execute the request and paginate
 	// over results, then call GetError() to check for an iteration error.
 	ListBreakGlassCredentials(clusterInternalID InternalID, searchExpression string) BreakGlassCredentialListIterator
+
+	// GetVersion sends a GET request to fetch an OpenShift version from Cluster Service.
+	GetVersion(ctx context.Context, versionID string) (*cmv1.Version, error)
+
+	// ListVersions prepares a GET request with the given search expression. Call Items() on
+	// the returned iterator in a for/range loop to execute the request and paginate over results,
+	// then call GetError() to check for an iteration error.
+	ListVersions(searchExpression string) VersionListIterator
+
+	// GetMachineType sends a GET request to fetch a machine type from Cluster Service.
+	// If Cluster Service does not offer the machine type, or the ID is not a valid machine
+	// type name, the error has a 404 status.
+	GetMachineType(ctx context.Context, machineTypeID string) (*cmv1.MachineType, error)
 }
 
 type ClusterServiceClient struct {
@@ -176,6 +213,47 @@ func (csc *ClusterServiceClient) ListClusters(searchExpression string) ClusterLi
 	return ClusterListIterator{request: clustersListRequest}
 }
 
+func (csc *ClusterServiceClient) GetControlPlaneUpgradePolicy(ctx context.Context, internalID InternalID) (*cmv1.ControlPlaneUpgradePolicy, error) {
+	client, ok := internalID.GetControlPlaneUpgradePolicyClient(csc.Conn)
+	if !ok {
+		return nil, fmt.Errorf("OCM path is not a control plane upgrade policy: %s", internalID)
+	}
+	upgradePolicyGetResponse, err := client.Get().SendContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	upgradePolicy, ok := upgradePolicyGetResponse.GetBody()
+	if !ok {
+		return nil, fmt.Errorf("empty response body")
+	}
+	return upgradePolicy, nil
+}
+
+func (csc *ClusterServiceClient) PostControlPlaneUpgradePolicy(ctx context.Context, clusterInternalID InternalID, upgradePolicy *cmv1.ControlPlaneUpgradePolicy) (*cmv1.ControlPlaneUpgradePolicy, error) {
+	client, ok := clusterInternalID.GetClusterClient(csc.Conn)
+	if !ok {
+		return nil, fmt.Errorf("OCM path is not a cluster: %s", clusterInternalID)
+	}
+	upgradePoliciesAddResponse, err := client.ControlPlane().UpgradePolicies().Add().Body(upgradePolicy).SendContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	upgradePolicy, ok = upgradePoliciesAddResponse.GetBody()
+	if !ok {
+		return nil, fmt.Errorf("empty response body")
+	}
+	return upgradePolicy, nil
+}
+
+func (csc *ClusterServiceClient) DeleteControlPlaneUpgradePolicy(ctx context.Context, internalID InternalID) error {
+	client, ok := internalID.GetControlPlaneUpgradePolicyClient(csc.Conn)
+	if !ok {
+		return fmt.Errorf("OCM path is not a control plane upgrade policy: %s", internalID)
+	}
+	_, err := client.Delete().SendContext(ctx)
+	return err
+}
+
 func (csc *ClusterServiceClient) GetNodePool(ctx context.Context, internalID InternalID) (*cmv1.NodePool, error) {
 	client, ok := internalID.GetNodePoolClient(csc.Conn)
 	if !ok {
@@ -245,6 +323,47 @@ func (csc *ClusterServiceClient) ListNodePools(clusterInternalID InternalID, sea
 	return NodePoolListIterator{request: nodePoolsListRequest}
 }
 
+func (csc *ClusterServiceClient) GetNodePoolUpgradePolicy(ctx context.Context, internalID InternalID) (*cmv1.NodePoolUpgradePolicy, error) {
+	client, ok := internalID.GetNodePoolUpgradePolicyClient(csc.Conn)
+	if !ok {
+		return nil, fmt.Errorf("OCM path is not a node pool upgrade policy: %s", internalID)
+	}
+	upgradePolicyGetResponse, err := client.Get().SendContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	upgradePolicy, ok := upgradePolicyGetResponse.GetBody()
+	if !ok {
+		return nil, fmt.Errorf("empty response body")
+	}
+	return upgradePolicy, nil
+}
+
+func (csc *ClusterServiceClient) PostNodePoolUpgradePolicy(ctx context.Context, nodePoolInternalID InternalID, upgradePolicy *cmv1.NodePoolUpgradePolicy) (*cmv1.NodePoolUpgradePolicy, error) {
+	client, ok := nodePoolInternalID.GetNodePoolClient(csc.Conn)
+	if !ok {
+		return nil, fmt.Errorf("OCM path is not a node pool: %s", nodePoolInternalID)
+	}
+	upgradePoliciesAddResponse, err := client.UpgradePolicies().Add().Body(upgradePolicy).SendContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	upgradePolicy, ok = upgradePoliciesAddResponse.GetBody()
+	if !ok {
+		return nil, fmt.Errorf("empty response body")
+	}
+	return upgradePolicy, nil
+}
+
+func (csc *ClusterServiceClient) DeleteNodePoolUpgradePolicy(ctx context.Context, internalID InternalID) error {
+	client, ok := internalID.GetNodePoolUpgradePolicyClient(csc.Conn)
+	if !ok {
+		return fmt.Errorf("OCM path is not a node pool upgrade policy: %s", internalID)
+	}
+	_, err := client.Delete().SendContext(ctx)
+	return err
+}
+
 func (csc *ClusterServiceClient) GetBreakGlassCredential(ctx context.Context, internalID InternalID) (*cmv1.BreakGlassCredential, error) {
 	client, ok := internalID.GetBreakGlassCredentialClient(csc.Conn)
 	if !ok {
@@ -297,3 +416,56 @@ func (csc *ClusterServiceClient) ListBreakGlassCredentials(clusterInternalID Int
 	}
 	return BreakGlassCredentialListIterator{request: breakGlassCredentialsListRequest}
 }
+
+func (csc *ClusterServiceClient) GetVersion(ctx context.Context, versionID string) (*cmv1.Version, error) {
+	versionGetResponse, err := csc.Conn.ClustersMgmt().V1().Versions().Version(versionID).Get().SendContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	version, ok := versionGetResponse.GetBody()
+	if !ok {
+		return nil, fmt.Errorf("empty response body")
+	}
+	return version, nil
+}
+
+func (csc *ClusterServiceClient) ListVersions(searchExpression string) VersionListIterator {
+	versionsListRequest := csc.Conn.ClustersMgmt().V1().Versions().List()
+	if searchExpression != "" {
+		versionsListRequest.Search(searchExpression)
+	}
+	return VersionListIterator{request: versionsListRequest}
+}
+
+func (csc *ClusterServiceClient) GetMachineType(ctx context.Context, machineTypeID string) (*cmv1.MachineType, error) {
+	notFound := func() error {
+		notFoundError, err := ocmerrors.NewError().
+			Status(http.StatusNotFound).
+			Reason(fmt.Sprintf("Machine type '%s' not found", machineTypeID)).
+			Build()
+		if err != nil {
+			return err
+		}
+		return notFoundError
+	}
+
+	// The machine type ID is pasted into a search expression,
+	// so refuse anything that is not a plain machine type name.
+	if !machineTypeIDRegex.MatchString(machineTypeID) {
+		return nil, notFound()
+	}
+
+	// Machine types can only be listed, so search for the one we want.
+	machineTypesListResponse, err := csc.Conn.ClustersMgmt().V1().MachineTypes().List().
+		Search(fmt.Sprintf("id = '%s'", machineTypeID)).
+		Size(1).
+		SendContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := machineTypesListResponse.Items()
+	if items == nil || items.Empty() {
+		return nil, notFound()
+	}
+	return items.Slice()[0], nil
+}
@@ -0,0 +1,554 @@
+package ocm
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+
+	"github.com/Azure/ARO-HCP/internal/ocm/ocmtest"
+)
+
+var testTiming = ocmtest.Timing{
+	ClusterInstall:       10 * time.Minute,
+	ClusterUninstall:     5 * time.Minute,
+	ControlPlaneUpgrade:  15 * time.Minute,
+	NodePoolTransition:   5 * time.Minute,
+	CredentialTransition: 10 * time.Second,
+}
+
+func newTestClusterServiceClient(t *testing.T) (*ClusterServiceClient, *ocmtest.FakeClock) {
+	t.Helper()
+
+	clock := ocmtest.NewFakeClock(time.Now())
+	server := ocmtest.NewServer(clock, testTiming)
+	t.Cleanup(server.Close)
+
+	conn, err := server.NewConnection(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return &ClusterServiceClient{Conn: conn}, clock
+}
+
+func newTestCluster(t *testing.T, csc *ClusterServiceClient) InternalID {
+	t.Helper()
+
+	cluster, err := arohcpv1alpha1.NewCluster().Name("test-cluster").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cluster, err = csc.PostCluster(context.Background(), cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internalID, err := NewInternalID(cluster.HREF())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return internalID
+}
+
+func expectNotFound(t *testing.T, err error) {
+	t.Helper()
+
+	var ocmError *ocmerrors.Error
+	if !errors.As(err, &ocmError) || ocmError.Status() != http.StatusNotFound {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestClusterServiceClientCluster(t *testing.T) {
+	ctx := context.Background()
+	csc, clock := newTestClusterServiceClient(t)
+
+	internalID := newTestCluster(t, csc)
+
+	expectClusterState := func(expected arohcpv1alpha1.ClusterState) {
+		t.Helper()
+
+		status, err := csc.GetClusterStatus(ctx, internalID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.State() != expected {
+			t.Errorf("expected cluster state '%s', got '%s'", expected, status.State())
+		}
+	}
+
+	expectClusterState(arohcpv1alpha1.ClusterStateInstalling)
+	clock.Step(testTiming.ClusterInstall)
+	expectClusterState(arohcpv1alpha1.ClusterStateReady)
+
+	update, err := arohcpv1alpha1.NewCluster().DisableUserWorkloadMonitoring(true).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster, err := csc.UpdateCluster(ctx, internalID, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Name() != "test-cluster" || !cluster.DisableUserWorkloadMonitoring() {
+		t.Errorf("unexpected cluster after update: name=%q disableUserWorkloadMonitoring=%t",
+			cluster.Name(), cluster.DisableUserWorkloadMonitoring())
+	}
+
+	count := 0
+	iterator := csc.ListClusters(fmt.Sprintf("id in ('%s')", internalID.ID()))
+	for range iterator.Items(ctx) {
+		count++
+	}
+	if err = iterator.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 cluster, got %d", count)
+	}
+
+	err = csc.DeleteCluster(ctx, internalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectClusterState(arohcpv1alpha1.ClusterStateUninstalling)
+	clock.Step(testTiming.ClusterUninstall)
+
+	_, err = csc.GetCluster(ctx, internalID)
+	expectNotFound(t, err)
+}
+
+func TestClusterServiceClientControlPlaneUpgradePolicy(t *testing.T) {
+	ctx := context.Background()
+	csc, clock := newTestClusterServiceClient(t)
+
+	cluster, err := arohcpv1alpha1.NewCluster().
+		Name("test-cluster").
+		Version(cmv1.NewVersion().
+			ID("openshift-v4.16.0").
+			ChannelGroup("stable").
+			AvailableUpgrades("4.16.1", "4.17.0")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cluster, err = csc.PostCluster(ctx, cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusterInternalID, err := NewInternalID(cluster.HREF())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Step(testTiming.ClusterInstall)
+
+	upgradePolicy, err := cmv1.NewControlPlaneUpgradePolicy().
+		UpgradeType(cmv1.UpgradeTypeControlPlane).
+		ScheduleType(cmv1.ScheduleTypeManual).
+		Version("4.17.0").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upgradePolicy, err = csc.PostControlPlaneUpgradePolicy(ctx, clusterInternalID, upgradePolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internalID, err := NewInternalID(upgradePolicy.HREF())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if internalID.Kind() != cmv1.ControlPlaneUpgradePolicyKind {
+		t.Fatalf("expected kind '%s', got '%s'", cmv1.ControlPlaneUpgradePolicyKind, internalID.Kind())
+	}
+
+	// Only one upgrade may be in progress at a time.
+	_, err = csc.PostControlPlaneUpgradePolicy(ctx, clusterInternalID, upgradePolicy)
+	var ocmError *ocmerrors.Error
+	if !errors.As(err, &ocmError) || ocmError.Status() != http.StatusConflict {
+		t.Errorf("expected a conflict error, got %v", err)
+	}
+
+	expectState := func(expected cmv1.UpgradePolicyStateValue) {
+		t.Helper()
+
+		upgradePolicy, err := csc.GetControlPlaneUpgradePolicy(ctx, internalID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if upgradePolicy.State().Value() != expected {
+			t.Errorf("expected upgrade policy state '%s', got '%s'", expected, upgradePolicy.State().Value())
+		}
+	}
+
+	expectState(cmv1.UpgradePolicyStateValueStarted)
+	clock.Step(testTiming.ControlPlaneUpgrade)
+	expectState(cmv1.UpgradePolicyStateValueCompleted)
+
+	cluster, err = csc.GetCluster(ctx, clusterInternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Version().ID() != "openshift-v4.17.0" {
+		t.Errorf("expected cluster version 'openshift-v4.17.0', got '%s'", cluster.Version().ID())
+	}
+	if len(cluster.Version().AvailableUpgrades()) != 0 {
+		t.Errorf("unexpected available upgrades after upgrade: %v", cluster.Version().AvailableUpgrades())
+	}
+}
+
+func TestClusterServiceClientNodePool(t *testing.T) {
+	ctx := context.Background()
+	csc, clock := newTestClusterServiceClient(t)
+
+	clusterInternalID := newTestCluster(t, csc)
+
+	nodePool, err := cmv1.NewNodePool().ID("test-np").Replicas(2).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodePool, err = csc.PostNodePool(ctx, clusterInternalID, nodePool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internalID, err := NewInternalID(nodePool.HREF())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if internalID.Kind() != cmv1.NodePoolKind {
+		t.Fatalf("expected kind '%s', got '%s'", cmv1.NodePoolKind, internalID.Kind())
+	}
+
+	clock.Step(testTiming.NodePoolTransition)
+
+	update, err := cmv1.NewNodePool().Replicas(3).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = csc.UpdateNodePool(ctx, internalID, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodePool, err = csc.GetNodePool(ctx, internalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodePool.Replicas() != 3 {
+		t.Errorf("expected 3 replicas, got %d", nodePool.Replicas())
+	}
+
+	count := 0
+	iterator := csc.ListNodePools(clusterInternalID, "")
+	for range iterator.Items(ctx) {
+		count++
+	}
+	if err = iterator.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 node pool, got %d", count)
+	}
+
+	err = csc.DeleteNodePool(ctx, internalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Node pool remains visible while uninstalling.
+	_, err = csc.GetNodePool(ctx, internalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Step(testTiming.NodePoolTransition)
+
+	_, err = csc.GetNodePool(ctx, internalID)
+	expectNotFound(t, err)
+}
+
+func TestClusterServiceClientNodePoolUpgradePolicy(t *testing.T) {
+	ctx := context.Background()
+	csc, clock := newTestClusterServiceClient(t)
+
+	clusterInternalID := newTestCluster(t, csc)
+
+	nodePool, err := cmv1.NewNodePool().
+		ID("test-np").
+		Version(cmv1.NewVersion().
+			ID("openshift-v4.16.0").
+			ChannelGroup("stable").
+			AvailableUpgrades("4.16.1", "4.16.2")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodePool, err = csc.PostNodePool(ctx, clusterInternalID, nodePool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodePoolInternalID, err := NewInternalID(nodePool.HREF())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Step(testTiming.NodePoolTransition)
+
+	upgradePolicy, err := cmv1.NewNodePoolUpgradePolicy().
+		UpgradeType(cmv1.UpgradeTypeNodePool).
+		ScheduleType(cmv1.ScheduleTypeManual).
+		Version("4.16.1").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upgradePolicy, err = csc.PostNodePoolUpgradePolicy(ctx, nodePoolInternalID, upgradePolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internalID, err := NewInternalID(upgradePolicy.HREF())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if internalID.Kind() != cmv1.NodePoolUpgradePolicyKind {
+		t.Fatalf("expected kind '%s', got '%s'", cmv1.NodePoolUpgradePolicyKind, internalID.Kind())
+	}
+
+	expectState := func(expected cmv1.UpgradePolicyStateValue) {
+		t.Helper()
+
+		upgradePolicy, err := csc.GetNodePoolUpgradePolicy(ctx, internalID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if upgradePolicy.State().Value() != expected {
+			t.Errorf("expected upgrade policy state '%s', got '%s'", expected, upgradePolicy.State().Value())
+		}
+	}
+
+	expectState(cmv1.UpgradePolicyStateValueStarted)
+	clock.Step(testTiming.NodePoolTransition)
+	expectState(cmv1.UpgradePolicyStateValueCompleted)
+
+	nodePool, err = csc.GetNodePool(ctx, nodePoolInternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodePool.Version().ID() != "openshift-v4.16.1" {
+		t.Errorf("expected node pool version 'openshift-v4.16.1', got '%s'", nodePool.Version().ID())
+	}
+	if !slices.Equal(nodePool.Version().AvailableUpgrades(), []string{"4.16.2"}) {
+		t.Errorf("unexpected available upgrades after upgrade: %v", nodePool.Version().AvailableUpgrades())
+	}
+}
+
+func TestClusterServiceClientDeleteNodePoolUpgradePolicy(t *testing.T) {
+	ctx := context.Background()
+	csc, clock := newTestClusterServiceClient(t)
+
+	clusterInternalID := newTestCluster(t, csc)
+
+	nodePool, err := cmv1.NewNodePool().
+		ID("test-np").
+		Version(cmv1.NewVersion().
+			ID("openshift-v4.16.0").
+			AvailableUpgrades("4.16.1")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodePool, err = csc.PostNodePool(ctx, clusterInternalID, nodePool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodePoolInternalID, err := NewInternalID(nodePool.HREF())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upgradePolicy, err := cmv1.NewNodePoolUpgradePolicy().Version("4.16.1").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upgradePolicy, err = csc.PostNodePoolUpgradePolicy(ctx, nodePoolInternalID, upgradePolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internalID, err := NewInternalID(upgradePolicy.HREF())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = csc.DeleteNodePoolUpgradePolicy(ctx, internalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = csc.GetNodePoolUpgradePolicy(ctx, internalID)
+	expectNotFound(t, err)
+
+	// A canceled upgrade is never applied.
+	clock.Step(testTiming.NodePoolTransition)
+
+	nodePool, err = csc.GetNodePool(ctx, nodePoolInternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodePool.Version().ID() != "openshift-v4.16.0" {
+		t.Errorf("expected node pool version 'openshift-v4.16.0', got '%s'", nodePool.Version().ID())
+	}
+
+	err = csc.DeleteNodePoolUpgradePolicy(ctx, internalID)
+	expectNotFound(t, err)
+}
+
+func TestClusterServiceClientBreakGlassCredential(t *testing.T) {
+	ctx := context.Background()
+	csc, clock := newTestClusterServiceClient(t)
+
+	clusterInternalID := newTestCluster(t, csc)
+
+	credential, err := csc.PostBreakGlassCredential(ctx, clusterInternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internalID, err := NewInternalID(credential.HREF())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectStatus := func(expected string) *cmv1.BreakGlassCredential {
+		t.Helper()
+
+		credential, err := csc.GetBreakGlassCredential(ctx, internalID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(credential.Status()) != expected {
+			t.Errorf("expected credential status '%s', got '%s'", expected, credential.Status())
+		}
+		return credential
+	}
+
+	expectStatus("created")
+	clock.Step(testTiming.CredentialTransition)
+	if credential = expectStatus("issued"); credential.Kubeconfig() == "" {
+		t.Error("expected an issued credential to have a kubeconfig")
+	}
+
+	err = csc.DeleteBreakGlassCredentials(ctx, clusterInternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectStatus("awaiting_revocation")
+	clock.Step(testTiming.CredentialTransition)
+	expectStatus("revoked")
+}
+
+func TestClusterServiceClientVersion(t *testing.T) {
+	ctx := context.Background()
+	csc, _ := newTestClusterServiceClient(t)
+
+	version, err := csc.GetVersion(ctx, "openshift-v4.17.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.RawID() != "4.17.0" || !version.Enabled() {
+		t.Errorf("expected enabled version '4.17.0', got '%s' (enabled: %t)", version.RawID(), version.Enabled())
+	}
+
+	_, err = csc.GetVersion(ctx, "openshift-v4.0.0")
+	expectNotFound(t, err)
+}
+
+func TestClusterServiceClientListVersions(t *testing.T) {
+	ctx := context.Background()
+	csc, _ := newTestClusterServiceClient(t)
+
+	var ids []string
+	iterator := csc.ListVersions("")
+	for version := range iterator.Items(ctx) {
+		ids = append(ids, version.ID())
+	}
+	if err := iterator.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 4 {
+		t.Errorf("expected 4 versions, got %v", ids)
+	}
+
+	ids = nil
+	iterator = csc.ListVersions("id = 'openshift-v4.18.0-candidate'")
+	for version := range iterator.Items(ctx) {
+		ids = append(ids, version.ID())
+	}
+	if err := iterator.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != "openshift-v4.18.0-candidate" {
+		t.Errorf("expected version 'openshift-v4.18.0-candidate', got %v", ids)
+	}
+
+	ids = nil
+	iterator = csc.ListVersions("enabled = 'true' and hosted_control_plane_enabled = 'true'")
+	for version := range iterator.Items(ctx) {
+		ids = append(ids, version.ID())
+	}
+	if err := iterator.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(ids, "openshift-v4.15.0") || len(ids) != 3 {
+		t.Errorf("expected the 3 enabled versions, got %v", ids)
+	}
+}
+
+func TestClusterServiceClientMachineType(t *testing.T) {
+	ctx := context.Background()
+	csc, _ := newTestClusterServiceClient(t)
+
+	machineType, err := csc.GetMachineType(ctx, "Standard_D8s_v3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if machineType.ID() != "Standard_D8s_v3" {
+		t.Errorf("expected machine type 'Standard_D8s_v3', got '%s'", machineType.ID())
+	}
+
+	_, err = csc.GetMachineType(ctx, "Standard_Z1")
+	expectNotFound(t, err)
+
+	// A machine type ID must not be able to alter the search expression.
+	_, err = csc.GetMachineType(ctx, "Standard_Z1' or id = 'Standard_D8s_v3")
+	expectNotFound(t, err)
+}
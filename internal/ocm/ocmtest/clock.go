@@ -0,0 +1,54 @@
+package ocmtest
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time to a Server. Resource state transitions
+// are computed from the Clock whenever a resource is read, so a FakeClock
+// lets tests step through transitions without sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// RealClock returns a Clock that uses the system time.
+func RealClock() Clock {
+	return realClock{}
+}
+
+// FakeClock is a Clock that only advances when told to.
+type FakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+// NewFakeClock returns a FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the fake clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// Step advances the fake clock by the given duration.
+func (c *FakeClock) Step(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,1193 @@
+package ocmtest
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the Apache License 2.0.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+const (
+	aroHcpV1Alpha1Path = "/api/aro_hcp/v1alpha1"
+	v1Path             = "/api/clusters_mgmt/v1"
+
+	// Node pool states are not enumerated by ocm-sdk-go.
+	nodePoolStateInstalling   = "installing"
+	nodePoolStateReady        = "ready"
+	nodePoolStateUpdating     = "updating"
+	nodePoolStateUninstalling = "uninstalling"
+
+	breakGlassCredentialStatusCreated            = "created"
+	breakGlassCredentialStatusIssued             = "issued"
+	breakGlassCredentialStatusExpired            = "expired"
+	breakGlassCredentialStatusAwaitingRevocation = "awaiting_revocation"
+	breakGlassCredentialStatusRevoked            = "revoked"
+
+	defaultPageSize = 100
+)
+
+// Timing controls how long a Server takes to transition resources between
+// states. Zero values are replaced with defaults.
+type Timing struct {
+	// ClusterInstall is how long a new cluster stays "installing" before
+	// becoming "ready". Defaults to 10 minutes.
+	ClusterInstall time.Duration
+
+	// ClusterUninstall is how long a deleted cluster stays "uninstalling"
+	// before it disappears. Defaults to 5 minutes.
+	ClusterUninstall time.Duration
+
+	// ControlPlaneUpgrade is how long a control plane upgrade policy
+	// stays "started". Defaults to 15 minutes.
+	ControlPlaneUpgrade time.Duration
+
+	// NodePoolTransition is how long a node pool stays "installing",
+	// "updating" or "uninstalling", and how long a node pool upgrade
+	// policy stays "started". Defaults to 5 minutes.
+	NodePoolTransition time.Duration
+
+	// CredentialTransition is how long a break-glass credential stays
+	// "created" or "awaiting_revocation". Defaults to 10 seconds.
+	CredentialTransition time.Duration
+
+	// CredentialLifetime is how long an issued break-glass credential
+	// is valid. Defaults to 24 hours.
+	CredentialLifetime time.Duration
+}
+
+func (t *Timing) setDefaults() {
+	if t.ClusterInstall == 0 {
+		t.ClusterInstall = 10 * time.Minute
+	}
+	if t.ClusterUninstall == 0 {
+		t.ClusterUninstall = 5 * time.Minute
+	}
+	if t.ControlPlaneUpgrade == 0 {
+		t.ControlPlaneUpgrade = 15 * time.Minute
+	}
+	if t.NodePoolTransition == 0 {
+		t.NodePoolTransition = 5 * time.Minute
+	}
+	if t.CredentialTransition == 0 {
+		t.CredentialTransition = 10 * time.Second
+	}
+	if t.CredentialLifetime == 0 {
+		t.CredentialLifetime = 24 * time.Hour
+	}
+}
+
+// object is a generic JSON object as sent by an ocm-sdk-go client.
+type object map[string]any
+
+type cluster struct {
+	id                    string
+	body                  object
+	created               time.Time
+	deleted               time.Time
+	provisionErrorCode    string
+	provisionErrorMessage string
+	nodePools             map[string]*nodePool
+	credentials           map[string]*breakGlassCredential
+	upgradePolicies       map[string]*upgradePolicy
+}
+
+func (c *cluster) href() string {
+	return aroHcpV1Alpha1Path + "/clusters/" + c.id
+}
+
+// v1HREF returns the "clusters_mgmt/v1" path of the cluster, which is
+// the parent path of its node pools and other sub-resources.
+func (c *cluster) v1HREF() string {
+	return v1Path + "/clusters/" + c.id
+}
+
+type nodePool struct {
+	id              string
+	body            object
+	created         time.Time
+	updated         time.Time
+	deleted         time.Time
+	upgradePolicies map[string]*upgradePolicy
+}
+
+func (np *nodePool) href(c *cluster) string {
+	return c.v1HREF() + "/node_pools/" + strings.ToLower(np.id)
+}
+
+type upgradePolicy struct {
+	id       string
+	version  string
+	created  time.Time
+	duration time.Duration
+	applied  bool
+}
+
+type breakGlassCredential struct {
+	id      string
+	created time.Time
+	revoked time.Time
+}
+
+// version is an entry in the Server's catalogue of OpenShift versions.
+type version struct {
+	id           string
+	rawID        string
+	channelGroup string
+	enabled      bool
+}
+
+// catalogueVersions are the OpenShift versions every Server offers.
+var catalogueVersions = []version{
+	{id: "openshift-v4.15.0", rawID: "4.15.0", channelGroup: "stable", enabled: false},
+	{id: "openshift-v4.16.0", rawID: "4.16.0", channelGroup: "stable", enabled: true},
+	{id: "openshift-v4.17.0", rawID: "4.17.0", channelGroup: "stable", enabled: true},
+	{id: "openshift-v4.18.0-candidate", rawID: "4.18.0", channelGroup: "candidate", enabled: true},
+}
+
+// catalogueMachineTypes are the Azure VM sizes every Server offers.
+var catalogueMachineTypes = []string{
+	"Standard_D4s_v3",
+	"Standard_D8s_v3",
+	"Standard_D16s_v3",
+}
+
+// Server is a stand-in for the Cluster Service API, serving the subset of
+// "aro_hcp/v1alpha1" cluster endpoints and "clusters_mgmt/v1" node pool, node
+// pool, upgrade policy, break-glass credential, version and machine type
+// endpoints used by ocm.ClusterServiceClient.
+//
+// Versions and machine types come from a fixed catalogue: OpenShift 4.15.0
+// (disabled), 4.16.0 and 4.17.0 in the "stable" channel group, 4.18.0 in the
+// "candidate" channel group, and a few Azure VM sizes in the D-series.
+//
+// Resources move through states according to the Server's Clock and Timing
+// rather than by any real provisioning. A deleted cluster or node pool stays
+// readable while "uninstalling" and then responds with 404 Not Found.
+type Server struct {
+	*httptest.Server
+
+	clock          Clock
+	timing         Timing
+	mutex          sync.Mutex
+	clusters       map[string]*cluster
+	requestHeaders []http.Header
+}
+
+// NewServer starts and returns a new Server. The caller should call Close
+// when finished to shut it down. If clock is nil the system time is used.
+func NewServer(clock Clock, timing Timing) *Server {
+	if clock == nil {
+		clock = RealClock()
+	}
+	timing.setDefaults()
+
+	s := &Server{
+		clock:    clock,
+		timing:   timing,
+		clusters: make(map[string]*cluster),
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST "+aroHcpV1Alpha1Path+"/clusters", s.postCluster)
+	mux.HandleFunc("GET "+aroHcpV1Alpha1Path+"/clusters", s.listClusters)
+	mux.HandleFunc("GET "+aroHcpV1Alpha1Path+"/clusters/{cluster}", s.getCluster)
+	mux.HandleFunc("PATCH "+aroHcpV1Alpha1Path+"/clusters/{cluster}", s.patchCluster)
+	mux.HandleFunc("DELETE "+aroHcpV1Alpha1Path+"/clusters/{cluster}", s.deleteCluster)
+	mux.HandleFunc("GET "+aroHcpV1Alpha1Path+"/clusters/{cluster}/status", s.getClusterStatus)
+
+	mux.HandleFunc("POST "+v1Path+"/clusters/{cluster}/control_plane/upgrade_policies", s.postControlPlaneUpgradePolicy)
+	mux.HandleFunc("GET "+v1Path+"/clusters/{cluster}/control_plane/upgrade_policies/{upgradePolicy}", s.getControlPlaneUpgradePolicy)
+	mux.HandleFunc("DELETE "+v1Path+"/clusters/{cluster}/control_plane/upgrade_policies/{upgradePolicy}", s.deleteControlPlaneUpgradePolicy)
+
+	mux.HandleFunc("POST "+v1Path+"/clusters/{cluster}/node_pools", s.postNodePool)
+	mux.HandleFunc("GET "+v1Path+"/clusters/{cluster}/node_pools", s.listNodePools)
+	mux.HandleFunc("GET "+v1Path+"/clusters/{cluster}/node_pools/{nodePool}", s.getNodePool)
+	mux.HandleFunc("PATCH "+v1Path+"/clusters/{cluster}/node_pools/{nodePool}", s.patchNodePool)
+	mux.HandleFunc("DELETE "+v1Path+"/clusters/{cluster}/node_pools/{nodePool}", s.deleteNodePool)
+
+	mux.HandleFunc("POST "+v1Path+"/clusters/{cluster}/node_pools/{nodePool}/upgrade_policies", s.postNodePoolUpgradePolicy)
+	mux.HandleFunc("GET "+v1Path+"/clusters/{cluster}/node_pools/{nodePool}/upgrade_policies/{upgradePolicy}", s.getNodePoolUpgradePolicy)
+	mux.HandleFunc("DELETE "+v1Path+"/clusters/{cluster}/node_pools/{nodePool}/upgrade_policies/{upgradePolicy}", s.deleteNodePoolUpgradePolicy)
+
+	mux.HandleFunc("POST "+v1Path+"/clusters/{cluster}/break_glass_credentials", s.postBreakGlassCredential)
+	mux.HandleFunc("GET "+v1Path+"/clusters/{cluster}/break_glass_credentials", s.listBreakGlassCredentials)
+	mux.HandleFunc("DELETE "+v1Path+"/clusters/{cluster}/break_glass_credentials", s.deleteBreakGlassCredentials)
+	mux.HandleFunc("GET "+v1Path+"/clusters/{cluster}/break_glass_credentials/{credential}", s.getBreakGlassCredential)
+
+	mux.HandleFunc("GET "+v1Path+"/versions", s.listVersions)
+	mux.HandleFunc("GET "+v1Path+"/versions/{version}", s.getVersion)
+
+	mux.HandleFunc("GET "+v1Path+"/machine_types", s.listMachineTypes)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		s.requestHeaders = append(s.requestHeaders, r.Header.Clone())
+		s.mutex.Unlock()
+
+		mux.ServeHTTP(w, r)
+	}))
+
+	return s
+}
+
+// NewConnection returns an ocm-sdk-go connection to the Server, with an
+// optional transport wrapper.
+func (s *Server) NewConnection(transportWrapper func(http.RoundTripper) http.RoundTripper) (*sdk.Connection, error) {
+	builder := sdk.NewUnauthenticatedConnectionBuilder().URL(s.URL)
+	if transportWrapper != nil {
+		builder = builder.TransportWrapper(transportWrapper)
+	}
+	return builder.Build()
+}
+
+// RequestHeaders returns the headers of all requests received so far,
+// in the order they were received.
+func (s *Server) RequestHeaders() []http.Header {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return slices.Clone(s.requestHeaders)
+}
+
+// FailCluster puts a cluster into the "error" state with the given provision
+// error code and message. It returns false if the cluster does not exist.
+func (s *Server) FailCluster(clusterID, code, message string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(clusterID)
+	if c == nil {
+		return false
+	}
+
+	c.provisionErrorCode = code
+	c.provisionErrorMessage = message
+
+	return true
+}
+
+// lookupCluster returns the cluster with the given ID, or nil if it does
+// not exist or has finished uninstalling. The mutex must be held.
+func (s *Server) lookupCluster(clusterID string) *cluster {
+	c, ok := s.clusters[strings.ToLower(clusterID)]
+	if !ok {
+		return nil
+	}
+
+	if !c.deleted.IsZero() && !s.clock.Now().Before(c.deleted.Add(s.timing.ClusterUninstall)) {
+		delete(s.clusters, c.id)
+		return nil
+	}
+
+	s.applyUpgradePolicies(c.body, c.upgradePolicies)
+
+	return c
+}
+
+// lookupNodePool returns the node pool with the given ID, or nil if it does
+// not exist or has finished uninstalling. The mutex must be held.
+func (s *Server) lookupNodePool(c *cluster, nodePoolID string) *nodePool {
+	np, ok := c.nodePools[strings.ToLower(nodePoolID)]
+	if !ok {
+		return nil
+	}
+
+	if !np.deleted.IsZero() && !s.clock.Now().Before(np.deleted.Add(s.timing.NodePoolTransition)) {
+		delete(c.nodePools, strings.ToLower(np.id))
+		return nil
+	}
+
+	s.applyUpgradePolicies(np.body, np.upgradePolicies)
+
+	return np
+}
+
+// applyUpgradePolicies sets the version in the body of a cluster or node
+// pool from any completed upgrade policies that have not yet been applied.
+// The mutex must be held.
+func (s *Server) applyUpgradePolicies(body object, upgradePolicies map[string]*upgradePolicy) {
+	policies := slices.SortedFunc(maps.Values(upgradePolicies), func(a, b *upgradePolicy) int {
+		return a.created.Compare(b.created)
+	})
+
+	for _, up := range policies {
+		if up.applied || s.upgradePolicyState(up) != cmv1.UpgradePolicyStateValueCompleted {
+			continue
+		}
+
+		version, _ := body["version"].(map[string]any)
+		if version == nil {
+			version = object{"kind": "Version"}
+			body["version"] = version
+		}
+		version["id"] = "openshift-v" + up.version
+
+		// Drop the applied upgrade and any older versions.
+		if available, ok := version["available_upgrades"].([]any); ok {
+			index := slices.Index(available, any(up.version))
+			version["available_upgrades"] = slices.Clone(available[index+1:])
+		}
+
+		up.applied = true
+	}
+}
+
+func (s *Server) clusterState(c *cluster) arohcpv1alpha1.ClusterState {
+	now := s.clock.Now()
+
+	switch {
+	case !c.deleted.IsZero():
+		return arohcpv1alpha1.ClusterStateUninstalling
+	case c.provisionErrorCode != "":
+		return arohcpv1alpha1.ClusterStateError
+	case now.Before(c.created.Add(s.timing.ClusterInstall)):
+		return arohcpv1alpha1.ClusterStateInstalling
+	default:
+		return arohcpv1alpha1.ClusterStateReady
+	}
+}
+
+func (s *Server) clusterStatusObject(c *cluster) object {
+	status := object{
+		"kind":  "ClusterStatus",
+		"id":    c.id,
+		"href":  c.href() + "/status",
+		"state": s.clusterState(c),
+	}
+	if c.provisionErrorCode != "" {
+		status["provision_error_code"] = c.provisionErrorCode
+		status["provision_error_message"] = c.provisionErrorMessage
+	}
+	return status
+}
+
+func (s *Server) clusterObject(c *cluster) object {
+	obj := maps.Clone(c.body)
+	obj["kind"] = "Cluster"
+	obj["id"] = c.id
+	obj["href"] = c.href()
+	obj["state"] = s.clusterState(c)
+	obj["status"] = s.clusterStatusObject(c)
+	obj["creation_timestamp"] = c.created.UTC().Format(time.RFC3339)
+	return obj
+}
+
+func (s *Server) nodePoolState(np *nodePool) string {
+	now := s.clock.Now()
+
+	switch {
+	case !np.deleted.IsZero():
+		return nodePoolStateUninstalling
+	case now.Before(np.created.Add(s.timing.NodePoolTransition)):
+		return nodePoolStateInstalling
+	case now.Before(np.updated.Add(s.timing.NodePoolTransition)):
+		return nodePoolStateUpdating
+	default:
+		return nodePoolStateReady
+	}
+}
+
+func (s *Server) nodePoolObject(c *cluster, np *nodePool) object {
+	obj := maps.Clone(np.body)
+	obj["kind"] = "NodePool"
+	obj["id"] = np.id
+	obj["href"] = np.href(c)
+	obj["status"] = object{
+		"kind": "NodePoolStatus",
+		"state": object{
+			"node_pool_state_value": s.nodePoolState(np),
+		},
+	}
+	return obj
+}
+
+func (s *Server) upgradePolicyState(up *upgradePolicy) cmv1.UpgradePolicyStateValue {
+	if s.clock.Now().Before(up.created.Add(up.duration)) {
+		return cmv1.UpgradePolicyStateValueStarted
+	}
+	return cmv1.UpgradePolicyStateValueCompleted
+}
+
+func (s *Server) controlPlaneUpgradePolicyObject(c *cluster, up *upgradePolicy) object {
+	return object{
+		"kind":          "ControlPlaneUpgradePolicy",
+		"id":            up.id,
+		"href":          c.v1HREF() + "/control_plane/upgrade_policies/" + up.id,
+		"cluster_id":    c.id,
+		"schedule_type": cmv1.ScheduleTypeManual,
+		"upgrade_type":  cmv1.UpgradeTypeControlPlane,
+		"version":       up.version,
+		"next_run":      up.created.UTC().Format(time.RFC3339),
+		"state": object{
+			"value": s.upgradePolicyState(up),
+		},
+	}
+}
+
+func (s *Server) nodePoolUpgradePolicyObject(c *cluster, np *nodePool, up *upgradePolicy) object {
+	return object{
+		"kind":          "NodePoolUpgradePolicy",
+		"id":            up.id,
+		"href":          np.href(c) + "/upgrade_policies/" + up.id,
+		"cluster_id":    c.id,
+		"node_pool_id":  np.id,
+		"schedule_type": cmv1.ScheduleTypeManual,
+		"upgrade_type":  cmv1.UpgradeTypeNodePool,
+		"version":       up.version,
+		"next_run":      up.created.UTC().Format(time.RFC3339),
+		"state": object{
+			"value": s.upgradePolicyState(up),
+		},
+	}
+}
+
+func (s *Server) breakGlassCredentialObject(c *cluster, bgc *breakGlassCredential) object {
+	var status string
+
+	now := s.clock.Now()
+	expiration := bgc.created.Add(s.timing.CredentialLifetime)
+
+	switch {
+	case !bgc.revoked.IsZero() && now.Before(bgc.revoked.Add(s.timing.CredentialTransition)):
+		status = breakGlassCredentialStatusAwaitingRevocation
+	case !bgc.revoked.IsZero():
+		status = breakGlassCredentialStatusRevoked
+	case now.Before(bgc.created.Add(s.timing.CredentialTransition)):
+		status = breakGlassCredentialStatusCreated
+	case !now.Before(expiration):
+		status = breakGlassCredentialStatusExpired
+	default:
+		status = breakGlassCredentialStatusIssued
+	}
+
+	obj := object{
+		"kind":                 "BreakGlassCredential",
+		"id":                   bgc.id,
+		"href":                 c.v1HREF() + "/break_glass_credentials/" + bgc.id,
+		"username":             "system:customer-break-glass:" + bgc.id,
+		"status":               status,
+		"expiration_timestamp": expiration.UTC().Format(time.RFC3339),
+	}
+	if status == breakGlassCredentialStatusIssued {
+		obj["kubeconfig"] = fmt.Sprintf("# kubeconfig for %s", obj["username"])
+	}
+	if !bgc.revoked.IsZero() {
+		obj["revocation_timestamp"] = bgc.revoked.UTC().Format(time.RFC3339)
+	}
+	return obj
+}
+
+func (s *Server) postCluster(w http.ResponseWriter, r *http.Request) {
+	body, ok := readObject(w, r)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := &cluster{
+		id:              newID(),
+		body:            body,
+		created:         s.clock.Now(),
+		nodePools:       make(map[string]*nodePool),
+		credentials:     make(map[string]*breakGlassCredential),
+		upgradePolicies: make(map[string]*upgradePolicy),
+	}
+	s.clusters[c.id] = c
+
+	writeObject(w, http.StatusCreated, s.clusterObject(c))
+}
+
+func (s *Server) listClusters(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	items := make([]object, 0, len(s.clusters))
+	for _, id := range slices.Sorted(maps.Keys(s.clusters)) {
+		if c := s.lookupCluster(id); c != nil {
+			items = append(items, s.clusterObject(c))
+		}
+	}
+
+	writeList(w, r, "ClusterList", items)
+}
+
+func (s *Server) getCluster(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	writeObject(w, http.StatusOK, s.clusterObject(c))
+}
+
+func (s *Server) patchCluster(w http.ResponseWriter, r *http.Request) {
+	patch, ok := readObject(w, r)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	mergePatch(c.body, patch)
+
+	writeObject(w, http.StatusOK, s.clusterObject(c))
+}
+
+func (s *Server) deleteCluster(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	if c.deleted.IsZero() {
+		c.deleted = s.clock.Now()
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getClusterStatus(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	writeObject(w, http.StatusOK, s.clusterStatusObject(c))
+}
+
+func (s *Server) postControlPlaneUpgradePolicy(w http.ResponseWriter, r *http.Request) {
+	body, ok := readObject(w, r)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	version, _ := body["version"].(string)
+	if version == "" {
+		writeError(w, http.StatusBadRequest, "Upgrade policy version is required")
+		return
+	}
+
+	if upgradePolicyInProgress(c.upgradePolicies) {
+		writeError(w, http.StatusConflict, "Cluster '%s' already has an upgrade policy in progress", c.id)
+		return
+	}
+
+	up := &upgradePolicy{
+		id:       newID(),
+		version:  version,
+		created:  s.clock.Now(),
+		duration: s.timing.ControlPlaneUpgrade,
+	}
+	c.upgradePolicies[up.id] = up
+
+	writeObject(w, http.StatusCreated, s.controlPlaneUpgradePolicyObject(c, up))
+}
+
+func (s *Server) getControlPlaneUpgradePolicy(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	up, ok := c.upgradePolicies[strings.ToLower(r.PathValue("upgradePolicy"))]
+	if !ok {
+		writeNotFound(w, "Upgrade policy", r.PathValue("upgradePolicy"))
+		return
+	}
+
+	writeObject(w, http.StatusOK, s.controlPlaneUpgradePolicyObject(c, up))
+}
+
+func (s *Server) deleteControlPlaneUpgradePolicy(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	if !deleteUpgradePolicy(c.upgradePolicies, r.PathValue("upgradePolicy")) {
+		writeNotFound(w, "Upgrade policy", r.PathValue("upgradePolicy"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) postNodePool(w http.ResponseWriter, r *http.Request) {
+	body, ok := readObject(w, r)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	id, _ := body["id"].(string)
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "Node pool ID is required")
+		return
+	}
+	if s.lookupNodePool(c, id) != nil {
+		writeError(w, http.StatusConflict, "Node pool '%s' already exists for cluster '%s'", id, c.id)
+		return
+	}
+
+	np := &nodePool{
+		id:              id,
+		body:            body,
+		created:         s.clock.Now(),
+		upgradePolicies: make(map[string]*upgradePolicy),
+	}
+	c.nodePools[strings.ToLower(id)] = np
+
+	writeObject(w, http.StatusCreated, s.nodePoolObject(c, np))
+}
+
+func (s *Server) listNodePools(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	items := make([]object, 0, len(c.nodePools))
+	for _, id := range slices.Sorted(maps.Keys(c.nodePools)) {
+		if np := s.lookupNodePool(c, id); np != nil {
+			items = append(items, s.nodePoolObject(c, np))
+		}
+	}
+
+	writeList(w, r, "NodePoolList", items)
+}
+
+func (s *Server) getNodePool(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	np := s.lookupNodePool(c, r.PathValue("nodePool"))
+	if np == nil {
+		writeNotFound(w, "Node pool", r.PathValue("nodePool"))
+		return
+	}
+
+	writeObject(w, http.StatusOK, s.nodePoolObject(c, np))
+}
+
+func (s *Server) patchNodePool(w http.ResponseWriter, r *http.Request) {
+	patch, ok := readObject(w, r)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	np := s.lookupNodePool(c, r.PathValue("nodePool"))
+	if np == nil {
+		writeNotFound(w, "Node pool", r.PathValue("nodePool"))
+		return
+	}
+
+	// The node pool ID is immutable.
+	delete(patch, "id")
+
+	mergePatch(np.body, patch)
+	np.updated = s.clock.Now()
+
+	writeObject(w, http.StatusOK, s.nodePoolObject(c, np))
+}
+
+func (s *Server) deleteNodePool(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	np := s.lookupNodePool(c, r.PathValue("nodePool"))
+	if np == nil {
+		writeNotFound(w, "Node pool", r.PathValue("nodePool"))
+		return
+	}
+
+	if np.deleted.IsZero() {
+		np.deleted = s.clock.Now()
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) postNodePoolUpgradePolicy(w http.ResponseWriter, r *http.Request) {
+	body, ok := readObject(w, r)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	np := s.lookupNodePool(c, r.PathValue("nodePool"))
+	if np == nil {
+		writeNotFound(w, "Node pool", r.PathValue("nodePool"))
+		return
+	}
+
+	version, _ := body["version"].(string)
+	if version == "" {
+		writeError(w, http.StatusBadRequest, "Upgrade policy version is required")
+		return
+	}
+
+	if upgradePolicyInProgress(np.upgradePolicies) {
+		writeError(w, http.StatusConflict, "Node pool '%s' already has an upgrade policy in progress", np.id)
+		return
+	}
+
+	now := s.clock.Now()
+
+	up := &upgradePolicy{
+		id:       newID(),
+		version:  version,
+		created:  now,
+		duration: s.timing.NodePoolTransition,
+	}
+	np.upgradePolicies[up.id] = up
+	np.updated = now
+
+	writeObject(w, http.StatusCreated, s.nodePoolUpgradePolicyObject(c, np, up))
+}
+
+func (s *Server) getNodePoolUpgradePolicy(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	np := s.lookupNodePool(c, r.PathValue("nodePool"))
+	if np == nil {
+		writeNotFound(w, "Node pool", r.PathValue("nodePool"))
+		return
+	}
+
+	up, ok := np.upgradePolicies[strings.ToLower(r.PathValue("upgradePolicy"))]
+	if !ok {
+		writeNotFound(w, "Upgrade policy", r.PathValue("upgradePolicy"))
+		return
+	}
+
+	writeObject(w, http.StatusOK, s.nodePoolUpgradePolicyObject(c, np, up))
+}
+
+func (s *Server) deleteNodePoolUpgradePolicy(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	np := s.lookupNodePool(c, r.PathValue("nodePool"))
+	if np == nil {
+		writeNotFound(w, "Node pool", r.PathValue("nodePool"))
+		return
+	}
+
+	if !deleteUpgradePolicy(np.upgradePolicies, r.PathValue("upgradePolicy")) {
+		writeNotFound(w, "Upgrade policy", r.PathValue("upgradePolicy"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) postBreakGlassCredential(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	bgc := &breakGlassCredential{
+		id:      newID(),
+		created: s.clock.Now(),
+	}
+	c.credentials[bgc.id] = bgc
+
+	writeObject(w, http.StatusCreated, s.breakGlassCredentialObject(c, bgc))
+}
+
+func (s *Server) listBreakGlassCredentials(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	items := make([]object, 0, len(c.credentials))
+	for _, id := range slices.Sorted(maps.Keys(c.credentials)) {
+		items = append(items, s.breakGlassCredentialObject(c, c.credentials[id]))
+	}
+
+	writeList(w, r, "BreakGlassCredentialList", items)
+}
+
+func (s *Server) deleteBreakGlassCredentials(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	now := s.clock.Now()
+	for _, bgc := range c.credentials {
+		if bgc.revoked.IsZero() {
+			bgc.revoked = now
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getBreakGlassCredential(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := s.lookupCluster(r.PathValue("cluster"))
+	if c == nil {
+		writeNotFound(w, "Cluster", r.PathValue("cluster"))
+		return
+	}
+
+	bgc, ok := c.credentials[strings.ToLower(r.PathValue("credential"))]
+	if !ok {
+		writeNotFound(w, "Break glass credential", r.PathValue("credential"))
+		return
+	}
+
+	writeObject(w, http.StatusOK, s.breakGlassCredentialObject(c, bgc))
+}
+
+func versionObject(v version) object {
+	return object{
+		"kind":                         "Version",
+		"id":                           v.id,
+		"href":                         v1Path + "/versions/" + v.id,
+		"raw_id":                       v.rawID,
+		"channel_group":                v.channelGroup,
+		"enabled":                      v.enabled,
+		"hosted_control_plane_enabled": true,
+	}
+}
+
+func machineTypeObject(id string) object {
+	return object{
+		"kind": "MachineType",
+		"id":   id,
+		"href": v1Path + "/machine_types/" + id,
+		"name": id,
+		"cloud_provider": object{
+			"kind": "CloudProviderLink",
+			"id":   "azure",
+			"href": v1Path + "/cloud_providers/azure",
+		},
+	}
+}
+
+func (s *Server) listVersions(w http.ResponseWriter, r *http.Request) {
+	items := make([]object, 0, len(catalogueVersions))
+	for _, v := range catalogueVersions {
+		items = append(items, versionObject(v))
+	}
+
+	writeList(w, r, "VersionList", items)
+}
+
+func (s *Server) getVersion(w http.ResponseWriter, r *http.Request) {
+	for _, v := range catalogueVersions {
+		if strings.EqualFold(v.id, r.PathValue("version")) {
+			writeObject(w, http.StatusOK, versionObject(v))
+			return
+		}
+	}
+
+	writeNotFound(w, "Version", r.PathValue("version"))
+}
+
+func (s *Server) listMachineTypes(w http.ResponseWriter, r *http.Request) {
+	items := make([]object, 0, len(catalogueMachineTypes))
+	for _, id := range catalogueMachineTypes {
+		items = append(items, machineTypeObject(id))
+	}
+
+	writeList(w, r, "MachineTypeList", items)
+}
+
+// upgradePolicyInProgress returns true if any of the upgrade policies
+// have not yet been applied. The mutex must be held.
+func upgradePolicyInProgress(upgradePolicies map[string]*upgradePolicy) bool {
+	for _, up := range upgradePolicies {
+		if !up.applied {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteUpgradePolicy removes an upgrade policy so that it is never applied.
+// It returns false if the upgrade policy does not exist. The mutex must be held.
+func deleteUpgradePolicy(upgradePolicies map[string]*upgradePolicy, upgradePolicyID string) bool {
+	upgradePolicyID = strings.ToLower(upgradePolicyID)
+	if _, ok := upgradePolicies[upgradePolicyID]; !ok {
+		return false
+	}
+	delete(upgradePolicies, upgradePolicyID)
+	return true
+}
+
+// newID returns a random identifier resembling a Cluster Service ID.
+func newID() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// mergePatch applies a JSON merge patch (RFC 7396) to target.
+func mergePatch(target, patch object) {
+	for key, value := range patch {
+		switch value := value.(type) {
+		case nil:
+			delete(target, key)
+		case map[string]any:
+			if existing, ok := target[key].(map[string]any); ok {
+				mergePatch(existing, value)
+			} else {
+				target[key] = value
+			}
+		default:
+			target[key] = value
+		}
+	}
+}
+
+func readObject(w http.ResponseWriter, r *http.Request) (object, bool) {
+	var body object
+
+	data, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(data, &body)
+	}
+	if err != nil || body == nil {
+		writeError(w, http.StatusBadRequest, "Request body is not a JSON object")
+		return nil, false
+	}
+
+	return body, true
+}
+
+func writeObject(w http.ResponseWriter, statusCode int, obj any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(obj)
+}
+
+// writeError writes an error in the format ocm-sdk-go expects, so that
+// clients receive an *errors.Error with the corresponding status code.
+func writeError(w http.ResponseWriter, statusCode int, format string, args ...any) {
+	id := strconv.Itoa(statusCode)
+	writeObject(w, statusCode, object{
+		"kind":   "Error",
+		"id":     id,
+		"href":   v1Path + "/errors/" + id,
+		"code":   "CLUSTERS-MGMT-" + id,
+		"reason": fmt.Sprintf(format, args...),
+	})
+}
+
+func writeNotFound(w http.ResponseWriter, kind, id string) {
+	writeError(w, http.StatusNotFound, "%s '%s' not found", kind, id)
+}
+
+// searchIDsPattern matches the quoted values in a search expression.
+var searchIDsPattern = regexp.MustCompile(`'([^']*)'`)
+
+// searchTermPattern matches a "field = 'value'" term in a search expression.
+var searchTermPattern = regexp.MustCompile(`^([a-z_]+) = '([^']*)'$`)
+
+// parseSearch supports the search expressions the resource provider uses
+// to filter list results: "id in ('a', 'b', ...)" and one or more "field =
+// 'value'" terms joined by "and". It returns a function that reports whether
+// an item matches, or nil if the search expression is empty.
+func parseSearch(search string) (func(object) bool, error) {
+	search = strings.TrimSpace(search)
+	if search == "" {
+		return nil, nil
+	}
+
+	normalized := strings.Join(strings.Fields(search), " ")
+
+	if strings.HasPrefix(strings.ToLower(normalized), "id in (") {
+		ids := make(map[string]bool)
+		for _, match := range searchIDsPattern.FindAllStringSubmatch(search, -1) {
+			ids[strings.ToLower(match[1])] = true
+		}
+		return func(item object) bool {
+			id, _ := item["id"].(string)
+			return ids[strings.ToLower(id)]
+		}, nil
+	}
+
+	var terms [][]string
+	for _, term := range strings.Split(normalized, " and ") {
+		match := searchTermPattern.FindStringSubmatch(term)
+		if match == nil {
+			return nil, fmt.Errorf("unsupported search expression: %s", search)
+		}
+		terms = append(terms, match[1:])
+	}
+
+	return func(item object) bool {
+		for _, term := range terms {
+			if !strings.EqualFold(fmt.Sprint(item[term[0]]), term[1]) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// writeList filters and paginates items according to the request's
+// "search", "page" and "size" query parameters.
+func writeList(w http.ResponseWriter, r *http.Request, kind string, items []object) {
+	query := r.URL.Query()
+
+	match, err := parseSearch(query.Get("search"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+
+	if match != nil {
+		items = slices.DeleteFunc(items, func(item object) bool {
+			return !match(item)
+		})
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.Atoi(query.Get("size"))
+	if err != nil || size < 1 {
+		size = defaultPageSize
+	}
+
+	total := len(items)
+	start := min((page-1)*size, total)
+	end := min(start+size, total)
+	items = items[start:end]
+
+	writeObject(w, http.StatusOK, object{
+		"kind":  kind,
+		"page":  page,
+		"size":  len(items),
+		"total": total,
+		"items": items,
+	})
+}